@@ -0,0 +1,35 @@
+package pegomock_test
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+)
+
+func BenchmarkParallelInvocationsOfOneMock(b *testing.B) {
+	display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) { b.Fatal(message) }))
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			display.Flash("Hello", 1)
+		}
+	})
+}
+
+func BenchmarkParallelInvocationsOfStubbedMock(b *testing.B) {
+	display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) { b.Fatal(message) }))
+	When(display.MultipleParamsAndReturnValue(Any[string](), Any[int]())).ThenReturn("stubbed")
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			display.MultipleParamsAndReturnValue("Hello", 1)
+		}
+	})
+}
+
+func BenchmarkParallelInvocationsOfSeparateMocks(b *testing.B) {
+	b.RunParallel(func(pb *testing.PB) {
+		display := NewMockDisplay(WithFailHandler(func(message string, callerSkip ...int) { b.Fatal(message) }))
+		for pb.Next() {
+			display.Flash("Hello", 1)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// ArgumentCaptor captures the arguments of verified invocations with their static type, e.g.:
+//
+//	captor := Captor[string]()
+//	display.VerifyWasCalled(AtLeast(1)).Show(captor.Capture())
+//	Expect(captor.GetCapturedArguments()).To(Equal("Hello"))
+//
+// Every successful verification using Capture() adds the arguments of the invocations it matched.
+type ArgumentCaptor[T any] struct {
+	mutex  sync.Mutex
+	values []T
+	log    *CaptureLog
+}
+
+func Captor[T any]() *ArgumentCaptor[T] {
+	return &ArgumentCaptor[T]{}
+}
+
+// CaptorIn is like Captor, but additionally records everything it captures in log.
+func CaptorIn[T any](log *CaptureLog) *ArgumentCaptor[T] {
+	return &ArgumentCaptor[T]{log: log}
+}
+
+// Capture matches any argument of type T, like Any[T](), and captures it when the verification succeeds.
+func (captor *ArgumentCaptor[T]) Capture() T {
+	var t T
+	RegisterMatcher(&captureMatcher[T]{ArgumentMatcher: NewAnyMatcher(reflect.TypeOf(&t).Elem()), captor: captor})
+	return t
+}
+
+// CaptureMatching is like Capture, but only matches, and so only captures, arguments that matcher
+// matches, e.g. captor.CaptureMatching(MatchesRegex("^Hello")). This way, several stubbings or
+// verifications of a method can use captors while still telling invocations apart.
+func (captor *ArgumentCaptor[T]) CaptureMatching(matcher T) T {
+	RegisterMatcher(&captureMatcher[T]{ArgumentMatcher: popMatchers("CaptureMatching", 1)[0], captor: captor})
+	var t T
+	return t
+}
+
+// GetCapturedArguments returns the last captured argument, or the zero value if none was captured.
+func (captor *ArgumentCaptor[T]) GetCapturedArguments() T {
+	captor.mutex.Lock()
+	defer captor.mutex.Unlock()
+	if len(captor.values) == 0 {
+		var t T
+		return t
+	}
+	return captor.values[len(captor.values)-1]
+}
+
+// GetAllValues returns all captured arguments in the order of the invocations they were passed to.
+func (captor *ArgumentCaptor[T]) GetAllValues() []T {
+	captor.mutex.Lock()
+	defer captor.mutex.Unlock()
+	return append([]T(nil), captor.values...)
+}
+
+func (captor *ArgumentCaptor[T]) capture(arguments []CapturedArgument) {
+	captor.mutex.Lock()
+	defer captor.mutex.Unlock()
+	for i, argument := range arguments {
+		value, _ := argument.Value.(T) // nil arguments become the zero value
+		captor.values = append(captor.values, value)
+		arguments[i].Value = value
+	}
+	if captor.log != nil {
+		captor.log.add(arguments)
+	}
+}
+
+type argumentCapturer interface {
+	capture(arguments []CapturedArgument)
+}
+
+// CapturedArgument is an argument captured into a CaptureLog.
+type CapturedArgument struct {
+	Method   string // prefixed with the name of the mock, if it has one
+	Position int    // of the argument, starting at 0
+	Value    Param
+	Sequence int // of the invocation across all mocks
+}
+
+// CaptureLog collects the arguments captured by several captors, possibly for different methods
+// of different mocks, in the order of the invocations they were passed to. This way, tests can
+// correlate them, e.g.:
+//
+//	log := NewCaptureLog()
+//	store.VerifyWasCalledOnce().Save(CaptorIn[string](log).Capture())
+//	bus.VerifyWasCalledOnce().Publish(CaptorIn[string](log).Capture())
+//	entries := log.Entries()
+//	Expect(entries[1].Value).To(Equal(entries[0].Value))
+type CaptureLog struct {
+	mutex   sync.Mutex
+	entries []CapturedArgument
+}
+
+func NewCaptureLog() *CaptureLog {
+	return &CaptureLog{}
+}
+
+// Entries returns the captured arguments ordered by the sequence of the invocations they were
+// passed to. An argument captured by several verifications appears only once.
+func (log *CaptureLog) Entries() []CapturedArgument {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	return append([]CapturedArgument(nil), log.entries...)
+}
+
+// ValuesOf returns the captured arguments of method, in the order of its invocations.
+func (log *CaptureLog) ValuesOf(method string) []Param {
+	var values []Param
+	for _, entry := range log.Entries() {
+		if entry.Method == method {
+			values = append(values, entry.Value)
+		}
+	}
+	return values
+}
+
+func (log *CaptureLog) add(arguments []CapturedArgument) {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+	for _, argument := range arguments {
+		if !log.contains(argument) {
+			log.entries = append(log.entries, argument)
+		}
+	}
+	sort.SliceStable(log.entries, func(i, j int) bool {
+		if log.entries[i].Sequence != log.entries[j].Sequence {
+			return log.entries[i].Sequence < log.entries[j].Sequence
+		}
+		return log.entries[i].Position < log.entries[j].Position
+	})
+}
+
+func (log *CaptureLog) contains(argument CapturedArgument) bool {
+	for _, entry := range log.entries {
+		if entry.Sequence == argument.Sequence && entry.Position == argument.Position {
+			return true
+		}
+	}
+	return false
+}
+
+type captureMatcher[T any] struct {
+	ArgumentMatcher
+	captor *ArgumentCaptor[T]
+}
+
+func (matcher *captureMatcher[T]) capture(arguments []CapturedArgument) {
+	matcher.captor.capture(arguments)
+}
+
+func (matcher *captureMatcher[T]) String() string {
+	if _, isAnyMatcher := matcher.ArgumentMatcher.(*AnyMatcher); isAnyMatcher {
+		return fmt.Sprintf("Captor[%v]().Capture()", reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return fmt.Sprintf("Captor[%v]().CaptureMatching(%v)", reflect.TypeOf((*T)(nil)).Elem(), matcher.ArgumentMatcher)
+}
+
+// captureArguments passes the arguments of the verified invocations to the capturing matchers.
+func captureArguments(matchers Matchers, methodName string, invocations []MethodInvocation) {
+	for i, matcher := range matchers {
+		capturer, isCapturer := matcher.(argumentCapturer)
+		if !isCapturer {
+			continue
+		}
+		var arguments []CapturedArgument
+		for _, invocation := range invocations {
+			if i < len(invocation.params) {
+				arguments = append(arguments, CapturedArgument{
+					Method:   methodName,
+					Position: i,
+					Value:    invocation.params[i],
+					Sequence: invocation.orderingInvocationNumber,
+				})
+			}
+		}
+		capturer.capture(arguments)
+	}
+}
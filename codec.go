@@ -0,0 +1,289 @@
+package pegomock
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Codec serializes Params and ReturnValues, so recorded invocations and stubbings can be shipped
+// to another process, e.g. a test shard or an out-of-process mock server. Decode gets the static
+// types of the values, as declared by the mocked method.
+type Codec interface {
+	Encode(values []Param) ([]byte, error)
+	Decode(data []byte, types []reflect.Type) ([]Param, error)
+}
+
+var (
+	// GobCodec encodes values with encoding/gob. Values of interface types must be nil, because gob
+	// cannot decode a concrete value into an interface type that was not registered with gob.Register.
+	GobCodec Codec = gobCodec{}
+	// JSONCodec encodes values with encoding/json. Unexported struct fields are lost, and non-nil
+	// values of interface types other than interface{} cannot be decoded.
+	JSONCodec Codec = jsonCodec{}
+)
+
+type gobCodec struct{}
+
+func (gobCodec) Encode(values []Param) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := gob.NewEncoder(&buf)
+	// gob cannot encode nil values, so which values are nil is encoded separately
+	isNil := make([]bool, len(values))
+	for i, value := range values {
+		isNil[i] = value == nil || isNilValue(value)
+	}
+	if e := encoder.Encode(isNil); e != nil {
+		return nil, e
+	}
+	for i, value := range values {
+		if !isNil[i] {
+			if e := encoder.EncodeValue(reflect.ValueOf(value)); e != nil {
+				return nil, e
+			}
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Decode(data []byte, types []reflect.Type) ([]Param, error) {
+	decoder := gob.NewDecoder(bytes.NewReader(data))
+	var isNil []bool
+	if e := decoder.Decode(&isNil); e != nil {
+		return nil, e
+	}
+	if len(isNil) != len(types) {
+		return nil, fmt.Errorf("Expected %v values, but got %v", len(types), len(isNil))
+	}
+	values := make([]Param, len(types))
+	for i, typ := range types {
+		value := reflect.New(typ)
+		if !isNil[i] {
+			if e := decoder.DecodeValue(value); e != nil {
+				return nil, e
+			}
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+func isNilValue(value Param) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(values []Param) ([]byte, error) {
+	return json.Marshal(values)
+}
+
+func (jsonCodec) Decode(data []byte, types []reflect.Type) ([]Param, error) {
+	var rawValues []json.RawMessage
+	if e := json.Unmarshal(data, &rawValues); e != nil {
+		return nil, e
+	}
+	if len(rawValues) != len(types) {
+		return nil, fmt.Errorf("Expected %v values, but got %v", len(types), len(rawValues))
+	}
+	values := make([]Param, len(types))
+	for i, typ := range types {
+		value := reflect.New(typ)
+		if e := json.Unmarshal(rawValues[i], value.Interface()); e != nil {
+			return nil, e
+		}
+		values[i] = value.Elem().Interface()
+	}
+	return values, nil
+}
+
+// EncodedInvocation is an invocation recorded on a mock, with its params encoded by a Codec.
+type EncodedInvocation struct {
+	Method string
+	Params []byte
+}
+
+// EncodeInvocations encodes the invocations recorded on mock, ordered by the sequence in which
+// they happened.
+func EncodeInvocations(mock Mock, codec Codec) ([]EncodedInvocation, error) {
+	genericMock := GetGenericMockFrom(mock)
+	genericMock.Lock()
+	type invocationWithNumber struct {
+		number     int
+		methodName string
+		params     []Param
+	}
+	var invocations []invocationWithNumber
+	for methodName, method := range genericMock.mockedMethods {
+		method.Lock()
+		for _, invocation := range method.recordedInvocations() {
+			invocations = append(invocations, invocationWithNumber{invocation.orderingInvocationNumber, methodName, invocation.params})
+		}
+		method.Unlock()
+	}
+	genericMock.Unlock()
+	sort.Slice(invocations, func(i, j int) bool { return invocations[i].number < invocations[j].number })
+
+	result := make([]EncodedInvocation, len(invocations))
+	for i, invocation := range invocations {
+		params, e := encodeParams(mock, codec, invocation.methodName, invocation.params)
+		if e != nil {
+			return nil, fmt.Errorf("Could not encode params of invocation %v(%v): %v", invocation.methodName, formatParams(invocation.params), e)
+		}
+		result[i] = EncodedInvocation{Method: invocation.methodName, Params: params}
+	}
+	return result, nil
+}
+
+// ReplayInvocations decodes the invocations and invokes them on mock, e.g. to verify in one process
+// what happened in another. Stubbings of mock apply as usual; their return values are discarded.
+func ReplayInvocations(mock Mock, codec Codec, invocations []EncodedInvocation) error {
+	for _, invocation := range invocations {
+		method, e := methodOf(mock, invocation.Method)
+		if e != nil {
+			return e
+		}
+		args, e := decodeParams(method.Type(), codec, invocation.Params)
+		if e != nil {
+			return fmt.Errorf("Could not decode params of invocation of %v: %v", invocation.Method, e)
+		}
+		if method.Type().IsVariadic() {
+			method.CallSlice(args)
+		} else {
+			method.Call(args)
+		}
+	}
+	return nil
+}
+
+// EncodedStubbing is a stubbing of a method for exact params, with params and return values encoded
+// by a Codec.
+type EncodedStubbing struct {
+	Method       string
+	Params       []byte
+	ReturnValues []byte
+}
+
+// EncodeStubbing encodes a stubbing like StubInvocation(mock, methodName, params, returnValues)
+// would create it, so it can be applied to a mock of the same type in another process using
+// ApplyStubbings.
+func EncodeStubbing(mock Mock, codec Codec, methodName string, params []Param, returnValues ReturnValues) (EncodedStubbing, error) {
+	encodedParams, e := encodeParams(mock, codec, methodName, params)
+	if e != nil {
+		return EncodedStubbing{}, fmt.Errorf("Could not encode params of stubbing of %v: %v", methodName, e)
+	}
+	values := make([]Param, len(returnValues))
+	for i, returnValue := range returnValues {
+		values[i] = returnValue
+	}
+	encodedReturnValues, e := codec.Encode(values)
+	if e != nil {
+		return EncodedStubbing{}, fmt.Errorf("Could not encode return values of stubbing of %v: %v", methodName, e)
+	}
+	return EncodedStubbing{Method: methodName, Params: encodedParams, ReturnValues: encodedReturnValues}, nil
+}
+
+// ApplyStubbings decodes the stubbings and stubs mock with them.
+func ApplyStubbings(mock Mock, codec Codec, stubbings []EncodedStubbing) error {
+	for _, stubbing := range stubbings {
+		method, e := methodOf(mock, stubbing.Method)
+		if e != nil {
+			return e
+		}
+		args, e := decodeParams(method.Type(), codec, stubbing.Params)
+		if e != nil {
+			return fmt.Errorf("Could not decode params of stubbing of %v: %v", stubbing.Method, e)
+		}
+		returnTypes := make([]reflect.Type, method.Type().NumOut())
+		for i := range returnTypes {
+			returnTypes[i] = method.Type().Out(i)
+		}
+		values, e := codec.Decode(stubbing.ReturnValues, returnTypes)
+		if e != nil {
+			return fmt.Errorf("Could not decode return values of stubbing of %v: %v", stubbing.Method, e)
+		}
+		returnValues := make(ReturnValues, len(values))
+		for i, value := range values {
+			returnValues[i] = value
+		}
+		StubInvocation(mock, stubbing.Method, flatten(method.Type(), args), returnValues)
+	}
+	return nil
+}
+
+// encodeParams encodes the params of an invocation of methodName. Mocks record variadic args
+// flattened, but they are encoded as one slice, so they can be decoded using the method's declared
+// param types.
+func encodeParams(mock Mock, codec Codec, methodName string, params []Param) ([]byte, error) {
+	method, e := methodOf(mock, methodName)
+	if e != nil {
+		return nil, e
+	}
+	if methodType := method.Type(); methodType.IsVariadic() {
+		numFixedParams := methodType.NumIn() - 1
+		if len(params) < numFixedParams {
+			return nil, fmt.Errorf("Expected at least %v params, but got %v", numFixedParams, len(params))
+		}
+		variadicType := methodType.In(numFixedParams)
+		variadicParams := reflect.MakeSlice(variadicType, 0, len(params)-numFixedParams)
+		for _, param := range params[numFixedParams:] {
+			variadicParams = reflect.Append(variadicParams, valueOf(param, variadicType.Elem()))
+		}
+		params = append(params[:numFixedParams:numFixedParams], variadicParams.Interface())
+	}
+	return codec.Encode(params)
+}
+
+func decodeParams(methodType reflect.Type, codec Codec, data []byte) ([]reflect.Value, error) {
+	types := make([]reflect.Type, methodType.NumIn())
+	for i := range types {
+		types[i] = methodType.In(i)
+	}
+	values, e := codec.Decode(data, types)
+	if e != nil {
+		return nil, e
+	}
+	result := make([]reflect.Value, len(values))
+	for i, value := range values {
+		result[i] = valueOf(value, types[i])
+	}
+	return result, nil
+}
+
+// flatten turns decoded args back into params the way mocks record them.
+func flatten(methodType reflect.Type, args []reflect.Value) []Param {
+	var params []Param
+	for i, arg := range args {
+		if methodType.IsVariadic() && i == len(args)-1 {
+			for u := 0; u < arg.Len(); u++ {
+				params = append(params, arg.Index(u).Interface())
+			}
+		} else {
+			params = append(params, arg.Interface())
+		}
+	}
+	return params
+}
+
+func methodOf(mock Mock, methodName string) (reflect.Value, error) {
+	method := reflect.ValueOf(mock).MethodByName(methodName)
+	if !method.IsValid() {
+		return reflect.Value{}, fmt.Errorf("Mock %T has no method %v", mock, methodName)
+	}
+	return method, nil
+}
+
+func valueOf(param Param, typ reflect.Type) reflect.Value {
+	if param == nil {
+		return reflect.Zero(typ)
+	}
+	return reflect.ValueOf(param)
+}
@@ -17,9 +17,13 @@ package pegomock
 import (
 	"bytes"
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"runtime"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -27,19 +31,32 @@ import (
 	"github.com/petergtz/pegomock/v4/internal/verify"
 )
 
-var GlobalFailHandler FailHandler
+var (
+	GlobalFailHandler FailHandler
+	// globalFailHandlerMutex guards GlobalFailHandler against parallel subtests (re)registering it.
+	globalFailHandlerMutex sync.RWMutex
+)
 
 func RegisterMockFailHandler(handler FailHandler) {
+	globalFailHandlerMutex.Lock()
+	defer globalFailHandlerMutex.Unlock()
 	GlobalFailHandler = handler
 }
+
+func globalFailHandler() FailHandler {
+	globalFailHandlerMutex.RLock()
+	defer globalFailHandlerMutex.RUnlock()
+	return GlobalFailHandler
+}
 func RegisterMockTestingT(t *testing.T) {
-	RegisterMockFailHandler(BuildTestingTFailHandler(t))
+	fail := BuildTestingTFailHandler(t)
+	RegisterMockFailHandler(fail)
+	t.Cleanup(registerTestForCurrentGoroutine(t.Name(), fail, t.Cleanup))
 }
 
-var (
-	lastInvocation      *invocation
-	lastInvocationMutex sync.Mutex
-)
+// lastInvocation is the invocation When refers to. It's an atomic.Pointer, so that invocations on
+// parallel goroutines don't contend for a lock.
+var lastInvocation atomic.Pointer[invocation]
 
 var globalArgMatchers Matchers
 
@@ -51,25 +68,413 @@ type invocation struct {
 	genericMock *GenericMock
 	MethodName  string
 	Params      []Param
+	typedParams TypedParams // set instead of Params by InvokeTyped
 	ReturnTypes []reflect.Type
+	stubbing    *Stubbing // that answered the invocation, if the mock doesn't record invocations
+}
+
+func (invocation *invocation) params() []Param {
+	if invocation.typedParams != nil {
+		return invocation.typedParams.Params()
+	}
+	return invocation.Params
+}
+
+// TypedParams holds the params of an invocation in typed fields. Generated mocks use it for
+// methods with only primitive params, which avoids boxing every single param into a Param.
+type TypedParams interface {
+	Params() []Param
 }
 
 type GenericMock struct {
-	sync.Mutex
-	mockedMethods map[string]*mockedMethod
-	fail          FailHandler
+	sync.RWMutex
+	mockedMethods      map[string]*mockedMethod
+	fail               FailHandler
+	invocationTimeouts map[string]time.Duration
+	cloneArguments     bool
+	deepCloneArguments bool
+	referenceMethods   map[string]bool
+	name               string
+	labels             map[string]string
+
+	testScopedVerification bool
+	test                   namedTest // the mock was created for with WithT
+	readOnly               bool      // for snapshots created with SnapshotInteractions
+	recordCallSites        bool
+	recordingDisabled      bool // for mocks created with WithoutInvocationRecording
+
+	fakeStore FakeStore // for mocks generated with --fake
 }
 
 func (genericMock *GenericMock) Invoke(methodName string, params []Param, returnTypes []reflect.Type) ReturnValues {
-	lastInvocationMutex.Lock()
-	lastInvocation = &invocation{
+	genericMock.verifyNotReadOnly(methodName)
+	current := &invocation{
 		genericMock: genericMock,
 		MethodName:  methodName,
 		Params:      params,
 		ReturnTypes: returnTypes,
 	}
-	lastInvocationMutex.Unlock()
-	return genericMock.getOrCreateMockedMethod(methodName).Invoke(params)
+	lastInvocation.Store(current)
+	settings := genericMock.invocationSettingsFor(methodName)
+	method := genericMock.getOrCreateMockedMethod(methodName)
+	if settings.recordingDisabled {
+		stubbing := method.stubbingFor(params)
+		if stubbing == nil {
+			return ReturnValues{}
+		}
+		// there's no recorded invocation telling When which stubbing's use to undo
+		answered := *current
+		answered.stubbing = stubbing
+		lastInvocation.CompareAndSwap(current, &answered)
+		return stubbing.Invoke(params)
+	}
+	recordedParams := settings.recordableParams(params)
+	if settings.timeout > 0 {
+		return genericMock.invokeWithTimeout(methodName, params, recordedParams, returnTypes, settings.testName(), settings.callSite(), settings.timeout)
+	}
+	return method.Invoke(params, recordedParams, returnTypes, settings.testName(), settings.callSite())
+}
+
+// InvokeTyped is like Invoke, but takes the params in typed form. As long as the method isn't
+// stubbed, the invocation is recorded without converting them to []Param. That only happens
+// when they are actually needed, e.g. for verification.
+func (genericMock *GenericMock) InvokeTyped(methodName string, params TypedParams, returnTypes []reflect.Type) ReturnValues {
+	genericMock.verifyNotReadOnly(methodName)
+	settings := genericMock.invocationSettingsFor(methodName)
+	method := genericMock.getOrCreateMockedMethod(methodName)
+	method.RLock()
+	isStubbed := len(method.stubbings) != 0
+	method.RUnlock()
+	if isStubbed || settings.timeout > 0 {
+		return genericMock.Invoke(methodName, params.Params(), returnTypes)
+	}
+	lastInvocation.Store(&invocation{
+		genericMock: genericMock,
+		MethodName:  methodName,
+		typedParams: params,
+		ReturnTypes: returnTypes,
+	})
+	if settings.recordingDisabled {
+		return ReturnValues{}
+	}
+	method.record(MethodInvocation{
+		typedParams:              params,
+		orderingInvocationNumber: globalInvocationCounter.nextNumber(),
+		timestamp:                time.Now(),
+		testName:                 settings.testName(),
+		callSite:                 settings.callSite(),
+		unstubbed:                true,
+	}, returnTypes)
+	return ReturnValues{}
+}
+
+// invocationSettings are the settings of a mock that apply to an invocation of one of its methods.
+// They are read all at once, so that an invocation takes the lock of the mock only once.
+type invocationSettings struct {
+	recordingDisabled bool
+	cloneArguments    bool
+	deepClone         bool
+	recordCallSites   bool
+	testScoped        bool
+	ownTest           namedTest
+	timeout           time.Duration
+}
+
+func (genericMock *GenericMock) invocationSettingsFor(methodName string) invocationSettings {
+	genericMock.RLock()
+	defer genericMock.RUnlock()
+	timeout, exists := genericMock.invocationTimeouts[methodName]
+	if !exists {
+		timeout = genericMock.invocationTimeouts[anyMethod]
+	}
+	return invocationSettings{
+		recordingDisabled: genericMock.recordingDisabled,
+		cloneArguments:    (genericMock.cloneArguments || genericMock.deepCloneArguments) && !genericMock.referenceMethods[methodName],
+		deepClone:         genericMock.deepCloneArguments,
+		recordCallSites:   genericMock.recordCallSites,
+		testScoped:        genericMock.testScopedVerification,
+		ownTest:           genericMock.test,
+		timeout:           timeout,
+	}
+}
+
+func (settings invocationSettings) callSite() string {
+	if !settings.recordCallSites {
+		return ""
+	}
+	return callSite()
+}
+
+// testName returns the name of the test the invocation happens in. Only invocations of mocks with
+// test-scoped verification are attributed to tests, because finding the test walks the stack.
+// Invocations from goroutines that didn't register a test, e.g. those started by the code under
+// test, are attributed to the test the mock was created for with WithT, if any.
+func (settings invocationSettings) testName() string {
+	if !settings.testScoped {
+		return ""
+	}
+	if test, isRegisteredTest := currentTest(); isRegisteredTest {
+		return test.name
+	}
+	if settings.ownTest == nil {
+		return ""
+	}
+	return settings.ownTest.Name()
+}
+
+// recordableParams returns the params as they should be recorded for verification and argument
+// capturing. With argument cloning, slices are copied, so that verification sees them as they were
+// passed, even if the code under test reuses the underlying buffer afterwards. With deep argument
+// cloning, everything reachable from the params is copied.
+func (settings invocationSettings) recordableParams(params []Param) []Param {
+	if !settings.cloneArguments {
+		return params
+	}
+	recordedParams := make([]Param, len(params))
+	for i, param := range params {
+		if settings.deepClone {
+			recordedParams[i] = cloneDeep(param)
+		} else {
+			recordedParams[i] = cloneSlice(param)
+		}
+	}
+	return recordedParams
+}
+
+func cloneSlice(param Param) Param {
+	value := reflect.ValueOf(param)
+	if value.Kind() != reflect.Slice || value.IsNil() {
+		return param
+	}
+	clone := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+	reflect.Copy(clone, value)
+	return clone.Interface()
+}
+
+func cloneDeep(param Param) Param {
+	if param == nil {
+		return nil
+	}
+	return deepCopy(reflect.ValueOf(param), make(map[uintptr]reflect.Value)).Interface()
+}
+
+// deepCopy copies value and everything reachable from it. Pointers, maps and slices that are
+// reachable more than once, e.g. in cyclic data structures, are copied only once. Channels and
+// functions are not copied, and neither is anything reachable from unexported struct fields.
+func deepCopy(value reflect.Value, copies map[uintptr]reflect.Value) reflect.Value {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.IsNil() {
+			return value
+		}
+		if copied, exists := copies[value.Pointer()]; exists && copied.Type() == value.Type() {
+			return copied
+		}
+		copied := reflect.New(value.Type().Elem())
+		copies[value.Pointer()] = copied
+		copied.Elem().Set(deepCopy(value.Elem(), copies))
+		return copied
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(deepCopy(value.Index(i), copies))
+		}
+		return copied
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+		if copied, exists := copies[value.Pointer()]; exists && copied.Type() == value.Type() {
+			return copied
+		}
+		copied := reflect.MakeMapWithSize(value.Type(), value.Len())
+		copies[value.Pointer()] = copied
+		iter := value.MapRange()
+		for iter.Next() {
+			copied.SetMapIndex(deepCopy(iter.Key(), copies), deepCopy(iter.Value(), copies))
+		}
+		return copied
+	case reflect.Array:
+		copied := reflect.New(value.Type()).Elem()
+		for i := 0; i < value.Len(); i++ {
+			copied.Index(i).Set(deepCopy(value.Index(i), copies))
+		}
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(value)
+		for i := 0; i < value.NumField(); i++ {
+			if copied.Field(i).CanSet() {
+				copied.Field(i).Set(deepCopy(value.Field(i), copies))
+			}
+		}
+		return copied
+	case reflect.Interface:
+		if value.IsNil() {
+			return value
+		}
+		copied := reflect.New(value.Type()).Elem()
+		copied.Set(deepCopy(value.Elem(), copies))
+		return copied
+	}
+	return value
+}
+
+func (genericMock *GenericMock) setArgumentCloning(clone bool, methodNames []string) {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	if len(methodNames) == 0 {
+		genericMock.cloneArguments = clone
+		return
+	}
+	if genericMock.referenceMethods == nil {
+		genericMock.referenceMethods = make(map[string]bool)
+	}
+	for _, methodName := range methodNames {
+		genericMock.referenceMethods[methodName] = !clone
+	}
+}
+
+func (genericMock *GenericMock) setDeepArgumentCloning() {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	genericMock.deepCloneArguments = true
+}
+
+func (genericMock *GenericMock) disableRecording() {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	genericMock.recordingDisabled = true
+}
+
+func (genericMock *GenericMock) setCallSiteRecording() {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	genericMock.recordCallSites = true
+}
+
+var pegomockPackagePath = reflect.TypeOf(GenericMock{}).PkgPath()
+
+// callSite returns the location of the code that invoked a mock method, e.g. "handler.go:87". It
+// skips the frames of pegomock itself and the frame of the generated mock method.
+func callSite() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	mockMethodSkipped := false
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pegomockPackagePath+".") {
+			if mockMethodSkipped {
+				return fmt.Sprintf("%v:%v", filepath.Base(frame.File), frame.Line)
+			}
+			mockMethodSkipped = true
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+func (genericMock *GenericMock) setInvocationTimeout(timeout time.Duration, methodNames []string) {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	if genericMock.invocationTimeouts == nil {
+		genericMock.invocationTimeouts = make(map[string]time.Duration)
+	}
+	if len(methodNames) == 0 {
+		methodNames = []string{anyMethod}
+	}
+	for _, methodName := range methodNames {
+		genericMock.invocationTimeouts[methodName] = timeout
+	}
+}
+
+const anyMethod = ""
+
+func (genericMock *GenericMock) setName(name string) {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	genericMock.name = name
+}
+
+func (genericMock *GenericMock) setLabel(key, value string) {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	if genericMock.labels == nil {
+		genericMock.labels = make(map[string]string)
+	}
+	genericMock.labels[key] = value
+}
+
+// qualifiedMethodName prefixes methodName with the mock's name, if it has one, so that failure
+// messages identify which of several mocks of the same type was involved.
+func (genericMock *GenericMock) qualifiedMethodName(methodName string) string {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	if genericMock.name == "" {
+		return methodName
+	}
+	return genericMock.name + "." + methodName
+}
+
+// invokeWithTimeout runs the invocation, e.g. a blocking Answer, in a separate goroutine, so that
+// it can fail the test instead of hanging it. Panics of the invocation are re-panicked here.
+func (genericMock *GenericMock) invokeWithTimeout(methodName string, params []Param, recordedParams []Param, returnTypes []reflect.Type, testName string, callSite string, timeout time.Duration) ReturnValues {
+	type result struct {
+		returnValues ReturnValues
+		panicValue   interface{}
+		panicked     bool
+	}
+	done := make(chan result, 1)
+	go func() {
+		r := result{panicked: true}
+		defer func() {
+			if r.panicked {
+				r.panicValue = recover()
+			}
+			done <- r
+		}()
+		r.returnValues = genericMock.getOrCreateMockedMethod(methodName).Invoke(params, recordedParams, returnTypes, testName, callSite)
+		r.panicked = false
+	}()
+	select {
+	case r := <-done:
+		if r.panicked {
+			panic(r.panicValue)
+		}
+		return r.returnValues
+	case <-time.After(timeout):
+		fail := globalFailHandler()
+		if genericMock.fail != nil {
+			fail = genericMock.fail
+		}
+		if fail == nil {
+			panic("No FailHandler set. Please use either RegisterMockFailHandler or RegisterMockTestingT or TODO to set a fail handler.")
+		}
+		fail(fmt.Sprintf("Mock invocation %v(%v) was not answered within %v. Is a stubbed Answer blocking?",
+			genericMock.qualifiedMethodName(methodName), formatParams(params), timeout))
+		return ReturnValues{}
+	}
+}
+
+// ShouldUseFakeBehavior reports whether a hybrid fake should answer an invocation itself, i.e.
+// whether the invocation neither matches a stubbing nor is part of a When(...) using matchers.
+func (genericMock *GenericMock) ShouldUseFakeBehavior(methodName string, params []Param) bool {
+	if len(globalArgMatchers) != 0 {
+		return false
+	}
+	method := genericMock.getOrCreateMockedMethod(methodName)
+	method.RLock()
+	defer method.RUnlock()
+	return method.stubbings.find(params) == nil
+}
+
+// FakeStore returns the in-memory state that the methods of a hybrid fake share.
+func (genericMock *GenericMock) FakeStore() *FakeStore {
+	return &genericMock.fakeStore
 }
 
 func (genericMock *GenericMock) stub(methodName string, paramMatchers []ArgumentMatcher, returnValues ReturnValues) {
@@ -81,6 +486,12 @@ func (genericMock *GenericMock) stubWithCallback(methodName string, paramMatcher
 }
 
 func (genericMock *GenericMock) getOrCreateMockedMethod(methodName string) *mockedMethod {
+	genericMock.RLock()
+	method, exists := genericMock.mockedMethods[methodName]
+	genericMock.RUnlock()
+	if exists {
+		return method
+	}
 	genericMock.Lock()
 	defer genericMock.Unlock()
 	if _, ok := genericMock.mockedMethods[methodName]; !ok {
@@ -104,14 +515,33 @@ func (genericMock *GenericMock) Verify(
 	if len(options) == 1 {
 		timeout = options[0].(time.Duration)
 	}
-	if genericMock.fail == nil && GlobalFailHandler == nil {
+	fail := globalFailHandler()
+	if genericMock.fail == nil && fail == nil {
 		panic("No FailHandler set. Please use either RegisterMockFailHandler or RegisterMockTestingT or TODO to set a fail handler.")
 	}
-	fail := GlobalFailHandler
+	genericMock.Lock()
+	testScoped := genericMock.testScopedVerification
+	recordingDisabled := genericMock.recordingDisabled
+	genericMock.Unlock()
+	qualifiedMethodName := genericMock.qualifiedMethodName(methodName)
+	verify.Argument(!recordingDisabled,
+		"Cannot verify %v, because the mock was created with WithoutInvocationRecording.", qualifiedMethodName)
+	test, isRegisteredTest := currentTest()
+	testScoped = testScoped && isRegisteredTest
+	if testScoped {
+		// with parallel subtests, GlobalFailHandler might belong to another test
+		fail = test.fail
+	}
 	if genericMock.fail != nil {
 		fail = genericMock.fail
 	}
-	defer func() { globalArgMatchers = nil }() // We don't want a panic somewhere during verification screw our global argMatchers
+	defer func() {
+		// We don't want a panic somewhere during verification screw our global argMatchers.
+		// Only writing when necessary avoids data races between parallel subtests not using matchers.
+		if globalArgMatchers != nil {
+			globalArgMatchers = nil
+		}
+	}()
 
 	if len(globalArgMatchers) != 0 {
 		verifyArgMatcherUse(globalArgMatchers, params)
@@ -121,6 +551,9 @@ func (genericMock *GenericMock) Verify(
 	for {
 		genericMock.Lock()
 		methodInvocations := genericMock.methodInvocations(methodName, params, globalArgMatchers)
+		if testScoped {
+			methodInvocations = invocationsInTest(methodInvocations, test.name)
+		}
 		genericMock.Unlock()
 		if inOrderContext != nil {
 			for _, methodInvocation := range methodInvocations {
@@ -130,10 +563,10 @@ func (genericMock *GenericMock) Verify(
 					// 	continue timeoutLoop
 					// }
 					fail(fmt.Sprintf("Expected function call %v(%v) before function call %v(%v)",
-						methodName, formatParams(params), inOrderContext.lastInvokedMethodName, formatParams(inOrderContext.lastInvokedMethodParams)))
+						qualifiedMethodName, formatParams(params), inOrderContext.lastInvokedMethodName, formatParams(inOrderContext.lastInvokedMethodParams)))
 				}
 				inOrderContext.invocationCounter = methodInvocation.orderingInvocationNumber
-				inOrderContext.lastInvokedMethodName = methodName
+				inOrderContext.lastInvokedMethodName = qualifiedMethodName
 				inOrderContext.lastInvokedMethodParams = params
 			}
 		}
@@ -150,10 +583,14 @@ func (genericMock *GenericMock) Verify(
 			if timeout > 0 {
 				timeoutInfo = fmt.Sprintf(" after timeout of %v", timeout)
 			}
+			interactions := genericMock.allInteractions()
 			fail(fmt.Sprintf(
-				"Mock invocation count for %v(%v) does not match expectation%v.\n\n\t%v\n\n\t%v",
-				methodName, paramsOrMatchers, timeoutInfo, invocationCountMatcher.FailureMessage(), formatInteractions(genericMock.allInteractions())))
+				"Mock invocation count for %v(%v) does not match expectation%v.\n\n\t%v\n\n\t%v%v",
+				qualifiedMethodName, paramsOrMatchers, timeoutInfo, invocationCountMatcher.FailureMessage(), formatInteractions(interactions),
+				describeMismatches(methodName, interactions[methodName], globalArgMatchers)))
 		}
+		captureArguments(globalArgMatchers, qualifiedMethodName, methodInvocations)
+		genericMock.markVerified(methodName, methodInvocations)
 		return methodInvocations
 	}
 }
@@ -163,7 +600,14 @@ func (genericMock *GenericMock) GetInvocationParams(methodInvocations []MethodIn
 	if len(methodInvocations) == 0 {
 		return nil
 	}
-	result := make([][]Param, len(methodInvocations[len(methodInvocations)-1].params))
+	// with variadic params, invocations can differ in the number of params, e.g. when matched by AnyRemaining
+	numParams := 0
+	for _, invocation := range methodInvocations {
+		if len(invocation.params) > numParams {
+			numParams = len(invocation.params)
+		}
+	}
+	result := make([][]Param, numParams)
 	for i, invocation := range methodInvocations {
 		for u, param := range invocation.params {
 			if result[u] == nil {
@@ -175,11 +619,44 @@ func (genericMock *GenericMock) GetInvocationParams(methodInvocations []MethodIn
 	return result
 }
 
+// Invocations returns the recorded invocations of methodName in the order in which they happened.
+// Unlike Verify, it never fails, so generated accessors like MockDisplay.ShowCalls() can use it to
+// hand the invocations to other assertion libraries.
+func (genericMock *GenericMock) Invocations(methodName string) []MethodInvocation {
+	genericMock.Lock()
+	method, exists := genericMock.mockedMethods[methodName]
+	genericMock.Unlock()
+	if !exists {
+		return nil
+	}
+	method.Lock()
+	defer method.Unlock()
+	return append([]MethodInvocation(nil), method.recordedInvocations()...)
+}
+
+// Sequence is the number of the invocation in the order of all invocations on all mocks.
+func (invocation MethodInvocation) Sequence() int { return invocation.orderingInvocationNumber }
+
+// Timestamp is the time at which the invocation happened.
+func (invocation MethodInvocation) Timestamp() time.Time { return invocation.timestamp }
+
+// GetVariadicInvocationParams returns, for each of the invocations, its variadic params, i.e. its
+// params after the first numFixedParams.
+func (genericMock *GenericMock) GetVariadicInvocationParams(methodInvocations []MethodInvocation, numFixedParams int) [][]Param {
+	result := make([][]Param, len(methodInvocations))
+	for i, invocation := range methodInvocations {
+		if len(invocation.params) > numFixedParams {
+			result[i] = invocation.params[numFixedParams:]
+		}
+	}
+	return result
+}
+
 func (genericMock *GenericMock) methodInvocations(methodName string, params []Param, matchers []ArgumentMatcher) []MethodInvocation {
 	var invocations []MethodInvocation
 	if method, exists := genericMock.mockedMethods[methodName]; exists {
 		method.Lock()
-		for _, invocation := range method.invocations {
+		for _, invocation := range method.recordedInvocations() {
 			if len(matchers) != 0 {
 				if Matchers(matchers).Matches(invocation.params) {
 					invocations = append(invocations, invocation)
@@ -196,6 +673,15 @@ func (genericMock *GenericMock) methodInvocations(methodName string, params []Pa
 	return invocations
 }
 
+func invocationsInTest(invocations []MethodInvocation, testName string) (result []MethodInvocation) {
+	for _, invocation := range invocations {
+		if invocation.testName == testName {
+			result = append(result, invocation)
+		}
+	}
+	return
+}
+
 func formatInteractions(interactions map[string][]MethodInvocation) string {
 	if len(interactions) == 0 {
 		return "There were no other interactions with this mock"
@@ -207,9 +693,33 @@ func formatInteractions(interactions map[string][]MethodInvocation) string {
 	return result
 }
 
+// describeMismatches explains why the arguments of the invocations didn't match, as far as the
+// matchers implement MismatchDescriber.
+func describeMismatches(methodName string, invocations []MethodInvocation, matchers Matchers) (result string) {
+	for _, invocation := range invocations {
+		if len(invocation.params) != len(matchers) {
+			continue
+		}
+		for i, matcher := range matchers {
+			if describer, isDescriber := matcher.(MismatchDescriber); isDescriber && !matcher.Matches(invocation.params[i]) {
+				result += fmt.Sprintf("\t%v(%v): argument %v: %v\n", methodName, formatParams(invocation.params), i+1,
+					strings.ReplaceAll(describer.DescribeMismatch(invocation.params[i]), "\n", "\n\t\t"))
+			}
+		}
+	}
+	if result != "" {
+		result = "\n\tArgument mismatches were:\n" + result
+	}
+	return
+}
+
 func formatInvocations(methodName string, invocations []MethodInvocation) (result string) {
 	for _, invocation := range invocations {
-		result += "\t" + methodName + "(" + formatParams(invocation.params) + ")\n"
+		result += "\t" + methodName + "(" + formatParams(invocation.params) + ")"
+		if invocation.callSite != "" {
+			result += " called from " + invocation.callSite
+		}
+		result += "\n"
 	}
 	return
 }
@@ -219,7 +729,7 @@ func formatParams(params []Param) (result string) {
 		if i > 0 {
 			result += ", "
 		}
-		result += fmt.Sprintf("%#v", param)
+		result += formatParam(param)
 	}
 	return
 }
@@ -247,68 +757,177 @@ func sortedMethodNames(interactions map[string][]MethodInvocation) []string {
 
 func (genericMock *GenericMock) allInteractions() map[string][]MethodInvocation {
 	interactions := make(map[string][]MethodInvocation)
-	for methodName := range genericMock.mockedMethods {
-		for _, invocation := range genericMock.mockedMethods[methodName].invocations {
-			interactions[methodName] = append(interactions[methodName], invocation)
-		}
+	for methodName, method := range genericMock.mockedMethods {
+		method.Lock()
+		interactions[methodName] = append(interactions[methodName], method.recordedInvocations()...)
+		method.Unlock()
 	}
 	return interactions
 }
 
-type mockedMethod struct {
+// numInvocationShards is the number of shards a method records its invocations in. Invocations
+// from parallel goroutines are spread over the shards, so they rarely wait for each other.
+const numInvocationShards = 16
+
+type invocationShard struct {
 	sync.Mutex
+	invocations []MethodInvocation
+}
+
+// mockedMethod records invocations in shards and merges them into invocations, ordered by their
+// sequence, only when they are read. Its lock guards stubbings, returnTypes and the merged
+// invocations. Invoking a stubbed method only takes the read lock.
+type mockedMethod struct {
+	sync.RWMutex
 	name        string
 	invocations []MethodInvocation
+	shards      [numInvocationShards]invocationShard
 	stubbings   Stubbings
+	returnTypes []reflect.Type
 }
 
-func (method *mockedMethod) Invoke(params []Param) ReturnValues {
-	method.Lock()
-	method.invocations = append(method.invocations, MethodInvocation{params, globalInvocationCounter.nextNumber()})
-	method.Unlock()
+func (method *mockedMethod) Invoke(params []Param, recordedParams []Param, returnTypes []reflect.Type, testName string, callSite string) ReturnValues {
+	method.RLock()
 	stubbing := method.stubbings.find(params)
+	method.RUnlock()
+	method.record(MethodInvocation{
+		params:                   recordedParams,
+		orderingInvocationNumber: globalInvocationCounter.nextNumber(),
+		timestamp:                time.Now(),
+		testName:                 testName,
+		callSite:                 callSite,
+		unstubbed:                stubbing == nil,
+		stubbing:                 stubbing,
+	}, returnTypes)
 	if stubbing == nil {
 		return ReturnValues{}
 	}
 	return stubbing.Invoke(params)
 }
 
+// record appends invocation to the shard picked by its sequence number.
+func (method *mockedMethod) record(invocation MethodInvocation, returnTypes []reflect.Type) {
+	method.RLock()
+	knowsReturnTypes := method.returnTypes != nil
+	method.RUnlock()
+	if !knowsReturnTypes {
+		method.Lock()
+		method.returnTypes = returnTypes
+		method.Unlock()
+	}
+	shard := &method.shards[invocation.orderingInvocationNumber%numInvocationShards]
+	shard.Lock()
+	shard.invocations = append(shard.invocations, invocation)
+	shard.Unlock()
+}
+
+// answer returns what the stubbing matching params returns, without recording the invocation.
+func (method *mockedMethod) stubbingFor(params []Param) *Stubbing {
+	method.RLock()
+	defer method.RUnlock()
+	return method.stubbings.find(params)
+}
+
 func (method *mockedMethod) stub(paramMatchers Matchers, callback func([]Param) ReturnValues) {
+	method.Lock()
+	defer method.Unlock()
 	stubbing := method.stubbings.findByMatchers(paramMatchers)
 	if stubbing == nil {
 		stubbing = &Stubbing{paramMatchers: paramMatchers}
 		method.stubbings = append(method.stubbings, stubbing)
 	}
+	stubbing.Lock()
 	stubbing.callbackSequence = append(stubbing.callbackSequence, callback)
+	stubbing.Unlock()
+}
+
+// recordedInvocations returns the invocations of method in the order in which they happened, with
+// the params of all typed invocations converted to []Param. The caller must hold the lock of method.
+func (method *mockedMethod) recordedInvocations() []MethodInvocation {
+	method.mergeShards()
+	for i := range method.invocations {
+		if method.invocations[i].typedParams != nil {
+			method.invocations[i].params = method.invocations[i].typedParams.Params()
+			method.invocations[i].typedParams = nil
+		}
+	}
+	return method.invocations
+}
+
+// mergeShards moves the invocations recorded in the shards into invocations. The caller must hold
+// the lock of method.
+func (method *mockedMethod) mergeShards() {
+	merged := false
+	for i := range method.shards {
+		shard := &method.shards[i]
+		shard.Lock()
+		if len(shard.invocations) > 0 {
+			method.invocations = append(method.invocations, shard.invocations...)
+			shard.invocations = nil
+			merged = true
+		}
+		shard.Unlock()
+	}
+	if merged {
+		sort.SliceStable(method.invocations, func(i, j int) bool {
+			return method.invocations[i].orderingInvocationNumber < method.invocations[j].orderingInvocationNumber
+		})
+	}
+}
+
+// forgetInvocations drops all recorded invocations. The caller must hold the lock of method.
+func (method *mockedMethod) forgetInvocations() {
+	method.invocations = nil
+	for i := range method.shards {
+		method.shards[i].Lock()
+		method.shards[i].invocations = nil
+		method.shards[i].Unlock()
+	}
 }
 
 func (method *mockedMethod) removeLastInvocation() {
-	method.invocations = method.invocations[:len(method.invocations)-1]
+	method.Lock()
+	defer method.Unlock()
+	method.mergeShards()
+	// nothing was recorded for mocks created with WithoutInvocationRecording
+	if len(method.invocations) > 0 {
+		// the invocation passed to When doesn't count as a use of the stubbing that answered it
+		if stubbing := method.invocations[len(method.invocations)-1].stubbing; stubbing != nil {
+			stubbing.Lock()
+			stubbing.numAnswers--
+			stubbing.Unlock()
+		}
+		method.invocations = method.invocations[:len(method.invocations)-1]
+	}
 }
 
 func (method *mockedMethod) reset(paramMatchers Matchers) {
+	method.Lock()
+	defer method.Unlock()
 	method.stubbings.removeByMatchers(paramMatchers)
 }
 
 type Counter struct {
-	count int
-	sync.Mutex
+	count atomic.Int64
 }
 
-func (counter *Counter) nextNumber() (nextNumber int) {
-	counter.Lock()
-	defer counter.Unlock()
-
-	nextNumber = counter.count
-	counter.count++
-	return
+func (counter *Counter) nextNumber() int {
+	return int(counter.count.Add(1))
 }
 
-var globalInvocationCounter = Counter{count: 1}
+// globalInvocationCounter numbers invocations starting at 1.
+var globalInvocationCounter Counter
 
 type MethodInvocation struct {
 	params                   []Param
+	typedParams              TypedParams // set instead of params for invocations recorded by InvokeTyped
 	orderingInvocationNumber int
+	timestamp                time.Time
+	testName                 string // empty, if the invocation couldn't be attributed to a test
+	callSite                 string // e.g. "handler.go:87", if the mock records call sites
+	unstubbed                bool
+	stubbing                 *Stubbing // the stubbing that answered the invocation, if any
+	verified                 bool      // whether a verification matched the invocation
 }
 
 type Stubbings []*Stubbing
@@ -352,23 +971,33 @@ func matchersEqual(a, b Matchers) bool {
 }
 
 type Stubbing struct {
+	sync.Mutex       // guards callbackSequence, sequencePointer and numAnswers
 	paramMatchers    Matchers
 	callbackSequence []func([]Param) ReturnValues
 	sequencePointer  int
+	numAnswers       int
 }
 
 func (stubbing *Stubbing) Invoke(params []Param) ReturnValues {
-	defer func() {
-		if stubbing.sequencePointer < len(stubbing.callbackSequence)-1 {
-			stubbing.sequencePointer++
-		}
-	}()
-	return stubbing.callbackSequence[stubbing.sequencePointer](params)
+	stubbing.Lock()
+	stubbing.numAnswers++
+	callback := stubbing.callbackSequence[stubbing.sequencePointer]
+	if stubbing.sequencePointer < len(stubbing.callbackSequence)-1 {
+		stubbing.sequencePointer++
+	}
+	stubbing.Unlock()
+	return callback(params)
 }
 
 type Matchers []ArgumentMatcher
 
 func (matchers Matchers) Matches(params []Param) bool {
+	if len(matchers) > 0 {
+		if tailMatcher, isVariadicMatcher := matchers[len(matchers)-1].(VariadicMatcher); isVariadicMatcher {
+			fixed := len(matchers) - 1
+			return len(params) >= fixed && matchers[:fixed].Matches(params[:fixed]) && tailMatcher.MatchesVariadic(params[fixed:])
+		}
+	}
 	if len(matchers) != len(params) { // Technically, this is not an error. Variadic arguments can cause this
 		return false
 	}
@@ -392,26 +1021,37 @@ type ongoingStubbing struct {
 	returnTypes   []reflect.Type
 }
 
+// StubInvocation stubs methodName of mock to return returnValues when invoked with exactly params,
+// like When(mock.Method(params...)).ThenReturn(returnValues...) would. It is meant for tools creating
+// stubbings programmatically, e.g. from values generated in property-based tests.
+func StubInvocation(mock Mock, methodName string, params []Param, returnValues ReturnValues) {
+	GetGenericMockFrom(mock).stub(methodName, transformParamsIntoEqMatchers(params), returnValues)
+}
+
 func When(invocation ...interface{}) *ongoingStubbing {
 	callIfIsFunc(invocation)
-	verify.Argument(lastInvocation != nil,
+	stubbedInvocation := lastInvocation.Swap(nil)
+	verify.Argument(stubbedInvocation != nil,
 		"When() requires an argument which has to be 'a method call on a mock'.")
 	defer func() {
-		lastInvocationMutex.Lock()
-		lastInvocation = nil
-		lastInvocationMutex.Unlock()
-
 		globalArgMatchers = nil
 	}()
-	lastInvocation.genericMock.mockedMethods[lastInvocation.MethodName].removeLastInvocation()
+	stubbedInvocation.genericMock.getOrCreateMockedMethod(stubbedInvocation.MethodName).removeLastInvocation()
+	if stubbedInvocation.stubbing != nil {
+		// the invocation passed to When doesn't count as a use of the stubbing that answered it
+		stubbedInvocation.stubbing.Lock()
+		stubbedInvocation.stubbing.numAnswers--
+		stubbedInvocation.stubbing.Unlock()
+	}
+	stubbedInvocation.genericMock.fakeStore.discardPendingWrite()
 
-	paramMatchers := paramMatchersFromArgMatchersOrParams(globalArgMatchers, lastInvocation.Params)
-	lastInvocation.genericMock.reset(lastInvocation.MethodName, paramMatchers)
+	paramMatchers := paramMatchersFromArgMatchersOrParams(globalArgMatchers, stubbedInvocation.params())
+	stubbedInvocation.genericMock.reset(stubbedInvocation.MethodName, paramMatchers)
 	return &ongoingStubbing{
-		genericMock:   lastInvocation.genericMock,
-		MethodName:    lastInvocation.MethodName,
+		genericMock:   stubbedInvocation.genericMock,
+		MethodName:    stubbedInvocation.MethodName,
 		ParamMatchers: paramMatchers,
-		returnTypes:   lastInvocation.ReturnTypes,
+		returnTypes:   stubbedInvocation.ReturnTypes,
 	}
 }
 
@@ -464,21 +1104,35 @@ func transformParamsIntoEqMatchers(params []Param) []ArgumentMatcher {
 	return paramMatchers
 }
 
-var (
-	genericMocksMutex sync.Mutex
-	genericMocks      = make(map[Mock]*GenericMock)
-)
+// genericMocks maps each Mock to its *GenericMock. It's a sync.Map, because every invocation looks
+// up its GenericMock, while GenericMocks are only added when mocks are created.
+var genericMocks sync.Map
 
 func GetGenericMockFrom(mock Mock) *GenericMock {
-	genericMocksMutex.Lock()
-	defer genericMocksMutex.Unlock()
-	if genericMocks[mock] == nil {
-		genericMocks[mock] = &GenericMock{
-			mockedMethods: make(map[string]*mockedMethod),
-			fail:          mock.FailHandler(),
+	if value, exists := genericMocks.Load(mock); exists {
+		genericMock := value.(*GenericMock)
+		if genericMock.hasFailHandler() || mock.FailHandler() == nil {
+			return genericMock
 		}
 	}
-	return genericMocks[mock]
+	value, _ := genericMocks.LoadOrStore(mock, &GenericMock{
+		mockedMethods: make(map[string]*mockedMethod),
+		fail:          mock.FailHandler(),
+	})
+	genericMock := value.(*GenericMock)
+	genericMock.Lock()
+	if genericMock.fail == nil && mock.FailHandler() != nil {
+		// options like WithInvocationTimeout may create the GenericMock before WithFailHandler is applied
+		genericMock.fail = mock.FailHandler()
+	}
+	genericMock.Unlock()
+	return genericMock
+}
+
+func (genericMock *GenericMock) hasFailHandler() bool {
+	genericMock.RLock()
+	defer genericMock.RUnlock()
+	return genericMock.fail != nil
 }
 
 func (stubbing *ongoingStubbing) ThenReturn(values ...ReturnValue) *ongoingStubbing {
@@ -534,6 +1188,20 @@ type ArgumentMatcher interface {
 	fmt.Stringer
 }
 
+// VariadicMatcher is an ArgumentMatcher that, when used for the last argument, matches all
+// remaining arguments at once, i.e. the variadic tail, instead of only one of them.
+type VariadicMatcher interface {
+	ArgumentMatcher
+	MatchesVariadic(params []Param) bool
+}
+
+// MismatchDescriber can optionally be implemented by an ArgumentMatcher to explain why an argument
+// didn't match. Verification failures include the explanation for every argument of the actual
+// invocations the matcher rejected.
+type MismatchDescriber interface {
+	DescribeMismatch(actual Param) string
+}
+
 // InvocationCountMatcher can be used to match invocation counts. It is guaranteed that
 // FailureMessage will always be called after Matches so an implementation can save state.
 type InvocationCountMatcher interface {
@@ -557,13 +1225,15 @@ func DumpInvocationsFor(mock Mock) {
 func SDumpInvocationsFor(mock Mock) string {
 	result := &bytes.Buffer{}
 	for _, mockedMethod := range GetGenericMockFrom(mock).mockedMethods {
-		for _, invocation := range mockedMethod.invocations {
+		mockedMethod.Lock()
+		for _, invocation := range mockedMethod.recordedInvocations() {
 			fmt.Fprintf(result, "Method invocation: %v (\n", mockedMethod.name)
 			for _, param := range invocation.params {
 				fmt.Fprint(result, format.Object(param, 1), ",\n")
 			}
 			fmt.Fprintln(result, ")")
 		}
+		mockedMethod.Unlock()
 	}
 	return result.String()
 }
@@ -575,7 +1245,7 @@ func SDumpInvocationsFor(mock Mock) string {
 // with a fail handler that simply annotates failures.  The original fail handler
 // is reset when InterceptMockFailures returns.
 func InterceptMockFailures(f func()) []string {
-	originalHandler := GlobalFailHandler
+	originalHandler := globalFailHandler()
 	failures := []string{}
 	RegisterMockFailHandler(func(message string, callerSkip ...int) {
 		failures = append(failures, message)
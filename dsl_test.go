@@ -15,15 +15,25 @@
 package pegomock_test
 
 import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/onsi/ginkgo/v2"
 	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/gcustom"
 	"github.com/petergtz/pegomock/v4"
 	. "github.com/petergtz/pegomock/v4"
 	"github.com/petergtz/pegomock/v4/test_interface"
@@ -38,6 +48,8 @@ var (
 	Context          = ginkgo.Context
 	BeNil            = gomega.BeNil
 	BeTrue           = gomega.BeTrue
+	BeFalse          = gomega.BeFalse
+	BeEmpty          = gomega.BeEmpty
 	ConsistOf        = gomega.ConsistOf
 	ContainSubstring = gomega.ContainSubstring
 	MatchError       = gomega.MatchError
@@ -70,6 +82,19 @@ func NeverMatchingRequest() http.Request {
 	return http.Request{}
 }
 
+type LengthMatcher struct{ Length int }
+
+func (matcher *LengthMatcher) Matches(param Param) bool { return len(param.(string)) == matcher.Length }
+func (matcher *LengthMatcher) DescribeMismatch(actual Param) string {
+	return fmt.Sprintf("has length %v instead of %v", len(actual.(string)), matcher.Length)
+}
+func (matcher *LengthMatcher) String() string { return fmt.Sprintf("HasLength(%v)", matcher.Length) }
+
+func HasLength(length int) string {
+	RegisterMatcher(&LengthMatcher{length})
+	return ""
+}
+
 var _ = Describe("MockDisplay", func() {
 	var display *MockDisplay
 
@@ -593,6 +618,738 @@ var _ = Describe("MockDisplay", func() {
 		})
 	})
 
+	Describe("MatchesRegex matcher", func() {
+		It("succeeds verification when argument matches the pattern", func() {
+			display.Show("Hello World")
+			display.VerifyWasCalledOnce().Show(MatchesRegex("^Hel+o"))
+		})
+
+		It("stubs only invocations with arguments matching the pattern", func() {
+			When(display.MultipleParamsAndReturnValue(MatchesRegex("^[0-9]+$"), Any[int]())).ThenReturn("number")
+
+			Expect(display.MultipleParamsAndReturnValue("123", 1)).To(Equal("number"))
+			Expect(display.MultipleParamsAndReturnValue("abc", 1)).To(Equal(""))
+		})
+
+		It("fails verification with the pattern in the message when argument does not match", func() {
+			display.Show("Goodbye")
+			Expect(func() { display.VerifyWasCalledOnce().Show(MatchesRegex("^Hel+o")) }).To(PanicWithMessageTo(SatisfyAll(
+				ContainSubstring(`Show(MatchesRegex("^Hel+o"))`),
+				ContainSubstring(`Show("Goodbye")`),
+			)))
+		})
+	})
+
+	Describe("FieldsMatch matcher", func() {
+		It("succeeds verification when the given subset of fields matches", func() {
+			display.NetHttpRequestParam(http.Request{Method: "GET", Host: "x.com"})
+			display.VerifyWasCalledOnce().NetHttpRequestParam(FieldsMatch[http.Request](map[string]interface{}{"Host": "x.com"}))
+		})
+
+		It("matches fields through pointers", func() {
+			display.NetHttpRequestPtrParam(&http.Request{Method: "GET", Host: "x.com"})
+			display.VerifyWasCalledOnce().NetHttpRequestPtrParam(FieldsMatch[*http.Request](map[string]interface{}{"Method": "GET"}))
+		})
+
+		It("fails verification naming the mismatching field", func() {
+			display.NetHttpRequestParam(http.Request{Host: "y.com"})
+			Expect(func() {
+				display.VerifyWasCalledOnce().NetHttpRequestParam(FieldsMatch[http.Request](map[string]interface{}{"Host": "x.com"}))
+			}).To(PanicWithMessageTo(ContainSubstring(`NetHttpRequestParam(FieldsMatch({Host: "x.com"}))`)))
+		})
+
+		It("does not match when a field does not exist", func() {
+			matcher := &FieldsMatcher{Fields: map[string]interface{}{"NoSuchField": 1}}
+			Expect(matcher.Matches(http.Request{})).To(BeFalse())
+			Expect(matcher.FailureMessage()).To(ContainSubstring("http.Request has no field NoSuchField"))
+		})
+
+		It("does not match fields promoted through nil embedded pointers", func() {
+			type Base struct{ ID int }
+			type Entity struct {
+				*Base
+				Name string
+			}
+			display.InterfaceParam(Entity{Name: "entity"})
+
+			display.VerifyWasCalled(Never()).InterfaceParam(FieldsMatch[interface{}](map[string]interface{}{"ID": 1}))
+			matcher := &FieldsMatcher{Fields: map[string]interface{}{"ID": 1}}
+			Expect(matcher.Matches(Entity{Name: "entity"})).To(BeFalse())
+			Expect(matcher.FailureMessage()).To(ContainSubstring("field ID is unreachable through nil embedded pointer"))
+		})
+	})
+
+	Describe("EqCmp matcher", func() {
+		type Entity struct{ ID, Revision int }
+
+		It("succeeds verification when argument is equal according to the cmp options", func() {
+			display.InterfaceParam(Entity{ID: 1, Revision: 2})
+			display.VerifyWasCalledOnce().InterfaceParam(EqCmp[interface{}](Entity{ID: 1, Revision: 3}, cmpopts.IgnoreFields(Entity{}, "Revision")))
+		})
+
+		It("describes the mismatch with a diff", func() {
+			matcher := &CmpMatcher{Value: Entity{ID: 2, Revision: 2}}
+			Expect(matcher.Matches(Entity{ID: 1, Revision: 2})).To(BeFalse())
+			Expect(matcher.FailureMessage()).To(ContainSubstring("Diff (-expected +actual)"))
+		})
+	})
+
+	Describe("EqJSON matcher", func() {
+		It("succeeds verification when argument is JSON equivalent regardless of key order and whitespace", func() {
+			display.Show(`{"b": [1, 2], "a": {"c": null}}`)
+			display.VerifyWasCalledOnce().Show(EqJSON(`{"a":{"c":null},"b":[1,2]}`))
+		})
+
+		It("stubs only invocations with JSON equivalent arguments", func() {
+			When(display.MultipleParamsAndReturnValue(EqJSON(`{"id": 1}`), Any[int]())).ThenReturn("found")
+
+			Expect(display.MultipleParamsAndReturnValue(`{ "id":1 }`, 1)).To(Equal("found"))
+			Expect(display.MultipleParamsAndReturnValue(`{"id": 2}`, 1)).To(Equal(""))
+			Expect(display.MultipleParamsAndReturnValue(`not json`, 1)).To(Equal(""))
+		})
+
+		It("matches []byte arguments", func() {
+			matcher := NewJSONMatcher(`[1, "two"]`)
+			Expect(matcher.Matches([]byte(`[ 1,"two" ]`))).To(BeTrue())
+			Expect(matcher.Matches([]byte(`["two", 1]`))).To(BeFalse())
+			Expect(matcher.FailureMessage()).To(Equal(`Expected: JSON equivalent to [1, "two"]; but got: "[\"two\", 1]"`))
+		})
+
+		It("panics when the expected value is not valid JSON", func() {
+			Expect(func() { NewJSONMatcher(`{"a":`) }).To(Panic())
+		})
+	})
+
+	Describe("Numeric comparison matchers", func() {
+		It("succeeds verification when the argument compares as expected", func() {
+			display.Flash("Hello", 5)
+			display.VerifyWasCalledOnce().Flash(Any[string](), Gt(4))
+			display.VerifyWasCalledOnce().Flash(Any[string](), Gte(5))
+			display.VerifyWasCalledOnce().Flash(Any[string](), Lt(6))
+			display.VerifyWasCalledOnce().Flash(Any[string](), Lte(5))
+			display.VerifyWasCalledOnce().Flash(Any[string](), InRange(5, 10))
+			display.VerifyWasCalled(Never()).Flash(Any[string](), Gt(5))
+			display.VerifyWasCalled(Never()).Flash(Any[string](), Lt(5))
+			display.VerifyWasCalled(Never()).Flash(Any[string](), InRange(6, 10))
+		})
+
+		It("works with floats", func() {
+			display.FloatParam(1.5)
+			display.VerifyWasCalledOnce().FloatParam(InRange[float32](1, 2))
+		})
+
+		It("shows the matcher in failure messages", func() {
+			display.Flash("Hello", 5)
+			Expect(func() { display.VerifyWasCalledOnce().Flash(Any[string](), InRange(6, 10)) }).
+				To(PanicWithMessageTo(ContainSubstring(`Flash(Any(string), InRange(6, 10))`)))
+		})
+	})
+
+	Describe("EqApprox matcher", func() {
+		It("succeeds verification when the float is within tolerance", func() {
+			display.FloatParam(0.1 + 0.2)
+			display.VerifyWasCalledOnce().FloatParam(EqApprox[float32](0.3, 1e-6))
+			display.VerifyWasCalled(Never()).FloatParam(EqApprox[float32](0.31, 1e-6))
+		})
+
+		It("shows the matcher in failure messages", func() {
+			display.FloatParam(1)
+			Expect(func() { display.VerifyWasCalledOnce().FloatParam(EqApprox[float32](2, 0.5)) }).
+				To(PanicWithMessageTo(ContainSubstring(`FloatParam(EqApprox(2, 0.5))`)))
+		})
+	})
+
+	Describe("TimeWithin matcher", func() {
+		It("succeeds verification when the time is within delta of the expected time", func() {
+			expected := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+			display.UseTime(expected.Add(-300 * time.Millisecond))
+			display.VerifyWasCalledOnce().UseTime(TimeWithin(expected, time.Second))
+			display.VerifyWasCalledOnce().UseTime(TimeWithin(expected, 300*time.Millisecond))
+			display.VerifyWasCalled(Never()).UseTime(TimeWithin(expected, 100*time.Millisecond))
+		})
+
+		It("shows the matcher in failure messages", func() {
+			display.UseTime(time.Date(2023, 5, 1, 12, 0, 5, 0, time.UTC))
+			Expect(func() {
+				display.VerifyWasCalledOnce().UseTime(TimeWithin(time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC), time.Second))
+			}).To(PanicWithMessageTo(ContainSubstring(`UseTime(TimeWithin(2023-05-01 12:00:00 +0000 UTC, 1s))`)))
+		})
+	})
+
+	Describe("Context matchers", func() {
+		type requestIDKey struct{}
+
+		It("succeeds verification when the context carries the value", func() {
+			display.InterfaceParam(context.WithValue(context.Background(), requestIDKey{}, "abc"))
+			display.VerifyWasCalledOnce().InterfaceParam(ContextWithValue(requestIDKey{}, "abc"))
+			display.VerifyWasCalled(Never()).InterfaceParam(ContextWithValue(requestIDKey{}, "xyz"))
+			display.VerifyWasCalled(Never()).InterfaceParam(ContextWithDeadline())
+		})
+
+		It("succeeds verification when the context has a deadline", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			display.InterfaceParam(ctx)
+			display.VerifyWasCalledOnce().InterfaceParam(ContextWithDeadline())
+		})
+
+		It("does not match non-context arguments", func() {
+			display.InterfaceParam("abc")
+			display.InterfaceParam(nil)
+			display.VerifyWasCalled(Never()).InterfaceParam(ContextWithDeadline())
+		})
+	})
+
+	Describe("ExportInvocations", func() {
+		type userID struct{ id int }
+
+		BeforeEach(func() {
+			RegisterFormatter(func(u userID) string { return fmt.Sprintf("user-%v", u.id) })
+			display.Show("hello")
+			display.InterfaceParam(userID{7})
+		})
+
+		It("writes invocations as JSONL in the order in which they happened", func() {
+			var buffer bytes.Buffer
+			Expect(ExportInvocations(&buffer, JSONL, display)).To(BeNil())
+
+			lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+			Expect(lines).To(HaveLen(2))
+			var first, second ExportedInvocation
+			Expect(json.Unmarshal([]byte(lines[0]), &first)).To(BeNil())
+			Expect(json.Unmarshal([]byte(lines[1]), &second)).To(BeNil())
+			Expect(first.Mock).To(Equal("MockDisplay"))
+			Expect(first.Method).To(Equal("Show"))
+			Expect(first.Params).To(Equal([]string{`"hello"`}))
+			Expect(second.Method).To(Equal("InterfaceParam"))
+			Expect(second.Params).To(Equal([]string{"user-7"}))
+			Expect(second.Sequence > first.Sequence).To(BeTrue())
+		})
+
+		It("writes invocations as CSV", func() {
+			var buffer bytes.Buffer
+			Expect(ExportInvocations(&buffer, CSV, display)).To(BeNil())
+
+			records, e := csv.NewReader(&buffer).ReadAll()
+			Expect(e).To(BeNil())
+			Expect(records).To(HaveLen(3))
+			Expect(records[0]).To(Equal([]string{"mock", "method", "sequence", "test", "labels", "params"}))
+			Expect(records[1][1]).To(Equal("Show"))
+			Expect(records[2][5]).To(Equal("user-7"))
+		})
+
+		It("applies registered formatters to failure messages", func() {
+			Expect(func() { display.VerifyWasCalled(Never()).InterfaceParam(userID{7}) }).
+				To(PanicWithMessageTo(ContainSubstring("InterfaceParam(user-7)")))
+		})
+
+		It("fails for unknown formats", func() {
+			Expect(ExportInvocations(io.Discard, "xml", display)).To(MatchError(`unknown export format "xml"`))
+		})
+	})
+
+	Describe("Methods with only primitive params", func() {
+		It("records invocations made before and after stubbing alike", func() {
+			display.MultipleParamsAndReturnValue("before", 1)
+			When(display.MultipleParamsAndReturnValue("stubbed", 2)).ThenReturn("result")
+			Expect(display.MultipleParamsAndReturnValue("stubbed", 2)).To(Equal("result"))
+			display.MultipleParamsAndReturnValue("after", 3)
+
+			display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("before", 1)
+			display.VerifyWasCalledOnce().MultipleParamsAndReturnValue("stubbed", 2)
+			texts, numbers := display.VerifyWasCalled(Times(3)).MultipleParamsAndReturnValue(Any[string](), Any[int]()).GetAllCapturedArguments()
+			Expect(texts).To(Equal([]string{"before", "stubbed", "after"}))
+			Expect(numbers).To(Equal([]int{1, 2, 3}))
+		})
+	})
+
+	Describe("InvocationRecorder", func() {
+		It("records invocations in order and can filter and reset them", func() {
+			var recorder InvocationRecorder
+			recorder.Record("Show", []Param{"one"})
+			recorder.Record("Flash", []Param{"two", 2})
+			recorder.Record("Show", []Param{"three"})
+
+			Expect(recorder.Invocations()).To(Equal([]RecordedInvocation{
+				{MethodName: "Show", Params: []Param{"one"}},
+				{MethodName: "Flash", Params: []Param{"two", 2}},
+				{MethodName: "Show", Params: []Param{"three"}},
+			}))
+			Expect(recorder.InvocationsOf("Show")).To(HaveLen(2))
+
+			recorder.Reset()
+			Expect(recorder.Invocations()).To(BeEmpty())
+		})
+	})
+
+	Describe("Gomega matcher adapter", func() {
+		It("stubs and verifies with Gomega matchers", func() {
+			When(display.MultipleParamsAndReturnValue(Satisfies[string](HavePrefix("Hel")), Satisfies[int](gomega.BeNumerically(">", 300)))).
+				ThenReturn("Bla")
+
+			Expect(display.MultipleParamsAndReturnValue("Hello", 333)).To(Equal("Bla"))
+			Expect(display.MultipleParamsAndReturnValue("Hello", 3)).To(Equal(""))
+			display.VerifyWasCalledOnce().MultipleParamsAndReturnValue(Satisfies[string](gomega.HaveSuffix("llo")), Eq(333))
+		})
+
+		It("does not match when the Gomega matcher fails with an error", func() {
+			display.InterfaceParam("not a number")
+			display.VerifyWasCalled(Never()).InterfaceParam(Satisfies[interface{}](gomega.BeNumerically(">", 1)))
+		})
+
+		It("shows the Gomega matcher in failure messages", func() {
+			Expect(func() { display.VerifyWasCalledOnce().Show(Satisfies[string](HavePrefix("Hel"))) }).
+				To(PanicWithMessageTo(ContainSubstring(`Show(Satisfies(matchers.HavePrefixMatcher{Prefix:Hel Args:[]}))`)))
+		})
+
+		It("shows Gomega matchers that aren't pointers in failure messages", func() {
+			display.Show("Bye")
+			isHello := gcustom.MakeMatcher(func(text string) (bool, error) { return text == "Hello", nil })
+
+			Expect(func() { display.VerifyWasCalledOnce().Show(Satisfies[string](isHello)) }).
+				To(PanicWithMessageTo(SatisfyAll(
+					ContainSubstring("Show(Satisfies(gcustom.CustomGomegaMatcher"),
+					ContainSubstring(`Show("Bye")`))))
+		})
+	})
+
+	Describe("Generic argument captor", func() {
+		It("captures the arguments of matched invocations with their static type", func() {
+			display.Flash("Hello", 1)
+			display.Flash("Hi", 2)
+			display.Flash("Bye", 3)
+
+			words, numbers := Captor[string](), Captor[int]()
+			display.VerifyWasCalled(Times(3)).Flash(words.Capture(), numbers.Capture())
+			Expect(words.GetAllValues()).To(Equal([]string{"Hello", "Hi", "Bye"}))
+
+			display.VerifyWasCalled(Times(2)).Flash(MatchesRegex("^H"), numbers.Capture())
+			Expect(numbers.GetAllValues()).To(Equal([]int{1, 2, 3, 1, 2}))
+			Expect(numbers.GetCapturedArguments()).To(Equal(2))
+		})
+
+		It("captures nil arguments as zero value", func() {
+			display.ErrorParam(nil)
+
+			captor := Captor[error]()
+			display.VerifyWasCalledOnce().ErrorParam(captor.Capture())
+			Expect(captor.GetCapturedArguments()).To(BeNil())
+			Expect(captor.GetAllValues()).To(HaveLen(1))
+		})
+
+		It("only matches and captures arguments the wrapped matcher matches", func() {
+			display.Show("Hello")
+			display.Show("Bye")
+			display.Show("Hi")
+
+			captor := Captor[string]()
+			display.VerifyWasCalled(Times(2)).Show(captor.CaptureMatching(MatchesRegex("^H")))
+			Expect(captor.GetAllValues()).To(Equal([]string{"Hello", "Hi"}))
+		})
+
+		It("keeps stubbings using captors apart", func() {
+			When(display.MultipleParamsAndReturnValue(Captor[string]().CaptureMatching(Eq("a")), Any[int]())).ThenReturn("A")
+			When(display.MultipleParamsAndReturnValue(Captor[string]().CaptureMatching(Eq("b")), Any[int]())).ThenReturn("B")
+
+			Expect(display.MultipleParamsAndReturnValue("a", 1)).To(Equal("A"))
+			Expect(display.MultipleParamsAndReturnValue("b", 2)).To(Equal("B"))
+		})
+
+		It("shows the wrapped matcher in failure messages", func() {
+			Expect(func() { display.VerifyWasCalledOnce().Show(Captor[string]().CaptureMatching(Eq("Hello"))) }).
+				To(PanicWithMessageTo(ContainSubstring(`Show(Captor[string]().CaptureMatching(Eq("Hello")))`)))
+		})
+
+		It("records arguments captured for different methods and mocks in a shared log, in invocation order", func() {
+			publisher := NewMockDisplay(WithName("publisher"))
+			publisher.Flash("id-1", 1)
+			display.Show("id-1")
+			publisher.Flash("id-2", 2)
+
+			log := NewCaptureLog()
+			display.VerifyWasCalledOnce().Show(CaptorIn[string](log).Capture())
+			publisher.VerifyWasCalled(Times(2)).Flash(CaptorIn[string](log).Capture(), Any[int]())
+			// verifying again doesn't add entries for the same arguments
+			publisher.VerifyWasCalled(Times(2)).Flash(CaptorIn[string](log).Capture(), CaptorIn[int](log).Capture())
+
+			Expect(log.Entries()).To(HaveLen(5))
+			Expect(log.Entries()[0]).To(Equal(CapturedArgument{Method: "publisher.Flash", Position: 0, Value: "id-1", Sequence: log.Entries()[0].Sequence}))
+			Expect(log.Entries()[2].Method).To(Equal("Show"))
+			Expect(log.ValuesOf("Show")).To(Equal([]Param{"id-1"}))
+			Expect(log.ValuesOf("publisher.Flash")).To(Equal([]Param{"id-1", 1, "id-2", 2}))
+		})
+
+		It("captures nothing when the verification fails", func() {
+			display.Show("Hello")
+
+			captor := Captor[string]()
+			Expect(func() { display.VerifyWasCalled(Times(2)).Show(captor.Capture()) }).
+				To(PanicWithMessageTo(ContainSubstring("Show(Captor[string]().Capture())")))
+			Expect(captor.GetAllValues()).To(BeEmpty())
+			Expect(captor.GetCapturedArguments()).To(Equal(""))
+		})
+	})
+
+	Describe("Encoding invocations and stubbings", func() {
+		for _, codec := range []Codec{GobCodec, JSONCodec} {
+			codec := codec
+
+			It(fmt.Sprintf("replays invocations encoded with %T on another mock", codec), func() {
+				display.Flash("Hello", 333)
+				display.NormalAndVariadicParam("one", 2, "three", "four")
+				display.VariadicParam()
+				display.ErrorParam(nil)
+
+				invocations, e := EncodeInvocations(display, codec)
+				Expect(e).To(BeNil())
+				Expect(invocations).To(HaveLen(4))
+				Expect(invocations[0].Method).To(Equal("Flash"))
+
+				replayed := NewMockDisplay()
+				Expect(ReplayInvocations(replayed, codec, invocations)).To(BeNil())
+				inOrder := new(InOrderContext)
+				replayed.VerifyWasCalledInOrder(Once(), inOrder).Flash("Hello", 333)
+				replayed.VerifyWasCalledInOrder(Once(), inOrder).NormalAndVariadicParam("one", 2, "three", "four")
+				replayed.VerifyWasCalledInOrder(Once(), inOrder).VariadicParam()
+				replayed.VerifyWasCalledInOrder(Once(), inOrder).ErrorParam(nil)
+			})
+
+			It(fmt.Sprintf("applies stubbings encoded with %T to another mock", codec), func() {
+				stubbing, e := EncodeStubbing(display, codec, "MultipleParamsAndReturnValue", []Param{"Hello", 333}, ReturnValues{"Bla"})
+				Expect(e).To(BeNil())
+				variadicStubbing, e := EncodeStubbing(display, codec, "NormalAndVariadicParam", []Param{"one", 2, "three"}, nil)
+				Expect(e).To(BeNil())
+
+				Expect(ApplyStubbings(display, codec, []EncodedStubbing{stubbing, variadicStubbing})).To(BeNil())
+				Expect(display.MultipleParamsAndReturnValue("Hello", 333)).To(Equal("Bla"))
+				Expect(display.MultipleParamsAndReturnValue("Hello", 334)).To(Equal(""))
+			})
+		}
+
+		It("fails for unknown methods", func() {
+			Expect(ReplayInvocations(display, JSONCodec, []EncodedInvocation{{Method: "Unknown", Params: []byte("[]")}})).
+				To(MatchError("Mock *pegomock_test.MockDisplay has no method Unknown"))
+		})
+	})
+
+	Describe("Snapshotting interactions", func() {
+		It("keeps the invocations up to the snapshot verifiable after the mock is reset and reused", func() {
+			display.Show("Hello")
+			display.Flash("Hello", 1)
+
+			firstPhase := SnapshotInteractions(display)
+			ResetInvocations(display)
+			display.Show("Bye")
+
+			firstPhase.VerifyWasCalledOnce().Show("Hello")
+			firstPhase.VerifyWasCalledOnce().Flash("Hello", 1)
+			firstPhase.VerifyWasCalled(Never()).Show("Bye")
+			display.VerifyWasCalled(Never()).Show("Hello")
+			display.VerifyWasCalledOnce().Show("Bye")
+		})
+
+		It("keeps stubbings when resetting invocations", func() {
+			When(display.SomeValue()).ThenReturn("Hello")
+			ResetInvocations(display)
+
+			Expect(display.SomeValue()).To(Equal("Hello"))
+		})
+
+		It("does not allow invoking the snapshot", func() {
+			snapshot := SnapshotInteractions(display)
+
+			Expect(func() { snapshot.Show("Hello") }).To(PanicWithMessageTo(ContainSubstring(
+				"Cannot invoke Show on a snapshot created with SnapshotInteractions")))
+		})
+	})
+
+	Describe("Mismatch descriptions", func() {
+		It("explains in failure messages why arguments didn't match", func() {
+			display.Flash("Hello", 333)
+			display.Flash("Hi", 333)
+
+			Expect(func() { display.VerifyWasCalledOnce().Flash(HasLength(3), Eq(333)) }).To(PanicWithMessageTo(ContainSubstring(
+				"Argument mismatches were:\n" +
+					"\tFlash(\"Hello\", 333): argument 1: has length 5 instead of 3\n" +
+					"\tFlash(\"Hi\", 333): argument 1: has length 2 instead of 3\n")))
+		})
+
+		It("only describes arguments that didn't match", func() {
+			display.Flash("Hey", 1)
+
+			Expect(func() { display.VerifyWasCalledOnce().Flash(HasLength(3), Eq(2)) }).To(PanicWithMessageTo(SatisfyAll(
+				gomega.Not(ContainSubstring("Argument mismatches")),
+				ContainSubstring("Flash(\"Hey\", 1)"))))
+		})
+
+		It("uses the failure message of Gomega matchers", func() {
+			display.Show("Goodbye")
+
+			Expect(func() { display.VerifyWasCalledOnce().Show(Satisfies[string](HavePrefix("Hel"))) }).To(PanicWithMessageTo(ContainSubstring(
+				"\tShow(\"Goodbye\"): argument 1: Expected\n\t\t    <string>: Goodbye\n\t\tto have prefix\n\t\t    <string>: Hel")))
+		})
+	})
+
+	Describe("MatchedBy matcher", func() {
+		It("succeeds verification when the predicate holds", func() {
+			display.Flash("Hello", 333)
+			display.VerifyWasCalledOnce().Flash(MatchedBy(func(s string) bool { return strings.HasPrefix(s, "He") }), Any[int]())
+			display.VerifyWasCalled(Never()).Flash(Any[string](), MatchedBy(func(i int) bool { return i%2 == 0 }))
+		})
+
+		It("passes nil arguments as zero value", func() {
+			display.ErrorParam(nil)
+			display.VerifyWasCalledOnce().ErrorParam(MatchedBy(func(e error) bool { return e == nil }))
+		})
+
+		It("uses the description in failure messages", func() {
+			Expect(func() {
+				display.VerifyWasCalledOnce().Flash(MatchedBy(func(s string) bool { return s != "" }, "a non-empty string"), Any[int]())
+			}).To(PanicWithMessageTo(ContainSubstring("Flash(a non-empty string, Any(int))")))
+			Expect(func() {
+				display.VerifyWasCalledOnce().Flash(MatchedBy(func(s string) bool { return s != "" }), Any[int]())
+			}).To(PanicWithMessageTo(ContainSubstring("Flash(MatchedBy(func(string) bool), Any(int))")))
+		})
+	})
+
+	Describe("IsA matcher", func() {
+		It("succeeds verification when the dynamic type is or implements the type", func() {
+			display.InterfaceParam(&bytes.Buffer{})
+			display.VerifyWasCalledOnce().InterfaceParam(IsA[*bytes.Buffer]())
+			display.VerifyWasCalledOnce().InterfaceParam(IsA[io.Reader]())
+			display.VerifyWasCalled(Never()).InterfaceParam(IsA[*strings.Reader]())
+			display.VerifyWasCalled(Never()).InterfaceParam(IsA[bytes.Buffer]())
+		})
+
+		It("does not match nil", func() {
+			display.InterfaceParam(nil)
+			display.VerifyWasCalledOnce().InterfaceParam(Any[io.Reader]())
+			display.VerifyWasCalled(Never()).InterfaceParam(IsA[io.Reader]())
+		})
+
+		It("shows the matcher in failure messages", func() {
+			Expect(func() { display.VerifyWasCalledOnce().InterfaceParam(IsA[io.Reader]()) }).
+				To(PanicWithMessageTo(ContainSubstring("InterfaceParam(IsA[io.Reader]())")))
+		})
+	})
+
+	Describe("NotNil matcher", func() {
+		It("matches only non-nil arguments", func() {
+			display.NetHttpRequestPtrParam(&http.Request{})
+			display.NetHttpRequestPtrParam(nil)
+			display.VerifyWasCalled(Times(2)).NetHttpRequestPtrParam(Any[*http.Request]())
+			display.VerifyWasCalledOnce().NetHttpRequestPtrParam(NotNil[*http.Request]())
+		})
+
+		It("does not match nil interfaces", func() {
+			display.InterfaceParam(nil)
+			display.VerifyWasCalled(Never()).InterfaceParam(NotNil[io.Reader]())
+		})
+
+		It("shows the matcher in failure messages", func() {
+			Expect(func() { display.VerifyWasCalledOnce().NetHttpRequestPtrParam(NotNil[*http.Request]()) }).
+				To(PanicWithMessageTo(ContainSubstring("NetHttpRequestPtrParam(NotNil[*http.Request]())")))
+		})
+	})
+
+	Describe("Same matcher", func() {
+		It("succeeds verification only for the very same pointer", func() {
+			request := &http.Request{Method: "GET"}
+			display.NetHttpRequestPtrParam(request)
+			display.VerifyWasCalledOnce().NetHttpRequestPtrParam(Same(request))
+			display.VerifyWasCalled(Never()).NetHttpRequestPtrParam(Same(&http.Request{Method: "GET"}))
+		})
+
+		It("succeeds verification only for the very same map", func() {
+			options := map[string]interface{}{"retries": 3}
+			display.MapOfStringToInterfaceParam(options)
+			display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(Same(options))
+			display.VerifyWasCalled(Never()).MapOfStringToInterfaceParam(Same(map[string]interface{}{"retries": 3}))
+		})
+
+		It("panics for values without identity", func() {
+			Expect(func() { Same("hello") }).To(PanicWithMessageTo(HavePrefix("Same requires a pointer, map, channel or slice")))
+		})
+	})
+
+	Describe("Error matchers", func() {
+		It("succeeds verification when the error wraps the target", func() {
+			display.ErrorParam(fmt.Errorf("reading config: %w", io.ErrUnexpectedEOF))
+			display.VerifyWasCalledOnce().ErrorParam(ErrorIs(io.ErrUnexpectedEOF))
+			display.VerifyWasCalled(Never()).ErrorParam(ErrorIs(io.EOF))
+		})
+
+		It("succeeds verification when the error wraps an error of the target type", func() {
+			display.ErrorParam(fmt.Errorf("reading config: %w", &os.PathError{Op: "open", Path: "config", Err: io.EOF}))
+			display.VerifyWasCalledOnce().ErrorParam(ErrorAs[*os.PathError]())
+			display.VerifyWasCalled(Never()).ErrorParam(ErrorAs[*os.LinkError]())
+		})
+
+		It("does not match nil errors", func() {
+			display.ErrorParam(nil)
+			display.VerifyWasCalled(Never()).ErrorParam(ErrorIs(io.EOF))
+			display.VerifyWasCalled(Never()).ErrorParam(ErrorAs[*os.PathError]())
+		})
+
+		It("shows the matchers in failure messages", func() {
+			display.ErrorParam(io.EOF)
+			Expect(func() { display.VerifyWasCalledOnce().ErrorParam(ErrorAs[*os.PathError]()) }).
+				To(PanicWithMessageTo(ContainSubstring(`ErrorParam(ErrorAs[*fs.PathError]())`)))
+		})
+	})
+
+	Describe("Slice matchers", func() {
+		It("succeeds verification when the slice contains the element", func() {
+			display.ArrayParam([]string{"one", "two"})
+			display.VerifyWasCalledOnce().ArrayParam(Contains("two"))
+			display.VerifyWasCalled(Never()).ArrayParam(Contains("three"))
+		})
+
+		It("succeeds verification when the slice consists of the elements in any order", func() {
+			display.ArrayParam([]string{"one", "two", "one"})
+			display.VerifyWasCalledOnce().ArrayParam(ConsistsOf("two", "one", "one"))
+			display.VerifyWasCalled(Never()).ArrayParam(ConsistsOf("one", "two", "two"))
+			display.VerifyWasCalled(Never()).ArrayParam(ConsistsOf("one", "two"))
+		})
+
+		It("succeeds verification when the slice has the expected length", func() {
+			display.ArrayParam([]string{"one", "two"})
+			display.VerifyWasCalledOnce().ArrayParam(HasLen[[]string](2))
+			display.VerifyWasCalled(Never()).ArrayParam(HasLen[[]string](3))
+		})
+
+		It("shows the matchers in failure messages", func() {
+			display.ArrayParam([]string{"one"})
+			Expect(func() { display.VerifyWasCalledOnce().ArrayParam(ConsistsOf("one", "two")) }).
+				To(PanicWithMessageTo(ContainSubstring(`ArrayParam(ConsistsOf("one", "two"))`)))
+		})
+	})
+
+	Describe("Hybrid fake", func() {
+		var store *MockUserStore
+
+		BeforeEach(func() { store = NewMockUserStore() })
+
+		It("answers unstubbed CRUD methods from an in-memory store", func() {
+			Expect(store.PutUser("a", test_interface.User{Name: "Ann"})).To(BeNil())
+			Expect(store.GetUser("a")).To(Equal(test_interface.User{Name: "Ann"}))
+
+			Expect(store.DeleteUser("a")).To(BeNil())
+			_, e := store.GetUser("a")
+			Expect(e).To(MatchError(ErrFakeNotFound))
+		})
+
+		It("does not store the values of the invocation passed to When", func() {
+			When(store.PutUser("a", test_interface.User{Name: "Ann"})).ThenReturn(errors.New("store full"))
+
+			_, e := store.GetUser("a")
+			Expect(e).To(MatchError(ErrFakeNotFound))
+			Expect(store.PutUser("a", test_interface.User{Name: "Ann"})).To(MatchError("store full"))
+		})
+
+		It("does not delete values with the invocation passed to When", func() {
+			Expect(store.PutUser("a", test_interface.User{Name: "Ann"})).To(BeNil())
+			When(store.DeleteUser("a")).ThenReturn(errors.New("read-only"))
+
+			Expect(store.GetUser("a")).To(Equal(test_interface.User{Name: "Ann"}))
+		})
+	})
+
+	Describe("Map matchers", func() {
+		BeforeEach(func() {
+			display.MapOfStringToInterfaceParam(map[string]interface{}{"timeout": 5, "retries": 3})
+		})
+
+		It("succeeds verification when the map has the key", func() {
+			display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(HasKey[interface{}]("timeout"))
+			display.VerifyWasCalled(Never()).MapOfStringToInterfaceParam(HasKey[interface{}]("verbose"))
+		})
+
+		It("succeeds verification when the map has the entry", func() {
+			display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(HasEntry[string, interface{}]("retries", 3))
+			display.VerifyWasCalled(Never()).MapOfStringToInterfaceParam(HasEntry[string, interface{}]("retries", 4))
+		})
+
+		It("succeeds verification when the map is a subset of the given map", func() {
+			display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(IsSubsetOf(map[string]interface{}{"timeout": 5, "retries": 3, "verbose": true}))
+			display.VerifyWasCalled(Never()).MapOfStringToInterfaceParam(IsSubsetOf(map[string]interface{}{"timeout": 5}))
+		})
+
+		It("shows the matcher in failure messages", func() {
+			Expect(func() { display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(HasKey[interface{}]("verbose")) }).
+				To(PanicWithMessageTo(ContainSubstring(`MapOfStringToInterfaceParam(HasKey("verbose"))`)))
+		})
+
+		It("does not match maps whose keys have another type", func() {
+			display.InterfaceParam(map[int]int{1: 5})
+
+			display.VerifyWasCalled(Never()).InterfaceParam(HasKey[int]("timeout"))
+			display.VerifyWasCalled(Never()).InterfaceParam(HasEntry[string, int]("timeout", 5))
+			display.VerifyWasCalled(Never()).InterfaceParam(IsSubsetOf(map[string]int{"timeout": 5}))
+			display.VerifyWasCalledOnce().InterfaceParam(HasKey[int](1))
+		})
+	})
+
+	Describe("Not/And/Or matcher combinators", func() {
+		It("succeeds verification with a negated matcher", func() {
+			display.Show("Hello")
+			display.VerifyWasCalledOnce().Show(Not(Eq("Goodbye")))
+			display.VerifyWasCalled(Never()).Show(Not(MatchesRegex("^Hel")))
+		})
+
+		It("stubs with composed matchers for each argument", func() {
+			When(display.MultipleParamsAndReturnValue(And(MatchesRegex("^a"), NotEq("ab")), Or(Eq(1), Eq(2)))).ThenReturn("composed")
+
+			Expect(display.MultipleParamsAndReturnValue("abc", 2)).To(Equal("composed"))
+			Expect(display.MultipleParamsAndReturnValue("ab", 2)).To(Equal(""))
+			Expect(display.MultipleParamsAndReturnValue("abc", 3)).To(Equal(""))
+		})
+
+		It("captures arguments of invocations matched by composed matchers", func() {
+			display.MultipleParamsAndReturnValue("one", 1)
+			display.MultipleParamsAndReturnValue("two", 2)
+			display.MultipleParamsAndReturnValue("three", 3)
+
+			s, i := display.VerifyWasCalled(Times(2)).MultipleParamsAndReturnValue(Not(Eq("two")), Or(Eq(1), Eq(3))).GetAllCapturedArguments()
+
+			Expect(s).To(Equal([]string{"one", "three"}))
+			Expect(i).To(Equal([]int{1, 3}))
+		})
+
+		It("captures arguments with captors inside And", func() {
+			display.Show("apple")
+			display.Show("avocado")
+			display.Show("banana")
+
+			words := Captor[string]()
+			display.VerifyWasCalled(Times(2)).Show(And(words.Capture(), MatchesRegex("^a")))
+
+			Expect(words.GetAllValues()).To(Equal([]string{"apple", "avocado"}))
+		})
+
+		It("captures arguments with captors inside Or only when their own matcher matches", func() {
+			display.Show("apple")
+			display.Show("banana")
+			display.Show("cherry")
+
+			aWords, bWords := Captor[string](), Captor[string]()
+			display.VerifyWasCalled(Times(2)).Show(Or(aWords.CaptureMatching(MatchesRegex("^a")), bWords.CaptureMatching(MatchesRegex("^b"))))
+
+			Expect(aWords.GetAllValues()).To(Equal([]string{"apple"}))
+			Expect(bWords.GetAllValues()).To(Equal([]string{"banana"}))
+		})
+
+		It("shows the composition in failure messages", func() {
+			display.Show("Hello")
+			Expect(func() { display.VerifyWasCalledOnce().Show(And(Not(Eq("Hello")), MatchesRegex("^H"))) }).
+				To(PanicWithMessageTo(ContainSubstring(`Show(And(Not(Eq("Hello")), MatchesRegex("^H")))`)))
+		})
+
+		It("panics when combinator arguments are raw values", func() {
+			Expect(func() { Not("raw") }).To(Panic())
+		})
+	})
+
 	Describe("Logic around matchers and verification", func() {
 		// TODO maybe this should go somewhere else
 		It("Fails when http.Request-parameter is passed as null value and verified as never matching http.Request", func() {
@@ -793,6 +1550,62 @@ var _ = Describe("MockDisplay", func() {
 			})
 		})
 
+		Context("Matching the variadic arguments as a whole", func() {
+			It("matches them as one slice with EqSlice", func() {
+				display.VariadicParam("one", "two")
+				display.NormalAndVariadicParam("one", 2)
+
+				display.VerifyWasCalledOnce().VariadicParam(EqSlice([]string{"one", "two"}))
+				display.VerifyWasCalled(Never()).VariadicParam(EqSlice([]string{"one"}))
+				display.VerifyWasCalledOnce().NormalAndVariadicParam(Eq("one"), Eq(2), EqSlice([]string{}))
+			})
+
+			It("matches any remaining ones with AnyRemaining", func() {
+				display.NormalAndVariadicParam("one", 2)
+				display.NormalAndVariadicParam("one", 2, "three")
+				display.NormalAndVariadicParam("one", 2, "three", "four")
+
+				display.VerifyWasCalled(Times(3)).NormalAndVariadicParam(Eq("one"), Eq(2), AnyRemaining[string]())
+				display.VerifyWasCalled(Times(2)).NormalAndVariadicParam(Eq("one"), Eq(2), Eq("three"), AnyRemaining[string]())
+			})
+
+			It("stubs with them", func() {
+				When(func() { display.VariadicParam(EqSlice([]string{"one", "two"})) }).Then(func([]Param) ReturnValues { panic("stubbed") })
+
+				display.VariadicParam("one")
+				Expect(func() { display.VariadicParam("one", "two") }).To(PanicWith("stubbed"))
+			})
+
+			It("captures the variadic arguments of invocations with different numbers of them", func() {
+				display.NormalAndVariadicParam("one", 2, "three", "four", "five")
+				display.NormalAndVariadicParam("six", 7)
+				display.NormalAndVariadicParam("eight", 9, "ten")
+
+				stringArg, intArg, varArgs := display.VerifyWasCalled(Times(3)).NormalAndVariadicParam(Any[string](), Any[int](), AnyRemaining[string]()).GetAllCapturedArguments()
+				Expect(stringArg).To(Equal([]string{"one", "six", "eight"}))
+				Expect(intArg).To(Equal([]int{2, 7, 9}))
+				Expect(varArgs).To(Equal([][]string{{"three", "four", "five"}, {}, {"ten"}}))
+			})
+
+			It("captures the arguments grouped by invocation", func() {
+				display.NormalAndVariadicParam("one", 2, "three", "four")
+				display.NormalAndVariadicParam("five", 6)
+
+				Expect(display.VerifyWasCalled(Times(2)).NormalAndVariadicParam(Any[string](), Any[int](), AnyRemaining[string]()).GetAllCapturedInvocations()).To(Equal(
+					[]MockDisplay_NormalAndVariadicParam_CapturedInvocation{
+						{S: "one", I: 2, V: []string{"three", "four"}},
+						{S: "five", I: 6, V: []string{}},
+					}))
+			})
+
+			It("shows them in failure messages", func() {
+				Expect(func() { display.VerifyWasCalledOnce().VariadicParam(EqSlice([]string{"one"})) }).
+					To(PanicWithMessageTo(ContainSubstring(`VariadicParam(EqSlice("one"))`)))
+				Expect(func() { display.VerifyWasCalledOnce().VariadicParam(AnyRemaining[string]()) }).
+					To(PanicWithMessageTo(ContainSubstring(`VariadicParam(AnyRemaining[string]())`)))
+			})
+		})
+
 		Context("Concurrent access to mock", func() {
 			It("does not panic", func() {
 				Expect(func() {
@@ -856,6 +1669,47 @@ var _ = Describe("MockDisplay", func() {
 					}).ToNot(Panic())
 				})
 			})
+
+			Context("Parallel invocations of the same method", func() {
+				It("records all of them in the order in which they happened", func() {
+					wg := sync.WaitGroup{}
+					for i := 0; i < 20; i++ {
+						wg.Add(1)
+						go func(i int) {
+							defer wg.Done()
+							for u := 0; u < 50; u++ {
+								display.Flash("goroutine", i*50+u)
+							}
+						}(i)
+					}
+					wg.Wait()
+
+					calls := display.FlashCalls()
+					Expect(calls).To(HaveLen(1000))
+					for i := 1; i < len(calls); i++ {
+						Expect(calls[i].Sequence).To(gomega.BeNumerically(">", calls[i-1].Sequence))
+					}
+					_, ints := display.VerifyWasCalled(Times(1000)).Flash(Any[string](), Any[int]()).GetAllCapturedArguments()
+					Expect(ints).To(ConsistOf(lo.Range(1000)))
+				})
+
+				It("advances a sequence of stubbed return values once per invocation", func() {
+					When(display.SomeValue()).ThenReturn("first").ThenReturn("second")
+					results := make(chan string, 10)
+					wg := sync.WaitGroup{}
+					for i := 0; i < 10; i++ {
+						wg.Add(1)
+						go func() {
+							defer wg.Done()
+							results <- display.SomeValue()
+						}()
+					}
+					wg.Wait()
+					close(results)
+
+					Expect(lo.ChannelToSlice(results)).To(ConsistOf(append([]string{"first"}, lo.Times(9, func(int) string { return "second" })...)))
+				})
+			})
 		})
 
 	})
@@ -907,6 +1761,313 @@ var _ = Describe("MockDisplay", func() {
 		})
 	})
 
+	Context("Mock created with a name", func() {
+		It("identifies the mock in failure messages", func() {
+			primaryStore := NewMockDisplay(WithName("primaryStore"))
+			Expect(func() { primaryStore.VerifyWasCalledOnce().Show("hello") }).
+				To(PanicWithMessageTo(HavePrefix("Mock invocation count for primaryStore.Show(\"hello\") does not match expectation.")))
+		})
+
+		It("identifies the mock in in-order verification failures", func() {
+			primaryStore := NewMockDisplay(WithName("primaryStore"))
+			secondaryStore := NewMockDisplay(WithName("secondaryStore"))
+			secondaryStore.Show("second")
+			primaryStore.Show("first")
+			inOrderContext := new(InOrderContext)
+			primaryStore.VerifyWasCalledInOrder(Once(), inOrderContext).Show("first")
+			Expect(func() { secondaryStore.VerifyWasCalledInOrder(Once(), inOrderContext).Show("second") }).
+				To(PanicWithMessageTo(Equal(`Expected function call secondaryStore.Show("second") before function call primaryStore.Show("first")`)))
+		})
+
+		It("identifies the mock in exported invocations", func() {
+			primaryStore := NewMockDisplay(WithName("primaryStore"))
+			primaryStore.Show("hello")
+			var buffer bytes.Buffer
+			Expect(ExportInvocations(&buffer, JSONL, primaryStore)).To(BeNil())
+			Expect(buffer.String()).To(ContainSubstring(`"mock":"primaryStore"`))
+		})
+	})
+
+	Context("Mock created with labels", func() {
+		It("exposes the labels", func() {
+			cache := NewMockDisplay(WithLabel("layer", "cache"), WithLabel("team", "storage"))
+			Expect(LabelsOf(cache)).To(Equal(map[string]string{"layer": "cache", "team": "storage"}))
+			Expect(LabelsOf(NewMockDisplay())).To(BeEmpty())
+		})
+
+		It("includes the labels in exported invocations", func() {
+			cache := NewMockDisplay(WithLabel("layer", "cache"), WithLabel("team", "storage"))
+			cache.Show("hello")
+
+			var jsonl bytes.Buffer
+			Expect(ExportInvocations(&jsonl, JSONL, cache)).To(BeNil())
+			var invocation ExportedInvocation
+			Expect(json.Unmarshal(jsonl.Bytes(), &invocation)).To(BeNil())
+			Expect(invocation.Labels).To(Equal(map[string]string{"layer": "cache", "team": "storage"}))
+
+			var csvOutput bytes.Buffer
+			Expect(ExportInvocations(&csvOutput, CSV, cache)).To(BeNil())
+			records, e := csv.NewReader(&csvOutput).ReadAll()
+			Expect(e).To(BeNil())
+			Expect(records[1][4]).To(Equal("layer=cache;team=storage"))
+		})
+	})
+
+	Context("Mock created with strict stubbing", func() {
+		var strictDisplay *MockDisplay
+
+		BeforeEach(func() {
+			strictDisplay = NewMockDisplay(WithStrictStubbing(), WithName("display"))
+		})
+
+		It("does not fail when all invocations are stubbed", func() {
+			When(strictDisplay.MultipleParamsAndReturnValue("Hello", 333)).ThenReturn("Bla")
+			When(func() { strictDisplay.Show(Any[string]()) }).ThenReturn()
+
+			strictDisplay.MultipleParamsAndReturnValue("Hello", 333)
+			strictDisplay.Show("Hello")
+
+			Expect(func() { VerifyNoUnstubbedInvocations(strictDisplay) }).NotTo(Panic())
+		})
+
+		It("suggests stubbings for unstubbed invocations", func() {
+			When(strictDisplay.MultipleParamsAndReturnValue("Hello", 333)).ThenReturn("Bla")
+
+			strictDisplay.MultipleParamsAndReturnValue("Bye", 333)
+			strictDisplay.MultipleParamsAndReturnValue("Bye", 333)
+			strictDisplay.FloatParam(1)
+			strictDisplay.NetHttpRequestPtrParam(&http.Request{})
+			strictDisplay.ErrorReturnValue()
+
+			Expect(func() { VerifyNoUnstubbedInvocations(strictDisplay) }).To(PanicWithMessageTo(Equal(
+				"Mock display has unstubbed invocations. To stub them, use e.g.:\n\n" +
+					"\tpegomock.When(display.MultipleParamsAndReturnValue(pegomock.Eq(\"Bye\"), pegomock.Eq(333))).ThenReturn(\"\")\n" +
+					"\tpegomock.When(func() { display.FloatParam(pegomock.Eq(float32(1))) }).ThenReturn()\n" +
+					"\tpegomock.When(func() { display.NetHttpRequestPtrParam(pegomock.Any[*http.Request]()) }).ThenReturn()\n" +
+					"\tpegomock.When(display.ErrorReturnValue()).ThenReturn(nil)",
+			)))
+		})
+	})
+
+	Context("Mock created with invocation timeout", func() {
+		var failureMessage string
+
+		BeforeEach(func() { failureMessage = "" })
+
+		failHandler := WithFailHandler(func(message string, callerSkip ...int) { failureMessage = message })
+
+		It("fails the test with method name and timeout when an Answer blocks", func() {
+			unblock := make(chan struct{})
+			defer close(unblock)
+			display := NewMockDisplay(WithInvocationTimeout(20*time.Millisecond), failHandler)
+			When(display.SomeValue()).Then(func([]Param) ReturnValues {
+				<-unblock
+				return ReturnValues{"too late"}
+			})
+
+			Expect(display.SomeValue()).To(BeEmpty())
+			Expect(failureMessage).To(Equal("Mock invocation SomeValue() was not answered within 20ms. Is a stubbed Answer blocking?"))
+		})
+
+		It("returns stubbed values answered in time", func() {
+			display := NewMockDisplay(failHandler, WithInvocationTimeout(time.Second))
+			When(display.MultipleParamsAndReturnValue("a", 1)).ThenReturn("b")
+
+			Expect(display.MultipleParamsAndReturnValue("a", 1)).To(Equal("b"))
+			Expect(failureMessage).To(BeEmpty())
+		})
+
+		It("applies only to the given methods", func() {
+			display := NewMockDisplay(WithInvocationTimeout(20*time.Millisecond, "Show"), failHandler)
+			When(display.SomeValue()).Then(func([]Param) ReturnValues {
+				time.Sleep(50 * time.Millisecond)
+				return ReturnValues{"slow"}
+			})
+
+			Expect(display.SomeValue()).To(Equal("slow"))
+			Expect(failureMessage).To(BeEmpty())
+		})
+
+		It("still panics when the Answer panics", func() {
+			display := NewMockDisplay(WithInvocationTimeout(time.Second), failHandler)
+			When(display.SomeValue()).ThenPanic("boom")
+
+			Expect(func() { display.SomeValue() }).To(PanicWith("boom"))
+		})
+	})
+
+	Context("Mock created with argument cloning", func() {
+		It("verifies slice arguments as they were passed", func() {
+			display := NewMockDisplay(WithArgumentCloning())
+			buffer := []string{"one", "two"}
+			display.ArrayParam(buffer)
+			buffer[0] = "mutated"
+
+			display.VerifyWasCalledOnce().ArrayParam([]string{"one", "two"})
+			Expect(display.VerifyWasCalledOnce().ArrayParam(Any[[]string]()).GetCapturedArguments()).To(Equal([]string{"one", "two"}))
+		})
+
+		It("records references for methods that opt out", func() {
+			display := NewMockDisplay(WithArgumentCloning(), WithArgumentReferences("ArrayParam"))
+			buffer := []string{"one", "two"}
+			display.ArrayParam(buffer)
+			buffer[0] = "mutated"
+
+			display.VerifyWasCalledOnce().ArrayParam([]string{"mutated", "two"})
+		})
+
+		It("passes the original slice to stubbed Answers", func() {
+			display := NewMockDisplay(WithArgumentCloning())
+			When(func() { display.ArrayParam(Any[[]string]()) }).Then(func(params []Param) ReturnValues {
+				params[0].([]string)[0] = "changed by answer"
+				return nil
+			})
+			buffer := []string{"one"}
+			display.ArrayParam(buffer)
+
+			Expect(buffer).To(Equal([]string{"changed by answer"}))
+		})
+	})
+
+	Context("Mock created with deep argument cloning", func() {
+		It("verifies maps, pointers and nested slices as they were passed", func() {
+			display := NewMockDisplay(WithDeepArgumentCloning())
+			options := map[string]interface{}{"retries": 3, "hosts": []string{"a", "b"}}
+			display.MapOfStringToInterfaceParam(options)
+			options["retries"] = 4
+			options["hosts"].([]string)[0] = "mutated"
+			request := &http.Request{Method: "GET", Header: http.Header{"Accept": {"text/plain"}}}
+			display.NetHttpRequestPtrParam(request)
+			request.Method = "POST"
+			request.Header["Accept"][0] = "mutated"
+
+			display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(map[string]interface{}{"retries": 3, "hosts": []string{"a", "b"}})
+			captured := display.VerifyWasCalledOnce().NetHttpRequestPtrParam(Any[*http.Request]()).GetCapturedArguments()
+			Expect(captured.Method).To(Equal("GET"))
+			Expect(captured.Header).To(Equal(http.Header{"Accept": {"text/plain"}}))
+		})
+
+		It("copies cyclic data structures", func() {
+			type node struct{ Next *node }
+			cycle := &node{}
+			cycle.Next = cycle
+			display := NewMockDisplay(WithDeepArgumentCloning())
+
+			display.InterfaceParam(cycle)
+
+			captured := display.VerifyWasCalledOnce().InterfaceParam(Any[interface{}]()).GetCapturedArguments().(*node)
+			Expect(captured).NotTo(gomega.BeIdenticalTo(cycle))
+			Expect(captured.Next).To(gomega.BeIdenticalTo(captured))
+		})
+
+		It("records references for methods that opt out", func() {
+			display := NewMockDisplay(WithDeepArgumentCloning(), WithArgumentReferences("MapOfStringToInterfaceParam"))
+			options := map[string]interface{}{"retries": 3}
+			display.MapOfStringToInterfaceParam(options)
+			options["retries"] = 4
+
+			display.VerifyWasCalledOnce().MapOfStringToInterfaceParam(map[string]interface{}{"retries": 4})
+		})
+	})
+
+	Context("Calls accessors", func() {
+		It("return typed records of the invocations, in order", func() {
+			before := time.Now()
+			display.Flash("one", 1)
+			display.Show("Hello")
+			display.Flash("two", 2)
+
+			calls := display.FlashCalls()
+			Expect(calls).To(HaveLen(2))
+			Expect(calls[0].Param0).To(Equal("one"))
+			Expect(calls[0].Param1).To(Equal(1))
+			Expect(calls[1].Param0).To(Equal("two"))
+			Expect(calls[1].Sequence - calls[0].Sequence).To(Equal(2))
+			Expect(calls[0].Timestamp).To(gomega.BeTemporally(">=", before))
+			Expect(calls[1].Timestamp).To(gomega.BeTemporally(">=", calls[0].Timestamp))
+		})
+
+		It("group variadic args per invocation", func() {
+			display.NormalAndVariadicParam("one", 2, "three", "four")
+			display.NormalAndVariadicParam("five", 6)
+
+			calls := display.NormalAndVariadicParamCalls()
+			Expect(calls).To(HaveLen(2))
+			Expect(calls[0].V).To(Equal([]string{"three", "four"}))
+			Expect(calls[1].S).To(Equal("five"))
+			Expect(calls[1].V).To(BeEmpty())
+		})
+
+		It("don't fail for methods that weren't invoked", func() {
+			Expect(display.ShowCalls()).To(BeEmpty())
+			Expect(display.SomeValueCalls()).To(BeEmpty())
+		})
+	})
+
+	Context("Mock created without invocation recording", func() {
+		BeforeEach(func() {
+			display = NewMockDisplay(WithoutInvocationRecording())
+		})
+
+		It("answers invocations from stubbings", func() {
+			When(display.MultipleParamsAndReturnValue("Hello", 1)).ThenReturn("stubbed")
+			When(func() { display.Show("Hello") }).Then(func([]Param) ReturnValues { panic("stubbed") })
+
+			Expect(display.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("stubbed"))
+			Expect(display.MultipleParamsAndReturnValue("Hello", 2)).To(BeEmpty())
+			Expect(func() { display.Show("Hello") }).To(PanicWith("stubbed"))
+			display.Flash("Hello", 1)
+		})
+
+		It("doesn't record invocations", func() {
+			display.Show("Hello")
+			display.Flash("Hello", 1)
+
+			Expect(GetGenericMockFrom(display).GetInvocationParams(nil)).To(BeEmpty())
+			Expect(func() { display.VerifyWasCalledOnce().Show("Hello") }).
+				To(PanicWith("Cannot verify Show, because the mock was created with WithoutInvocationRecording."))
+		})
+
+		It("doesn't count the invocation passed to When as a use of the stubbing answering it", func() {
+			var failures []string
+			display = NewMockDisplay(WithoutInvocationRecording(),
+				WithFailHandler(func(message string, callerSkip ...int) { failures = append(failures, message) }))
+			When(display.MultipleParamsAndReturnValue(Any[string](), Any[int]())).ThenReturn("any")
+			When(display.MultipleParamsAndReturnValue("Hello", 1)).ThenReturn("hello")
+
+			Expect(display.MultipleParamsAndReturnValue("Hello", 1)).To(Equal("hello"))
+
+			VerifyNoUnusedStubbings(display)
+			Expect(failures).To(ConsistOf(ContainSubstring("has unused stubbings")))
+		})
+	})
+
+	Context("Mock created with call sites", func() {
+		BeforeEach(func() {
+			display = NewMockDisplay(WithCallSites())
+		})
+
+		It("shows where actual interactions came from in failure messages", func() {
+			_, _, line, _ := runtime.Caller(0)
+			display.Show("Hello")
+			display.Flash("Hello", 1)
+
+			Expect(func() { display.VerifyWasCalledOnce().Show("Bye") }).To(PanicWithMessageTo(SatisfyAll(
+				ContainSubstring(fmt.Sprintf("\tShow(\"Hello\") called from dsl_test.go:%v\n", line+1)),
+				ContainSubstring(fmt.Sprintf("\tFlash(\"Hello\", 1) called from dsl_test.go:%v\n", line+2)),
+			)))
+		})
+
+		It("shows where invocations of stubbed methods came from", func() {
+			When(display.SomeValue()).ThenReturn("stubbed")
+			_, _, line, _ := runtime.Caller(0)
+			display.SomeValue()
+
+			Expect(func() { display.VerifyWasCalled(Times(2)).SomeValue() }).
+				To(PanicWithMessageTo(ContainSubstring(fmt.Sprintf("\tSomeValue() called from dsl_test.go:%v\n", line+1))))
+		})
+	})
+
 	Context("channels", func() {
 
 		Context("using send-/receive-only channels in return types", func() {
@@ -962,6 +2123,36 @@ var _ = Describe("GenericMockDisplay", func() {
 			Expect(display.GenericParams(map[string]int64{"Hello": 333})).To(Equal(int64(666)))
 		})
 	})
+
+	Context("Variadic params of type parameter types", func() {
+		It("stubs and verifies them with matchers", func() {
+			When(display.GenericVariadicWithFixedParam(Eq("key"), AnyRemaining[map[string][]int64]())).ThenReturn(7)
+
+			Expect(display.GenericVariadicWithFixedParam("key", map[string][]int64{"a": {1}}, nil)).To(Equal(7))
+			Expect(display.GenericVariadicWithFixedParam("other")).To(Equal(0))
+			display.GenericVariadic(int64(1), int64(2))
+
+			display.VerifyWasCalledOnce().GenericVariadic(EqSlice([]int64{1, 2}))
+			display.VerifyWasCalledOnce().GenericVariadicWithFixedParam(Eq("key"), AnyRemaining[map[string][]int64]())
+		})
+
+		It("captures them as slices", func() {
+			display.GenericVariadic(int64(1), int64(2))
+			display.GenericVariadic()
+			display.GenericVariadicWithFixedParam("key", map[string][]int64{"a": {1}})
+
+			Expect(display.VerifyWasCalled(Times(2)).GenericVariadic(AnyRemaining[int64]()).GetAllCapturedArguments()).To(Equal([][]int64{{1, 2}, {}}))
+			key, values := display.VerifyWasCalledOnce().GenericVariadicWithFixedParam(Any[string](), AnyRemaining[map[string][]int64]()).GetCapturedArguments()
+			Expect(key).To(Equal("key"))
+			Expect(values).To(Equal([]map[string][]int64{{"a": {1}}}))
+		})
+
+		It("captures invocations without variadic args", func() {
+			display.GenericVariadic()
+
+			Expect(display.VerifyWasCalledOnce().GenericVariadic().GetCapturedArguments()).To(BeEmpty())
+		})
+	})
 })
 
 type expectation struct {
@@ -0,0 +1,123 @@
+package pegomock
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var formatters sync.Map // map from reflect.Type to func(Param) string
+
+// RegisterFormatter registers how params of type T are rendered in failure messages and
+// exported invocations, e.g. to show only the ID of a large struct. Without a formatter, params
+// are rendered with %#v.
+func RegisterFormatter[T any](format func(T) string) {
+	formatters.Store(reflect.TypeOf((*T)(nil)).Elem(), func(param Param) string { return format(param.(T)) })
+}
+
+func formatParam(param Param) string {
+	if param != nil {
+		if format, exists := formatters.Load(reflect.TypeOf(param)); exists {
+			return format.(func(Param) string)(param)
+		}
+	}
+	return fmt.Sprintf("%#v", param)
+}
+
+// ExportFormat is the file format used by ExportInvocations.
+type ExportFormat string
+
+const (
+	// JSONL writes one JSON object per invocation and line.
+	JSONL ExportFormat = "jsonl"
+	// CSV writes a header line and one row per invocation. All params go into a single column, and
+	// so do all labels, formatted as key=value pairs separated by semicolons.
+	CSV ExportFormat = "csv"
+)
+
+// ExportedInvocation is a single invocation as written by ExportInvocations.
+type ExportedInvocation struct {
+	Mock     string            `json:"mock"`
+	Method   string            `json:"method"`
+	Sequence int               `json:"sequence"`
+	Test     string            `json:"test,omitempty"` // only for mocks with WithTestScopedVerification
+	Labels   map[string]string `json:"labels,omitempty"`
+	Params   []string          `json:"params"`
+}
+
+// ExportInvocations writes the invocations recorded on mocks to w in the given format, ordered
+// by the sequence in which they happened, so they can be analyzed outside of the test, e.g. in a
+// notebook. Params are rendered using the formatters registered with RegisterFormatter.
+func ExportInvocations(w io.Writer, format ExportFormat, mocks ...Mock) error {
+	invocations := exportedInvocationsFrom(mocks)
+	switch format {
+	case JSONL:
+		encoder := json.NewEncoder(w)
+		for _, invocation := range invocations {
+			if err := encoder.Encode(invocation); err != nil {
+				return err
+			}
+		}
+		return nil
+	case CSV:
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"mock", "method", "sequence", "test", "labels", "params"})
+		for _, invocation := range invocations {
+			writer.Write([]string{invocation.Mock, invocation.Method, strconv.Itoa(invocation.Sequence), invocation.Test,
+				formatLabels(invocation.Labels), strings.Join(invocation.Params, ", ")})
+		}
+		writer.Flush()
+		return writer.Error()
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+func exportedInvocationsFrom(mocks []Mock) []ExportedInvocation {
+	var result []ExportedInvocation
+	for _, mock := range mocks {
+		labels := LabelsOf(mock)
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		mockName := genericMock.name
+		if mockName == "" {
+			mockName = reflect.Indirect(reflect.ValueOf(mock)).Type().Name()
+		}
+		for methodName, method := range genericMock.mockedMethods {
+			method.Lock()
+			for _, invocation := range method.recordedInvocations() {
+				params := make([]string, len(invocation.params))
+				for i, param := range invocation.params {
+					params[i] = formatParam(param)
+				}
+				result = append(result, ExportedInvocation{
+					Mock:     mockName,
+					Method:   methodName,
+					Sequence: invocation.orderingInvocationNumber,
+					Test:     invocation.testName,
+					Labels:   labels,
+					Params:   params,
+				})
+			}
+			method.Unlock()
+		}
+		genericMock.Unlock()
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Sequence < result[j].Sequence })
+	return result
+}
+
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
@@ -0,0 +1,63 @@
+package pegomock
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrFakeNotFound is returned by CRUD-shaped getter methods of hybrid fakes when nothing was
+// stored under the requested key.
+var ErrFakeNotFound = errors.New("pegomock: fake has no value stored under this key")
+
+// FakeStore holds the in-memory state of mocks generated with --fake. Writes only take effect
+// when the store is used next, so that When can discard the write of the invocation passed to it.
+type FakeStore struct {
+	sync.Mutex
+	entities     map[string]map[interface{}]interface{}
+	pendingWrite func()
+}
+
+func (store *FakeStore) Put(entity string, key interface{}, value interface{}) {
+	store.Lock()
+	defer store.Unlock()
+	store.applyPendingWrite()
+	store.pendingWrite = func() {
+		if store.entities == nil {
+			store.entities = make(map[string]map[interface{}]interface{})
+		}
+		if store.entities[entity] == nil {
+			store.entities[entity] = make(map[interface{}]interface{})
+		}
+		store.entities[entity][key] = value
+	}
+}
+
+func (store *FakeStore) Get(entity string, key interface{}) (value interface{}, exists bool) {
+	store.Lock()
+	defer store.Unlock()
+	store.applyPendingWrite()
+	value, exists = store.entities[entity][key]
+	return
+}
+
+func (store *FakeStore) Delete(entity string, key interface{}) {
+	store.Lock()
+	defer store.Unlock()
+	store.applyPendingWrite()
+	store.pendingWrite = func() { delete(store.entities[entity], key) }
+}
+
+func (store *FakeStore) applyPendingWrite() {
+	if store.pendingWrite != nil {
+		store.pendingWrite()
+		store.pendingWrite = nil
+	}
+}
+
+// discardPendingWrite drops the write of the invocation passed to When, which only describes
+// what to stub.
+func (store *FakeStore) discardPendingWrite() {
+	store.Lock()
+	defer store.Unlock()
+	store.pendingWrite = nil
+}
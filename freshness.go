@@ -0,0 +1,41 @@
+package pegomock
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/petergtz/pegomock/v4/internal/freshness"
+)
+
+// FreshnessCheckEnvVar enables CheckFreshness when set to a non-empty value, e.g. in CI.
+const FreshnessCheckEnvVar = "PEGOMOCK_CHECK_FRESHNESS"
+
+var (
+	checkedMocks      sync.Map
+	freshnessWarnings io.Writer = os.Stderr
+)
+
+// CheckFreshness is called by generated mock constructors. If enabled via FreshnessCheckEnvVar,
+// it warns once per interface when the interface's declaration no longer matches the source hash
+// embedded into the mock, i.e. when someone forgot to regenerate a mock that still compiles. The
+// hash covers interfaces embedded from the same package, but not interfaces embedded from other
+// packages or types the methods refer to, so changes to those go unnoticed.
+func CheckFreshness(importPath, interfaceName, generatedSourceHash string) {
+	if os.Getenv(FreshnessCheckEnvVar) == "" {
+		return
+	}
+	if _, checked := checkedMocks.LoadOrStore(importPath+"."+interfaceName, true); checked {
+		return
+	}
+	currentSourceHash, e := freshness.SourceHash(importPath, interfaceName)
+	if e != nil {
+		fmt.Fprintf(freshnessWarnings, "pegomock: could not check freshness of mock for %v.%v: %v\n", importPath, interfaceName, e)
+		return
+	}
+	if currentSourceHash != generatedSourceHash {
+		fmt.Fprintf(freshnessWarnings, "pegomock: mock for %v.%v is stale: the interface changed since the mock was generated. Please regenerate it.\n",
+			importPath, interfaceName)
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	. "github.com/onsi/ginkgo/v2"
+	"github.com/petergtz/pegomock/v4/mockgen"
 	"github.com/petergtz/pegomock/v4/pegomock/filehandling"
 )
 
@@ -36,4 +37,19 @@ var _ = It("Generate mocks", func() {
 		[]string{"github.com/petergtz/pegomock/v4/test_interface", "GenericDisplay"},
 		"../../mock_generic_display_test.go", "MockGenericDisplay", "pegomock_test",
 		"", false, os.Stdout)
+
+	filehandling.GenerateMockFile(
+		[]string{"github.com/petergtz/pegomock/v4/test_interface", "UserStore"},
+		"../../mock_user_store_test.go", "MockUserStore", "pegomock_test",
+		"", false, os.Stdout, mockgen.WithHybridFake())
+
+	filehandling.GenerateMockFile(
+		[]string{"github.com/petergtz/pegomock/v4/test_interface", "Display"},
+		"../../gomock/mock_display_test.go", "MockDisplay", "gomock_test",
+		"", false, os.Stdout, mockgen.WithGomockStyle())
+
+	filehandling.GenerateMockFile(
+		[]string{"github.com/petergtz/pegomock/v4/test_interface", "GenericDisplay"},
+		"../../gomock/mock_generic_display_test.go", "MockGenericDisplay", "gomock_test",
+		"", false, os.Stdout, mockgen.WithGomockStyle())
 })
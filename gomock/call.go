@@ -0,0 +1,260 @@
+package gomock
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Call is an expected call of a mock method. By default, it is expected exactly once and returns
+// zero values.
+type Call struct {
+	t          TestHelper
+	receiver   interface{}
+	method     string
+	methodType reflect.Type
+	args       []Matcher
+	origin     string // file:line of the code expecting the call
+
+	minCalls, maxCalls int
+	numCalls           int
+
+	prerequisites []*Call
+	// actions run in order for every matching call. The last non-nil result of an action is returned.
+	actions []func(args []interface{}) []interface{}
+}
+
+func newCall(t TestHelper, receiver interface{}, method string, methodType reflect.Type, args ...interface{}) *Call {
+	t.Helper()
+	matchers := make([]Matcher, len(args))
+	for i, arg := range args {
+		if matcher, isMatcher := arg.(Matcher); isMatcher {
+			matchers[i] = matcher
+		} else if arg == nil {
+			// nil args don't tell their type, so they match nil values of any type
+			matchers[i] = Nil()
+		} else {
+			matchers[i] = Eq(arg)
+		}
+	}
+	return &Call{
+		t:          t,
+		receiver:   receiver,
+		method:     method,
+		methodType: methodType,
+		args:       matchers,
+		origin:     callerOrigin(),
+		minCalls:   1,
+		maxCalls:   1,
+	}
+}
+
+// callerOrigin returns the location of the first caller outside this package and generated
+// recorders, i.e. the test expecting the call.
+func callerOrigin() string {
+	for skip := 2; ; skip++ {
+		pc, file, line, ok := runtime.Caller(skip)
+		if !ok {
+			return "unknown"
+		}
+		function := runtime.FuncForPC(pc)
+		if function == nil || !strings.Contains(function.Name(), "/gomock.") && !strings.Contains(function.Name(), "MockRecorder") {
+			return fmt.Sprintf("%v:%v", file, line)
+		}
+	}
+}
+
+// Return sets the values the call returns.
+func (call *Call) Return(rets ...interface{}) *Call {
+	call.t.Helper()
+	if len(rets) != call.methodType.NumOut() {
+		call.t.Fatalf("wrong number of arguments to Return for %T.%v: got %d, want %d [%v]",
+			call.receiver, call.method, len(rets), call.methodType.NumOut(), call.origin)
+	}
+	values := make([]interface{}, len(rets))
+	for i, ret := range rets {
+		want := call.methodType.Out(i)
+		switch {
+		case ret == nil:
+			switch want.Kind() {
+			case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+			default:
+				call.t.Fatalf("wrong type of argument %d to Return for %T.%v: %v is not nillable [%v]",
+					i, call.receiver, call.method, want, call.origin)
+			}
+			values[i] = reflect.Zero(want).Interface()
+		case reflect.TypeOf(ret).AssignableTo(want):
+			values[i] = convertedTo(want, reflect.ValueOf(ret))
+		default:
+			call.t.Fatalf("wrong type of argument %d to Return for %T.%v: %T is not assignable to %v [%v]",
+				i, call.receiver, call.method, ret, want, call.origin)
+		}
+	}
+	call.actions = append(call.actions, func([]interface{}) []interface{} { return values })
+	return call
+}
+
+// Do sets a function that is called with the args of every matching call. Its results are
+// ignored.
+func (call *Call) Do(f interface{}) *Call {
+	fn := reflect.ValueOf(f)
+	call.actions = append(call.actions, func(args []interface{}) []interface{} {
+		fn.Call(argValuesFor(fn.Type(), args))
+		return nil
+	})
+	return call
+}
+
+// DoAndReturn sets a function that is called with the args of every matching call. Its results
+// are returned from the call.
+func (call *Call) DoAndReturn(f interface{}) *Call {
+	fn := reflect.ValueOf(f)
+	call.actions = append(call.actions, func(args []interface{}) []interface{} {
+		results := fn.Call(argValuesFor(fn.Type(), args))
+		rets := make([]interface{}, len(results))
+		for i, result := range results {
+			if i < call.methodType.NumOut() && result.Type().AssignableTo(call.methodType.Out(i)) {
+				rets[i] = convertedTo(call.methodType.Out(i), result)
+			} else {
+				rets[i] = result.Interface()
+			}
+		}
+		return rets
+	})
+	return call
+}
+
+// convertedTo converts value to typ, so generated mocks can type-assert it, e.g. a chan T returned
+// as <-chan T. Values returned as interface types keep their dynamic type.
+func convertedTo(typ reflect.Type, value reflect.Value) interface{} {
+	if typ.Kind() == reflect.Interface {
+		return value.Interface()
+	}
+	return value.Convert(typ).Interface()
+}
+
+func argValuesFor(fnType reflect.Type, args []interface{}) []reflect.Value {
+	values := make([]reflect.Value, len(args))
+	for i, arg := range args {
+		var argType reflect.Type
+		if fnType.IsVariadic() && i >= fnType.NumIn()-1 {
+			argType = fnType.In(fnType.NumIn() - 1).Elem()
+		} else {
+			argType = fnType.In(i)
+		}
+		values[i] = valueOf(arg, argType)
+	}
+	return values
+}
+
+func valueOf(arg interface{}, typ reflect.Type) reflect.Value {
+	if arg == nil {
+		return reflect.Zero(typ)
+	}
+	return reflect.ValueOf(arg)
+}
+
+// Times sets the number of times the call is expected.
+func (call *Call) Times(n int) *Call {
+	call.minCalls, call.maxCalls = n, n
+	return call
+}
+
+// MinTimes sets the minimum number of times the call is expected. Unless MaxTimes was called
+// before, the call may then happen any number of times above that.
+func (call *Call) MinTimes(n int) *Call {
+	call.minCalls = n
+	if call.maxCalls == 1 {
+		call.maxCalls = 1e8
+	}
+	return call
+}
+
+// MaxTimes sets the maximum number of times the call is allowed. Unless MinTimes was called
+// before, the call may then also not happen at all.
+func (call *Call) MaxTimes(n int) *Call {
+	call.maxCalls = n
+	if call.minCalls == 1 {
+		call.minCalls = 0
+	}
+	return call
+}
+
+// AnyTimes allows the call any number of times, including zero.
+func (call *Call) AnyTimes() *Call {
+	call.minCalls, call.maxCalls = 0, 1e8
+	return call
+}
+
+// After makes the call match only after preReq happened as often as expected.
+func (call *Call) After(preReq *Call) *Call {
+	call.t.Helper()
+	if call == preReq {
+		call.t.Fatalf("A call isn't allowed to be its own prerequisite")
+	}
+	call.prerequisites = append(call.prerequisites, preReq)
+	return call
+}
+
+// InOrder makes the calls match only in the order they are given.
+func InOrder(calls ...*Call) {
+	for i := 1; i < len(calls); i++ {
+		calls[i].After(calls[i-1])
+	}
+}
+
+func (call *Call) String() string {
+	args := make([]string, len(call.args))
+	for i, arg := range call.args {
+		args[i] = arg.String()
+	}
+	return fmt.Sprintf("%T.%v(%v) %v", call.receiver, call.method, strings.Join(args, ", "), call.origin)
+}
+
+// matches returns an error describing the first arg that doesn't match. If the method is variadic,
+// the variadic args can be matched either one by one or all together by the last matcher.
+func (call *Call) matches(args []interface{}) error {
+	if len(call.args) == len(args) {
+		var e error
+		for i, matcher := range call.args {
+			if !matcher.Matches(args[i]) {
+				e = fmt.Errorf("argument %v is %#v, but want %v", i, args[i], matcher)
+				break
+			}
+		}
+		if e == nil || !call.methodType.IsVariadic() {
+			return e
+		}
+	}
+	if !call.methodType.IsVariadic() || len(call.args) != call.methodType.NumIn() || len(args) < len(call.args)-1 {
+		return fmt.Errorf("got %v arguments, but want %v", len(args), len(call.args))
+	}
+	numFixedArgs := call.methodType.NumIn() - 1
+	for i := 0; i < numFixedArgs; i++ {
+		if !call.args[i].Matches(args[i]) {
+			return fmt.Errorf("argument %v is %#v, but want %v", i, args[i], call.args[i])
+		}
+	}
+	variadicArgs := reflect.MakeSlice(call.methodType.In(numFixedArgs), 0, len(args)-numFixedArgs)
+	for _, arg := range args[numFixedArgs:] {
+		variadicArgs = reflect.Append(variadicArgs, valueOf(arg, variadicArgs.Type().Elem()))
+	}
+	if !call.args[numFixedArgs].Matches(variadicArgs.Interface()) {
+		return fmt.Errorf("variadic arguments are %#v, but want %v", variadicArgs.Interface(), call.args[numFixedArgs])
+	}
+	return nil
+}
+
+func (call *Call) exhausted() bool { return call.numCalls >= call.maxCalls }
+
+func (call *Call) satisfied() bool { return call.numCalls >= call.minCalls }
+
+func (call *Call) missingPrerequisite() *Call {
+	for _, prerequisite := range call.prerequisites {
+		if !prerequisite.satisfied() {
+			return prerequisite
+		}
+	}
+	return nil
+}
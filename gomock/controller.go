@@ -0,0 +1,189 @@
+// Package gomock is the runtime of mocks generated with "pegomock generate --style gomock". It
+// mirrors the API of github.com/golang/mock/gomock, so tests written against golang/mock keep
+// working after regenerating their mocks with Pegomock and replacing the import path of gomock.
+package gomock
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// TestReporter is the subset of *testing.T that a Controller reports failures to.
+type TestReporter interface {
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// TestHelper is a TestReporter that can also mark helper functions, like *testing.T.
+type TestHelper interface {
+	TestReporter
+	Helper()
+}
+
+type cleanuper interface {
+	Cleanup(func())
+}
+
+// Controller holds the expected calls of the mocks created with it and checks the actual calls
+// against them.
+type Controller struct {
+	// T is the test the controller reports to. Generated mocks call T.Helper(), so failures are
+	// reported at the call sites in the code under test.
+	T TestHelper
+
+	mutex         sync.Mutex
+	expectedCalls []*Call
+	finished      bool
+}
+
+// NewController returns a controller reporting to t. If t has a Cleanup method, like *testing.T,
+// Finish is called automatically when the test ends.
+func NewController(t TestReporter) *Controller {
+	helper, isHelper := t.(TestHelper)
+	if !isHelper {
+		helper = nopHelper{t}
+	}
+	ctrl := &Controller{T: helper}
+	if c, isCleanuper := t.(cleanuper); isCleanuper {
+		c.Cleanup(func() {
+			ctrl.T.Helper()
+			ctrl.finish(true)
+		})
+	}
+	return ctrl
+}
+
+type nopHelper struct{ TestReporter }
+
+func (nopHelper) Helper() {}
+
+// RecordCall records an expected call of method on receiver with the given args, which are
+// either Matchers or values compared using Eq.
+func (ctrl *Controller) RecordCall(receiver interface{}, method string, args ...interface{}) *Call {
+	ctrl.T.Helper()
+	methodValue := reflect.ValueOf(receiver).MethodByName(method)
+	if !methodValue.IsValid() {
+		ctrl.T.Fatalf("gomock: failed finding method %v on %T", method, receiver)
+		return nil
+	}
+	return ctrl.RecordCallWithMethodType(receiver, method, methodValue.Type(), args...)
+}
+
+// RecordCallWithMethodType is like RecordCall, but with the type of the method given explicitly.
+// Generated recorders use it.
+func (ctrl *Controller) RecordCallWithMethodType(receiver interface{}, method string, methodType reflect.Type, args ...interface{}) *Call {
+	ctrl.T.Helper()
+	call := newCall(ctrl.T, receiver, method, methodType, args...)
+
+	ctrl.mutex.Lock()
+	defer ctrl.mutex.Unlock()
+	ctrl.expectedCalls = append(ctrl.expectedCalls, call)
+	return call
+}
+
+// Call is called by generated mocks for every actual call. It fails the test if no expected call
+// matches, and returns the values to return otherwise.
+func (ctrl *Controller) Call(receiver interface{}, method string, args ...interface{}) []interface{} {
+	ctrl.T.Helper()
+
+	ctrl.mutex.Lock()
+	call, e := ctrl.matchingCall(receiver, method, args)
+	if e != nil {
+		ctrl.mutex.Unlock()
+		ctrl.T.Fatalf("Unexpected call to %T.%v(%v) because: %v", receiver, method, formatArgs(args), e)
+		return nil
+	}
+	call.numCalls++
+	actions := call.actions
+	ctrl.mutex.Unlock()
+
+	// actions run without the lock held, so they can call the mocks themselves
+	var rets []interface{}
+	for _, action := range actions {
+		if actionRets := action(args); actionRets != nil {
+			rets = actionRets
+		}
+	}
+	if rets == nil {
+		rets = zeroValuesOf(call.methodType)
+	}
+	return rets
+}
+
+func (ctrl *Controller) matchingCall(receiver interface{}, method string, args []interface{}) (*Call, error) {
+	var reasons []string
+	for _, call := range ctrl.expectedCalls {
+		if call.receiver != receiver || call.method != method {
+			continue
+		}
+		if e := call.matches(args); e != nil {
+			reasons = append(reasons, fmt.Sprintf("expected call at %v doesn't match: %v", call.origin, e))
+			continue
+		}
+		if call.exhausted() {
+			reasons = append(reasons, fmt.Sprintf("expected call at %v has already been called the max number of times", call.origin))
+			continue
+		}
+		if prerequisite := call.missingPrerequisite(); prerequisite != nil {
+			reasons = append(reasons, fmt.Sprintf("expected call at %v must happen after the call at %v", call.origin, prerequisite.origin))
+			continue
+		}
+		return call, nil
+	}
+	if len(reasons) == 0 {
+		return nil, fmt.Errorf("there are no expected calls of the method %q for that receiver", method)
+	}
+	return nil, fmt.Errorf("%v", strings.Join(reasons, "\n\t"))
+}
+
+// Finish fails the test for every expected call that happened fewer times than expected.
+// Controllers created with a *testing.T call it automatically when the test ends.
+func (ctrl *Controller) Finish() {
+	ctrl.T.Helper()
+	ctrl.finish(false)
+}
+
+func (ctrl *Controller) finish(cleanup bool) {
+	ctrl.T.Helper()
+	ctrl.mutex.Lock()
+	if ctrl.finished {
+		ctrl.mutex.Unlock()
+		if !cleanup {
+			ctrl.T.Fatalf("Controller.Finish was called more than once. It has to be called exactly once.")
+		}
+		return
+	}
+	ctrl.finished = true
+	var missing []*Call
+	for _, call := range ctrl.expectedCalls {
+		if !call.satisfied() {
+			missing = append(missing, call)
+		}
+	}
+	ctrl.mutex.Unlock()
+
+	for _, call := range missing {
+		ctrl.T.Errorf("missing call(s) to %v", call)
+	}
+	if len(missing) > 0 {
+		ctrl.T.Fatalf("aborting test due to missing call(s)")
+	}
+}
+
+func zeroValuesOf(methodType reflect.Type) []interface{} {
+	rets := make([]interface{}, methodType.NumOut())
+	for i := range rets {
+		rets[i] = reflect.Zero(methodType.Out(i)).Interface()
+	}
+	return rets
+}
+
+func formatArgs(args []interface{}) string {
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		formatted[i] = fmt.Sprintf("%#v", arg)
+	}
+	return strings.Join(formatted, ", ")
+}
@@ -0,0 +1,208 @@
+package gomock_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/gomock"
+)
+
+func TestGomock(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Gomock Suite")
+}
+
+// fakeReporter records failures. Fatalf panics like *testing.T stops the test.
+type fakeReporter struct {
+	errors []string
+	fatals []string
+}
+
+type fatal struct{}
+
+func (r *fakeReporter) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *fakeReporter) Fatalf(format string, args ...interface{}) {
+	r.fatals = append(r.fatals, fmt.Sprintf(format, args...))
+	panic(fatal{})
+}
+
+func (r *fakeReporter) Helper() {}
+
+func (r *fakeReporter) run(f func()) {
+	defer func() {
+		if e := recover(); e != nil {
+			if _, isFatal := e.(fatal); !isFatal {
+				panic(e)
+			}
+		}
+	}()
+	f()
+}
+
+var _ = Describe("Mocks generated with --style gomock", func() {
+	var (
+		reporter *fakeReporter
+		ctrl     *gomock.Controller
+		display  *MockDisplay
+	)
+
+	BeforeEach(func() {
+		reporter = &fakeReporter{}
+		ctrl = gomock.NewController(reporter)
+		display = NewMockDisplay(ctrl)
+	})
+
+	It("returns the values given to Return", func() {
+		display.EXPECT().MultipleParamsAndReturnValue("a", 1).Return("one")
+		display.EXPECT().MultipleValues().Return("b", 2, float32(3))
+
+		Expect(display.MultipleParamsAndReturnValue("a", 1)).To(Equal("one"))
+		s, i, f := display.MultipleValues()
+		Expect([]interface{}{s, i, f}).To(Equal([]interface{}{"b", 2, float32(3)}))
+
+		reporter.run(ctrl.Finish)
+		Expect(reporter.errors).To(BeEmpty())
+		Expect(reporter.fatals).To(BeEmpty())
+	})
+
+	It("returns zero values without Return and nil for nil Return values", func() {
+		display.EXPECT().SomeValue()
+		display.EXPECT().ErrorReturnValue().Return(nil)
+
+		Expect(display.SomeValue()).To(BeEmpty())
+		Expect(display.ErrorReturnValue()).To(BeNil())
+	})
+
+	It("returns channels with the direction of the method's results", func() {
+		strings, errs := make(chan string), make(chan error)
+		display.EXPECT().ChanReturnValues().Return(strings, errs)
+
+		s, e := display.ChanReturnValues()
+		Expect(s == strings).To(BeTrue())
+		Expect(e == errs).To(BeTrue())
+	})
+
+	It("fails unexpected calls", func() {
+		display.EXPECT().Show("a")
+
+		reporter.run(func() { display.Show("b") })
+
+		Expect(reporter.fatals).To(ConsistOf(And(
+			HavePrefix(`Unexpected call to *gomock_test.MockDisplay.Show("b") because: expected call at `),
+			HaveSuffix(`doesn't match: argument 0 is "b", but want is equal to "a"`))))
+	})
+
+	It("fails calls of methods without expected calls", func() {
+		reporter.run(func() { display.Flash("a", 1) })
+
+		Expect(reporter.fatals).To(ConsistOf(
+			`Unexpected call to *gomock_test.MockDisplay.Flash("a", 1) because: there are no expected calls of the method "Flash" for that receiver`))
+	})
+
+	It("fails missing calls on Finish", func() {
+		display.EXPECT().Show("a")
+
+		reporter.run(ctrl.Finish)
+
+		Expect(reporter.errors).To(ConsistOf(HavePrefix(`missing call(s) to *gomock_test.MockDisplay.Show(is equal to "a") `)))
+		Expect(reporter.fatals).To(ConsistOf("aborting test due to missing call(s)"))
+	})
+
+	It("expects calls the given number of times", func() {
+		display.EXPECT().Show("a").Times(2)
+		display.EXPECT().Show("b").AnyTimes()
+		display.EXPECT().Show("c").MinTimes(1)
+
+		display.Show("a")
+		display.Show("a")
+		display.Show("c")
+		display.Show("c")
+		reporter.run(func() { display.Show("a") })
+		Expect(reporter.fatals).To(ConsistOf(ContainSubstring("has already been called the max number of times")))
+
+		reporter.fatals = nil
+		reporter.run(ctrl.Finish)
+		Expect(reporter.errors).To(BeEmpty())
+		Expect(reporter.fatals).To(BeEmpty())
+	})
+
+	It("matches args with matchers", func() {
+		display.EXPECT().Flash(gomock.Any(), gomock.Not(0)).Return()
+		display.EXPECT().ArrayParam(gomock.Len(2))
+		display.EXPECT().ErrorParam(gomock.Nil())
+		display.EXPECT().InterfaceParam(gomock.AssignableToTypeOf(""))
+
+		display.Flash("x", 1)
+		display.ArrayParam([]string{"a", "b"})
+		display.ErrorParam(nil)
+		display.InterfaceParam("y")
+
+		reporter.run(ctrl.Finish)
+		Expect(reporter.errors).To(BeEmpty())
+	})
+
+	It("matches variadic args one by one or all together", func() {
+		display.EXPECT().NormalAndVariadicParam("a", 1, "b", gomock.Any())
+		display.EXPECT().NormalAndVariadicParam("c", 2, []string{"d", "e", "f"})
+		display.EXPECT().VariadicParam(gomock.Len(0))
+
+		display.NormalAndVariadicParam("a", 1, "b", "x")
+		display.NormalAndVariadicParam("c", 2, "d", "e", "f")
+		display.VariadicParam()
+
+		reporter.run(ctrl.Finish)
+		Expect(reporter.errors).To(BeEmpty())
+	})
+
+	It("runs the functions given to Do and DoAndReturn", func() {
+		var shown []string
+		display.EXPECT().Show(gomock.Any()).Do(func(s string) { shown = append(shown, s) }).Times(2)
+		display.EXPECT().MultipleParamsAndReturnValue(gomock.Any(), gomock.Any()).DoAndReturn(func(s string, i int) string {
+			return fmt.Sprint(s, i)
+		})
+		display.EXPECT().ErrorReturnValue().DoAndReturn(func() error { return errors.New("failed") })
+
+		display.Show("a")
+		display.Show("b")
+		Expect(shown).To(Equal([]string{"a", "b"}))
+		Expect(display.MultipleParamsAndReturnValue("c", 3)).To(Equal("c3"))
+		Expect(display.ErrorReturnValue()).To(MatchError("failed"))
+	})
+
+	It("enforces the order given to InOrder", func() {
+		gomock.InOrder(
+			display.EXPECT().Show("first"),
+			display.EXPECT().Show("second"),
+		)
+
+		reporter.run(func() { display.Show("second") })
+		Expect(reporter.fatals).To(ConsistOf(ContainSubstring("must happen after the call at")))
+
+		reporter.fatals = nil
+		display.Show("first")
+		display.Show("second")
+		Expect(reporter.fatals).To(BeEmpty())
+	})
+
+	It("fails Return with wrong types", func() {
+		reporter.run(func() { display.EXPECT().SomeValue().Return(1) })
+
+		Expect(reporter.fatals).To(ConsistOf(HavePrefix(
+			"wrong type of argument 0 to Return for *gomock_test.MockDisplay.SomeValue: int is not assignable to string")))
+	})
+
+	It("mocks generic interfaces", func() {
+		genericDisplay := NewMockGenericDisplay[string, int64](ctrl)
+		genericDisplay.EXPECT().GenericParams(map[string]int64{"a": 1}).Return(int64(2))
+		genericDisplay.EXPECT().GenericVariadicWithFixedParam("b", gomock.Len(2)).Return(3)
+
+		Expect(genericDisplay.GenericParams(map[string]int64{"a": 1})).To(Equal(int64(2)))
+		Expect(genericDisplay.GenericVariadicWithFixedParam("b", nil, nil)).To(Equal(3))
+	})
+})
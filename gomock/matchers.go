@@ -0,0 +1,92 @@
+package gomock
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Matcher matches the args of expected calls. Args given to a recorder that are no Matchers are
+// matched with Eq.
+type Matcher interface {
+	Matches(x interface{}) bool
+	String() string
+}
+
+type anyMatcher struct{}
+
+func (anyMatcher) Matches(interface{}) bool { return true }
+func (anyMatcher) String() string           { return "is anything" }
+
+// Any matches any value.
+func Any() Matcher { return anyMatcher{} }
+
+type eqMatcher struct{ x interface{} }
+
+func (m eqMatcher) Matches(x interface{}) bool { return reflect.DeepEqual(m.x, x) }
+func (m eqMatcher) String() string             { return fmt.Sprintf("is equal to %#v", m.x) }
+
+// Eq matches values that are reflect.DeepEqual to x.
+func Eq(x interface{}) Matcher { return eqMatcher{x} }
+
+type nilMatcher struct{}
+
+func (nilMatcher) Matches(x interface{}) bool {
+	if x == nil {
+		return true
+	}
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+	return false
+}
+func (nilMatcher) String() string { return "is nil" }
+
+// Nil matches nil values of any nillable type.
+func Nil() Matcher { return nilMatcher{} }
+
+type notMatcher struct{ m Matcher }
+
+func (m notMatcher) Matches(x interface{}) bool { return !m.m.Matches(x) }
+func (m notMatcher) String() string             { return "not(" + m.m.String() + ")" }
+
+// Not negates x, which is either a Matcher or a value matched with Eq.
+func Not(x interface{}) Matcher {
+	if m, isMatcher := x.(Matcher); isMatcher {
+		return notMatcher{m}
+	}
+	return notMatcher{Eq(x)}
+}
+
+type lenMatcher struct{ i int }
+
+func (m lenMatcher) Matches(x interface{}) bool {
+	v := reflect.ValueOf(x)
+	switch v.Kind() {
+	case reflect.Array, reflect.Chan, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == m.i
+	}
+	return false
+}
+func (m lenMatcher) String() string { return fmt.Sprintf("has length %d", m.i) }
+
+// Len matches arrays, channels, maps, slices and strings of length i.
+func Len(i int) Matcher { return lenMatcher{i} }
+
+type assignableToTypeOfMatcher struct{ targetType reflect.Type }
+
+func (m assignableToTypeOfMatcher) Matches(x interface{}) bool {
+	if x == nil {
+		return false
+	}
+	return reflect.TypeOf(x).AssignableTo(m.targetType)
+}
+func (m assignableToTypeOfMatcher) String() string {
+	return "is assignable to " + m.targetType.Name()
+}
+
+// AssignableToTypeOf matches values assignable to the type of x.
+func AssignableToTypeOf(x interface{}) Matcher {
+	return assignableToTypeOfMatcher{reflect.TypeOf(x)}
+}
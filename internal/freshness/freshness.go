@@ -0,0 +1,142 @@
+// Package freshness computes hashes of interface declarations, so that generated mocks can
+// detect at runtime whether the interface changed since they were generated.
+package freshness
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// SourceHash returns a hash of the declaration of interface interfaceName in the package with
+// importPath, including the declarations of the interfaces it embeds from the same package.
+// Comments and formatting don't affect the hash. Neither do changes to interfaces embedded from
+// other packages or to types declared elsewhere that the interface's methods refer to.
+func SourceHash(importPath, interfaceName string) (string, error) {
+	goFiles, e := goFilesOf(importPath)
+	if e != nil {
+		return "", e
+	}
+	fileSet := token.NewFileSet()
+	specs := map[string]*ast.TypeSpec{}
+	for _, goFile := range goFiles {
+		file, e := parser.ParseFile(fileSet, goFile, nil, parser.SkipObjectResolution)
+		if e != nil {
+			return "", e
+		}
+		addTypeSpecs(specs, file)
+	}
+	spec, exists := specs[interfaceName]
+	if !exists {
+		return "", fmt.Errorf("Did not find declaration of %v in package %v", interfaceName, importPath)
+	}
+	declarations := []string{canonicalDeclarationOf(spec)}
+	for _, embedded := range embeddedSpecsOf(spec, specs, map[string]bool{interfaceName: true}) {
+		declarations = append(declarations, canonicalDeclarationOf(embedded))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(declarations, "\n")))
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// embeddedSpecsOf returns the declarations of the interfaces from specs that spec embeds, directly
+// or indirectly, in order of appearance. Interfaces in visited are skipped.
+func embeddedSpecsOf(spec *ast.TypeSpec, specs map[string]*ast.TypeSpec, visited map[string]bool) []*ast.TypeSpec {
+	ifaceType, isInterface := spec.Type.(*ast.InterfaceType)
+	if !isInterface {
+		return nil
+	}
+	var result []*ast.TypeSpec
+	for _, field := range ifaceType.Methods.List {
+		if len(field.Names) != 0 {
+			continue
+		}
+		name := embeddedNameOf(field.Type)
+		embedded, exists := specs[name]
+		if !exists || visited[name] {
+			continue
+		}
+		visited[name] = true
+		result = append(append(result, embedded), embeddedSpecsOf(embedded, specs, visited)...)
+	}
+	return result
+}
+
+// embeddedNameOf returns the name of an embedded type of the same package, like Base or Base[T],
+// or "" for others, like io.Reader.
+func embeddedNameOf(expr ast.Expr) string {
+	switch expr := expr.(type) {
+	case *ast.Ident:
+		return expr.Name
+	case *ast.IndexExpr:
+		return embeddedNameOf(expr.X)
+	case *ast.IndexListExpr:
+		return embeddedNameOf(expr.X)
+	}
+	return ""
+}
+
+// goFilesOf returns the Go files of the package with importPath, including its _test.go files, so
+// interfaces declared in tests are found, too. It uses "go list" rather than go/packages to keep
+// golang.org/x/tools out of the binaries of tests using Pegomock.
+func goFilesOf(importPath string) ([]string, error) {
+	output, e := exec.Command("go", "list", "-find", "-f",
+		"{{.Dir}}{{range .GoFiles}}\n{{.}}{{end}}{{range .TestGoFiles}}\n{{.}}{{end}}{{range .XTestGoFiles}}\n{{.}}{{end}}", importPath).Output()
+	if e != nil {
+		var exitErr *exec.ExitError
+		if errors.As(e, &exitErr) {
+			return nil, fmt.Errorf("go list %v failed: %v", importPath, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, e
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	goFiles := make([]string, 0, len(lines)-1)
+	for _, goFile := range lines[1:] {
+		goFiles = append(goFiles, filepath.Join(lines[0], goFile))
+	}
+	return goFiles, nil
+}
+
+// canonicalDeclarationOf renders spec on a single line without comments, so that only changes
+// to the declaration itself change its hash.
+func canonicalDeclarationOf(spec *ast.TypeSpec) string {
+	declaration := spec.Name.Name
+	if spec.TypeParams != nil {
+		typeParams := make([]string, len(spec.TypeParams.List))
+		for i, field := range spec.TypeParams.List {
+			typeParams[i] = fieldString(field)
+		}
+		declaration += "[" + strings.Join(typeParams, ", ") + "]"
+	}
+	return declaration + " " + types.ExprString(spec.Type)
+}
+
+func fieldString(field *ast.Field) string {
+	names := make([]string, len(field.Names))
+	for i, name := range field.Names {
+		names[i] = name.Name
+	}
+	return strings.Join(names, ", ") + " " + types.ExprString(field.Type)
+}
+
+func addTypeSpecs(specs map[string]*ast.TypeSpec, file *ast.File) {
+	for _, decl := range file.Decls {
+		genDecl, isGenDecl := decl.(*ast.GenDecl)
+		if !isGenDecl || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if _, exists := specs[typeSpec.Name.Name]; !exists {
+				specs[typeSpec.Name.Name] = typeSpec
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package freshness_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/internal/freshness"
+)
+
+func TestFreshness(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Freshness Suite")
+}
+
+var _ = Describe("SourceHash", func() {
+	It("does not change when only comments or formatting change", func() {
+		original, e := freshness.SourceHash("./testdata/original", "Store")
+		Expect(e).NotTo(HaveOccurred())
+		reformatted, e := freshness.SourceHash("./testdata/reformatted", "Store")
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(original).NotTo(BeEmpty())
+		Expect(reformatted).To(Equal(original))
+	})
+
+	It("changes when the interface changes", func() {
+		original, e := freshness.SourceHash("./testdata/original", "Store")
+		Expect(e).NotTo(HaveOccurred())
+		changed, e := freshness.SourceHash("./testdata/changed", "Store")
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(changed).NotTo(Equal(original))
+	})
+
+	It("changes when an interface embedded from the same package changes", func() {
+		original, e := freshness.SourceHash("./testdata/embedding", "Store")
+		Expect(e).NotTo(HaveOccurred())
+		changed, e := freshness.SourceHash("./testdata/embedding_changed", "Store")
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(changed).NotTo(Equal(original))
+	})
+
+	It("only covers the declaration itself for interfaces embedding no interfaces of the same package", func() {
+		hash, e := freshness.SourceHash("./testdata/original", "Store")
+		Expect(e).NotTo(HaveOccurred())
+
+		// the hash of mocks generated before embedded interfaces were covered
+		sum := sha256.Sum256([]byte("Store interface{Get(key string) (string, error); Put(key, value string) error}"))
+		Expect(hash).To(Equal(hex.EncodeToString(sum[:8])))
+	})
+
+	It("fails when the interface does not exist", func() {
+		_, e := freshness.SourceHash("./testdata/original", "NoSuchInterface")
+		Expect(e).To(MatchError(ContainSubstring("Did not find declaration of NoSuchInterface")))
+	})
+})
@@ -0,0 +1,6 @@
+package store
+
+type Store interface {
+	Get(key string) (string, error)
+	Put(key, value string, overwrite bool) error
+}
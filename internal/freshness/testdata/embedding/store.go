@@ -0,0 +1,10 @@
+package store
+
+type Store interface {
+	Getter
+	Put(key, value string) error
+}
+
+type Getter interface {
+	Get(key string) (string, error)
+}
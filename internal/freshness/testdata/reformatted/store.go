@@ -0,0 +1,9 @@
+package store
+
+// Store stores things.
+type Store interface {
+	// Get gets things.
+	Get(key string) (string, error)
+
+	Put(key, value string) error // Put puts things.
+}
@@ -0,0 +1,21 @@
+//go:build go1.20
+
+// Interfaces satisfy comparable only as of Go 1.20, which map matchers with interface keys need.
+
+package pegomock_test
+
+import (
+	"testing"
+
+	"github.com/petergtz/pegomock/v4"
+)
+
+func TestHasKeyMatchesNilKeysOnlyInMapsWithInterfaceKeys(t *testing.T) {
+	display := NewMockDisplay(pegomock.WithT(t))
+	display.InterfaceParam(map[interface{}]int{nil: 5})
+	display.InterfaceParam(map[string]int{"timeout": 5})
+
+	display.VerifyWasCalledOnce().InterfaceParam(pegomock.HasKey[int, interface{}](nil))
+	display.VerifyWasCalledOnce().InterfaceParam(pegomock.HasEntry[interface{}, int](nil, 5))
+	display.VerifyWasCalledOnce().InterfaceParam(pegomock.HasKey[int, interface{}]("timeout"))
+}
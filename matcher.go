@@ -1,12 +1,21 @@
 package pegomock
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 
 	"sync"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega/types"
 	"github.com/petergtz/pegomock/v4/internal/verify"
+	"golang.org/x/exp/constraints"
 )
 
 type EqMatcher struct {
@@ -118,3 +127,757 @@ func (matcher *AtMostIntMatcher) FailureMessage() string {
 func (matcher *AtMostIntMatcher) String() string {
 	return fmt.Sprintf("AtMost(%v)", matcher.Value)
 }
+
+type RegexMatcher struct {
+	Pattern *regexp.Regexp
+	actual  Param
+	sync.Mutex
+}
+
+func NewRegexMatcher(pattern string) *RegexMatcher {
+	return &RegexMatcher{Pattern: regexp.MustCompile(pattern)}
+}
+
+func (matcher *RegexMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	s, isString := param.(string)
+	return isString && matcher.Pattern.MatchString(s)
+}
+
+func (matcher *RegexMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: string matching regex %q; but got: %#v", matcher.Pattern, matcher.actual)
+}
+
+func (matcher *RegexMatcher) String() string {
+	return fmt.Sprintf("MatchesRegex(%q)", matcher.Pattern)
+}
+
+type FieldsMatcher struct {
+	Fields   map[string]interface{}
+	mismatch string
+	sync.Mutex
+}
+
+func (matcher *FieldsMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.mismatch = ""
+	value := reflect.ValueOf(param)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		matcher.mismatch = fmt.Sprintf("%#v is not a struct", param)
+		return false
+	}
+	for _, name := range sortedFieldNames(matcher.Fields) {
+		structField, exists := value.Type().FieldByName(name)
+		if !exists {
+			matcher.mismatch = fmt.Sprintf("%v has no field %v", value.Type(), name)
+			return false
+		}
+		field, e := value.FieldByIndexErr(structField.Index)
+		switch {
+		case e != nil:
+			matcher.mismatch = fmt.Sprintf("field %v is unreachable through nil embedded pointer", name)
+			return false
+		case !field.CanInterface():
+			matcher.mismatch = fmt.Sprintf("field %v of %v is unexported", name, value.Type())
+			return false
+		case !reflect.DeepEqual(field.Interface(), matcher.Fields[name]):
+			matcher.mismatch = fmt.Sprintf("field %v is %#v", name, field.Interface())
+			return false
+		}
+	}
+	return true
+}
+
+func (matcher *FieldsMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: struct with fields %v; but %v", matcher.formatFields(), matcher.mismatch)
+}
+
+func (matcher *FieldsMatcher) String() string {
+	return fmt.Sprintf("FieldsMatch(%v)", matcher.formatFields())
+}
+
+func (matcher *FieldsMatcher) formatFields() string {
+	fields := make([]string, len(matcher.Fields))
+	for i, name := range sortedFieldNames(matcher.Fields) {
+		fields[i] = fmt.Sprintf("%v: %#v", name, matcher.Fields[name])
+	}
+	return "{" + strings.Join(fields, ", ") + "}"
+}
+
+func sortedFieldNames(fields map[string]interface{}) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type CmpMatcher struct {
+	Value   Param
+	Options []cmp.Option
+	actual  Param
+	sync.Mutex
+}
+
+func (matcher *CmpMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	return cmp.Equal(matcher.Value, param, matcher.Options...)
+}
+
+func (matcher *CmpMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: %v; but got: %v\nDiff (-expected +actual):\n%v",
+		matcher.Value, matcher.actual, cmp.Diff(matcher.Value, matcher.actual, matcher.Options...))
+}
+
+func (matcher *CmpMatcher) String() string {
+	return fmt.Sprintf("EqCmp(%#v)", matcher.Value)
+}
+
+type JSONMatcher struct {
+	Expected string
+	decoded  interface{}
+	actual   Param
+	sync.Mutex
+}
+
+// NewJSONMatcher panics if expected is not valid JSON, so that mistakes in stubbings surface early.
+func NewJSONMatcher(expected string) *JSONMatcher {
+	var decoded interface{}
+	if err := json.Unmarshal([]byte(expected), &decoded); err != nil {
+		panic(fmt.Sprintf("EqJSON: invalid expected JSON %q: %v", expected, err))
+	}
+	return &JSONMatcher{Expected: expected, decoded: decoded}
+}
+
+func (matcher *JSONMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	var raw []byte
+	switch typedParam := param.(type) {
+	case string:
+		raw = []byte(typedParam)
+	case []byte:
+		raw = typedParam
+	default:
+		return false
+	}
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(matcher.decoded, decoded)
+}
+
+func (matcher *JSONMatcher) FailureMessage() string {
+	actual := fmt.Sprintf("%#v", matcher.actual)
+	if b, isBytes := matcher.actual.([]byte); isBytes {
+		actual = fmt.Sprintf("%q", b)
+	}
+	return fmt.Sprintf("Expected: JSON equivalent to %v; but got: %v", matcher.Expected, actual)
+}
+
+func (matcher *JSONMatcher) String() string {
+	return fmt.Sprintf("EqJSON(%v)", matcher.Expected)
+}
+
+type NotMatcher struct {
+	Matcher ArgumentMatcher
+	actual  Param
+	sync.Mutex
+}
+
+func (matcher *NotMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	return !matcher.Matcher.Matches(param)
+}
+
+func (matcher *NotMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: not %v; but got: %#v", matcher.Matcher, matcher.actual)
+}
+
+func (matcher *NotMatcher) String() string {
+	return fmt.Sprintf("Not(%v)", matcher.Matcher)
+}
+
+type AndMatcher struct {
+	Matchers []ArgumentMatcher
+	failed   ArgumentMatcher
+	actual   Param
+	sync.Mutex
+}
+
+func (matcher *AndMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	matcher.failed = nil
+	for _, m := range matcher.Matchers {
+		if !m.Matches(param) {
+			matcher.failed = m
+			return false
+		}
+	}
+	return true
+}
+
+func (matcher *AndMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: %v; but got: %#v, which does not match %v", matcher, matcher.actual, matcher.failed)
+}
+
+// capture passes the arguments to all capturing matchers among matcher's, since an argument only
+// matches if it matches all of them.
+func (matcher *AndMatcher) capture(arguments []CapturedArgument) {
+	for _, m := range matcher.Matchers {
+		if capturer, isCapturer := m.(argumentCapturer); isCapturer {
+			// captors convert the values they capture, so each gets its own copy
+			capturer.capture(append([]CapturedArgument(nil), arguments...))
+		}
+	}
+}
+
+func (matcher *AndMatcher) String() string {
+	return fmt.Sprintf("And(%v)", formatMatchers(matcher.Matchers))
+}
+
+type OrMatcher struct {
+	Matchers []ArgumentMatcher
+	actual   Param
+	sync.Mutex
+}
+
+func (matcher *OrMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	for _, m := range matcher.Matchers {
+		if m.Matches(param) {
+			return true
+		}
+	}
+	return false
+}
+
+func (matcher *OrMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: %v; but got: %#v", matcher, matcher.actual)
+}
+
+// capture passes each argument to the capturing matchers among matcher's that match it.
+func (matcher *OrMatcher) capture(arguments []CapturedArgument) {
+	for _, m := range matcher.Matchers {
+		capturer, isCapturer := m.(argumentCapturer)
+		if !isCapturer {
+			continue
+		}
+		var matched []CapturedArgument
+		for _, argument := range arguments {
+			if m.Matches(argument.Value) {
+				matched = append(matched, argument)
+			}
+		}
+		if len(matched) > 0 {
+			capturer.capture(matched)
+		}
+	}
+}
+
+func (matcher *OrMatcher) String() string {
+	return fmt.Sprintf("Or(%v)", formatMatchers(matcher.Matchers))
+}
+
+type ContainsMatcher struct {
+	Element Param
+	actual  Param
+	sync.Mutex
+}
+
+func (matcher *ContainsMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	value := reflect.ValueOf(param)
+	if !isSliceOrArray(value) {
+		return false
+	}
+	for i := 0; i < value.Len(); i++ {
+		if reflect.DeepEqual(value.Index(i).Interface(), matcher.Element) {
+			return true
+		}
+	}
+	return false
+}
+
+func (matcher *ContainsMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: slice containing %#v; but got: %#v", matcher.Element, matcher.actual)
+}
+
+func (matcher *ContainsMatcher) String() string {
+	return fmt.Sprintf("Contains(%#v)", matcher.Element)
+}
+
+type ConsistsOfMatcher struct {
+	Elements []Param
+	actual   Param
+	sync.Mutex
+}
+
+func (matcher *ConsistsOfMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	value := reflect.ValueOf(param)
+	if !isSliceOrArray(value) || value.Len() != len(matcher.Elements) {
+		return false
+	}
+	used := make([]bool, len(matcher.Elements))
+nextElement:
+	for i := 0; i < value.Len(); i++ {
+		for j, expected := range matcher.Elements {
+			if !used[j] && reflect.DeepEqual(value.Index(i).Interface(), expected) {
+				used[j] = true
+				continue nextElement
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (matcher *ConsistsOfMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: slice consisting of %v in any order; but got: %#v", matcher.formatElements(), matcher.actual)
+}
+
+func (matcher *ConsistsOfMatcher) String() string {
+	return fmt.Sprintf("ConsistsOf(%v)", matcher.formatElements())
+}
+
+func (matcher *ConsistsOfMatcher) formatElements() string {
+	elements := make([]string, len(matcher.Elements))
+	for i, element := range matcher.Elements {
+		elements[i] = fmt.Sprintf("%#v", element)
+	}
+	return strings.Join(elements, ", ")
+}
+
+type LenMatcher struct {
+	Len    int
+	actual Param
+	sync.Mutex
+}
+
+func (matcher *LenMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	switch value := reflect.ValueOf(param); value.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.String, reflect.Chan:
+		return value.Len() == matcher.Len
+	}
+	return false
+}
+
+func (matcher *LenMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: length %v; but got: %#v", matcher.Len, matcher.actual)
+}
+
+func (matcher *LenMatcher) String() string {
+	return fmt.Sprintf("HasLen(%v)", matcher.Len)
+}
+
+func isSliceOrArray(value reflect.Value) bool {
+	return value.Kind() == reflect.Slice || value.Kind() == reflect.Array
+}
+
+type MapMatcher struct {
+	Description string
+	Predicate   func(m reflect.Value) bool
+	actual      Param
+	sync.Mutex
+}
+
+func (matcher *MapMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	value := reflect.ValueOf(param)
+	return value.Kind() == reflect.Map && matcher.Predicate(value)
+}
+
+func (matcher *MapMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: map matching %v; but got: %#v", matcher.Description, matcher.actual)
+}
+
+func (matcher *MapMatcher) String() string {
+	return matcher.Description
+}
+
+func mapHasEntry(m reflect.Value, key, value interface{}) bool {
+	actualValue := mapIndex(m, key)
+	return actualValue.IsValid() && reflect.DeepEqual(actualValue.Interface(), value)
+}
+
+// mapIndex is like m.MapIndex, but returns the zero Value instead of panicking when key can't be a
+// key of m, e.g. when matchers of interface{} params get maps of other key types.
+func mapIndex(m reflect.Value, key interface{}) reflect.Value {
+	keyValue := reflect.ValueOf(key)
+	if !keyValue.IsValid() {
+		if m.Type().Key().Kind() != reflect.Interface {
+			return reflect.Value{}
+		}
+		keyValue = reflect.Zero(m.Type().Key())
+	}
+	if !keyValue.Type().AssignableTo(m.Type().Key()) {
+		return reflect.Value{}
+	}
+	return m.MapIndex(keyValue)
+}
+
+type OrderedMatcher[T constraints.Ordered] struct {
+	Description string
+	Predicate   func(T) bool
+	actual      Param
+	sync.Mutex
+}
+
+func (matcher *OrderedMatcher[T]) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	value, isT := param.(T)
+	return isT && matcher.Predicate(value)
+}
+
+func (matcher *OrderedMatcher[T]) FailureMessage() string {
+	return fmt.Sprintf("Expected: %v; but got: %#v", matcher.Description, matcher.actual)
+}
+
+func (matcher *OrderedMatcher[T]) String() string {
+	return matcher.Description
+}
+
+type ErrorMatcher struct {
+	Description string
+	Predicate   func(error) bool
+	actual      Param
+	sync.Mutex
+}
+
+func (matcher *ErrorMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	err, isError := param.(error)
+	return isError && matcher.Predicate(err)
+}
+
+func (matcher *ErrorMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: error matching %v; but got: %#v", matcher.Description, matcher.actual)
+}
+
+func (matcher *ErrorMatcher) String() string {
+	return matcher.Description
+}
+
+type TimeMatcher struct {
+	Expected time.Time
+	Delta    time.Duration
+	actual   Param
+	sync.Mutex
+}
+
+func (matcher *TimeMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	actual, isTime := param.(time.Time)
+	if !isTime {
+		return false
+	}
+	difference := actual.Sub(matcher.Expected)
+	return -matcher.Delta <= difference && difference <= matcher.Delta
+}
+
+func (matcher *TimeMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: time within %v of %v; but got: %v", matcher.Delta, matcher.Expected, matcher.actual)
+}
+
+func (matcher *TimeMatcher) String() string {
+	return fmt.Sprintf("TimeWithin(%v, %v)", matcher.Expected, matcher.Delta)
+}
+
+type ContextMatcher struct {
+	Description string
+	Predicate   func(context.Context) bool
+	actual      Param
+	sync.Mutex
+}
+
+func (matcher *ContextMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	ctx, isContext := param.(context.Context)
+	return isContext && ctx != nil && matcher.Predicate(ctx)
+}
+
+func (matcher *ContextMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: context matching %v; but got: %v", matcher.Description, matcher.actual)
+}
+
+func (matcher *ContextMatcher) String() string {
+	return matcher.Description
+}
+
+type SameMatcher struct {
+	Expected Param
+	actual   Param
+	sync.Mutex
+}
+
+func (matcher *SameMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	expected, actual := reflect.ValueOf(matcher.Expected), reflect.ValueOf(param)
+	if !actual.IsValid() || actual.Type() != expected.Type() {
+		return false
+	}
+	if expected.Kind() == reflect.Slice {
+		return actual.Pointer() == expected.Pointer() && actual.Len() == expected.Len()
+	}
+	return actual.Pointer() == expected.Pointer()
+}
+
+func (matcher *SameMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: same instance as %#v; but got: %#v", matcher.Expected, matcher.actual)
+}
+
+func (matcher *SameMatcher) String() string {
+	return fmt.Sprintf("Same(%#v)", matcher.Expected)
+}
+
+func hasIdentity(value Param) bool {
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Chan, reflect.Slice, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+type IsAMatcher struct {
+	Type   reflect.Type
+	actual reflect.Type
+	sync.Mutex
+}
+
+func (matcher *IsAMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = reflect.TypeOf(param)
+	if matcher.actual == nil {
+		return false
+	}
+	if matcher.Type.Kind() == reflect.Interface {
+		return matcher.actual.Implements(matcher.Type)
+	}
+	return matcher.actual == matcher.Type
+}
+
+func (matcher *IsAMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: value of type %v; but got: %v", matcher.Type, matcher.actual)
+}
+
+func (matcher *IsAMatcher) String() string {
+	return fmt.Sprintf("IsA[%v]()", matcher.Type)
+}
+
+type NotNilMatcher struct {
+	Type   reflect.Type
+	actual Param
+	sync.Mutex
+}
+
+func (matcher *NotNilMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	return param != nil && !isNilValue(param) && reflect.TypeOf(param).AssignableTo(matcher.Type)
+}
+
+func (matcher *NotNilMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: non-nil %v; but got: %#v", matcher.Type, matcher.actual)
+}
+
+func (matcher *NotNilMatcher) String() string {
+	return fmt.Sprintf("NotNil[%v]()", matcher.Type)
+}
+
+type PredicateMatcher[T any] struct {
+	Description string
+	Predicate   func(T) bool
+	actual      Param
+	sync.Mutex
+}
+
+func (matcher *PredicateMatcher[T]) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	value, isT := param.(T)
+	if !isT && param == nil && NewAnyMatcher(reflect.TypeOf(&value).Elem()).Matches(nil) {
+		// nil interfaces, pointers etc. don't have a dynamic type, but are valid values of T
+		isT = true
+	}
+	return isT && matcher.Predicate(value)
+}
+
+func (matcher *PredicateMatcher[T]) FailureMessage() string {
+	return fmt.Sprintf("Expected: %v; but got: %#v", matcher.Description, matcher.actual)
+}
+
+func (matcher *PredicateMatcher[T]) String() string {
+	return matcher.Description
+}
+
+// GomegaMatcher adapts a Gomega matcher, e.g. HavePrefix("Hello"), to an ArgumentMatcher.
+type GomegaMatcher struct {
+	Matcher types.GomegaMatcher
+	actual  Param
+	err     error
+	sync.Mutex
+}
+
+func NewGomegaMatcher(matcher types.GomegaMatcher) *GomegaMatcher {
+	verify.Argument(matcher != nil, "Must provide a non-nil Gomega matcher")
+	return &GomegaMatcher{Matcher: matcher}
+}
+
+func (matcher *GomegaMatcher) Matches(param Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = param
+	success, err := matcher.Matcher.Match(param)
+	matcher.err = err
+	return err == nil && success
+}
+
+func (matcher *GomegaMatcher) FailureMessage() string {
+	if matcher.err != nil {
+		return fmt.Sprintf("Expected: %v; but got an error for %#v: %v", matcher, matcher.actual, matcher.err)
+	}
+	return matcher.Matcher.FailureMessage(matcher.actual)
+}
+
+func (matcher *GomegaMatcher) DescribeMismatch(actual Param) string {
+	if _, err := matcher.Matcher.Match(actual); err != nil {
+		return err.Error()
+	}
+	return matcher.Matcher.FailureMessage(actual)
+}
+
+func (matcher *GomegaMatcher) String() string {
+	value := reflect.ValueOf(matcher.Matcher)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		// matchers like gcustom's CustomGomegaMatcher are values rather than pointers
+		return fmt.Sprintf("Satisfies(%T%+v)", matcher.Matcher, matcher.Matcher)
+	}
+	return fmt.Sprintf("Satisfies(%v%+v)", value.Type().Elem(), value.Elem().Interface())
+}
+
+// VariadicEqMatcher matches the variadic tail of arguments as a whole. See EqSlice.
+type VariadicEqMatcher struct {
+	Elements []Param
+	actual   []Param
+	sync.Mutex
+}
+
+func (matcher *VariadicEqMatcher) Matches(param Param) bool {
+	return matcher.MatchesVariadic([]Param{param})
+}
+
+func (matcher *VariadicEqMatcher) MatchesVariadic(params []Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = params
+	if len(params) != len(matcher.Elements) {
+		return false
+	}
+	for i := range params {
+		if !reflect.DeepEqual(matcher.Elements[i], params[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (matcher *VariadicEqMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: variadic arguments %v; but got: %v", formatParams(matcher.Elements), formatParams(matcher.actual))
+}
+
+func (matcher *VariadicEqMatcher) String() string {
+	return fmt.Sprintf("EqSlice(%v)", formatParams(matcher.Elements))
+}
+
+// AnyRemainingMatcher matches any number of remaining variadic arguments of a type. See AnyRemaining.
+type AnyRemainingMatcher struct {
+	Type   reflect.Type
+	actual []Param
+	sync.Mutex
+}
+
+func (matcher *AnyRemainingMatcher) Matches(param Param) bool {
+	return matcher.MatchesVariadic([]Param{param})
+}
+
+func (matcher *AnyRemainingMatcher) MatchesVariadic(params []Param) bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	matcher.actual = params
+	for _, param := range params {
+		if !NewAnyMatcher(matcher.Type).Matches(param) {
+			return false
+		}
+	}
+	return true
+}
+
+func (matcher *AnyRemainingMatcher) FailureMessage() string {
+	return fmt.Sprintf("Expected: any number of arguments of type %v; but got: %v", matcher.Type, matcher.actual)
+}
+
+func (matcher *AnyRemainingMatcher) String() string {
+	return fmt.Sprintf("AnyRemaining[%v]()", matcher.Type)
+}
@@ -1,6 +1,19 @@
 package pegomock
 
-import "reflect"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/onsi/gomega/types"
+	"github.com/petergtz/pegomock/v4/internal/verify"
+	"golang.org/x/exp/constraints"
+)
 
 func Eq[T any](value T) T {
 	RegisterMatcher(&EqMatcher{Value: value})
@@ -20,8 +33,288 @@ func Any[T any]() T {
 	return t
 }
 
+// Satisfies matches arguments that the given Gomega matcher matches, e.g.
+// Satisfies[string](HavePrefix("Hello")), so the Gomega matcher ecosystem can be reused.
+func Satisfies[T any](matcher types.GomegaMatcher) T {
+	RegisterMatcher(NewGomegaMatcher(matcher))
+	var t T
+	return t
+}
+
+// MatchedBy matches arguments of type T for which predicate returns true. An optional description
+// is used in failure messages, e.g. MatchedBy(func(u User) bool { return u.Age >= 18 }, "an adult").
+func MatchedBy[T any](predicate func(T) bool, description ...string) T {
+	var t T
+	matcherDescription := fmt.Sprintf("MatchedBy(func(%v) bool)", reflect.TypeOf(&t).Elem())
+	if len(description) > 0 {
+		matcherDescription = strings.Join(description, " ")
+	}
+	RegisterMatcher(&PredicateMatcher[T]{Description: matcherDescription, Predicate: predicate})
+	return t
+}
+
+// IsA matches arguments whose dynamic type is T or, if T is an interface, implements T. Unlike
+// Any, it never matches nil. The result can be passed for any parameter type T is assignable to,
+// e.g. IsA[*bytes.Buffer]() for an io.Writer parameter.
+func IsA[T any]() T {
+	var t T
+	RegisterMatcher(&IsAMatcher{Type: reflect.TypeOf(&t).Elem()})
+	return t
+}
+
+// NotNil matches arguments of type T that are not nil, e.g. NotNil[*http.Request]().
+func NotNil[T any]() T {
+	var t T
+	RegisterMatcher(&NotNilMatcher{Type: reflect.TypeOf(&t).Elem()})
+	return t
+}
+
 func ArgThat[T any](matcher ArgumentMatcher) T {
 	RegisterMatcher(matcher)
 	var t T
 	return t
 }
+
+// MatchesRegex matches string arguments against pattern, which is compiled only once.
+func MatchesRegex(pattern string) string {
+	RegisterMatcher(NewRegexMatcher(pattern))
+	return ""
+}
+
+// FieldsMatch matches struct arguments (or pointers to them) whose given exported fields
+// deep-equal the given values. All other fields are ignored.
+func FieldsMatch[T any](fields map[string]interface{}) T {
+	RegisterMatcher(&FieldsMatcher{Fields: fields})
+	var t T
+	return t
+}
+
+// EqJSON matches string or []byte arguments that are structurally equal to expected when both
+// are decoded as JSON, i.e. key order and whitespace don't matter.
+func EqJSON[T string | []byte](expected T) T {
+	RegisterMatcher(NewJSONMatcher(string(expected)))
+	var t T
+	return t
+}
+
+// EqCmp matches arguments that are equal to value according to cmp.Equal with the given options,
+// e.g. cmpopts.IgnoreFields or cmpopts.EquateApprox.
+func EqCmp[T any](value T, opts ...cmp.Option) T {
+	RegisterMatcher(&CmpMatcher{Value: value, Options: opts})
+	var t T
+	return t
+}
+
+// Contains matches slice arguments that contain elem.
+func Contains[T any](elem T) []T {
+	RegisterMatcher(&ContainsMatcher{Element: elem})
+	return nil
+}
+
+// EqSlice matches the variadic arguments from its position on as a whole, e.g.
+// display.VariadicParam(EqSlice(words)) matches exactly the invocation display.VariadicParam(words...).
+// It must be used for the last argument.
+func EqSlice[T any](elems []T) T {
+	params := make([]Param, len(elems))
+	for i, elem := range elems {
+		params[i] = elem
+	}
+	RegisterMatcher(&VariadicEqMatcher{Elements: params})
+	var t T
+	return t
+}
+
+// AnyRemaining matches any number of variadic arguments of type T from its position on, including
+// none, e.g. display.NormalAndVariadicParam(Eq("one"), Eq(2), AnyRemaining[string]()). It must be
+// used for the last argument. Individual matchers instead match the variadic arguments element-wise.
+func AnyRemaining[T any]() T {
+	var t T
+	RegisterMatcher(&AnyRemainingMatcher{Type: reflect.TypeOf(&t).Elem()})
+	return t
+}
+
+// ConsistsOf matches slice arguments that contain exactly elems, in any order.
+func ConsistsOf[T any](elems ...T) []T {
+	elements := make([]Param, len(elems))
+	for i, elem := range elems {
+		elements[i] = elem
+	}
+	RegisterMatcher(&ConsistsOfMatcher{Elements: elements})
+	return nil
+}
+
+// HasLen matches arguments of length n. T can be any slice, array, map, string or channel type,
+// e.g. HasLen[[]string](2).
+func HasLen[T any](n int) T {
+	RegisterMatcher(&LenMatcher{Len: n})
+	var t T
+	return t
+}
+
+// HasKey matches map arguments that contain key. The map's value type has to be given explicitly,
+// e.g. HasKey[int]("timeout") for a map[string]int.
+func HasKey[V any, K comparable](key K) map[K]V {
+	RegisterMatcher(&MapMatcher{
+		Description: fmt.Sprintf("HasKey(%#v)", key),
+		Predicate:   func(m reflect.Value) bool { return mapIndex(m, key).IsValid() },
+	})
+	return nil
+}
+
+// HasEntry matches map arguments that contain key with a value equal to value.
+func HasEntry[K comparable, V any](key K, value V) map[K]V {
+	RegisterMatcher(&MapMatcher{
+		Description: fmt.Sprintf("HasEntry(%#v, %#v)", key, value),
+		Predicate:   func(m reflect.Value) bool { return mapHasEntry(m, key, value) },
+	})
+	return nil
+}
+
+// IsSubsetOf matches map arguments whose entries are all contained in m.
+func IsSubsetOf[K comparable, V any](m map[K]V) map[K]V {
+	RegisterMatcher(&MapMatcher{
+		Description: fmt.Sprintf("IsSubsetOf(%#v)", m),
+		Predicate: func(actual reflect.Value) bool {
+			for _, key := range actual.MapKeys() {
+				if !mapHasEntry(reflect.ValueOf(m), key.Interface(), actual.MapIndex(key).Interface()) {
+					return false
+				}
+			}
+			return true
+		},
+	})
+	return nil
+}
+
+// Gt matches arguments greater than value.
+func Gt[T constraints.Ordered](value T) T {
+	return registerOrderedMatcher(fmt.Sprintf("Gt(%#v)", value), func(actual T) bool { return actual > value })
+}
+
+// Gte matches arguments greater than or equal to value.
+func Gte[T constraints.Ordered](value T) T {
+	return registerOrderedMatcher(fmt.Sprintf("Gte(%#v)", value), func(actual T) bool { return actual >= value })
+}
+
+// Lt matches arguments less than value.
+func Lt[T constraints.Ordered](value T) T {
+	return registerOrderedMatcher(fmt.Sprintf("Lt(%#v)", value), func(actual T) bool { return actual < value })
+}
+
+// Lte matches arguments less than or equal to value.
+func Lte[T constraints.Ordered](value T) T {
+	return registerOrderedMatcher(fmt.Sprintf("Lte(%#v)", value), func(actual T) bool { return actual <= value })
+}
+
+// InRange matches arguments between min and max, both inclusive.
+func InRange[T constraints.Ordered](min, max T) T {
+	return registerOrderedMatcher(fmt.Sprintf("InRange(%#v, %#v)", min, max), func(actual T) bool { return min <= actual && actual <= max })
+}
+
+func registerOrderedMatcher[T constraints.Ordered](description string, predicate func(T) bool) T {
+	RegisterMatcher(&OrderedMatcher[T]{Description: description, Predicate: predicate})
+	var t T
+	return t
+}
+
+// EqApprox matches float arguments that differ from value by at most tolerance.
+func EqApprox[T ~float32 | ~float64](value, tolerance T) T {
+	return registerOrderedMatcher(fmt.Sprintf("EqApprox(%v, %v)", value, tolerance),
+		func(actual T) bool { return math.Abs(float64(actual)-float64(value)) <= float64(tolerance) })
+}
+
+// ErrorIs matches error arguments for which errors.Is(argument, target) holds, i.e. also errors
+// that wrap target.
+func ErrorIs(target error) error {
+	RegisterMatcher(&ErrorMatcher{
+		Description: fmt.Sprintf("ErrorIs(%#v)", target),
+		Predicate:   func(err error) bool { return errors.Is(err, target) },
+	})
+	return nil
+}
+
+// ErrorAs matches error arguments for which errors.As(argument, &t) holds for a t of type T, e.g.
+// ErrorAs[*fs.PathError]().
+func ErrorAs[T error]() error {
+	var t T
+	RegisterMatcher(&ErrorMatcher{
+		Description: fmt.Sprintf("ErrorAs[%v]()", reflect.TypeOf(&t).Elem()),
+		Predicate: func(err error) bool {
+			var target T
+			return errors.As(err, &target)
+		},
+	})
+	return nil
+}
+
+// TimeWithin matches time.Time arguments that differ from expected by at most delta.
+func TimeWithin(expected time.Time, delta time.Duration) time.Time {
+	RegisterMatcher(&TimeMatcher{Expected: expected, Delta: delta})
+	return time.Time{}
+}
+
+// ContextWithValue matches context.Context arguments on which key is set to a value equal to value.
+func ContextWithValue(key, value interface{}) context.Context {
+	RegisterMatcher(&ContextMatcher{
+		Description: fmt.Sprintf("ContextWithValue(%#v, %#v)", key, value),
+		Predicate:   func(ctx context.Context) bool { return reflect.DeepEqual(ctx.Value(key), value) },
+	})
+	return nil
+}
+
+// ContextWithDeadline matches context.Context arguments that have a deadline set.
+func ContextWithDeadline() context.Context {
+	RegisterMatcher(&ContextMatcher{
+		Description: "ContextWithDeadline()",
+		Predicate: func(ctx context.Context) bool {
+			_, hasDeadline := ctx.Deadline()
+			return hasDeadline
+		},
+	})
+	return nil
+}
+
+// Same matches arguments that are the very same instance as value, i.e. the same pointer, map,
+// channel or slice, rather than merely a deep-equal one.
+func Same[T any](value T) T {
+	verify.Argument(hasIdentity(value), "Same requires a pointer, map, channel or slice, but got %#v", value)
+	RegisterMatcher(&SameMatcher{Expected: value})
+	var t T
+	return t
+}
+
+// Not matches arguments that the given matcher doesn't match, e.g. Not(Eq("x")). Captors in the
+// given matcher capture nothing, since the arguments Not matches are the ones they don't.
+func Not[T any](matcher T) T {
+	RegisterMatcher(&NotMatcher{Matcher: popMatchers("Not", 1)[0]})
+	var t T
+	return t
+}
+
+// And matches arguments that all of the given matchers match, e.g. And(MatchesRegex("^a"), NotEq("ab")).
+// Captors among the given matchers capture all matched arguments.
+func And[T any](matchers ...T) T {
+	RegisterMatcher(&AndMatcher{Matchers: popMatchers("And", len(matchers))})
+	var t T
+	return t
+}
+
+// Or matches arguments that at least one of the given matchers matches, e.g. Or(Eq(1), Eq(2)).
+// Captors among the given matchers capture the matched arguments that their own matcher matches.
+func Or[T any](matchers ...T) T {
+	RegisterMatcher(&OrMatcher{Matchers: popMatchers("Or", len(matchers))})
+	var t T
+	return t
+}
+
+// popMatchers takes the matchers that were just registered for the arguments of a combinator off
+// the global matchers, since they describe a single argument together with the combinator.
+func popMatchers(combinator string, count int) []ArgumentMatcher {
+	verify.Argument(count > 0 && len(globalArgMatchers) >= count,
+		"Invalid use of %v(...)! All of its arguments have to be provided by matchers, e.g. %v(Eq(\"String by matcher\")).",
+		combinator, combinator)
+	matchers := make([]ArgumentMatcher, count)
+	copy(matchers, globalArgMatchers[len(globalArgMatchers)-count:])
+	globalArgMatchers = globalArgMatchers[:len(globalArgMatchers)-count]
+	return matchers
+}
@@ -0,0 +1,47 @@
+package mockgen
+
+// APILevel is the version of the Pegomock runtime API generated code is written against.
+type APILevel string
+
+const (
+	// APILevelV4 generates code against github.com/petergtz/pegomock/v4, the runtime of this
+	// version of the generator.
+	APILevelV4 APILevel = "v4"
+	// APILevelV3 generates code against github.com/petergtz/pegomock/v3, e.g. for packages of a
+	// monorepo that were not migrated to v4 yet. It leaves out everything that needs runtime API
+	// newer than v3: typed invocation recording, <Method>Calls() accessors and freshness checks.
+	// Hybrid fakes and recorders are not available at this level.
+	APILevelV3 APILevel = "v3"
+)
+
+var mockFrameworkImportPaths = map[APILevel]string{
+	APILevelV4: "github.com/petergtz/pegomock/v4",
+	APILevelV3: "github.com/petergtz/pegomock/v3",
+}
+
+// WithAPILevel makes the generated code use the runtime API of the given level. The default is
+// APILevelV4.
+func WithAPILevel(level APILevel) Option {
+	return func(g *generator) { g.apiLevel = level }
+}
+
+func (g *generator) mockFrameworkImportPath() string {
+	if importPath, exists := mockFrameworkImportPaths[g.apiLevel]; exists {
+		return importPath
+	}
+	return mockFrameworkImportPaths[APILevelV4]
+}
+
+// hasV4API reports whether the generated code may use runtime API introduced after v3.
+func (g *generator) hasV4API() bool {
+	return g.apiLevel != APILevelV3
+}
+
+func isMockFrameworkImportPath(importPath string) bool {
+	for _, frameworkImportPath := range mockFrameworkImportPaths {
+		if importPath == frameworkImportPath {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,60 @@
+package mockgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// generateCallsAccessors generates an accessor like ShowCalls() per method, which returns the
+// recorded invocations as typed records without going through verification. Methods whose
+// accessor would clash with another method of the interface get none.
+func (g *generator) generateCallsAccessors(iface *model.Interface, mockTypeName, typeParams, typeParamNames, pkgOverride string) {
+	methodNames := make(map[string]bool, len(iface.Methods))
+	for _, method := range iface.Methods {
+		methodNames[method.Name] = true
+	}
+	for _, method := range iface.Methods {
+		if methodNames[method.Name+"Calls"] {
+			continue
+		}
+		_, argNames, argTypes, _ := argDataFor(method, g.packageMap, pkgOverride)
+		callTypeName := fmt.Sprintf("%v_%v_Call", mockTypeName, method.Name)
+		ongoingVerificationTypeName := fmt.Sprintf("%v_%v_OngoingVerification", mockTypeName, method.Name)
+		fieldNames := capturedInvocationFieldNamesFor(argNames, "Sequence", "Timestamp")
+
+		g.p("type %v%v struct {", callTypeName, typeParams)
+		for i, fieldName := range fieldNames {
+			g.p("%v %v", fieldName, argTypes[i])
+		}
+		g.
+			p("Sequence int").
+			p("Timestamp time.Time").
+			p("}").
+			emptyLine()
+
+		fields := make([]string, 0, len(fieldNames)+2)
+		capturedArgNames := make([]string, len(argNames))
+		for i, fieldName := range fieldNames {
+			fields = append(fields, fmt.Sprintf("%v: _param%v[u]", fieldName, i))
+			capturedArgNames[i] = fmt.Sprintf("_param%v", i)
+		}
+		fields = append(fields, "Sequence: invocation.Sequence()", "Timestamp: invocation.Timestamp()")
+		g.
+			p("func (mock *%v%v) %vCalls() []%v%v {", mockTypeName, typeParamNames, method.Name, callTypeName, typeParamNames).
+			p("invocations := pegomock.GetGenericMockFrom(mock).Invocations(%q)", method.Name)
+		if len(argNames) > 0 {
+			g.p("%v := (&%v%v{mock: mock, methodInvocations: invocations}).GetAllCapturedArguments()",
+				join(capturedArgNames), ongoingVerificationTypeName, typeParamNames)
+		}
+		g.
+			p("calls := make([]%v%v, len(invocations))", callTypeName, typeParamNames).
+			p("for u, invocation := range invocations {").
+			p("calls[u] = %v%v{%v}", callTypeName, typeParamNames, strings.Join(fields, ", ")).
+			p("}").
+			p("return calls").
+			p("}").
+			emptyLine()
+	}
+}
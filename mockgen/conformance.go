@@ -0,0 +1,50 @@
+package mockgen
+
+import (
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// GenerateConformanceOutput generates a compile-time assertion which ensures that the
+// hand-written fake fakeTypeName keeps implementing the interface in ast, instead of generating a
+// mock for it. The fake must reside in packageOut. A method set test additionally logs methods of
+// the fake that are not part of the interface, without failing, since fakes often have helper
+// methods.
+func GenerateConformanceOutput(ast *model.Package, source, interfacePackage, fakeTypeName, packageOut, selfPackage string) []byte {
+	g := generator{}
+	g.generateConformanceCode(source, ast, interfacePackage, fakeTypeName, packageOut, selfPackage)
+	return g.formattedOutput()
+}
+
+func (g *generator) generateConformanceCode(source string, pkg *model.Package, interfacePackage, fakeTypeName, pkgName, selfPackage string) {
+	g.p("// Code generated by pegomock. DO NOT EDIT.")
+	g.p("// Source: %v", source)
+	g.emptyLine()
+
+	packageMap, _ := generateUniquePackageNamesFor(map[string]bool{interfacePackage: true}, nil)
+	g.p("package %v", pkgName)
+	g.emptyLine()
+	g.p("import (")
+	g.p("\"reflect\"")
+	g.p("\"testing\"")
+	if interfacePackage != selfPackage {
+		g.p("%v %q", packageMap[interfacePackage], vendorCleaned(interfacePackage))
+	}
+	g.p(")")
+
+	for _, iface := range pkg.Interfaces {
+		ifaceType := (&model.NamedType{Package: interfacePackage, Type: iface.Name}).String(packageMap, selfPackage)
+		g.
+			emptyLine().
+			p("var _ %v = (*%v)(nil)", ifaceType, fakeTypeName).
+			emptyLine().
+			p("func Test%vConformsTo%v(t *testing.T) {", fakeTypeName, iface.Name).
+			p("	ifaceType := reflect.TypeOf((*%v)(nil)).Elem()", ifaceType).
+			p("	fakeType := reflect.TypeOf((*%v)(nil))", fakeTypeName).
+			p("	for i := 0; i < fakeType.NumMethod(); i++ {").
+			p("		if _, exists := ifaceType.MethodByName(fakeType.Method(i).Name); !exists {").
+			p("			t.Logf(\"%v has method %%v, which is not (or no longer) part of %v\", fakeType.Method(i).Name)", fakeTypeName, iface.Name).
+			p("		}").
+			p("	}").
+			p("}")
+	}
+}
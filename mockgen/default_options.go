@@ -0,0 +1,46 @@
+package mockgen
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultOptions maps the names accepted by WithDefaultOptions to the runtime options they stand for.
+var defaultOptions = map[string]string{
+	"strict":        "pegomock.WithStrictStubbing()",
+	"cloneArgs":     "pegomock.WithArgumentCloning()",
+	"deepCloneArgs": "pegomock.WithDeepArgumentCloning()",
+	"callSites":     "pegomock.WithCallSites()",
+	"testScoped":    "pegomock.WithTestScopedVerification()",
+	"noRecording":   "pegomock.WithoutInvocationRecording()",
+}
+
+// DefaultOptionNames returns the names WithDefaultOptions accepts, sorted.
+func DefaultOptionNames() []string {
+	names := make([]string, 0, len(defaultOptions))
+	for name := range defaultOptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithDefaultOptions makes the generated constructors apply the named runtime options, e.g.
+// "strict" for pegomock.WithStrictStubbing(), before the options they are called with. This lets a
+// package enforce a policy for all its mocks without every test asking for it. Unknown names are
+// ignored, so validate them against DefaultOptionNames first.
+func WithDefaultOptions(names []string) Option {
+	return func(g *generator) {
+		for _, name := range names {
+			if option, exists := defaultOptions[name]; exists {
+				g.defaultOptions = append(g.defaultOptions, option)
+			}
+		}
+	}
+}
+
+func (g *generator) generateDefaultOptions() {
+	if len(g.defaultOptions) > 0 {
+		g.p("	options = append([]pegomock.Option{%v}, options...)", strings.Join(g.defaultOptions, ", "))
+	}
+}
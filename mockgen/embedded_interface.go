@@ -0,0 +1,20 @@
+package mockgen
+
+import (
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// WithEmbeddedInterface embeds the mocked interface in the mock struct. Methods added to the
+// interface after generating the mock are then promoted from the embedded interface, which is
+// nil, so the mock keeps compiling and only panics when such a method is called. This helps with
+// interfaces of fast-moving third-party modules.
+func WithEmbeddedInterface() Option {
+	return func(g *generator) { g.embedInterface = true }
+}
+
+func (g *generator) generateEmbeddedInterface(iface *model.Interface, typeParamNames, selfPackage string) {
+	if !g.embedInterface || g.interfacePackage == "" || iface.FuncType {
+		return
+	}
+	g.p("	%v%v", (&model.NamedType{Package: g.interfacePackage, Type: iface.Name}).String(g.packageMap, selfPackage), typeParamNames)
+}
@@ -0,0 +1,167 @@
+package mockgen
+
+import (
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// WithHybridFake makes generated mocks fall back to simple in-memory behavior for CRUD-shaped
+// methods (e.g. PutUser(id, user), GetUser(id), DeleteUser(id)) whenever an invocation is not
+// answered by a stubbing. All invocations are still recorded and can be verified as usual.
+func WithHybridFake() Option {
+	return func(g *generator) { g.hybridFake = true }
+}
+
+type fakeMethodKind int
+
+const (
+	fakePut fakeMethodKind = iota + 1
+	fakeGet
+	fakeDelete
+)
+
+var fakeMethodPrefixes = []struct {
+	kind     fakeMethodKind
+	prefixes []string
+}{
+	{fakePut, []string{"Put", "Set", "Save", "Store", "Create", "Add", "Insert", "Update"}},
+	{fakeGet, []string{"Get", "Find", "Load", "Fetch", "Lookup", "Read"}},
+	{fakeDelete, []string{"Delete", "Remove"}},
+}
+
+// classifyFakeMethod determines whether a method has an "obvious" CRUD shape. A leading
+// context.Context parameter is ignored. The entity is the method name without its prefix,
+// so that e.g. PutUser and GetUser share state, but GetOrder doesn't.
+func classifyFakeMethod(method *model.Method) (kind fakeMethodKind, entity string, keyIndex int) {
+	if method.Variadic != nil {
+		return
+	}
+	in := method.In
+	if len(in) > 0 && isContextType(in[0].Type) {
+		in = in[1:]
+		keyIndex = 1
+	}
+	if len(in) == 0 || !isFakeKeyType(in[0].Type) {
+		return
+	}
+	for _, candidate := range fakeMethodPrefixes {
+		for _, prefix := range candidate.prefixes {
+			if !strings.HasPrefix(method.Name, prefix) {
+				continue
+			}
+			entity = strings.TrimPrefix(method.Name, prefix)
+			if entity != "" && !(entity[0] >= 'A' && entity[0] <= 'Z') {
+				continue
+			}
+			switch {
+			case candidate.kind == fakePut && len(in) == 2 && hasOnlyErrorResult(method.Out),
+				candidate.kind == fakeDelete && len(in) == 1 && hasOnlyErrorResult(method.Out),
+				candidate.kind == fakeGet && len(in) == 1 && isGetResult(method.Out):
+				return candidate.kind, entity, keyIndex
+			}
+		}
+	}
+	return 0, "", 0
+}
+
+// fakeEntitiesOf returns the entities of methods that can share in-memory state, i.e. those whose
+// CRUD-shaped methods all agree on the types of keys and values. E.g. SaveUser(id string, u *User)
+// and GetUser(id string) (User, error) don't, since GetUser couldn't return what SaveUser stored.
+func fakeEntitiesOf(methods []*model.Method) map[string]bool {
+	type entityTypes struct{ keyTypes, valueTypes map[string]bool }
+	types := map[string]*entityTypes{}
+	for _, method := range methods {
+		kind, entity, keyIndex := classifyFakeMethod(method)
+		if kind == 0 {
+			continue
+		}
+		if types[entity] == nil {
+			types[entity] = &entityTypes{map[string]bool{}, map[string]bool{}}
+		}
+		types[entity].keyTypes[method.In[keyIndex].Type.String(nil, "")] = true
+		switch kind {
+		case fakePut:
+			types[entity].valueTypes[method.In[keyIndex+1].Type.String(nil, "")] = true
+		case fakeGet:
+			types[entity].valueTypes[method.Out[0].Type.String(nil, "")] = true
+		}
+	}
+	entities := map[string]bool{}
+	for entity, t := range types {
+		if len(t.keyTypes) == 1 && len(t.valueTypes) <= 1 {
+			entities[entity] = true
+		}
+	}
+	return entities
+}
+
+func isContextType(t model.Type) bool {
+	namedType, isNamed := t.(*model.NamedType)
+	return isNamed && namedType.Package == "context" && namedType.Type == "Context"
+}
+
+func isFakeKeyType(t model.Type) bool {
+	switch t.(type) {
+	case model.PredeclaredType, *model.NamedType:
+		return t != model.PredeclaredType("error") && !strings.HasPrefix(t.String(nil, ""), "interface")
+	}
+	return false
+}
+
+func isErrorType(t model.Type) bool { return t == model.PredeclaredType("error") }
+
+func hasOnlyErrorResult(out []*model.Parameter) bool {
+	return len(out) == 0 || (len(out) == 1 && isErrorType(out[0].Type))
+}
+
+func isGetResult(out []*model.Parameter) bool {
+	return len(out) == 1 ||
+		(len(out) == 2 && (isErrorType(out[1].Type) || out[1].Type == model.PredeclaredType("bool")))
+}
+
+func (g *generator) generateFakeBehavior(method *model.Method, argNames []string, returnTypes []model.Type, pkgOverride string) {
+	kind, entity, keyIndex := classifyFakeMethod(method)
+	if kind == 0 || !g.fakeEntities[entity] {
+		return
+	}
+	key := argNames[keyIndex]
+	g.p("if pegomock.GetGenericMockFrom(mock).ShouldUseFakeBehavior(\"%v\", _params) {", method.Name)
+	switch kind {
+	case fakePut:
+		g.p("pegomock.GetGenericMockFrom(mock).FakeStore().Put(%q, %v, %v)", entity, key, argNames[keyIndex+1])
+		g.generateFakeReturn(returnTypes)
+	case fakeDelete:
+		g.p("pegomock.GetGenericMockFrom(mock).FakeStore().Delete(%q, %v)", entity, key)
+		g.generateFakeReturn(returnTypes)
+	case fakeGet:
+		valueType := returnTypes[0].String(g.packageMap, pkgOverride)
+		g.
+			p("var _fakeValue %v", valueType).
+			p("_storedValue, _exists := pegomock.GetGenericMockFrom(mock).FakeStore().Get(%q, %v)", entity, key).
+			p("if _storedValue != nil {").
+			p("_fakeValue = _storedValue.(%v)", valueType).
+			p("}")
+		switch {
+		case len(returnTypes) == 1:
+			g.p("return _fakeValue")
+		case isErrorType(returnTypes[1]):
+			g.
+				p("if !_exists {").
+				p("return _fakeValue, pegomock.ErrFakeNotFound").
+				p("}").
+				p("return _fakeValue, nil")
+		default:
+			g.p("return _fakeValue, _exists")
+		}
+	}
+	g.p("}")
+}
+
+func (g *generator) generateFakeReturn(returnTypes []model.Type) {
+	if len(returnTypes) == 0 {
+		g.p("return")
+	} else {
+		g.p("return nil")
+	}
+}
@@ -0,0 +1,29 @@
+package mockgen
+
+import (
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+func hasFuncTypes(pkg *model.Package) bool {
+	for _, iface := range pkg.Interfaces {
+		if iface.FuncType {
+			return true
+		}
+	}
+	return false
+}
+
+// generateFuncTypeAccessor generates the method Func, which returns the mock's Call method as the
+// mocked function type, so it can be passed where the code under test expects such a function.
+func (g *generator) generateFuncTypeAccessor(iface *model.Interface, mockTypeName, typeParamNames, selfPackage string) {
+	if g.interfacePackage == "" {
+		return
+	}
+	funcType := (&model.NamedType{Package: g.interfacePackage, Type: iface.Name}).String(g.packageMap, selfPackage) + typeParamNames
+	g.
+		p("// Func returns a %v that invokes the mock.", iface.Name).
+		p("func (mock *%v%v) Func() %v {", mockTypeName, typeParamNames, funcType).
+		p("	return mock.%v", model.FuncTypeMethodName).
+		p("}").
+		emptyLine()
+}
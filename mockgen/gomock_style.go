@@ -0,0 +1,161 @@
+package mockgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+const gomockRuntimeImportPath = "github.com/petergtz/pegomock/v4/gomock"
+
+// WithGomockStyle generates mocks in the style of github.com/golang/mock: NewMock<Interface> takes
+// a *gomock.Controller and expectations are set up through EXPECT(). The mocks use the runtime in
+// github.com/petergtz/pegomock/v4/gomock, which mirrors the API of golang/mock's gomock package,
+// so existing tests only need to import it instead.
+func WithGomockStyle() Option {
+	return func(g *generator) { g.gomockStyle = true }
+}
+
+func (g *generator) generateGomockStyleCode(source string, pkg *model.Package, structName, pkgName, selfPackage string) {
+	g.generateHeader()
+	g.p("// Code generated by pegomock. DO NOT EDIT.")
+	g.p("// Source: %v", source)
+	g.emptyLine()
+	g.generateBuildConstraint()
+
+	importPaths := pkg.Imports()
+	importPaths[gomockRuntimeImportPath] = true
+	g.importInterfacePackage(importPaths, pkg)
+	packageMap, nonVendorPackageMap := generateUniquePackageNamesFor(importPaths, g.importAliases)
+	g.packageMap = packageMap
+
+	g.p("package %v", pkgName)
+	g.emptyLine()
+	g.p("import (")
+	g.p("\"reflect\"")
+	for packagePath, packageName := range nonVendorPackageMap {
+		if packagePath != selfPackage && packagePath != "reflect" {
+			g.p("%v %q", packageName, packagePath)
+		}
+	}
+	for _, packagePath := range pkg.DotImports {
+		g.p(". %q", packagePath)
+	}
+	g.p(")")
+
+	for _, iface := range pkg.Interfaces {
+		g.generateGomockStyleMockFor(iface, g.mockNameFor(structName, iface), selfPackage)
+	}
+}
+
+func (g *generator) generateGomockStyleMockFor(iface *model.Interface, mockTypeName, selfPackage string) {
+	gomock := g.packageMap[gomockRuntimeImportPath]
+	typeParamNames := typeParamsStringFrom(iface.TypeParams, g.packageMap, selfPackage, false)
+	typeParams := typeParamsStringFrom(iface.TypeParams, g.packageMap, selfPackage, true)
+	recorderTypeName := mockTypeName + "MockRecorder"
+	g.generateInterfaceAssertion(iface, mockTypeName, selfPackage)
+	g.
+		emptyLine().
+		p("// %v is a mock of interface %v.", mockTypeName, iface.Name).
+		p("type %v%v struct {", mockTypeName, typeParams)
+	g.generateEmbeddedInterface(iface, typeParamNames, selfPackage)
+	g.
+		p("	ctrl     *%v.Controller", gomock).
+		p("	recorder *%v%v", recorderTypeName, typeParamNames).
+		p("}").
+		emptyLine().
+		p("// %v is the mock recorder for %v.", recorderTypeName, mockTypeName).
+		p("type %v%v struct {", recorderTypeName, typeParams).
+		p("	mock *%v%v", mockTypeName, typeParamNames).
+		p("}").
+		emptyLine().
+		p("// New%v creates a new mock instance.", mockTypeName).
+		p("func New%v%v(ctrl *%v.Controller) *%v%v {", mockTypeName, typeParams, gomock, mockTypeName, typeParamNames).
+		p("	mock := &%v%v{ctrl: ctrl}", mockTypeName, typeParamNames).
+		p("	mock.recorder = &%v%v{mock}", recorderTypeName, typeParamNames).
+		p("	return mock").
+		p("}").
+		emptyLine().
+		p("// EXPECT returns an object that allows the caller to indicate expected use.").
+		p("func (mock *%v%v) EXPECT() *%v%v {", mockTypeName, typeParamNames, recorderTypeName, typeParamNames).
+		p("	return mock.recorder").
+		p("}")
+	if iface.FuncType {
+		g.emptyLine()
+		g.generateFuncTypeAccessor(iface, mockTypeName, typeParamNames, selfPackage)
+	}
+	mockedMethods, stubbedMethods := g.mockedAndStubbedMethodsOf(iface)
+	for _, method := range mockedMethods {
+		g.emptyLine()
+		g.generateGomockStyleMethod(mockTypeName, typeParamNames, method, selfPackage)
+		g.emptyLine()
+		g.generateGomockStyleRecorderMethod(mockTypeName, recorderTypeName, typeParamNames, method)
+	}
+	if len(stubbedMethods) > 0 {
+		g.emptyLine()
+	}
+	for _, method := range stubbedMethods {
+		g.generateStubMethod(mockTypeName, typeParamNames, method, selfPackage)
+	}
+}
+
+func (g *generator) generateGomockStyleMethod(mockTypeName, typeParamNames string, method *model.Method, selfPackage string) {
+	args, argNames, _, returnTypes := argDataFor(method, g.packageMap, selfPackage)
+	g.
+		p("// %v mocks base method.", method.Name).
+		p("func (mock *%v%v) %v(%v) (%v) {", mockTypeName, typeParamNames, method.Name, join(args), join(stringSliceFrom(returnTypes, g.packageMap, selfPackage))).
+		p("	mock.ctrl.T.Helper()")
+	callArgs := argNames
+	if method.Variadic != nil {
+		fixedArgNames, variadicArgName := argNames[:len(argNames)-1], argNames[len(argNames)-1]
+		g.
+			p("	_varargs := []interface{}{%v}", join(fixedArgNames)).
+			p("	for _, _arg := range %v {", variadicArgName).
+			p("		_varargs = append(_varargs, _arg)").
+			p("	}")
+		callArgs = []string{"_varargs..."}
+	}
+	call := fmt.Sprintf("mock.ctrl.Call(%v)", join(append([]string{"mock", fmt.Sprintf("%q", method.Name)}, callArgs...)))
+	if len(returnTypes) == 0 {
+		g.p("	%v", call)
+		g.p("}")
+		return
+	}
+	g.p("	_ret := %v", call)
+	returnValues := make([]string, len(returnTypes))
+	for i, returnType := range returnTypes {
+		returnValues[i] = fmt.Sprintf("_ret%v", i)
+		g.p("	_ret%v, _ := _ret[%v].(%v)", i, i, returnType.String(g.packageMap, selfPackage))
+	}
+	g.p("	return %v", strings.Join(returnValues, ", "))
+	g.p("}")
+}
+
+func (g *generator) generateGomockStyleRecorderMethod(mockTypeName, recorderTypeName, typeParamNames string, method *model.Method) {
+	gomock := g.packageMap[gomockRuntimeImportPath]
+	_, argNames, _, _ := argDataFor(method, g.packageMap, "")
+	params := make([]string, len(argNames))
+	for i, argName := range argNames {
+		params[i] = argName + " interface{}"
+	}
+	recordArgs := argNames
+	if method.Variadic != nil {
+		params[len(params)-1] = argNames[len(argNames)-1] + " ...interface{}"
+	}
+	g.
+		p("// %v indicates an expected call of %v.", method.Name, method.Name).
+		p("func (recorder *%v%v) %v(%v) *%v.Call {", recorderTypeName, typeParamNames, method.Name, join(params), gomock).
+		p("	recorder.mock.ctrl.T.Helper()")
+	if method.Variadic != nil {
+		g.p("	_varargs := append([]interface{}{%v}, %v...)", join(argNames[:len(argNames)-1]), argNames[len(argNames)-1])
+		recordArgs = []string{"_varargs..."}
+	}
+	g.
+		p("	return recorder.mock.ctrl.RecordCallWithMethodType(%v)", join(append([]string{
+			"recorder.mock",
+			fmt.Sprintf("%q", method.Name),
+			fmt.Sprintf("reflect.TypeOf((*%v%v)(nil).%v)", mockTypeName, typeParamNames, method.Name),
+		}, recordArgs...))).
+		p("}")
+}
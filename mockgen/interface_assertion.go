@@ -0,0 +1,32 @@
+package mockgen
+
+import (
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// WithInterfaceAssertion additionally generates a compile-time assertion like
+// var _ pkg.Display = (*MockDisplay)(nil) for each mock, so a mock that no longer matches its
+// interface breaks the build of the mock file itself instead of the code using it. Generic
+// interfaces are skipped, because the assertion needs concrete type arguments.
+func WithInterfaceAssertion() Option {
+	return func(g *generator) { g.interfaceAssertion = true }
+}
+
+// importInterfacePackage adds the package declaring the interfaces of pkg to importPaths, if the
+// assertions, embedded interfaces or function type accessors need it.
+func (g *generator) importInterfacePackage(importPaths map[string]bool, pkg *model.Package) {
+	if (g.interfaceAssertion || g.embedInterface || hasFuncTypes(pkg)) && pkg.PkgPath != "" {
+		g.interfacePackage = pkg.PkgPath
+		importPaths[pkg.PkgPath] = true
+	}
+}
+
+func (g *generator) generateInterfaceAssertion(iface *model.Interface, mockTypeName, selfPackage string) {
+	if !g.interfaceAssertion || g.interfacePackage == "" || len(iface.TypeParams) > 0 || iface.FuncType {
+		return
+	}
+	ifaceType := (&model.NamedType{Package: g.interfacePackage, Type: iface.Name}).String(g.packageMap, selfPackage)
+	g.
+		emptyLine().
+		p("var _ %v = (*%v)(nil)", ifaceType, mockTypeName)
+}
@@ -0,0 +1,192 @@
+package mockgen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// WithEmbeddedMatchers additionally generates typed matcher helpers for all parameter types of
+// an interface into the mock file. They are prefixed with the mock name, e.g.
+// MockDisplayAnyHttpRequest(), so that several mocks can live in the same package.
+func WithEmbeddedMatchers() Option {
+	return func(g *generator) { g.embeddedMatchers = true }
+}
+
+func (g *generator) generateEmbeddedMatchers(iface *model.Interface, mockTypeName, pkgOverride string) {
+	if len(iface.TypeParams) > 0 {
+		// matchers for type parameters can't be expressed without making the helpers generic as
+		// well, at which point the generic pegomock matchers can be used directly.
+		return
+	}
+	for _, matcher := range g.embeddedMatchersFor(iface, pkgOverride) {
+		typ := matcher.typ.String(g.packageMap, pkgOverride)
+		g.
+			p("func %vAny%v() %v {", mockTypeName, matcher.name, typ).
+			p("	return pegomock.Any[%v]()", typ).
+			p("}").
+			emptyLine().
+			p("func %vEq%v(value %v) %v {", mockTypeName, matcher.name, typ, typ).
+			p("	return pegomock.Eq(value)").
+			p("}").
+			emptyLine().
+			p("func %vNotEq%v(value %v) %v {", mockTypeName, matcher.name, typ, typ).
+			p("	return pegomock.NotEq(value)").
+			p("}").
+			emptyLine()
+		if isNillable(matcher.typ) {
+			g.
+				p("func %vNotNil%v() %v {", mockTypeName, matcher.name, typ).
+				p("	return pegomock.NotNil[%v]()", typ).
+				p("}").
+				emptyLine()
+		}
+		g.
+			p("func %vCaptor%v() *pegomock.ArgumentCaptor[%v] {", mockTypeName, matcher.name, typ).
+			p("	return pegomock.Captor[%v]()", typ).
+			p("}").
+			emptyLine().
+			p("func %v%vThat(matcher pegomock.ArgumentMatcher) %v {", mockTypeName, matcher.name, typ).
+			p("	return pegomock.ArgThat[%v](matcher)", typ).
+			p("}").
+			emptyLine()
+	}
+}
+
+type embeddedMatcher struct {
+	name string
+	typ  model.Type
+}
+
+// embeddedMatchersFor returns a matcher for every distinct parameter type of iface, in the order
+// of their first use. If different types map to the same name, e.g. the named type SliceOfString
+// and []string, the later ones get a numeric suffix, so names only change when the interface does.
+func (g *generator) embeddedMatchersFor(iface *model.Interface, pkgOverride string) []embeddedMatcher {
+	var matchers []embeddedMatcher
+	typesByName := make(map[string]string)
+	for _, method := range iface.Methods {
+		params := method.In
+		if method.Variadic != nil {
+			params = append(params[:len(params):len(params)], method.Variadic)
+		}
+		for _, param := range params {
+			baseName, ok := g.matcherNameFor(param.Type, pkgOverride)
+			if !ok {
+				continue
+			}
+			typ := param.Type.String(g.packageMap, pkgOverride)
+			if typ == "any" {
+				typ = "interface{}"
+			}
+			name := baseName
+			for i := 2; typesByName[name] != "" && typesByName[name] != typ; i++ {
+				name = fmt.Sprintf("%v%v", baseName, i)
+			}
+			if typesByName[name] == typ {
+				continue
+			}
+			typesByName[name] = typ
+			matchers = append(matchers, embeddedMatcher{name: name, typ: param.Type})
+		}
+	}
+	return matchers
+}
+
+// isNillable reports whether nil is a valid value of typ. Named types are only known by name, so
+// they are conservatively considered not nillable, except for error.
+func isNillable(typ model.Type) bool {
+	switch t := typ.(type) {
+	case model.PredeclaredType:
+		return t == "interface{}" || t == "any" || t == "error"
+	case *model.ArrayType:
+		return t.Len == -1
+	case *model.PointerType, *model.MapType, *model.ChanType, *model.FuncType:
+		return true
+	}
+	return false
+}
+
+// matcherNameFor derives the type part of a matcher helper name, e.g. SliceOfString for []string,
+// MapOfStringToHttpRequest for map[string]http.Request or SetOfInt for Set[int]. Types without a reasonable name, like
+// struct literals or funcs with parameters, are skipped.
+func (g *generator) matcherNameFor(typ model.Type, pkgOverride string) (string, bool) {
+	switch t := typ.(type) {
+	case model.PredeclaredType:
+		switch s := string(t); s {
+		case "interface{}", "any":
+			return "Interface", true
+		default:
+			return capitalizedIdentifier(s)
+		}
+	case *model.NamedType:
+		if strings.ContainsAny(t.Type, "[]") {
+			return "", false
+		}
+		name := t.Type
+		if t.Package != pkgOverride && t.Package != "" {
+			name = g.packageMap[t.Package] + capitalize(t.Type)
+		}
+		for i, typeArg := range t.TypeArgs {
+			typeArgName, ok := g.matcherNameFor(typeArg, pkgOverride)
+			if !ok {
+				return "", false
+			}
+			if i == 0 {
+				name += "Of" + typeArgName
+			} else {
+				name += "And" + typeArgName
+			}
+		}
+		return capitalizedIdentifier(name)
+	case *model.PointerType:
+		return g.prefixedMatcherName("PtrTo", t.Type, pkgOverride)
+	case *model.ArrayType:
+		if t.Len == -1 {
+			return g.prefixedMatcherName("SliceOf", t.Type, pkgOverride)
+		}
+		return g.prefixedMatcherName(fmt.Sprintf("ArrayOf%v", t.Len), t.Type, pkgOverride)
+	case *model.MapType:
+		key, ok := g.matcherNameFor(t.Key, pkgOverride)
+		if !ok {
+			return "", false
+		}
+		return g.prefixedMatcherName("MapOf"+key+"To", t.Value, pkgOverride)
+	case *model.ChanType:
+		switch t.Dir {
+		case model.RecvDir:
+			return g.prefixedMatcherName("RecvChanOf", t.Type, pkgOverride)
+		case model.SendDir:
+			return g.prefixedMatcherName("SendChanOf", t.Type, pkgOverride)
+		default:
+			return g.prefixedMatcherName("ChanOf", t.Type, pkgOverride)
+		}
+	case *model.FuncType:
+		if len(t.In) == 0 && len(t.Out) == 0 && t.Variadic == nil {
+			return "Func", true
+		}
+	}
+	return "", false
+}
+
+func (g *generator) prefixedMatcherName(prefix string, typ model.Type, pkgOverride string) (string, bool) {
+	name, ok := g.matcherNameFor(typ, pkgOverride)
+	return prefix + name, ok
+}
+
+func capitalizedIdentifier(s string) (string, bool) {
+	for _, r := range s {
+		if !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			return "", false
+		}
+	}
+	return capitalize(s), s != ""
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
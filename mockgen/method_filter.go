@@ -0,0 +1,38 @@
+package mockgen
+
+import (
+	"regexp"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// WithMethodFilter only mocks the methods whose names match include, if not nil, and don't match
+// exclude, if not nil. The remaining methods are generated as stubs that panic, so mocks of
+// enormous interfaces only contain what a test suite uses. Stubbed methods have no verification
+// methods either, so verifying them doesn't compile.
+func WithMethodFilter(include, exclude *regexp.Regexp) Option {
+	return func(g *generator) { g.includeMethods, g.excludeMethods = include, exclude }
+}
+
+// mockedAndStubbedMethodsOf splits the methods of iface into the ones to mock and the ones to
+// generate panic stubs for.
+func (g *generator) mockedAndStubbedMethodsOf(iface *model.Interface) (mocked, stubbed []*model.Method) {
+	for _, method := range iface.Methods {
+		if g.includeMethods != nil && !g.includeMethods.MatchString(method.Name) ||
+			g.excludeMethods != nil && g.excludeMethods.MatchString(method.Name) {
+			stubbed = append(stubbed, method)
+		} else {
+			mocked = append(mocked, method)
+		}
+	}
+	return
+}
+
+func (g *generator) generateStubMethod(mockType string, typeParamNames string, method *model.Method, pkgOverride string) {
+	args, _, _, returnTypes := argDataFor(method, g.packageMap, pkgOverride)
+	g.
+		p("func (mock *%v%v) %v(%v) (%v) {", mockType, typeParamNames, method.Name, join(args), join(stringSliceFrom(returnTypes, g.packageMap, pkgOverride))).
+		p("	panic(\"%v.%v is not mocked, because it was filtered out when generating %v. Regenerate it including this method to use it.\")", mockType, method.Name, mockType).
+		p("}").
+		emptyLine()
+}
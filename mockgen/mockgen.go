@@ -21,9 +21,9 @@ package mockgen
 import (
 	"bytes"
 	"fmt"
-	"go/format"
 	"go/token"
 	"path"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -31,29 +31,165 @@ import (
 
 	"github.com/petergtz/pegomock/v4/model"
 	"github.com/samber/lo"
+	"golang.org/x/tools/imports"
 )
 
-const mockFrameworkImportPath = "github.com/petergtz/pegomock/v4"
-
-func GenerateOutput(ast *model.Package, source, nameOut, packageOut, selfPackage string) []byte {
+func GenerateOutput(ast *model.Package, source, nameOut, packageOut, selfPackage string, options ...Option) []byte {
 	g := generator{}
+	for _, option := range options {
+		option(&g)
+	}
+	if g.outputTemplate != "" {
+		return g.generateTemplateOutput(source, ast, packageOut, selfPackage)
+	}
+	if g.plugin != "" {
+		return g.generatePluginOutput(source, ast, nameOut, packageOut, selfPackage)
+	}
+	if g.gomockStyle {
+		g.generateGomockStyleCode(source, ast, nameOut, packageOut, selfPackage)
+		return g.formattedOutput()
+	}
 	g.generateCode(source, ast, nameOut, packageOut, selfPackage)
 	return g.formattedOutput()
 }
 
+// CheckSupported returns an error for constructs in pkg that mocks can't be generated for yet.
+// These are methods with their own type parameters, which Go doesn't allow in interfaces yet, but
+// which future toolchains might accept.
+func CheckSupported(pkg *model.Package) error {
+	for _, iface := range pkg.Interfaces {
+		for _, method := range iface.Methods {
+			if len(method.TypeParams) > 0 {
+				typeParamNames := typeParamsStringFrom(method.TypeParams, nil, "", false)
+				return fmt.Errorf("Method %v of interface %v has type parameters %v. Pegomock cannot generate mocks for "+
+					"methods with type parameters yet. Consider declaring them on the interface instead, "+
+					"i.e. %v%v.", method.Name, iface.Name, typeParamNames, iface.Name, typeParamNames)
+			}
+		}
+	}
+	return nil
+}
+
+// Option customizes the generated code.
+type Option func(*generator)
+
+// WithBuildConstraint adds a //go:build line with the given expression to the generated file, e.g.
+// "linux && amd64", so mocks of platform-specific interfaces only compile where those do.
+func WithBuildConstraint(expr string) Option {
+	return func(g *generator) { g.buildConstraint = expr }
+}
+
+func (g *generator) generateBuildConstraint() {
+	if g.buildConstraint != "" {
+		g.p("//go:build %v", g.buildConstraint)
+		g.emptyLine()
+	}
+}
+
+// WithHeader starts the generated file with header, e.g. a license or ownership notice. Lines
+// that aren't comments yet are turned into // comments. The "Code generated" line follows the
+// header, so it still precedes the package clause as go generate conventions and linters expect,
+// and the header doesn't become the package's doc comment.
+func WithHeader(header string) Option {
+	return func(g *generator) { g.header = commentedHeader(header) }
+}
+
+func commentedHeader(header string) string {
+	header = strings.TrimRight(header, " \t\r\n")
+	if header == "" || strings.HasPrefix(strings.TrimSpace(header), "/*") {
+		return header
+	}
+	lines := strings.Split(header, "\n")
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(strings.TrimSpace(line), "//"):
+			lines[i] = line
+		case line == "":
+			lines[i] = "//"
+		default:
+			lines[i] = "// " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (g *generator) generateHeader() {
+	if g.header != "" {
+		g.p("%v", g.header)
+		g.emptyLine()
+	}
+}
+
 type generator struct {
-	buf        bytes.Buffer
-	packageMap map[string]string // map from import path to package name
+	buf                bytes.Buffer
+	packageMap         map[string]string // map from import path to package name
+	hybridFake         bool
+	fakeEntities       map[string]bool // entities of the current interface whose methods share fake state
+	embeddedMatchers   bool
+	recorder           bool
+	sourceHashes       map[string]sourceHash // map from interface name to the hash of its declaration
+	importAliases      map[string]string     // map from import path to the package name to use for it
+	apiLevel           APILevel
+	defaultOptions     []string // runtime options applied by the generated constructors
+	outputTemplate     string
+	plugin             string // path of the executable generating the output
+	gomockStyle        bool
+	nameTemplate       string
+	includeMethods     *regexp.Regexp
+	excludeMethods     *regexp.Regexp
+	buildConstraint    string // expression of the //go:build line of the generated file
+	header             string // comment block the generated file starts with
+	interfaceAssertion bool
+	embedInterface     bool
+	slim               bool // no verification code
+	nullObject         bool
+	nullObjectOnly     bool   // null objects instead of mocks
+	interfacePackage   string // import path of the package declaring the asserted or embedded interfaces
+}
+
+type sourceHash struct{ importPath, hash string }
+
+// WithSourceHash embeds the hash of the declaration of interface interfaceName into the generated
+// mock's constructor, so it can warn at runtime when the interface changed since generation.
+func WithSourceHash(importPath, interfaceName, hash string) Option {
+	return func(g *generator) {
+		if g.sourceHashes == nil {
+			g.sourceHashes = make(map[string]sourceHash)
+		}
+		g.sourceHashes[interfaceName] = sourceHash{importPath, hash}
+	}
+}
+
+// WithImportAliases makes the generated code import packages under the given names, e.g. to follow
+// the import aliases a linter like importas enforces. aliases maps import paths to package names.
+// Packages without alias are named after the base names of their import paths as usual, avoiding
+// the aliases.
+func WithImportAliases(aliases map[string]string) Option {
+	return func(g *generator) {
+		if g.importAliases == nil {
+			g.importAliases = make(map[string]string, len(aliases))
+		}
+		for importPath, alias := range aliases {
+			g.importAliases[importPath] = alias
+		}
+	}
 }
 
 func (g *generator) generateCode(source string, pkg *model.Package, structName, pkgName, selfPackage string) {
+	g.generateHeader()
 	g.p("// Code generated by pegomock. DO NOT EDIT.")
 	g.p("// Source: %v", source)
 	g.emptyLine()
+	g.generateBuildConstraint()
 
 	importPaths := pkg.Imports()
-	importPaths[mockFrameworkImportPath] = true
-	packageMap, nonVendorPackageMap := generateUniquePackageNamesFor(importPaths)
+	importPaths[g.mockFrameworkImportPath()] = true
+	if g.hasV4API() {
+		importPaths["testing"] = true
+	}
+	g.importInterfacePackage(importPaths, pkg)
+	packageMap, nonVendorPackageMap := generateUniquePackageNamesFor(importPaths, g.importAliases)
 	g.packageMap = packageMap
 
 	g.p("package %v", pkgName)
@@ -72,22 +208,34 @@ func (g *generator) generateCode(source string, pkg *model.Package, structName,
 	g.p(")")
 
 	for _, iface := range pkg.Interfaces {
-		sName := structName
-		if sName == "" {
-			sName = "Mock" + iface.Name
+		if !g.nullObjectOnly {
+			g.generateMockFor(iface, g.mockNameFor(structName, iface), selfPackage)
+		}
+		if g.nullObject {
+			g.generateNullObjectFor(iface, selfPackage)
 		}
-		g.generateMockFor(iface, sName, selfPackage)
 	}
 }
 
-func generateUniquePackageNamesFor(importPaths map[string]bool) (packageMap, nonVendorPackageMap map[string]string) {
+func generateUniquePackageNamesFor(importPaths map[string]bool, aliases map[string]string) (packageMap, nonVendorPackageMap map[string]string) {
 	packageMap = make(map[string]string, len(importPaths))
 	nonVendorPackageMap = make(map[string]string, len(importPaths))
 	packageNamesAlreadyUsed := make(map[string]bool, len(importPaths))
 
 	sortedImportPaths := lo.Keys(importPaths)
 	sort.Strings(sortedImportPaths)
+	// aliases are reserved up front, so packages imported without alias don't take them
 	for _, importPath := range sortedImportPaths {
+		if alias, exists := aliasFor(importPath, aliases); exists {
+			packageNamesAlreadyUsed[alias] = true
+		}
+	}
+	for _, importPath := range sortedImportPaths {
+		if alias, exists := aliasFor(importPath, aliases); exists {
+			packageMap[importPath] = alias
+			nonVendorPackageMap[vendorCleaned(importPath)] = alias
+			continue
+		}
 		sanitizedPackagePathBaseName := sanitize(path.Base(importPath))
 
 		// Local names for an imported package can usually be the basename of the import path.
@@ -101,7 +249,7 @@ func generateUniquePackageNamesFor(importPaths map[string]bool) (packageMap, non
 		}
 
 		// hardcode package name for pegomock, because it's hardcoded in the generated code too
-		if importPath == mockFrameworkImportPath {
+		if isMockFrameworkImportPath(importPath) {
 			packageName = "pegomock"
 		}
 
@@ -113,6 +261,19 @@ func generateUniquePackageNamesFor(importPaths map[string]bool) (packageMap, non
 	return
 }
 
+// aliasFor looks up the alias for importPath, also under its vendor-cleaned path. The pegomock
+// package can't be aliased, because its name is hardcoded in the generated code.
+func aliasFor(importPath string, aliases map[string]string) (string, bool) {
+	if isMockFrameworkImportPath(importPath) {
+		return "", false
+	}
+	if alias, exists := aliases[importPath]; exists {
+		return alias, true
+	}
+	alias, exists := aliases[vendorCleaned(importPath)]
+	return alias, exists
+}
+
 func vendorCleaned(importPath string) string {
 	if split := strings.Split(importPath, "/vendor/"); len(split) > 1 {
 		return split[1]
@@ -148,12 +309,37 @@ func sanitize(s string) string {
 func (g *generator) generateMockFor(iface *model.Interface, mockTypeName, selfPackage string) {
 	typeParamNames := typeParamsStringFrom(iface.TypeParams, g.packageMap, selfPackage, false)
 	typeParams := typeParamsStringFrom(iface.TypeParams, g.packageMap, selfPackage, true)
-	g.generateMockType(mockTypeName, typeParams,
-		typeParamNames)
-	for _, method := range iface.Methods {
+	g.generateInterfaceAssertion(iface, mockTypeName, selfPackage)
+	g.generateMockType(iface, mockTypeName, typeParams, typeParamNames, selfPackage)
+	if iface.FuncType {
+		g.generateFuncTypeAccessor(iface, mockTypeName, typeParamNames, selfPackage)
+	}
+	mockedMethods, stubbedMethods := g.mockedAndStubbedMethodsOf(iface)
+	if g.hybridFake {
+		g.fakeEntities = fakeEntitiesOf(mockedMethods)
+	}
+	for _, method := range mockedMethods {
 		g.generateMockMethod(mockTypeName, typeParamNames, method, selfPackage)
 		g.emptyLine()
 	}
+	for _, method := range stubbedMethods {
+		g.generateStubMethod(mockTypeName, typeParamNames, method, selfPackage)
+	}
+	// everything else only concerns the mocked methods
+	mockedIface := *iface
+	mockedIface.Methods = mockedMethods
+	if !g.slim {
+		g.generateVerificationCode(&mockedIface, mockTypeName, typeParams, typeParamNames, selfPackage)
+	}
+	if g.embeddedMatchers {
+		g.generateEmbeddedMatchers(&mockedIface, mockTypeName, selfPackage)
+	}
+	if g.recorder {
+		g.generateRecorderFor(iface, typeParams, typeParamNames, selfPackage)
+	}
+}
+
+func (g *generator) generateVerificationCode(iface *model.Interface, mockTypeName, typeParams, typeParamNames, selfPackage string) {
 	g.generateMockVerifyMethods(mockTypeName, typeParamNames)
 	g.generateVerifierType(mockTypeName, typeParams, typeParamNames)
 	for _, method := range iface.Methods {
@@ -163,6 +349,13 @@ func (g *generator) generateMockFor(iface *model.Interface, mockTypeName, selfPa
 		g.generateOngoingVerificationType(mockTypeName, typeParams, typeParamNames, ongoingVerificationTypeName)
 		g.generateOngoingVerificationGetCapturedArguments(ongoingVerificationTypeName, argNames, argTypes, typeParamNames)
 		g.generateOngoingVerificationGetAllCapturedArguments(ongoingVerificationTypeName, typeParamNames, argTypes, method.Variadic != nil)
+		if method.Variadic != nil {
+			capturedInvocationTypeName := fmt.Sprintf("%v_%v_CapturedInvocation", mockTypeName, method.Name)
+			g.generateOngoingVerificationGetAllCapturedInvocations(ongoingVerificationTypeName, capturedInvocationTypeName, typeParams, typeParamNames, argNames, argTypes)
+		}
+	}
+	if g.hasV4API() {
+		g.generateCallsAccessors(iface, mockTypeName, typeParams, typeParamNames, selfPackage)
 	}
 }
 
@@ -183,21 +376,39 @@ func typeParamsStringFrom(params []*model.Parameter, packageMap map[string]strin
 	return result + "]"
 }
 
-func (g *generator) generateMockType(mockTypeName string, typeParams string, typeParamNames string) {
+func (g *generator) generateMockType(iface *model.Interface, mockTypeName string, typeParams string, typeParamNames string, selfPackage string) {
+	ifaceName := iface.Name
 	g.
 		emptyLine().
-		p("type %v%v struct {", mockTypeName, typeParams).
-		p("	fail func(message string, callerSkip ...int)").
+		p("type %v%v struct {", mockTypeName, typeParams)
+	g.generateEmbeddedInterface(iface, typeParamNames, selfPackage)
+	g.p("	fail func(message string, callerSkip ...int)")
+	g.
 		p("}").
 		emptyLine().
-		p("func New%v%v(options ...pegomock.Option) *%v%v {", mockTypeName, typeParams, mockTypeName, typeParamNames).
+		p("func New%v%v(options ...pegomock.Option) *%v%v {", mockTypeName, typeParams, mockTypeName, typeParamNames)
+	if sourceHash, exists := g.sourceHashes[ifaceName]; exists && g.hasV4API() {
+		g.p("	pegomock.CheckFreshness(%q, %q, %q)", sourceHash.importPath, ifaceName, sourceHash.hash)
+	}
+	g.generateDefaultOptions()
+	g.
 		p("	mock := &%v%v{}", mockTypeName, typeParamNames).
 		p("	for _, option := range options {").
 		p("		option.Apply(mock)").
 		p("	}").
 		p("	return mock").
 		p("}").
-		emptyLine().
+		emptyLine()
+	if g.hasV4API() {
+		g.
+			p("// New%vWithT creates a mock reporting failures to t. When t finishes, it fails for stubbings", mockTypeName).
+			p("// that were never used and for invocations that were neither stubbed nor verified.").
+			p("func New%vWithT%v(t %v.TB, options ...pegomock.Option) *%v%v {", mockTypeName, typeParams, g.packageMap["testing"], mockTypeName, typeParamNames).
+			p("	return New%v%v(append([]pegomock.Option{pegomock.WithTestCleanup(t)}, options...)...)", mockTypeName, typeParamNames).
+			p("}").
+			emptyLine()
+	}
+	g.
 		p("func (mock *%v%v) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }", mockTypeName, typeParamNames).
 		p("func (mock *%v%v) FailHandler() pegomock.FailHandler      { return mock.fail }", mockTypeName, typeParamNames).
 		emptyLine()
@@ -206,21 +417,43 @@ func (g *generator) generateMockType(mockTypeName string, typeParams string, typ
 // If non-empty, pkgOverride is the package in which unqualified types reside.
 func (g *generator) generateMockMethod(mockType string, typeParamNames string, method *model.Method, pkgOverride string) *generator {
 	args, argNames, _, returnTypes := argDataFor(method, g.packageMap, pkgOverride)
+	usesTypedParams := g.usesTypedParams(method)
+	if usesTypedParams {
+		g.generateTypedParamsType(mockType, method)
+	}
+	reflectReturnTypes := make([]string, len(returnTypes))
+	for i, returnType := range returnTypes {
+		reflectReturnTypes[i] = fmt.Sprintf("reflect.TypeOf((*%v)(nil)).Elem()", returnType.String(g.packageMap, pkgOverride))
+	}
+	returnTypesExpr := fmt.Sprintf("[]reflect.Type{%v}", strings.Join(reflectReturnTypes, ", "))
+	// return types can only be computed once for non-generic mocks, because package-level variables
+	// can't refer to type parameters
+	if typeParamNames == "" {
+		returnTypesVarName := fmt.Sprintf("_%v_%v_ReturnTypes", mockType, method.Name)
+		g.p("var %v = %v", returnTypesVarName, returnTypesExpr)
+		g.emptyLine()
+		returnTypesExpr = returnTypesVarName
+	}
 	g.p("func (mock *%v%v) %v(%v) (%v) {", mockType, typeParamNames, method.Name, join(args), join(stringSliceFrom(returnTypes, g.packageMap, pkgOverride)))
 	g.p("if mock == nil {").
 		p("	panic(\"mock must not be nil. Use myMock := New%v().\")", mockType).
 		p("}")
-	g.GenerateParamsDeclaration(argNames, method.Variadic != nil)
-	reflectReturnTypes := make([]string, len(returnTypes))
-	for i, returnType := range returnTypes {
-		reflectReturnTypes[i] = fmt.Sprintf("reflect.TypeOf((*%v)(nil)).Elem()", returnType.String(g.packageMap, pkgOverride))
+	invokeFunc := "Invoke"
+	if usesTypedParams {
+		invokeFunc = "InvokeTyped"
+		g.p("_params := %v{%v}", typedParamsTypeName(mockType, method), join(argNames))
+	} else {
+		g.GenerateParamsDeclaration(argNames, method.Variadic != nil)
 	}
 	resultAssignment := ""
 	if len(method.Out) > 0 {
 		resultAssignment = "_result :="
 	}
-	g.p("%v pegomock.GetGenericMockFrom(mock).Invoke(\"%v\", _params, []reflect.Type{%v})",
-		resultAssignment, method.Name, strings.Join(reflectReturnTypes, ", "))
+	g.p("%v pegomock.GetGenericMockFrom(mock).%v(\"%v\", _params, %v)",
+		resultAssignment, invokeFunc, method.Name, returnTypesExpr)
+	if g.hybridFake {
+		g.generateFakeBehavior(method, argNames, returnTypes, pkgOverride)
+	}
 	if len(method.Out) > 0 {
 		// TODO: translate LastInvocation into a Matcher so it can be used as key for Stubbings
 		for i, returnType := range returnTypes {
@@ -348,34 +581,60 @@ func (g *generator) generateOngoingVerificationGetAllCapturedArguments(ongoingVe
 		argsAsArray[i] = fmt.Sprintf("_param%v []%v", i, argType)
 	}
 	g.p("func (c *%v%v) GetAllCapturedArguments() (%v) {", ongoingVerificationStructName, typeParamNames, strings.Join(argsAsArray, ", "))
-	if numArgs > 0 {
+	numFixedArgs := numArgs
+	if isVariadic {
+		numFixedArgs--
+	}
+	// v3 has no GetVariadicInvocationParams, so variadic args are taken from the invocation params too
+	capturesVariadicArgsFromParams := isVariadic && !g.hasV4API()
+	if numFixedArgs > 0 || capturesVariadicArgsFromParams {
 		g.p("_params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)")
+	}
+	if numFixedArgs > 0 {
 		g.p("if len(_params) > 0 {")
-		for i, argType := range argTypes {
-			if isVariadic && i == numArgs-1 {
-				variadicBasicType := strings.Replace(argType, "[]", "", 1)
-				g.
-					p("_param%v = make([]%v, len(c.methodInvocations))", i, argType).
-					p("for u := 0; u < len(c.methodInvocations); u++ {").
-					p("_param%v[u] = make([]%v, len(_params)-%v)", i, variadicBasicType, i).
-					p("for x := %v; x < len(_params); x++ {", i).
-					p("if _params[x][u] != nil {").
-					p("_param%v[u][x-%v] = _params[x][u].(%v)", i, i, variadicBasicType).
-					p("}").
-					p("}").
-					p("}")
-				break
-			} else {
-				// explicitly validate the length of the params slice to avoid out of bounds code smells
-				g.p("if len(_params) > %v {", i)
-				g.p("_param%v = make([]%v, len(c.methodInvocations))", i, argType)
-				g.p("for u, param := range _params[%v] {", i)
-				g.p("_param%v[u]=param.(%v)", i, argType)
-				g.p("}")
-				g.p("}")
-			}
+		for i, argType := range argTypes[:numFixedArgs] {
+			// explicitly validate the length of the params slice to avoid out of bounds code smells
+			g.p("if len(_params) > %v {", i)
+			g.p("_param%v = make([]%v, len(c.methodInvocations))", i, argType)
+			g.p("for u, param := range _params[%v] {", i)
+			g.p("_param%v[u]=param.(%v)", i, argType)
+			g.p("}")
+			g.p("}")
 		}
 		g.p("}")
+	}
+	if capturesVariadicArgsFromParams {
+		variadicType := argTypes[numFixedArgs]
+		variadicElemType := strings.TrimPrefix(variadicType, "[]")
+		g.
+			p("_param%v = make([]%v, len(c.methodInvocations))", numFixedArgs, variadicType).
+			p("if len(_params) > %v {", numFixedArgs).
+			p("for u := range c.methodInvocations {").
+			p("_param%v[u] = make([]%v, len(_params)-%v)", numFixedArgs, variadicElemType, numFixedArgs).
+			p("for x := %v; x < len(_params); x++ {", numFixedArgs).
+			p("if _params[x][u] != nil {").
+			p("_param%v[u][x-%v] = _params[x][u].(%v)", numFixedArgs, numFixedArgs, variadicElemType).
+			p("}").
+			p("}").
+			p("}").
+			p("}")
+	} else if isVariadic {
+		// variadic args are captured independently of the fixed ones, so invocations without any
+		// variadic args still yield an (empty) slice per invocation
+		variadicType := argTypes[numFixedArgs]
+		variadicElemType := strings.TrimPrefix(variadicType, "[]")
+		g.
+			p("_param%v = make([]%v, len(c.methodInvocations))", numFixedArgs, variadicType).
+			p("for u, variadicParams := range pegomock.GetGenericMockFrom(c.mock).GetVariadicInvocationParams(c.methodInvocations, %v) {", numFixedArgs).
+			p("_param%v[u] = make([]%v, len(variadicParams))", numFixedArgs, variadicElemType).
+			p("for x, param := range variadicParams {").
+			p("if param != nil {").
+			p("_param%v[u][x] = param.(%v)", numFixedArgs, variadicElemType).
+			p("}").
+			p("}").
+			p("}")
+	}
+	if numArgs > 0 {
 		g.p("return")
 	}
 	g.p("}")
@@ -383,6 +642,57 @@ func (g *generator) generateOngoingVerificationGetAllCapturedArguments(ongoingVe
 	return g
 }
 
+// generateOngoingVerificationGetAllCapturedInvocations groups the captured arguments by invocation,
+// so each invocation's variadic args come as one slice next to its fixed args.
+func (g *generator) generateOngoingVerificationGetAllCapturedInvocations(ongoingVerificationStructName, capturedInvocationStructName, typeParams, typeParamNames string, argNames []string, argTypes []string) *generator {
+	fieldNames := capturedInvocationFieldNamesFor(argNames)
+	g.p("type %v%v struct {", capturedInvocationStructName, typeParams)
+	for i, fieldName := range fieldNames {
+		g.p("%v %v", fieldName, argTypes[i])
+	}
+	g.p("}")
+	g.emptyLine()
+	indexedArgNames := make([]string, len(argNames))
+	for i, fieldName := range fieldNames {
+		indexedArgNames[i] = fmt.Sprintf("%v: _param%v[u]", fieldName, i)
+	}
+	capturedArgNames := make([]string, len(argNames))
+	for i := range argNames {
+		capturedArgNames[i] = fmt.Sprintf("_param%v", i)
+	}
+	g.
+		p("func (c *%v%v) GetAllCapturedInvocations() []%v%v {", ongoingVerificationStructName, typeParamNames, capturedInvocationStructName, typeParamNames).
+		p("%v := c.GetAllCapturedArguments()", join(capturedArgNames)).
+		p("invocations := make([]%v%v, len(c.methodInvocations))", capturedInvocationStructName, typeParamNames).
+		p("for u := range invocations {").
+		p("invocations[u] = %v%v{%v}", capturedInvocationStructName, typeParamNames, join(indexedArgNames)).
+		p("}").
+		p("return invocations").
+		p("}").
+		emptyLine()
+	return g
+}
+
+// capturedInvocationFieldNamesFor derives exported field names from param names, e.g. Param0 for
+// _param0, falling back to the position for names that would collide with each other or with the
+// reserved names.
+func capturedInvocationFieldNamesFor(argNames []string, reserved ...string) []string {
+	fieldNames := make([]string, len(argNames))
+	used := make(map[string]bool, len(argNames)+len(reserved))
+	for _, name := range reserved {
+		used[name] = true
+	}
+	for i, argName := range argNames {
+		fieldName := capitalize(strings.TrimLeft(argName, "_"))
+		if fieldName == "" || used[fieldName] {
+			fieldName = fmt.Sprintf("Param%v", i)
+		}
+		used[fieldName] = true
+		fieldNames[i] = fieldName
+	}
+	return fieldNames
+}
+
 func argDataFor(method *model.Method, packageMap map[string]string, pkgOverride string) (
 	args []string,
 	argNames []string,
@@ -434,8 +744,11 @@ func (g *generator) p(format string, args ...interface{}) *generator {
 
 func (g *generator) emptyLine() *generator { return g.p("") }
 
+// formattedOutput formats the generated code like goimports would. This also removes imports that
+// code paths of the generator ended up not using, e.g. "reflect" when all methods are stubbed out,
+// and groups the remaining imports.
 func (g *generator) formattedOutput() []byte {
-	src, err := format.Source(g.buf.Bytes())
+	src, err := imports.Process("", g.buf.Bytes(), &imports.Options{Comments: true, TabIndent: true, TabWidth: 8})
 	if err != nil {
 		panic(fmt.Errorf("Failed to format generated source code: %s\n%s", err, g.buf.String()))
 	}
@@ -0,0 +1,64 @@
+package mockgen
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"text/template"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// NameTemplateData is what name templates are executed with.
+type NameTemplateData struct {
+	// Interface is the name of the mocked interface.
+	Interface string
+}
+
+// WithNameTemplate names the generated mock types by executing the text/template tmpl with a
+// NameTemplateData, e.g. "{{.Interface}}Fake" or "Stub{{.Interface}}", instead of prefixing the
+// interface names with Mock. A mock name given to GenerateOutput still takes precedence. Validate
+// tmpl with ValidateNameTemplate first.
+func WithNameTemplate(tmpl string) Option {
+	return func(g *generator) { g.nameTemplate = tmpl }
+}
+
+// ValidateNameTemplate returns an error if tmpl can't be parsed or doesn't produce valid Go
+// identifiers.
+func ValidateNameTemplate(tmpl string) error {
+	_, e := mockNameFrom(tmpl, "Interface")
+	return e
+}
+
+func (g *generator) mockNameFor(structName string, iface *model.Interface) string {
+	if structName != "" {
+		return structName
+	}
+	if g.nameTemplate == "" {
+		if !token.IsExported(iface.Name) {
+			// mocks of unexported interfaces stay unexported, e.g. mockDisplay for display
+			return "mock" + strings.ToUpper(iface.Name[:1]) + iface.Name[1:]
+		}
+		return "Mock" + iface.Name
+	}
+	name, e := mockNameFrom(g.nameTemplate, iface.Name)
+	if e != nil {
+		panic(e)
+	}
+	return name
+}
+
+func mockNameFrom(tmpl string, interfaceName string) (string, error) {
+	t, e := template.New("name").Option("missingkey=error").Parse(tmpl)
+	if e != nil {
+		return "", fmt.Errorf("Could not parse name template: %v", e)
+	}
+	var name strings.Builder
+	if e := t.Execute(&name, NameTemplateData{Interface: interfaceName}); e != nil {
+		return "", fmt.Errorf("Could not execute name template: %v", e)
+	}
+	if !token.IsIdentifier(name.String()) {
+		return "", fmt.Errorf("Name template %q produces %q, which is not a valid Go identifier", tmpl, name.String())
+	}
+	return name.String(), nil
+}
@@ -0,0 +1,45 @@
+package mockgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// WithNullObject additionally generates a Null<Interface> type, which implements the interface
+// with methods that do nothing but return zero values. It doesn't depend on the Pegomock runtime.
+func WithNullObject() Option {
+	return func(g *generator) { g.nullObject = true }
+}
+
+// WithNullObjectOnly generates only the Null<Interface> types of WithNullObject instead of mocks,
+// so the generated file doesn't import Pegomock at all.
+func WithNullObjectOnly() Option {
+	return func(g *generator) { g.nullObject, g.nullObjectOnly = true, true }
+}
+
+func (g *generator) generateNullObjectFor(iface *model.Interface, pkgOverride string) {
+	nullObjectTypeName := "Null" + iface.Name
+	typeParamNames := typeParamsStringFrom(iface.TypeParams, g.packageMap, pkgOverride, false)
+	typeParams := typeParamsStringFrom(iface.TypeParams, g.packageMap, pkgOverride, true)
+	g.
+		emptyLine().
+		p("// %v implements %v with methods that do nothing and return zero values.", nullObjectTypeName, iface.Name).
+		p("type %v%v struct{}", nullObjectTypeName, typeParams).
+		emptyLine()
+	for _, method := range iface.Methods {
+		args, _, _, returnTypes := argDataFor(method, g.packageMap, pkgOverride)
+		g.p("func (%v%v) %v(%v) (%v) {", nullObjectTypeName, typeParamNames, method.Name, join(args),
+			join(stringSliceFrom(returnTypes, g.packageMap, pkgOverride)))
+		if len(returnTypes) > 0 {
+			returnValues := make([]string, len(returnTypes))
+			for i, returnType := range returnTypes {
+				g.p("var _ret%v %v", i, returnType.String(g.packageMap, pkgOverride))
+				returnValues[i] = fmt.Sprintf("_ret%v", i)
+			}
+			g.p("return %v", strings.Join(returnValues, ", "))
+		}
+		g.p("}").emptyLine()
+	}
+}
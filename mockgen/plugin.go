@@ -0,0 +1,71 @@
+package mockgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os/exec"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// PluginRequest is what generator plugins receive as JSON on their standard input, see WithPlugin.
+// The package is encoded as by pegomock model, and plugins written in Go can decode the request
+// with encoding/json.
+type PluginRequest struct {
+	Source      string         `json:"source"`                // where the interfaces come from, e.g. "io (interfaces: Reader)"
+	PackageName string         `json:"packageName"`           // of the generated code
+	SelfPackage string         `json:"selfPackage,omitempty"` // import path of the generated code, if it's in the interfaces' package
+	MockName    string         `json:"mockName,omitempty"`    // given with --mock-name, or empty
+	Package     *model.Package `json:"package"`               // the parsed interfaces
+	// Imports maps the import paths of all packages the interfaces refer to, except the one the
+	// code is generated into, to the names types should be qualified with.
+	Imports map[string]string `json:"imports"`
+	Header  string            `json:"header,omitempty"` // the header given with WithHeader as comment block, or empty
+}
+
+// WithPlugin replaces the generated mocks by the output of the executable at path. It is run with
+// a PluginRequest as JSON on its standard input and writes the generated file to its standard
+// output. This lets third parties ship their own output styles, e.g. company fakes or tracing
+// decorators, as separate programs. Output that is valid Go code is formatted like gofmt would.
+func WithPlugin(path string) Option {
+	return func(g *generator) { g.plugin = path }
+}
+
+func (g *generator) generatePluginOutput(source string, pkg *model.Package, nameOut, pkgName, selfPackage string) []byte {
+	_, nonVendorPackageMap := generateUniquePackageNamesFor(pkg.Imports(), g.importAliases)
+	imports := make(map[string]string, len(nonVendorPackageMap))
+	for packagePath, packageName := range nonVendorPackageMap {
+		if packagePath != selfPackage {
+			imports[packagePath] = packageName
+		}
+	}
+	request, e := json.Marshal(PluginRequest{
+		Source:      source,
+		PackageName: pkgName,
+		SelfPackage: selfPackage,
+		MockName:    nameOut,
+		Package:     pkg,
+		Imports:     imports,
+		Header:      g.header,
+	})
+	if e != nil {
+		panic(fmt.Errorf("Could not serialize request for plugin %v: %v", g.plugin, e))
+	}
+
+	cmd := exec.Command(g.plugin)
+	cmd.Stdin = bytes.NewReader(request)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, e := cmd.Output()
+	if e != nil {
+		panic(fmt.Errorf("Plugin %v failed: %v\n%v", g.plugin, e, strings.TrimSpace(stderr.String())))
+	}
+	// plugins may also produce something other than Go code, like documentation
+	if src, e := format.Source(output); e == nil {
+		return src
+	}
+	return output
+}
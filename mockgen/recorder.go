@@ -0,0 +1,44 @@
+package mockgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// WithRecorder additionally generates a lightweight Recorder<Interface> type, which implements the
+// interface by only recording invocations and returning zero values. It has no stubbing or
+// verification DSL.
+func WithRecorder() Option {
+	return func(g *generator) { g.recorder = true }
+}
+
+func (g *generator) generateRecorderFor(iface *model.Interface, typeParams, typeParamNames, pkgOverride string) {
+	recorderTypeName := "Recorder" + iface.Name
+	g.
+		p("type %v%v struct {", recorderTypeName, typeParams).
+		p("	pegomock.InvocationRecorder").
+		p("}").
+		emptyLine().
+		p("func New%v%v() *%v%v {", recorderTypeName, typeParams, recorderTypeName, typeParamNames).
+		p("	return &%v%v{}", recorderTypeName, typeParamNames).
+		p("}").
+		emptyLine()
+	for _, method := range iface.Methods {
+		args, argNames, _, returnTypes := argDataFor(method, g.packageMap, pkgOverride)
+		g.p("func (recorder *%v%v) %v(%v) (%v) {", recorderTypeName, typeParamNames, method.Name, join(args),
+			join(stringSliceFrom(returnTypes, g.packageMap, pkgOverride)))
+		g.GenerateParamsDeclaration(argNames, method.Variadic != nil)
+		g.p("recorder.Record(%q, _params)", method.Name)
+		if len(returnTypes) > 0 {
+			returnValues := make([]string, len(returnTypes))
+			for i, returnType := range returnTypes {
+				g.p("var _ret%v %v", i, returnType.String(g.packageMap, pkgOverride))
+				returnValues[i] = fmt.Sprintf("_ret%v", i)
+			}
+			g.p("return %v", strings.Join(returnValues, ", "))
+		}
+		g.p("}").emptyLine()
+	}
+}
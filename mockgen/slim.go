@@ -0,0 +1,8 @@
+package mockgen
+
+// WithSlimMocks generates mocks without their verification code: the VerifyWasCalled methods, the
+// Verifier and OngoingVerification types capturing arguments, and the <Method>Calls accessors
+// built on top of them. This roughly halves the mocks of projects that only stub.
+func WithSlimMocks() Option {
+	return func(g *generator) { g.slim = true }
+}
@@ -0,0 +1,95 @@
+package mockgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// TemplateData is what custom output templates are executed with, see WithOutputTemplate.
+type TemplateData struct {
+	Source      string         // where the interfaces come from, e.g. "io (interfaces: Reader)"
+	PackageName string         // of the generated code
+	Package     *model.Package // the parsed interfaces
+	// Imports maps the import paths of all packages the interfaces refer to, except the one the
+	// code is generated into, to the names the type function qualifies their types with.
+	Imports map[string]string
+	Header  string // the header given with WithHeader as comment block, or empty
+}
+
+// WithOutputTemplate replaces the generated mocks by the output of the text/template tmpl, which
+// is executed with a TemplateData. This lets organizations generate their own style of mocks or
+// fakes from the parsed interfaces. Besides the builtins, templates can use these functions:
+//
+//	type           renders a model.Type, e.g. "*http.Request"
+//	params         the parameters of a *model.Method, e.g. "key string, values ...int"
+//	argNames       the names of the parameters of a *model.Method, e.g. "key, values"
+//	results        the results of a *model.Method, e.g. "(string, error)", "error" or ""
+//	typeParams     the type parameters of a *model.Interface, e.g. "[K comparable, V any]"
+//	typeParamNames the names of the type parameters of a *model.Interface, e.g. "[K, V]"
+//
+// Output that is valid Go code is formatted like gofmt would.
+func WithOutputTemplate(tmpl string) Option {
+	return func(g *generator) { g.outputTemplate = tmpl }
+}
+
+func (g *generator) generateTemplateOutput(source string, pkg *model.Package, pkgName, selfPackage string) []byte {
+	packageMap, nonVendorPackageMap := generateUniquePackageNamesFor(pkg.Imports(), g.importAliases)
+	g.packageMap = packageMap
+	imports := make(map[string]string, len(nonVendorPackageMap))
+	for packagePath, packageName := range nonVendorPackageMap {
+		if packagePath != selfPackage {
+			imports[packagePath] = packageName
+		}
+	}
+
+	tmpl, e := template.New("output").Funcs(g.templateFuncs(selfPackage)).Parse(g.outputTemplate)
+	if e != nil {
+		panic(fmt.Errorf("Could not parse output template: %v", e))
+	}
+	var buf bytes.Buffer
+	e = tmpl.Execute(&buf, TemplateData{Source: source, PackageName: pkgName, Package: pkg, Imports: imports, Header: g.header})
+	if e != nil {
+		panic(fmt.Errorf("Could not execute output template: %v", e))
+	}
+	// templates may also produce something other than Go code, like documentation
+	if src, e := format.Source(buf.Bytes()); e == nil {
+		return src
+	}
+	return buf.Bytes()
+}
+
+func (g *generator) templateFuncs(selfPackage string) template.FuncMap {
+	return template.FuncMap{
+		"type": func(t model.Type) string { return t.String(g.packageMap, selfPackage) },
+		"params": func(method *model.Method) string {
+			args, _, _, _ := argDataFor(method, g.packageMap, selfPackage)
+			return join(args)
+		},
+		"argNames": func(method *model.Method) string {
+			_, argNames, _, _ := argDataFor(method, g.packageMap, selfPackage)
+			return join(argNames)
+		},
+		"results": func(method *model.Method) string {
+			_, _, _, returnTypes := argDataFor(method, g.packageMap, selfPackage)
+			results := stringSliceFrom(returnTypes, g.packageMap, selfPackage)
+			if len(results) == 1 {
+				return results[0]
+			}
+			if len(results) > 1 {
+				return "(" + strings.Join(results, ", ") + ")"
+			}
+			return ""
+		},
+		"typeParams": func(iface *model.Interface) string {
+			return typeParamsStringFrom(iface.TypeParams, g.packageMap, selfPackage, true)
+		},
+		"typeParamNames": func(iface *model.Interface) string {
+			return typeParamsStringFrom(iface.TypeParams, g.packageMap, selfPackage, false)
+		},
+	}
+}
@@ -0,0 +1,50 @@
+package mockgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+var primitiveTypes = map[model.PredeclaredType]bool{
+	"bool": true, "string": true, "byte": true, "rune": true,
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true,
+	"uint": true, "uint8": true, "uint16": true, "uint32": true, "uint64": true, "uintptr": true,
+	"float32": true, "float64": true,
+}
+
+// usesTypedParams reports whether invocations of method can be recorded via InvokeTyped, i.e.
+// whether all its params are primitives. Hybrid fakes always need the params as []Param.
+func (g *generator) usesTypedParams(method *model.Method) bool {
+	if g.hybridFake || !g.hasV4API() || method.Variadic != nil || len(method.In) == 0 {
+		return false
+	}
+	for _, param := range method.In {
+		if predeclaredType, isPredeclared := param.Type.(model.PredeclaredType); !isPredeclared || !primitiveTypes[predeclaredType] {
+			return false
+		}
+	}
+	return true
+}
+
+func typedParamsTypeName(mockType string, method *model.Method) string {
+	return fmt.Sprintf("%v_%v_Params", mockType, method.Name)
+}
+
+func (g *generator) generateTypedParamsType(mockType string, method *model.Method) *generator {
+	typeName := typedParamsTypeName(mockType, method)
+	fieldNames := make([]string, len(method.In))
+	g.p("type %v struct {", typeName)
+	for i, param := range method.In {
+		fieldNames[i] = fmt.Sprintf("params._param%d", i)
+		g.p("	_param%d %v", i, param.Type.String(g.packageMap, ""))
+	}
+	return g.
+		p("}").
+		emptyLine().
+		p("func (params %v) Params() []pegomock.Param {", typeName).
+		p("	return []pegomock.Param{%v}", strings.Join(fieldNames, ", ")).
+		p("}").
+		emptyLine()
+}
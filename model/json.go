@@ -0,0 +1,249 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The JSON encoding of a Type is an object whose "kind" tells which Type it is:
+//
+//	{"kind": "predeclared", "name": "int"}
+//	{"kind": "named", "package": "io", "name": "Reader", "typeArgs": [...]}
+//	{"kind": "pointer", "elem": ...}
+//	{"kind": "slice", "elem": ...}
+//	{"kind": "array", "len": 3, "elem": ...}
+//	{"kind": "map", "key": ..., "value": ...}
+//	{"kind": "chan", "dir": "recv", "elem": ...} (dir is "recv", "send" or omitted for both)
+//	{"kind": "func", "in": [...], "variadic": ..., "out": [...]}
+//	{"kind": "interface", "embedded": [...], "methods": [...], "implicit": true}
+//	{"kind": "union", "terms": [{"tilde": true, "type": ...}, ...]}
+
+// ParseJSON parses a package encoded by encoding/json, e.g. by pegomock model.
+func ParseJSON(data []byte) (*Package, error) {
+	var pkg Package
+	if e := json.Unmarshal(data, &pkg); e != nil {
+		return nil, e
+	}
+	return &pkg, nil
+}
+
+type jsonType struct {
+	Kind     string            `json:"kind"`
+	Name     string            `json:"name,omitempty"`
+	Package  string            `json:"package,omitempty"`
+	TypeArgs []json.RawMessage `json:"typeArgs,omitempty"`
+	Len      *int              `json:"len,omitempty"`
+	Dir      string            `json:"dir,omitempty"`
+	Elem     json.RawMessage   `json:"elem,omitempty"`
+	Key      json.RawMessage   `json:"key,omitempty"`
+	Value    json.RawMessage   `json:"value,omitempty"`
+	In       []*Parameter      `json:"in,omitempty"`
+	Variadic *Parameter        `json:"variadic,omitempty"`
+	Out      []*Parameter      `json:"out,omitempty"`
+	Embedded []json.RawMessage `json:"embedded,omitempty"`
+	Methods  []*Method         `json:"methods,omitempty"`
+	Implicit bool              `json:"implicit,omitempty"`
+	Terms    []*UnionTerm      `json:"terms,omitempty"`
+}
+
+var chanDirNames = map[ChanDir]string{RecvDir: "recv", SendDir: "send"}
+
+func (pt PredeclaredType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonType{Kind: "predeclared", Name: string(pt)})
+}
+
+func (nt *NamedType) MarshalJSON() ([]byte, error) {
+	typeArgs, e := marshalTypes(nt.TypeArgs)
+	if e != nil {
+		return nil, e
+	}
+	return json.Marshal(jsonType{Kind: "named", Package: nt.Package, Name: nt.Type, TypeArgs: typeArgs})
+}
+
+func (pt *PointerType) MarshalJSON() ([]byte, error) {
+	elem, e := json.Marshal(pt.Type)
+	if e != nil {
+		return nil, e
+	}
+	return json.Marshal(jsonType{Kind: "pointer", Elem: elem})
+}
+
+func (at *ArrayType) MarshalJSON() ([]byte, error) {
+	elem, e := json.Marshal(at.Type)
+	if e != nil {
+		return nil, e
+	}
+	if at.Len < 0 {
+		return json.Marshal(jsonType{Kind: "slice", Elem: elem})
+	}
+	length := at.Len
+	return json.Marshal(jsonType{Kind: "array", Len: &length, Elem: elem})
+}
+
+func (mt *MapType) MarshalJSON() ([]byte, error) {
+	key, e := json.Marshal(mt.Key)
+	if e != nil {
+		return nil, e
+	}
+	value, e := json.Marshal(mt.Value)
+	if e != nil {
+		return nil, e
+	}
+	return json.Marshal(jsonType{Kind: "map", Key: key, Value: value})
+}
+
+func (ct *ChanType) MarshalJSON() ([]byte, error) {
+	elem, e := json.Marshal(ct.Type)
+	if e != nil {
+		return nil, e
+	}
+	return json.Marshal(jsonType{Kind: "chan", Dir: chanDirNames[ct.Dir], Elem: elem})
+}
+
+func (ft *FuncType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonType{Kind: "func", In: ft.In, Variadic: ft.Variadic, Out: ft.Out})
+}
+
+func (it *InterfaceType) MarshalJSON() ([]byte, error) {
+	embedded, e := marshalTypes(it.Embedded)
+	if e != nil {
+		return nil, e
+	}
+	return json.Marshal(jsonType{Kind: "interface", Embedded: embedded, Methods: it.Methods, Implicit: it.Implicit})
+}
+
+func (ut *UnionType) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonType{Kind: "union", Terms: ut.Terms})
+}
+
+func (p *Parameter) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name string          `json:"name"`
+		Type json.RawMessage `json:"type"`
+	}
+	if e := json.Unmarshal(data, &raw); e != nil {
+		return e
+	}
+	t, e := unmarshalType(raw.Type)
+	if e != nil {
+		return e
+	}
+	p.Name, p.Type = raw.Name, t
+	return nil
+}
+
+func (ut *UnionTerm) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Tilde bool            `json:"tilde"`
+		Type  json.RawMessage `json:"type"`
+	}
+	if e := json.Unmarshal(data, &raw); e != nil {
+		return e
+	}
+	t, e := unmarshalType(raw.Type)
+	if e != nil {
+		return e
+	}
+	ut.Tilde, ut.Type = raw.Tilde, t
+	return nil
+}
+
+func marshalTypes(types []Type) ([]json.RawMessage, error) {
+	var result []json.RawMessage
+	for _, t := range types {
+		data, e := json.Marshal(t)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+func unmarshalTypes(data []json.RawMessage) ([]Type, error) {
+	var result []Type
+	for _, d := range data {
+		t, e := unmarshalType(d)
+		if e != nil {
+			return nil, e
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+func unmarshalType(data json.RawMessage) (Type, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("missing type")
+	}
+	var jt jsonType
+	if e := json.Unmarshal(data, &jt); e != nil {
+		return nil, e
+	}
+	switch jt.Kind {
+	case "predeclared":
+		return PredeclaredType(jt.Name), nil
+	case "named":
+		typeArgs, e := unmarshalTypes(jt.TypeArgs)
+		if e != nil {
+			return nil, e
+		}
+		return &NamedType{Package: jt.Package, Type: jt.Name, TypeArgs: typeArgs}, nil
+	case "pointer":
+		elem, e := unmarshalType(jt.Elem)
+		if e != nil {
+			return nil, e
+		}
+		return &PointerType{Type: elem}, nil
+	case "slice", "array":
+		elem, e := unmarshalType(jt.Elem)
+		if e != nil {
+			return nil, e
+		}
+		length := -1
+		if jt.Kind == "array" {
+			if jt.Len == nil {
+				return nil, fmt.Errorf("array type without len")
+			}
+			length = *jt.Len
+		}
+		return &ArrayType{Len: length, Type: elem}, nil
+	case "map":
+		key, e := unmarshalType(jt.Key)
+		if e != nil {
+			return nil, e
+		}
+		value, e := unmarshalType(jt.Value)
+		if e != nil {
+			return nil, e
+		}
+		return &MapType{Key: key, Value: value}, nil
+	case "chan":
+		elem, e := unmarshalType(jt.Elem)
+		if e != nil {
+			return nil, e
+		}
+		var dir ChanDir
+		for d, name := range chanDirNames {
+			if name == jt.Dir {
+				dir = d
+			}
+		}
+		if dir == 0 && jt.Dir != "" {
+			return nil, fmt.Errorf("unknown channel direction %q", jt.Dir)
+		}
+		return &ChanType{Dir: dir, Type: elem}, nil
+	case "func":
+		return &FuncType{In: jt.In, Variadic: jt.Variadic, Out: jt.Out}, nil
+	case "interface":
+		embedded, e := unmarshalTypes(jt.Embedded)
+		if e != nil {
+			return nil, e
+		}
+		return &InterfaceType{Embedded: embedded, Methods: jt.Methods, Implicit: jt.Implicit}, nil
+	case "union":
+		return &UnionType{Terms: jt.Terms}, nil
+	default:
+		return nil, fmt.Errorf("unknown type kind %q", jt.Kind)
+	}
+}
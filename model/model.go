@@ -23,9 +23,10 @@ import (
 
 // Package is a Go package. It may be a subset.
 type Package struct {
-	Name       string
-	Interfaces []*Interface
-	DotImports []string
+	Name       string       `json:"name"`
+	PkgPath    string       `json:"pkgPath"` // of the package declaring the interfaces, e.g. "example.com/store_test" for an external test package
+	Interfaces []*Interface `json:"interfaces"`
+	DotImports []string     `json:"dotImports,omitempty"`
 }
 
 func (pkg *Package) Print(w io.Writer) {
@@ -46,11 +47,20 @@ func (pkg *Package) Imports() map[string]bool {
 
 // Interface is a Go interface.
 type Interface struct {
-	Name       string
-	TypeParams []*Parameter
-	Methods    []*Method
+	Name       string       `json:"name"`
+	TypeParams []*Parameter `json:"typeParams,omitempty"`
+	Methods    []*Method    `json:"methods"`
+	// NeedsOwnPackage is set for interfaces that refer to unexported names of their package, e.g.
+	// because they are unexported themselves. Only code in that package can implement them.
+	NeedsOwnPackage bool `json:"needsOwnPackage,omitempty"`
+	// FuncType is set for named function types like type HandlerFunc func(string) error. They are
+	// modeled as interfaces with the single method FuncTypeMethodName.
+	FuncType bool `json:"funcType,omitempty"`
 }
 
+// FuncTypeMethodName is the name of the single method function types are modeled with.
+const FuncTypeMethodName = "Call"
+
 func (intf *Interface) Print(w io.Writer) {
 	fmt.Fprintf(w, "interface %s\n", intf.Name)
 	for _, m := range intf.Methods {
@@ -69,13 +79,23 @@ func (intf *Interface) addImports(im map[string]bool) {
 
 // Method is a single method of an interface.
 type Method struct {
-	Name     string
-	In, Out  []*Parameter
-	Variadic *Parameter // may be nil
+	Name string `json:"name"`
+	// TypeParams are the method's own type parameters. Go doesn't allow them in interfaces yet, so
+	// this is nil for all methods that compile today.
+	TypeParams []*Parameter `json:"typeParams,omitempty"`
+	In         []*Parameter `json:"in,omitempty"`
+	Out        []*Parameter `json:"out,omitempty"`
+	Variadic   *Parameter   `json:"variadic,omitempty"` // may be nil
 }
 
 func (m *Method) Print(w io.Writer) {
 	fmt.Fprintf(w, "  - method %s\n", m.Name)
+	if len(m.TypeParams) > 0 {
+		fmt.Fprintf(w, "    type params:\n")
+		for _, p := range m.TypeParams {
+			p.Print(w)
+		}
+	}
 	if len(m.In) > 0 {
 		fmt.Fprintf(w, "    in:\n")
 		for _, p := range m.In {
@@ -95,6 +115,9 @@ func (m *Method) Print(w io.Writer) {
 }
 
 func (m *Method) addImports(im map[string]bool) {
+	for _, p := range m.TypeParams {
+		p.Type.addImports(im)
+	}
 	for _, p := range m.In {
 		p.Type.addImports(im)
 	}
@@ -108,8 +131,8 @@ func (m *Method) addImports(im map[string]bool) {
 
 // Parameter is an argument or return parameter of a method.
 type Parameter struct {
-	Name string // may be empty
-	Type Type
+	Name string `json:"name,omitempty"` // may be empty
+	Type Type   `json:"type"`
 }
 
 func (p *Parameter) Print(w io.Writer) {
@@ -208,6 +231,67 @@ func (ft *FuncType) addImports(im map[string]bool) {
 	}
 }
 
+// InterfaceType is an interface literal with methods or type constraints, e.g. the constraint
+// interface{ ~int; String() string }. Empty interfaces are PredeclaredTypes.
+type InterfaceType struct {
+	Embedded []Type // embedded interfaces and unions
+	Methods  []*Method
+	// Implicit is set for constraints written without interface{...}, e.g. int | string in [T int | string].
+	Implicit bool
+}
+
+func (it *InterfaceType) String(pm map[string]string, pkgOverride string) string {
+	if it.Implicit && len(it.Embedded) == 1 && len(it.Methods) == 0 {
+		return it.Embedded[0].String(pm, pkgOverride)
+	}
+	elems := make([]string, 0, len(it.Embedded)+len(it.Methods))
+	for _, embedded := range it.Embedded {
+		elems = append(elems, embedded.String(pm, pkgOverride))
+	}
+	for _, m := range it.Methods {
+		elems = append(elems, m.Name+strings.TrimPrefix((&FuncType{In: m.In, Out: m.Out, Variadic: m.Variadic}).String(pm, pkgOverride), "func"))
+	}
+	return "interface{ " + strings.Join(elems, "; ") + " }"
+}
+
+func (it *InterfaceType) addImports(im map[string]bool) {
+	for _, embedded := range it.Embedded {
+		embedded.addImports(im)
+	}
+	for _, m := range it.Methods {
+		m.addImports(im)
+	}
+}
+
+// UnionType is a union of types in a constraint, e.g. ~string | int.
+type UnionType struct {
+	Terms []*UnionTerm
+}
+
+// UnionTerm is a single type of a union. Tilde is set for approximations like ~string, which
+// include all types with that underlying type.
+type UnionTerm struct {
+	Tilde bool `json:"tilde,omitempty"`
+	Type  Type `json:"type"`
+}
+
+func (ut *UnionType) String(pm map[string]string, pkgOverride string) string {
+	terms := make([]string, len(ut.Terms))
+	for i, term := range ut.Terms {
+		terms[i] = term.Type.String(pm, pkgOverride)
+		if term.Tilde {
+			terms[i] = "~" + terms[i]
+		}
+	}
+	return strings.Join(terms, " | ")
+}
+
+func (ut *UnionType) addImports(im map[string]bool) {
+	for _, term := range ut.Terms {
+		term.Type.addImports(im)
+	}
+}
+
 // MapType is a map type.
 type MapType struct {
 	Key, Value Type
@@ -224,21 +308,33 @@ func (mt *MapType) addImports(im map[string]bool) {
 
 // NamedType is an exported type in a package.
 type NamedType struct {
-	Package string // may be empty
-	Type    string // TODO: should this be typed Type?
+	Package  string // may be empty
+	Type     string // TODO: should this be typed Type?
+	TypeArgs []Type // of instantiated generic types, e.g. string for Set[string]
 }
 
 func (nt *NamedType) String(pm map[string]string, pkgOverride string) string {
+	typeArgs := ""
+	if len(nt.TypeArgs) > 0 {
+		args := make([]string, len(nt.TypeArgs))
+		for i, typeArg := range nt.TypeArgs {
+			args[i] = typeArg.String(pm, pkgOverride)
+		}
+		typeArgs = "[" + strings.Join(args, ", ") + "]"
+	}
 	// TODO: is this right?
 	if pkgOverride == nt.Package {
-		return nt.Type
+		return nt.Type + typeArgs
 	}
-	return pm[nt.Package] + "." + nt.Type
+	return pm[nt.Package] + "." + nt.Type + typeArgs
 }
 func (nt *NamedType) addImports(im map[string]bool) {
 	if nt.Package != "" {
 		im[nt.Package] = true
 	}
+	for _, typeArg := range nt.TypeArgs {
+		typeArg.addImports(im)
+	}
 }
 
 // PointerType is a pointer to another type.
@@ -0,0 +1,38 @@
+package xtools_packages
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+
+	"github.com/petergtz/pegomock/v4/model"
+	"golang.org/x/tools/go/gcexportdata"
+)
+
+// GenerateModelFromArchive is like GenerateModel, but reads the package from the export data of a
+// compiled package archive, e.g. built with go build -o store.a example.com/store, instead of from
+// source. importPath is the import path the archive was compiled for, because archives don't
+// record it themselves.
+func GenerateModelFromArchive(archivePath string, importPath string, interfaceName string) (*model.Package, error) {
+	archive, e := os.Open(archivePath)
+	if e != nil {
+		return nil, e
+	}
+	defer archive.Close()
+	exportData, e := gcexportdata.NewReader(bufio.NewReader(archive))
+	if e != nil {
+		return nil, fmt.Errorf("Could not read export data from %v: %v", archivePath, e)
+	}
+	typesPkg, e := gcexportdata.Read(exportData, token.NewFileSet(), make(map[string]*types.Package), importPath)
+	if e != nil {
+		return nil, fmt.Errorf("Could not read export data from %v: %v", archivePath, e)
+	}
+	pkg, e := modelFrom(typesPkg, importPath, interfaceName, nil)
+	if e == errInterfaceNotFound {
+		return nil, errors.New("Did not find interface name \"" + interfaceName + "\" in " + archivePath)
+	}
+	return pkg, e
+}
@@ -0,0 +1,139 @@
+package xtools_packages
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// DirectivePrefix starts the comments marking interfaces to mock, e.g.
+//
+//	//pegomock:generate --fake
+//	type Store interface { ... }
+const DirectivePrefix = "//pegomock:generate"
+
+// Directive is an interface marked with a DirectivePrefix comment.
+type Directive struct {
+	InterfaceRef
+	Args     []string // following DirectivePrefix, separated by spaces
+	Position string   // of the comment, as file:line
+}
+
+// FindDirectives loads all packages matching patterns, like FindInterfaces, and returns the
+// interfaces whose doc comments contain a line starting with DirectivePrefix, including those
+// declared in _test.go files. It fails for such lines above other declarations, since those are
+// most likely mistakes.
+func FindDirectives(patterns []string) ([]Directive, error) {
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles, Tests: true}, patterns...)
+	if e != nil {
+		return nil, e
+	}
+	var directives []Directive
+	// test variants contain the files of the package under test again
+	visited := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			if pkgErr.Kind == packages.ListError {
+				return nil, fmt.Errorf("Could not load %v: %v", pkg.PkgPath, pkgErr)
+			}
+		}
+		importPath := importPathOf(pkg)
+		if importPath == "" {
+			continue
+		}
+		for _, goFile := range pkg.GoFiles {
+			if visited[goFile] {
+				continue
+			}
+			visited[goFile] = true
+			fileDirectives, e := directivesIn(goFile, InterfaceRef{ImportPath: importPath, Dir: filepath.Dir(goFile)})
+			if e != nil {
+				return nil, e
+			}
+			directives = append(directives, fileDirectives...)
+		}
+	}
+	sort.SliceStable(directives, func(i, j int) bool {
+		if directives[i].ImportPath != directives[j].ImportPath {
+			return directives[i].ImportPath < directives[j].ImportPath
+		}
+		return directives[i].Name < directives[j].Name
+	})
+	return directives, nil
+}
+
+func directivesIn(goFile string, pkg InterfaceRef) ([]Directive, error) {
+	fileSet := token.NewFileSet()
+	file, e := parser.ParseFile(fileSet, goFile, nil, parser.ParseComments|parser.SkipObjectResolution)
+	if e != nil {
+		return nil, e
+	}
+	var directives []Directive
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			if comment := directiveIn(decl.Doc); comment != nil {
+				return nil, fmt.Errorf("%v: %v is not above an interface", positionOf(fileSet, comment), DirectivePrefix)
+			}
+		case *ast.GenDecl:
+			for _, spec := range decl.Specs {
+				doc := docOf(spec)
+				if doc == nil && len(decl.Specs) == 1 {
+					doc = decl.Doc
+				}
+				comment := directiveIn(doc)
+				if comment == nil {
+					continue
+				}
+				typeSpec, isTypeSpec := spec.(*ast.TypeSpec)
+				if !isTypeSpec {
+					return nil, fmt.Errorf("%v: %v is not above an interface", positionOf(fileSet, comment), DirectivePrefix)
+				}
+				if _, isInterface := typeSpec.Type.(*ast.InterfaceType); !isInterface {
+					return nil, fmt.Errorf("%v: %v is above %v, which is not an interface", positionOf(fileSet, comment), DirectivePrefix, typeSpec.Name.Name)
+				}
+				ref := pkg
+				ref.Name = typeSpec.Name.Name
+				directives = append(directives, Directive{
+					InterfaceRef: ref,
+					Args:         strings.Fields(strings.TrimPrefix(comment.Text, DirectivePrefix)),
+					Position:     positionOf(fileSet, comment),
+				})
+			}
+		}
+	}
+	return directives, nil
+}
+
+func positionOf(fileSet *token.FileSet, comment *ast.Comment) string {
+	position := fileSet.Position(comment.Pos())
+	return fmt.Sprintf("%v:%v", position.Filename, position.Line)
+}
+
+func docOf(spec ast.Spec) *ast.CommentGroup {
+	switch spec := spec.(type) {
+	case *ast.TypeSpec:
+		return spec.Doc
+	case *ast.ValueSpec:
+		return spec.Doc
+	}
+	return nil
+}
+
+func directiveIn(doc *ast.CommentGroup) *ast.Comment {
+	if doc == nil {
+		return nil
+	}
+	for _, comment := range doc.List {
+		if comment.Text == DirectivePrefix || strings.HasPrefix(comment.Text, DirectivePrefix+" ") {
+			return comment
+		}
+	}
+	return nil
+}
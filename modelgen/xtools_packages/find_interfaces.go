@@ -0,0 +1,86 @@
+package xtools_packages
+
+import (
+	"fmt"
+	"go/types"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// InterfaceRef identifies an interface found by FindInterfaces.
+type InterfaceRef struct {
+	ImportPath string
+	Name       string
+	Dir        string // directory of the package declaring the interface
+}
+
+// FindInterfaces loads all packages matching patterns, which may contain "..." wildcards like
+// "./internal/...", and returns their exported interfaces whose names fully match namePattern,
+// including those declared in _test.go files. Interfaces that can only be used as type constraints
+// are skipped, since they can't be mocked.
+func FindInterfaces(patterns []string, namePattern string) ([]InterfaceRef, error) {
+	nameRegex, e := regexp.Compile("^(?:" + namePattern + ")$")
+	if e != nil {
+		return nil, fmt.Errorf("Invalid interface name pattern: %v", e)
+	}
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedTypes, Tests: true}, patterns...)
+	if e != nil {
+		return nil, e
+	}
+	var refs []InterfaceRef
+	var loadErrors []string
+	found := make(map[InterfaceRef]bool)
+	reported := make(map[string]bool)
+	for _, pkg := range pkgs {
+		// test variants report the errors of the package under test again
+		for _, pkgErr := range pkg.Errors {
+			if !reported[pkgErr.Error()] {
+				reported[pkgErr.Error()] = true
+				loadErrors = append(loadErrors, pkgErr.Error())
+			}
+		}
+		importPath := importPathOf(pkg)
+		if pkg.Types == nil || len(pkg.GoFiles) == 0 || importPath == "" {
+			continue
+		}
+		scope := pkg.Types.Scope()
+		for _, name := range scope.Names() {
+			obj, isTypeName := scope.Lookup(name).(*types.TypeName)
+			if !isTypeName || !obj.Exported() || !nameRegex.MatchString(name) {
+				continue
+			}
+			ref := InterfaceRef{ImportPath: importPath, Name: name, Dir: filepath.Dir(pkg.GoFiles[0])}
+			if iface, isIface := obj.Type().Underlying().(*types.Interface); isIface && iface.IsMethodSet() && !found[ref] {
+				found[ref] = true
+				refs = append(refs, ref)
+			}
+		}
+	}
+	if len(refs) == 0 && len(loadErrors) > 0 {
+		return nil, fmt.Errorf("Errors while loading packages %v:\n\t%v", strings.Join(patterns, " "), strings.Join(loadErrors, "\n\t"))
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].ImportPath != refs[j].ImportPath {
+			return refs[i].ImportPath < refs[j].ImportPath
+		}
+		return refs[i].Name < refs[j].Name
+	})
+	return refs, nil
+}
+
+// importPathOf returns the import path GenerateModel finds the interfaces of pkg with. For the
+// test variants of a package, e.g. its external test package, that's the path of the package
+// under test. For the main packages go/packages generates for tests, it's "".
+func importPathOf(pkg *packages.Package) string {
+	if strings.HasSuffix(pkg.ID, ".test") {
+		return ""
+	}
+	if strings.HasSuffix(pkg.Name, "_test") {
+		return strings.TrimSuffix(pkg.PkgPath, "_test")
+	}
+	return pkg.PkgPath
+}
@@ -0,0 +1,110 @@
+package xtools_packages
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/imports"
+)
+
+// GenerateModelsFromSource is like GenerateModel, but type-checks the interfaces from src, Go
+// declarations like "type Store interface { Get(key string) ([]byte, error) }", e.g. given on the
+// command line, as if they were declared in the package pkgPath named pkgName. src doesn't need a
+// package clause or imports; packages it refers to, like io in io.Reader, are imported
+// automatically. It returns a model per interface or function type declared in src, in order.
+func GenerateModelsFromSource(src string, pkgPath string, pkgName string) ([]*model.Package, error) {
+	completeSrc, e := imports.Process("inline.go", []byte("package "+pkgName+"\n\n"+src), &imports.Options{Comments: true})
+	if e != nil {
+		return nil, fmt.Errorf("Could not parse %q: %v", src, e)
+	}
+	fileSet := token.NewFileSet()
+	file, e := parser.ParseFile(fileSet, "inline.go", completeSrc, parser.SkipObjectResolution)
+	if e != nil {
+		return nil, fmt.Errorf("Could not parse %q: %v", src, e)
+	}
+
+	imported, e := importedPackagesOf(file)
+	if e != nil {
+		return nil, e
+	}
+	typesConfig := types.Config{Importer: importerFunc(func(path string) (*types.Package, error) {
+		if pkg, isImported := imported[path]; isImported {
+			return pkg, nil
+		}
+		return nil, fmt.Errorf("could not import %v", path)
+	})}
+	typesPkg, e := typesConfig.Check(pkgPath, fileSet, []*ast.File{file}, nil)
+	if typeErr, isTypeErr := e.(types.Error); isTypeErr {
+		// positions would refer to the package clause and imports added to src
+		return nil, fmt.Errorf("Could not type-check %q: %v", src, typeErr.Msg)
+	}
+	if e != nil {
+		return nil, fmt.Errorf("Could not type-check %q: %v", src, e)
+	}
+
+	var models []*model.Package
+	for _, decl := range file.Decls {
+		genDecl, isGenDecl := decl.(*ast.GenDecl)
+		if !isGenDecl || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			switch typeSpec.Type.(type) {
+			case *ast.InterfaceType, *ast.FuncType:
+				pkg, e := modelFrom(typesPkg, pkgPath, typeSpec.Name.Name, nil)
+				if e != nil {
+					return nil, e
+				}
+				models = append(models, pkg)
+			}
+		}
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("%q declares no interfaces", src)
+	}
+	return models, nil
+}
+
+// importedPackagesOf loads the type information of the packages file imports, mapped by import
+// path.
+func importedPackagesOf(file *ast.File) (map[string]*types.Package, error) {
+	var importPaths []string
+	for _, spec := range file.Imports {
+		importPath, e := strconv.Unquote(spec.Path.Value)
+		if e != nil {
+			return nil, e
+		}
+		importPaths = append(importPaths, importPath)
+	}
+	imported := make(map[string]*types.Package, len(importPaths))
+	if len(importPaths) == 0 {
+		return imported, nil
+	}
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes}, importPaths...)
+	if e != nil {
+		return nil, e
+	}
+	var loadErrors []string
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			loadErrors = append(loadErrors, pkgErr.Error())
+		}
+		imported[pkg.PkgPath] = pkg.Types
+	}
+	if len(loadErrors) > 0 {
+		return nil, fmt.Errorf("Errors while loading packages %v:\n\t%v", strings.Join(importPaths, " "), strings.Join(loadErrors, "\n\t"))
+	}
+	return imported, nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) { return f(path) }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"go/types"
 	"path"
+	"strings"
 
 	"github.com/petergtz/pegomock/v4/model"
 	"golang.org/x/tools/go/packages"
@@ -33,33 +34,224 @@ func NewBlub[K1 comparable, V1 Number]() Bla[K1, V1] {
 }
 
 func GenerateModel(importPath string, interfaceName string) (*model.Package, error) {
+	pkg, _, e := GenerateModelWithWarnings(importPath, interfaceName)
+	return pkg, e
+}
+
+// GenerateModelWithWarnings is like GenerateModel, but additionally returns the errors found
+// while loading the package. As long as these errors don't affect the requested interface,
+// the model is generated on a best-effort basis from the partial type information.
+func GenerateModelWithWarnings(importPath string, interfaceName string) (*model.Package, []string, error) {
+	pkg, warnings, e := generateModel(importPath, interfaceName, false)
+	if e == errInterfaceNotFound {
+		// the interface might be declared in a _test.go file, so look in the test variants too
+		pkg, warnings, e = generateModel(importPath, interfaceName, true)
+	}
+	if e == errInterfaceNotFound {
+		if len(warnings) > 0 {
+			return nil, warnings, errors.New("Did not find interface name \"" + interfaceName + "\". " +
+				"Errors while loading the package:\n\t" + strings.Join(warnings, "\n\t"))
+		}
+		return nil, nil, errors.New("Did not find interface name \"" + interfaceName + "\"")
+	}
+	return pkg, warnings, e
+}
+
+var errInterfaceNotFound = errors.New("interface not found")
+
+// modelFrom builds the model of the interface or function type interfaceName of typesPkg. warnings
+// are the errors found while loading typesPkg, if any.
+func modelFrom(typesPkg *types.Package, pkgPath string, interfaceName string, warnings []string) (*model.Package, error) {
+	obj := typesPkg.Scope().Lookup(interfaceName)
+	if obj == nil {
+		return nil, errInterfaceNotFound
+	}
+
+	var typeParams *types.TypeParamList
+	if named, isNamed := obj.Type().(*types.Named); isNamed {
+		// aliases, like type IntCache = Cache[int], have no type parameters
+		typeParams = named.TypeParams()
+	}
+
+	// from here, things follow the spec in https://tip.golang.org/ref/spec
+	if iface, isIface := obj.Type().Underlying().(*types.Interface); isIface {
+		if method := firstMethodWithInvalidType(iface); method != nil {
+			return nil, fmt.Errorf("Interface %q is affected by errors in package %v: "+
+				"signature of method %v could not be type-checked:\n\t%v",
+				interfaceName, pkgPath, method.Name(), strings.Join(warnings, "\n\t"))
+		}
+		if method := firstUnexportedMethodOfOtherPackage(iface, typesPkg); method != nil {
+			return nil, fmt.Errorf("Interface %q embeds unexported method %v of package %v. "+
+				"Types outside that package cannot implement it, so it cannot be mocked.",
+				interfaceName, method.Name(), method.Pkg().Path())
+		}
+		return &model.Package{
+			Name:    path.Base(typesPkg.Name()),
+			PkgPath: pkgPath,
+			Interfaces: []*model.Interface{{
+				Name:       interfaceName,
+				Methods:    modelMethodsFrom(iface),
+				TypeParams: typeParamsFrom(typeParams),
+				NeedsOwnPackage: !obj.Exported() || refersToUnexportedNamesOf(typesPkg, iface) ||
+					typeParamsReferToUnexportedNamesOf(typesPkg, typeParams),
+			}},
+		}, nil
+
+	}
+	if signature, isFunc := obj.Type().Underlying().(*types.Signature); isFunc {
+		if _, isTypeName := obj.(*types.TypeName); !isTypeName {
+			return nil, fmt.Errorf("%q is a function, not an interface or function type. "+
+				"Only function types, like type %v func(), can be mocked.", interfaceName, interfaceName)
+		}
+		if strings.Contains(types.TypeString(signature, nil), "invalid type") {
+			return nil, fmt.Errorf("Function type %q is affected by errors in package %v: "+
+				"its signature could not be type-checked:\n\t%v",
+				interfaceName, pkgPath, strings.Join(warnings, "\n\t"))
+		}
+		// modeled as an interface with the single method Call, so it can be mocked like one
+		in, variadic := inParamsFrom(signature)
+		return &model.Package{
+			Name:    path.Base(typesPkg.Name()),
+			PkgPath: pkgPath,
+			Interfaces: []*model.Interface{{
+				Name:       interfaceName,
+				Methods:    []*model.Method{{Name: model.FuncTypeMethodName, In: in, Variadic: variadic, Out: outParamsFrom(signature)}},
+				TypeParams: typeParamsFrom(typeParams),
+				FuncType:   true,
+				NeedsOwnPackage: !obj.Exported() || refersToUnexportedNamesOf(typesPkg, signature) ||
+					typeParamsReferToUnexportedNamesOf(typesPkg, typeParams),
+			}},
+		}, nil
+	}
+	return nil, errInterfaceNotFound
+}
 
-	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedTypes}, importPath)
+func generateModel(importPath string, interfaceName string, withTests bool) (*model.Package, []string, error) {
+	// NeedSyntax makes go/packages type-check the package from source, which, unlike
+	// reading export data, still yields type information when some files don't compile.
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes | packages.NeedSyntax, Tests: withTests}, importPath)
 	if e != nil {
-		return nil, e
+		return nil, nil, e
 	}
+	var warnings []string
+	reported := make(map[string]bool)
 	for _, pkg := range pkgs {
-		scope := pkg.Types.Scope()
-		obj := scope.Lookup(interfaceName)
-		if obj == nil {
+		// test variants report the errors of the package under test again
+		for _, pkgErr := range pkg.Errors {
+			if !reported[pkgErr.Error()] {
+				reported[pkgErr.Error()] = true
+				warnings = append(warnings, pkgErr.Error())
+			}
+		}
+		modelPkg, e := modelFrom(pkg.Types, pkg.PkgPath, interfaceName, warnings)
+		if e == errInterfaceNotFound {
 			continue
 		}
+		return modelPkg, warnings, e
+	}
+	return nil, warnings, errInterfaceNotFound
+}
 
-		// from here, things follow the spec in https://tip.golang.org/ref/spec
-		if iface, isIface := obj.Type().Underlying().(*types.Interface); isIface {
-			return &model.Package{
-				Name: path.Base(pkg.Types.Name()),
-				Interfaces: []*model.Interface{{
-					Name:       interfaceName,
-					Methods:    modelMethodsFrom(iface),
-					TypeParams: typeParamsFrom(obj.Type().(*types.Named).TypeParams()),
-				}},
-			}, nil
+// firstMethodWithInvalidType returns the first method whose signature refers to a type that
+// could not be type-checked, or nil if there is none.
+func firstMethodWithInvalidType(iface *types.Interface) *types.Func {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if strings.Contains(types.TypeString(iface.Method(i).Type(), nil), "invalid type") {
+			return iface.Method(i)
+		}
+	}
+	return nil
+}
 
+// firstUnexportedMethodOfOtherPackage returns the first unexported method that iface gets from an
+// interface embedded from another package than pkg, or nil if there is none.
+func firstUnexportedMethodOfOtherPackage(iface *types.Interface, pkg *types.Package) *types.Func {
+	for i := 0; i < iface.NumMethods(); i++ {
+		if method := iface.Method(i); !method.Exported() && method.Pkg() != nil && method.Pkg().Path() != pkg.Path() {
+			return method
+		}
+	}
+	return nil
+}
+
+// refersToUnexportedNamesOf reports whether typ refers to unexported types or methods of pkg, which
+// only code in pkg can name.
+func refersToUnexportedNamesOf(pkg *types.Package, typ types.Type) bool {
+	switch typedTyp := typ.(type) {
+	case *types.Pointer:
+		return refersToUnexportedNamesOf(pkg, typedTyp.Elem())
+	case *types.Array:
+		return refersToUnexportedNamesOf(pkg, typedTyp.Elem())
+	case *types.Slice:
+		return refersToUnexportedNamesOf(pkg, typedTyp.Elem())
+	case *types.Map:
+		return refersToUnexportedNamesOf(pkg, typedTyp.Key()) || refersToUnexportedNamesOf(pkg, typedTyp.Elem())
+	case *types.Chan:
+		return refersToUnexportedNamesOf(pkg, typedTyp.Elem())
+	case *types.Named:
+		if !typedTyp.Obj().Exported() && typedTyp.Obj().Pkg() == pkg {
+			return true
+		}
+		for i := 0; i < typedTyp.TypeArgs().Len(); i++ {
+			if refersToUnexportedNamesOf(pkg, typedTyp.TypeArgs().At(i)) {
+				return true
+			}
+		}
+	case *types.Interface:
+		for i := 0; i < typedTyp.NumMethods(); i++ {
+			if method := typedTyp.Method(i); (!method.Exported() && method.Pkg() == pkg) || refersToUnexportedNamesOf(pkg, method.Type()) {
+				return true
+			}
+		}
+		for i := 0; i < typedTyp.NumEmbeddeds(); i++ {
+			if refersToUnexportedNamesOf(pkg, typedTyp.EmbeddedType(i)) {
+				return true
+			}
+		}
+	case *types.Union:
+		for i := 0; i < typedTyp.Len(); i++ {
+			if refersToUnexportedNamesOf(pkg, typedTyp.Term(i).Type()) {
+				return true
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < typedTyp.NumFields(); i++ {
+			if field := typedTyp.Field(i); (!field.Exported() && field.Pkg() == pkg) || refersToUnexportedNamesOf(pkg, field.Type()) {
+				return true
+			}
+		}
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{typedTyp.Params(), typedTyp.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				if refersToUnexportedNamesOf(pkg, tuple.At(i).Type()) {
+					return true
+				}
+			}
+		}
+	case typeAlias:
+		if typedTyp.Obj().Pkg() == nil {
+			return false
+		}
+		if !typedTyp.Obj().Exported() {
+			// resolved like in modelTypeFromAlias
+			return refersToUnexportedNamesOf(pkg, typedTyp.Rhs())
+		}
+		for i := 0; i < typedTyp.TypeArgs().Len(); i++ {
+			if refersToUnexportedNamesOf(pkg, typedTyp.TypeArgs().At(i)) {
+				return true
+			}
 		}
 	}
+	return false
+}
 
-	return nil, errors.New("Did not find interface name \"" + interfaceName + "\"")
+func typeParamsReferToUnexportedNamesOf(pkg *types.Package, typeParams *types.TypeParamList) bool {
+	for i := 0; i < typeParams.Len(); i++ {
+		if refersToUnexportedNamesOf(pkg, typeParams.At(i).Constraint()) {
+			return true
+		}
+	}
+	return false
 }
 
 func modelMethodsFrom(iface *types.Interface) (modelMethods []*model.Method) {
@@ -73,10 +265,11 @@ func modelMethodFrom(method *types.Func) *model.Method {
 	signature := method.Type().(*types.Signature)
 	in, variadic := inParamsFrom(signature)
 	return &model.Method{
-		Name:     method.Name(),
-		In:       in,
-		Variadic: variadic,
-		Out:      outParamsFrom(signature),
+		Name:       method.Name(),
+		TypeParams: typeParamsFrom(signature.TypeParams()),
+		In:         in,
+		Variadic:   variadic,
+		Out:        outParamsFrom(signature),
 	}
 }
 
@@ -112,6 +305,9 @@ func outParamsFrom(signature *types.Signature) (out []*model.Parameter) {
 func modelTypeFrom(typesType types.Type) model.Type {
 	switch typedTyp := typesType.(type) {
 	case *types.Basic:
+		if typedTyp.Kind() == types.UnsafePointer {
+			return &model.NamedType{Package: "unsafe", Type: "Pointer"}
+		}
 		if !predeclared(typedTyp.Kind()) {
 			panic(fmt.Sprintf("Unexpected Basic Type %v", typedTyp.Name()))
 		}
@@ -153,22 +349,85 @@ func modelTypeFrom(typesType types.Type) model.Type {
 		if typedTyp.Obj().Pkg() == nil {
 			return model.PredeclaredType(typedTyp.Obj().Name())
 		}
-		return &model.NamedType{
+		namedType := &model.NamedType{
 			Package: typedTyp.Obj().Pkg().Path(),
 			Type:    typedTyp.Obj().Name(),
 		}
-	case *types.Interface, *types.Struct:
+		for i := 0; i < typedTyp.TypeArgs().Len(); i++ {
+			namedType.TypeArgs = append(namedType.TypeArgs, modelTypeFrom(typedTyp.TypeArgs().At(i)))
+		}
+		return namedType
+	case *types.Interface:
+		if typedTyp.Empty() {
+			return model.PredeclaredType(typedTyp.String())
+		}
+		return interfaceTypeFrom(typedTyp)
+	case *types.Union:
+		union := &model.UnionType{}
+		for i := 0; i < typedTyp.Len(); i++ {
+			union.Terms = append(union.Terms, &model.UnionTerm{
+				Tilde: typedTyp.Term(i).Tilde(),
+				Type:  modelTypeFrom(typedTyp.Term(i).Type()),
+			})
+		}
+		return union
+	case *types.Struct:
 		return model.PredeclaredType(typedTyp.String())
 	case *types.Signature:
 		in, variadic := inParamsFrom(typedTyp)
 		return &model.FuncType{In: in, Out: outParamsFrom(typedTyp), Variadic: variadic}
 	case *types.TypeParam:
 		return model.PredeclaredType(typedTyp.Obj().Name())
+	case typeAlias:
+		return modelTypeFromAlias(typedTyp)
 	default:
 		panic(fmt.Sprintf("Unknown types.Type: %v (%T)", typesType, typesType))
 	}
 }
 
+// typeAlias is implemented by *types.Alias, which go/types uses for aliases like type ID = impl.ID
+// since Go 1.23. Earlier versions resolve aliases, so the type itself can't be referenced here.
+type typeAlias interface {
+	types.Type
+	Obj() *types.TypeName
+	Rhs() types.Type
+	TypeArgs() *types.TypeList
+}
+
+// modelTypeFromAlias refers to exported aliases by name, because the types they stand for might not
+// be importable, e.g. when they are declared in an internal package. Unexported aliases are
+// resolved instead, since only the declaring package could refer to them.
+func modelTypeFromAlias(alias typeAlias) model.Type {
+	if alias.Obj().Pkg() == nil {
+		// any
+		return model.PredeclaredType(alias.Obj().Name())
+	}
+	if !alias.Obj().Exported() {
+		return modelTypeFrom(alias.Rhs())
+	}
+	namedType := &model.NamedType{
+		Package: alias.Obj().Pkg().Path(),
+		Type:    alias.Obj().Name(),
+	}
+	for i := 0; i < alias.TypeArgs().Len(); i++ {
+		namedType.TypeArgs = append(namedType.TypeArgs, modelTypeFrom(alias.TypeArgs().At(i)))
+	}
+	return namedType
+}
+
+// interfaceTypeFrom models an interface literal by its embedded types and explicitly declared
+// methods, so the types they refer to are qualified and imported like all others.
+func interfaceTypeFrom(iface *types.Interface) *model.InterfaceType {
+	result := &model.InterfaceType{Implicit: iface.IsImplicit()}
+	for i := 0; i < iface.NumEmbeddeds(); i++ {
+		result.Embedded = append(result.Embedded, modelTypeFrom(iface.EmbeddedType(i)))
+	}
+	for i := 0; i < iface.NumExplicitMethods(); i++ {
+		result.Methods = append(result.Methods, modelMethodFrom(iface.ExplicitMethod(i)))
+	}
+	return result
+}
+
 func typeParamsFrom(typeParams *types.TypeParamList) (result []*model.Parameter) {
 	for i := 0; i < typeParams.Len(); i++ {
 		result = append(result, &model.Parameter{
@@ -1,6 +1,13 @@
 package xtools_packages_test
 
 import (
+	"bytes"
+	"encoding/json"
+	"go/build"
+	"os"
+	"os/exec"
+	"path/filepath"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/petergtz/pegomock/v4/model"
@@ -8,6 +15,8 @@ import (
 	. "github.com/petergtz/pegomock/v4/modelgen/xtools_packages"
 )
 
+const testsPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/tests"
+
 var _ = Describe("Packages", func() {
 	Describe("GenerateModel", func() {
 
@@ -61,5 +70,358 @@ var _ = Describe("Packages", func() {
 			Expect(pkg.Interfaces[0].Methods[0].Name).To(Equal("SumNumbers"))
 		})
 
+		Context("using a package with type errors in unrelated files", func() {
+			const partialPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/partial"
+
+			It("generates the model and reports the errors as warnings", func() {
+				pkg, warnings, e := GenerateModelWithWarnings(partialPkg, "Healthy")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces).To(HaveLen(1))
+				Expect(pkg.Interfaces[0].Methods).To(HaveLen(1))
+				Expect(pkg.Interfaces[0].Methods[0].Name).To(Equal("Do"))
+				Expect(warnings).To(ContainElement(ContainSubstring("broken.go")))
+			})
+
+			It("fails when the errors affect the requested interface", func() {
+				_, _, e := GenerateModelWithWarnings(partialPkg, "Affected")
+				Expect(e).To(MatchError(ContainSubstring(`Interface "Affected" is affected by errors`)))
+			})
+		})
+
+		It("models unions, approximations and constraint interfaces in type parameters", func() {
+			const constraintsPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/constraints"
+			pkg, e := GenerateModel(constraintsPkg, "Aggregator")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pkg.Interfaces).To(HaveLen(1))
+
+			packageMap := map[string]string{constraintsPkg: "constraints", "time": "time"}
+			var typeParams []string
+			for _, typeParam := range pkg.Interfaces[0].TypeParams {
+				typeParams = append(typeParams, typeParam.Name+" "+typeParam.Type.String(packageMap, ""))
+			}
+			Expect(typeParams).To(Equal([]string{
+				"N constraints.Number",
+				"K interface{ ~string | time.Duration }",
+				"L constraints.Labeled",
+				"T int | string",
+				"S interface{ ~string; Page(int) constraints.Page[T] }",
+			}))
+			Expect(pkg.Imports()).To(Equal(map[string]bool{constraintsPkg: true, "time": true}))
+		})
+
+		Context("using an interface embedding interfaces from other packages", func() {
+			const embeddingPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/embedding"
+
+			It("finds the methods of all embedded interfaces, with their types qualified by their packages", func() {
+				pkg, e := GenerateModel(embeddingPkg, "ItemRepository")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces).To(HaveLen(1))
+				methods := map[string]*model.Method{}
+				for _, method := range pkg.Interfaces[0].Methods {
+					methods[method.Name] = method
+				}
+				Expect(methods).To(HaveLen(7))
+				Expect(methods).To(HaveKey("Read"))
+				Expect(methods).To(HaveKey("Close"))
+				Expect(methods).To(HaveKey("Start"))
+				Expect(methods).To(HaveKey("Stop"))
+				Expect(methods).To(HaveKey("Count"))
+				Expect(methods["Put"].In[1].Type.String(map[string]string{embeddingPkg + "/base": "base"}, "")).To(Equal("*base.Item"))
+				Expect(methods["Get"].Out[0].Type.String(map[string]string{embeddingPkg + "/base": "base"}, "")).To(Equal("*base.Item"))
+			})
+
+			It("fails for unexported methods embedded from another package", func() {
+				_, e := GenerateModel(embeddingPkg, "Broken")
+				Expect(e).To(MatchError(ContainSubstring(`Interface "Broken" embeds unexported method reset of package ` + embeddingPkg + "/base")))
+			})
+		})
+
+		Context("using interfaces declared in test files", func() {
+			It("finds interfaces declared in _test.go files of the package", func() {
+				pkg, e := GenerateModel(testsPkg, "Ticker")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces).To(HaveLen(1))
+				Expect(pkg.Interfaces[0].Methods[0].Name).To(Equal("Tick"))
+				Expect(pkg.PkgPath).To(Equal(testsPkg))
+			})
+
+			It("finds interfaces declared in the external test package", func() {
+				pkg, e := GenerateModel(testsPkg, "Alarm")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces).To(HaveLen(1))
+				Expect(pkg.Interfaces[0].Methods[0].Name).To(Equal("Ring"))
+				Expect(pkg.Name).To(Equal("tests_test"))
+				Expect(pkg.PkgPath).To(Equal(testsPkg + "_test"))
+			})
+		})
+
+		Context("using type aliases", func() {
+			const aliasesPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/aliases"
+
+			It("refers to exported aliases by name", func() {
+				pkg, e := GenerateModel(aliasesPkg, "Repository")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].Methods[0].Name).To(Equal("Find"))
+				Expect(pkg.Interfaces[0].Methods[0].In[0].Type).To(Equal(model.PredeclaredType("any")))
+				Expect(pkg.Interfaces[0].Methods[1].Name).To(Equal("Get"))
+				Expect(pkg.Interfaces[0].Methods[1].In[0].Type).To(Equal(&model.NamedType{Package: aliasesPkg, Type: "ID"}))
+				Expect(pkg.Interfaces[0].Methods[1].Out[0].Type).To(Equal(&model.NamedType{Package: aliasesPkg, Type: "IntPair"}))
+			})
+
+			It("resolves unexported aliases", func() {
+				pkg, e := GenerateModel(aliasesPkg, "Directory")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].Methods[0].In[0].Type).To(Equal(model.PredeclaredType("string")))
+				Expect(pkg.Interfaces[0].NeedsOwnPackage).To(BeFalse())
+			})
+
+			It("finds interfaces declared as aliases of instantiated generic interfaces", func() {
+				pkg, e := GenerateModel(aliasesPkg, "IntCache")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].TypeParams).To(BeEmpty())
+				Expect(pkg.Interfaces[0].Methods[0].Out[0].Type).To(Equal(model.PredeclaredType("int")))
+			})
+		})
+
+		Context("using function types", func() {
+			const funcsPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/funcs"
+
+			It("models them as interfaces with the single method Call", func() {
+				pkg, e := GenerateModel(funcsPkg, "HandlerFunc")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].FuncType).To(BeTrue())
+				Expect(pkg.Interfaces[0].Methods).To(HaveLen(1))
+				Expect(pkg.Interfaces[0].Methods[0].Name).To(Equal("Call"))
+				Expect(pkg.Interfaces[0].Methods[0].In).To(HaveLen(2))
+				Expect(pkg.Interfaces[0].Methods[0].Out).To(HaveLen(2))
+			})
+
+			It("finds their type parameters", func() {
+				pkg, e := GenerateModel(funcsPkg, "Mapper")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].TypeParams).To(ConsistOf(&model.Parameter{Name: "T", Type: model.PredeclaredType("any")}))
+			})
+
+			It("fails for functions", func() {
+				_, e := GenerateModel(funcsPkg, "Serve")
+				Expect(e).To(MatchError(ContainSubstring(`"Serve" is a function, not an interface or function type.`)))
+			})
+		})
+
+		Context("using low-level types", func() {
+			const lowlevelPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/lowlevel"
+
+			It("models unsafe.Pointer as a type of package unsafe", func() {
+				pkg, e := GenerateModel(lowlevelPkg, "Allocator")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].Methods[0].Name).To(Equal("Alloc"))
+				Expect(pkg.Interfaces[0].Methods[0].Out[0].Type).To(Equal(&model.NamedType{Package: "unsafe", Type: "Pointer"}))
+				Expect(pkg.Imports()).To(HaveKey("unsafe"))
+			})
+
+			It("models cgo types by their Go names, which only their own package can refer to", func() {
+				if !build.Default.CgoEnabled {
+					Skip("cgo is disabled")
+				}
+				pkg, e := GenerateModel(lowlevelPkg+"/native", "Geometry")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].Methods[0].In[1].Type).To(Equal(&model.NamedType{Package: lowlevelPkg + "/native", Type: "_Ctype_int"}))
+				Expect(pkg.Interfaces[0].NeedsOwnPackage).To(BeTrue())
+			})
+		})
+
+		Context("using interfaces referring to unexported names", func() {
+			const unexportedPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/unexported"
+
+			It("marks unexported interfaces and interfaces with unexported methods or types", func() {
+				for _, interfaceName := range []string{"tokenSource", "Cache", "Resetter"} {
+					pkg, e := GenerateModel(unexportedPkg, interfaceName)
+					Expect(e).NotTo(HaveOccurred())
+					Expect(pkg.Interfaces[0].NeedsOwnPackage).To(BeTrue(), interfaceName)
+				}
+			})
+
+			It("doesn't mark interfaces with only exported names", func() {
+				pkg, e := GenerateModel(unexportedPkg, "Reader")
+				Expect(e).NotTo(HaveOccurred())
+				Expect(pkg.Interfaces[0].NeedsOwnPackage).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("GenerateModelFromArchive", func() {
+		const aliasesPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/aliases"
+
+		var archivePath string
+
+		BeforeEach(func() {
+			archivePath = filepath.Join(GinkgoT().TempDir(), "aliases.a")
+			output, e := exec.Command("go", "build", "-o", archivePath, aliasesPkg).CombinedOutput()
+			Expect(e).NotTo(HaveOccurred(), string(output))
+		})
+
+		It("generates the same model as from source", func() {
+			fromSource, e := GenerateModel(aliasesPkg, "Repository")
+			Expect(e).NotTo(HaveOccurred())
+
+			fromArchive, e := GenerateModelFromArchive(archivePath, aliasesPkg, "Repository")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(fromArchive).To(Equal(fromSource))
+		})
+
+		It("fails for interfaces the archive doesn't declare", func() {
+			_, e := GenerateModelFromArchive(archivePath, aliasesPkg, "Missing")
+			Expect(e).To(MatchError("Did not find interface name \"Missing\" in " + archivePath))
+		})
+
+		It("fails for files that aren't archives", func() {
+			_, e := GenerateModelFromArchive("packages_test.go", aliasesPkg, "Repository")
+			Expect(e).To(MatchError(ContainSubstring("Could not read export data from packages_test.go")))
+		})
+	})
+
+	Describe("GenerateModelsFromSource", func() {
+		It("type-checks the interfaces as if declared in the given package, importing the packages they refer to", func() {
+			models, e := GenerateModelsFromSource("type Store interface { Open(name string) (io.ReadCloser, error) }; type store interface{ Close() }",
+				"example.com/fakes", "fakes")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(models).To(HaveLen(2))
+			Expect(models[0].Name).To(Equal("fakes"))
+			Expect(models[0].PkgPath).To(Equal("example.com/fakes"))
+			Expect(models[0].Interfaces[0].Name).To(Equal("Store"))
+			Expect(models[0].Interfaces[0].Methods[0].Out[0].Type).To(Equal(&model.NamedType{Package: "io", Type: "ReadCloser"}))
+			Expect(models[1].Interfaces[0].Name).To(Equal("store"))
+			Expect(models[1].Interfaces[0].NeedsOwnPackage).To(BeTrue())
+		})
+
+		It("fails for sources that don't type-check", func() {
+			_, e := GenerateModelsFromSource("type Store interface { Get() Item }", "example.com/fakes", "fakes")
+			Expect(e).To(MatchError(`Could not type-check "type Store interface { Get() Item }": undefined: Item`))
+		})
+
+		It("fails for sources without interfaces", func() {
+			_, e := GenerateModelsFromSource("type Item struct{}", "example.com/fakes", "fakes")
+			Expect(e).To(MatchError(`"type Item struct{}" declares no interfaces`))
+		})
+	})
+
+	Describe("FindInterfaces", func() {
+		const wildcardPkgs = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/wildcard"
+
+		BeforeEach(func() {
+			// "..." wildcards never match testdata directories, but relative patterns within them do.
+			workingDir, e := os.Getwd()
+			Expect(e).NotTo(HaveOccurred())
+			Expect(os.Chdir("testdata/wildcard")).To(Succeed())
+			DeferCleanup(os.Chdir, workingDir)
+		})
+
+		It("finds exported interfaces matching the name pattern in all packages matching the wildcard", func() {
+			refs, e := FindInterfaces([]string{"./..."}, ".*Repository")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(refs).To(HaveLen(2))
+			Expect(refs[0].ImportPath).To(Equal(wildcardPkgs + "/orders"))
+			Expect(refs[0].Name).To(Equal("OrderRepository"))
+			Expect(refs[0].Dir).To(HaveSuffix("testdata/wildcard/orders"))
+			Expect(refs[1].ImportPath).To(Equal(wildcardPkgs + "/users"))
+			Expect(refs[1].Name).To(Equal("UserRepository"))
+		})
+
+		It("finds interfaces declared in test files", func() {
+			refs, e := FindInterfaces([]string{testsPkg}, ".*")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(refs).To(Equal([]InterfaceRef{
+				{ImportPath: testsPkg, Name: "Alarm", Dir: refs[0].Dir},
+				{ImportPath: testsPkg, Name: "Clock", Dir: refs[0].Dir},
+				{ImportPath: testsPkg, Name: "Ticker", Dir: refs[0].Dir},
+			}))
+			Expect(refs[0].Dir).To(HaveSuffix("testdata/tests"))
+		})
+
+		It("matches the full interface name only", func() {
+			refs, e := FindInterfaces([]string{"./..."}, "User")
+			Expect(e).NotTo(HaveOccurred())
+			Expect(refs).To(BeEmpty())
+		})
+
+		It("fails for invalid name patterns", func() {
+			_, e := FindInterfaces([]string{"./..."}, "(")
+			Expect(e).To(MatchError(ContainSubstring("Invalid interface name pattern")))
+		})
+	})
+
+	Describe("FindDirectives", func() {
+		const directivesPkg = "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/directives"
+
+		It("finds the interfaces marked with //pegomock:generate, together with the args of the directives", func() {
+			directives, e := FindDirectives([]string{directivesPkg})
+			Expect(e).NotTo(HaveOccurred())
+
+			Expect(directives).To(HaveLen(3))
+			Expect(directives[0].Name).To(Equal("Cache"))
+			Expect(directives[0].ImportPath).To(Equal(directivesPkg))
+			Expect(directives[0].Dir).To(HaveSuffix("testdata/directives"))
+			Expect(directives[0].Args).To(Equal([]string{"--fake", "--mock-name", "FakeCache"}))
+			Expect(directives[0].Position).To(HaveSuffix("testdata/directives/directives.go:10"))
+			Expect(directives[1].Name).To(Equal("Grouped"))
+			Expect(directives[1].Args).To(BeEmpty())
+			Expect(directives[2].Name).To(Equal("Store"))
+		})
+
+		It("finds directives in test files", func() {
+			directives, e := FindDirectives([]string{testsPkg})
+			Expect(e).NotTo(HaveOccurred())
+
+			Expect(directives).To(HaveLen(1))
+			Expect(directives[0].Name).To(Equal("Alarm"))
+			Expect(directives[0].ImportPath).To(Equal(testsPkg))
+			Expect(directives[0].Args).To(Equal([]string{"--fake"}))
+		})
+
+		It("fails for directives above other declarations", func() {
+			_, e := FindDirectives([]string{directivesPkg + "/invalid"})
+
+			Expect(e).To(MatchError(MatchRegexp(`invalid\.go:3: //pegomock:generate is above NotAnInterface, which is not an interface`)))
+		})
+	})
+
+	Describe("JSON encoding of models", func() {
+		roundTrip := func(pkg *model.Package) {
+			data, e := json.Marshal(pkg)
+			Expect(e).NotTo(HaveOccurred())
+			parsed, e := model.ParseJSON(data)
+			Expect(e).NotTo(HaveOccurred())
+			reencoded, e := json.Marshal(parsed)
+			Expect(e).NotTo(HaveOccurred())
+			Expect(reencoded).To(MatchJSON(data))
+
+			var printed, printedParsed bytes.Buffer
+			pkg.Print(&printed)
+			parsed.Print(&printedParsed)
+			Expect(printedParsed.String()).To(Equal(printed.String()))
+		}
+
+		It("round-trips generic interfaces with constraints", func() {
+			pkg, e := GenerateModel("github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/constraints", "Aggregator")
+			Expect(e).NotTo(HaveOccurred())
+			roundTrip(pkg)
+		})
+
+		It("round-trips channels and funcs", func() {
+			pkg, e := GenerateModel("context", "Context")
+			Expect(e).NotTo(HaveOccurred())
+			roundTrip(pkg)
+		})
+
+		It("tells types apart by their kind", func() {
+			data, e := json.Marshal(&model.Parameter{Name: "c", Type: &model.ChanType{Dir: model.RecvDir, Type: &model.ArrayType{Len: -1, Type: &model.NamedType{Package: "io", Type: "Reader"}}}})
+			Expect(e).NotTo(HaveOccurred())
+			Expect(data).To(MatchJSON(`{"name": "c", "type": {"kind": "chan", "dir": "recv", "elem": {"kind": "slice", "elem": {"kind": "named", "package": "io", "name": "Reader"}}}}`))
+		})
+
+		It("fails for unknown kinds", func() {
+			_, e := model.ParseJSON([]byte(`{"name": "p", "interfaces": [{"name": "I", "methods": [{"name": "M", "in": [{"type": {"kind": "tuple"}}]}]}]}`))
+			Expect(e).To(MatchError(ContainSubstring(`unknown type kind "tuple"`)))
+		})
 	})
 })
@@ -0,0 +1,23 @@
+package aliases
+
+import "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/aliases/internal/impl"
+
+// ID and IntPair stand for types of an internal package, which mocks outside of this module can't
+// import.
+type ID = impl.ID
+
+type IntPair = impl.Pair[string, int]
+
+type Repository interface {
+	Get(id ID) (IntPair, error)
+	Find(query any) []ID
+}
+
+// IntCache is an alias of an instantiated generic interface.
+type IntCache = impl.Cache[int]
+
+type name = string
+
+type Directory interface {
+	Lookup(n name) ID
+}
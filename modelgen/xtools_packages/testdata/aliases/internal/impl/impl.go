@@ -0,0 +1,12 @@
+package impl
+
+type Pair[K comparable, V any] struct {
+	Key   K
+	Value V
+}
+
+type ID string
+
+type Cache[V any] interface {
+	Get(id ID) V
+}
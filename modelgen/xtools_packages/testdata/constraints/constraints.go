@@ -0,0 +1,23 @@
+package constraints
+
+import "time"
+
+type Number interface {
+	~int | ~int64 | float64
+}
+
+type Labeled interface {
+	comparable
+	Label() string
+}
+
+type Page[T any] struct{ Items []T }
+
+type Aggregator[N Number, K interface{ ~string | time.Duration }, L Labeled, T int | string, S interface {
+	~string
+	Page(size int) Page[T]
+}] interface {
+	Sum(values ...N) N
+	Group(key K, labels []L) map[K]Page[T]
+	Describe(summary S) string
+}
@@ -0,0 +1,27 @@
+package directives
+
+//pegomock:generate
+type Store interface {
+	Get(key string) string
+}
+
+// Cache caches values.
+//
+//pegomock:generate --fake --mock-name FakeCache
+type Cache interface {
+	Put(key, value string)
+}
+
+type Unmarked interface {
+	Do()
+}
+
+type (
+	//pegomock:generate
+	Grouped interface {
+		Do()
+	}
+	Other interface {
+		Do()
+	}
+)
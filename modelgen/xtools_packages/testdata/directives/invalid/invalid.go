@@ -0,0 +1,4 @@
+package invalid
+
+//pegomock:generate
+type NotAnInterface struct{}
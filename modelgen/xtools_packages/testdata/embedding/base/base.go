@@ -0,0 +1,22 @@
+package base
+
+type Item struct{ ID string }
+
+type Store[T any] interface {
+	Get(key string) (T, error)
+	Put(key string, value T) error
+}
+
+type Lifecycle interface {
+	Start() error
+	Stop() error
+}
+
+type internal interface {
+	reset()
+}
+
+type WithUnexportedMethod interface {
+	internal
+	Name() string
+}
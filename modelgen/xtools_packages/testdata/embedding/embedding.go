@@ -0,0 +1,18 @@
+package embedding
+
+import (
+	"io"
+
+	"github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/embedding/base"
+)
+
+type ItemRepository interface {
+	io.ReadCloser
+	base.Store[*base.Item]
+	base.Lifecycle
+	Count() int
+}
+
+type Broken interface {
+	base.WithUnexportedMethod
+}
@@ -0,0 +1,9 @@
+package funcs
+
+import "context"
+
+type HandlerFunc func(ctx context.Context, req string) (string, error)
+
+type Mapper[T any] func(T) T
+
+func Serve(h HandlerFunc) (string, error) { return h(context.Background(), "ping") }
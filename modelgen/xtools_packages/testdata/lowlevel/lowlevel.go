@@ -0,0 +1,8 @@
+package lowlevel
+
+import "unsafe"
+
+type Allocator interface {
+	Alloc(size uintptr) unsafe.Pointer
+	Free(p unsafe.Pointer)
+}
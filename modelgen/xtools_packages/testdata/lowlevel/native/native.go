@@ -0,0 +1,8 @@
+package native
+
+// typedef struct { int x, y; } point;
+import "C"
+
+type Geometry interface {
+	Move(p *C.point, dx C.int) C.int
+}
@@ -0,0 +1,11 @@
+package partial
+
+// Healthy is unaffected by the type errors in broken.go.
+type Healthy interface {
+	Do(s string) int
+}
+
+// Affected refers to a type that does not exist.
+type Affected interface {
+	Do(u UndefinedType)
+}
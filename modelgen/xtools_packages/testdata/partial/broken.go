@@ -0,0 +1,3 @@
+package partial
+
+var unrelated int = "this does not compile"
@@ -0,0 +1,8 @@
+package tests_test
+
+import "github.com/petergtz/pegomock/v4/modelgen/xtools_packages/testdata/tests"
+
+//pegomock:generate --fake
+type Alarm interface {
+	Ring(clock tests.Clock) error
+}
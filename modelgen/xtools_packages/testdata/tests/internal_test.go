@@ -0,0 +1,5 @@
+package tests
+
+type Ticker interface {
+	Tick(clock Clock)
+}
@@ -0,0 +1,5 @@
+package tests
+
+type Clock interface {
+	Now() int64
+}
@@ -0,0 +1,21 @@
+package unexported
+
+import "io"
+
+type token string
+
+type tokenSource interface {
+	Token() string
+}
+
+type Cache interface {
+	Get(key string) token
+}
+
+type Resetter interface {
+	reset()
+}
+
+type Reader interface {
+	Read(r io.Reader) ([]byte, error)
+}
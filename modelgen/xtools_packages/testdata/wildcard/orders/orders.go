@@ -0,0 +1,9 @@
+package orders
+
+type OrderRepository interface {
+	Save(id int) error
+}
+
+type NumberRepository interface {
+	int64 | float64
+}
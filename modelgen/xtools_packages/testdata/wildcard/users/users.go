@@ -0,0 +1,13 @@
+package users
+
+type UserRepository interface {
+	Get(id string) (string, error)
+}
+
+type UserService interface {
+	Register(name string) error
+}
+
+type unexportedRepository interface {
+	Get(id string) (string, error)
+}
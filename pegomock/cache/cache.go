@@ -0,0 +1,149 @@
+// Package cache remembers the inputs generated mocks were generated from, so that generating them
+// again can be skipped as long as these inputs don't change.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Cache stores one entry per generated file in a directory.
+type Cache struct {
+	dir string
+}
+
+// New returns a Cache storing its entries in dir.
+func New(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// DefaultDir returns the directory of the user's cache Pegomock uses, e.g. ~/.cache/pegomock.
+func DefaultDir() (string, error) {
+	userCacheDir, e := os.UserCacheDir()
+	if e != nil {
+		return "", e
+	}
+	return filepath.Join(userCacheDir, "pegomock"), nil
+}
+
+// Generate calls generate to generate outputFilePath from the package with importPath, unless the
+// package's inputs and settings are the same as the last time and outputFilePath wasn't changed
+// since. The inputs are the Go files of the package, including its test files, and of all
+// packages it depends on outside of the standard library and the module cache, and the versions of
+// the modules it depends on. Packages only the test files depend on aren't inputs: the output is
+// usually one of these test files, so its own imports would otherwise change the inputs.
+// settings are whatever else affects the output, e.g. the command line. Generate returns whether
+// generate was called. Failing to read or write the cache only makes it call generate.
+func (c *Cache) Generate(outputFilePath string, importPath string, settings []string, generate func()) bool {
+	absOutputFilePath, e := filepath.Abs(outputFilePath)
+	if e != nil {
+		generate()
+		return true
+	}
+	entryPath := filepath.Join(c.dir, hashOf(absOutputFilePath))
+	key, e := inputsKey(importPath, absOutputFilePath, settings)
+	if e != nil {
+		generate()
+		return true
+	}
+	if entry, e := os.ReadFile(entryPath); e == nil && string(entry) == key+" "+outputHashOf(outputFilePath) {
+		return false
+	}
+	generate()
+	if e := os.MkdirAll(c.dir, 0755); e == nil {
+		_ = os.WriteFile(entryPath, []byte(key+" "+outputHashOf(outputFilePath)), 0644)
+	}
+	return true
+}
+
+// inputsKey hashes the inputs of the package with importPath, listed by "go list", together with
+// the Pegomock executable, so that upgrading Pegomock regenerates the mocks. The output file is
+// often one of the package's test files, but of course isn't an input.
+func inputsKey(importPath string, absOutputFilePath string, settings []string) (string, error) {
+	executableStamp, e := executableStamp()
+	if e != nil {
+		return "", e
+	}
+	output, e := exec.Command("go", "list", "-deps", "-f",
+		"{{if not .Standard}}{{.ImportPath}}\t{{with .Module}}{{.Path}}@{{with .Replace}}{{.Version}}{{else}}{{.Version}}{{end}}{{end}}\t{{.Dir}}"+
+			"{{range .GoFiles}}\t{{.}}{{end}}{{range .CgoFiles}}\t{{.}}{{end}}"+
+			"{{if not .DepOnly}}{{range .TestGoFiles}}\t{{.}}{{end}}{{range .XTestGoFiles}}\t{{.}}{{end}}{{end}}\n{{end}}",
+		importPath).Output()
+	if e != nil {
+		var exitErr *exec.ExitError
+		if errors.As(e, &exitErr) {
+			return "", fmt.Errorf("go list %v failed: %v", importPath, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", e
+	}
+	inputs := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+		if fields[1] != "" && !strings.HasSuffix(fields[1], "@") {
+			// modules with a version are in the module cache and don't change
+			inputs[fields[1]] = true
+			continue
+		}
+		for _, goFile := range fields[3:] {
+			if filepath.Join(fields[2], goFile) != absOutputFilePath {
+				inputs[filepath.Join(fields[2], goFile)] = true
+			}
+		}
+	}
+	sortedInputs := make([]string, 0, len(inputs))
+	for input := range inputs {
+		sortedInputs = append(sortedInputs, input)
+	}
+	sort.Strings(sortedInputs)
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%v\n%q\n%v\n", absOutputFilePath, settings, executableStamp)
+	for _, input := range sortedInputs {
+		fmt.Fprintln(hash, input)
+		if !filepath.IsAbs(input) {
+			continue
+		}
+		content, e := os.ReadFile(input)
+		if e != nil {
+			return "", e
+		}
+		fmt.Fprintln(hash, len(content))
+		hash.Write(content)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// executableStamp identifies the build of the running Pegomock binary without reading all of it.
+func executableStamp() (string, error) {
+	executable, e := os.Executable()
+	if e != nil {
+		return "", e
+	}
+	info, e := os.Stat(executable)
+	if e != nil {
+		return "", e
+	}
+	return fmt.Sprint(executable, info.Size(), info.ModTime().UnixNano()), nil
+}
+
+func outputHashOf(path string) string {
+	content, e := os.ReadFile(path)
+	if e != nil {
+		return "missing"
+	}
+	return hashOf(string(content))
+}
+
+func hashOf(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
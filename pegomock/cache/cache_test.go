@@ -0,0 +1,100 @@
+package cache_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/pegomock/cache"
+)
+
+func TestCache(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Cache Suite")
+}
+
+var _ = Describe("Generating with the cache", func() {
+	var (
+		moduleDir  string
+		originalWd string
+		c          *cache.Cache
+		generated  int
+	)
+
+	writeFile := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(moduleDir, path)), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(moduleDir, path), []byte(content), 0644)).To(Succeed())
+	}
+
+	generate := func() {
+		generated++
+		writeFile("store/mock_store_test.go", "package store_test\n")
+	}
+
+	BeforeEach(func() {
+		var e error
+		originalWd, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		moduleDir = GinkgoT().TempDir()
+		writeFile("go.mod", "module example.com/cached\n\ngo 1.18\n")
+		writeFile("store/store.go", "package store\n\nimport \"example.com/cached/kv\"\n\ntype Store interface {\n\tkv.Getter\n}\n")
+		writeFile("kv/kv.go", "package kv\n\ntype Getter interface {\n\tGet(key string) string\n}\n")
+		Expect(os.Chdir(moduleDir)).To(Succeed())
+		// the temporary module is not part of any workspace the tests might run in
+		GinkgoT().Setenv("GOWORK", "off")
+		c = cache.New(GinkgoT().TempDir())
+		generated = 0
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(originalWd)).To(Succeed())
+	})
+
+	It("generates only once as long as nothing changes", func() {
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)).To(BeTrue())
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)).To(BeFalse())
+
+		Expect(generated).To(Equal(1))
+	})
+
+	It("generates again when the package changed", func() {
+		c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)
+		writeFile("store/store.go", "package store\n\ntype Store interface {\n\tGet(key string) (string, error)\n}\n")
+
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)).To(BeTrue())
+	})
+
+	It("generates again when a package it depends on changed", func() {
+		c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)
+		writeFile("kv/kv.go", "package kv\n\ntype Getter interface {\n\tGet(key string) (string, bool)\n}\n")
+
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)).To(BeTrue())
+	})
+
+	It("generates again when the settings changed", func() {
+		c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)
+
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate", "--fake"}, generate)).To(BeTrue())
+	})
+
+	It("generates again when the output was changed or removed", func() {
+		c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)
+		writeFile("store/mock_store_test.go", "package store_test\n\n// edited\n")
+
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)).To(BeTrue())
+
+		Expect(os.Remove(filepath.Join(moduleDir, "store", "mock_store_test.go"))).To(Succeed())
+
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)).To(BeTrue())
+	})
+
+	It("doesn't remember failed generations", func() {
+		Expect(func() {
+			c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, func() { panic("failed") })
+		}).To(Panic())
+
+		Expect(c.Generate("store/mock_store_test.go", "example.com/cached/store", []string{"generate"}, generate)).To(BeTrue())
+	})
+})
@@ -0,0 +1,251 @@
+package compat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/pegomock/snapshot"
+)
+
+// Change is a difference between two versions of an interface.
+type Change struct {
+	Method      string // empty for changes of the interface itself, like its type parameters
+	Description string
+	// MockBreaking is set if generated mocks must be regenerated, or code using them has to be
+	// changed after regenerating them, e.g. for added, removed or changed methods.
+	MockBreaking bool
+}
+
+// CallSite is a line of code that uses the generated helpers of a method that changed.
+type CallSite struct {
+	File string
+	Line int
+	Code string
+}
+
+// Report lists the changes between two versions of an interface and the code they affect.
+type Report struct {
+	Interface      string
+	Changes        []Change
+	GeneratedFiles []string   // mocks of the interface that must be regenerated
+	CallSites      []CallSite // verifications of changed or removed methods
+}
+
+// Compare classifies the changes from old to current as mock-breaking or benign.
+func Compare(old, current *snapshot.Interface) []Change {
+	var changes []Change
+	if old.ImportPath+"."+old.Name != current.ImportPath+"."+current.Name {
+		changes = append(changes, Change{
+			Description:  fmt.Sprintf("interface changed: %v.%v -> %v.%v", old.ImportPath, old.Name, current.ImportPath, current.Name),
+			MockBreaking: true,
+		})
+	}
+	if strings.Join(old.TypeParams, ", ") != strings.Join(current.TypeParams, ", ") {
+		changes = append(changes, Change{
+			Description:  fmt.Sprintf("changed type parameters: [%v] -> [%v]", strings.Join(old.TypeParams, ", "), strings.Join(current.TypeParams, ", ")),
+			MockBreaking: true,
+		})
+	}
+	oldMethods, currentMethods := methodsByName(old), methodsByName(current)
+	for _, name := range sortedNames(oldMethods) {
+		oldMethod := oldMethods[name]
+		currentMethod, exists := currentMethods[name]
+		switch {
+		case !exists:
+			changes = append(changes, Change{Method: name, Description: "- removed method: " + name + signature(oldMethod, false), MockBreaking: true})
+		case signature(oldMethod, false) != signature(currentMethod, false):
+			changes = append(changes, Change{
+				Method:       name,
+				Description:  fmt.Sprintf("~ changed method: %v%v -> %v%v", name, signature(oldMethod, false), name, signature(currentMethod, false)),
+				MockBreaking: true,
+			})
+		case signature(oldMethod, true) != signature(currentMethod, true):
+			changes = append(changes, Change{
+				Method:      name,
+				Description: fmt.Sprintf("renamed parameters: %v%v -> %v%v", name, signature(oldMethod, true), name, signature(currentMethod, true)),
+			})
+		}
+	}
+	for _, name := range sortedNames(currentMethods) {
+		if _, exists := oldMethods[name]; !exists {
+			changes = append(changes, Change{Method: name, Description: "+ added method: " + name + signature(currentMethods[name], false), MockBreaking: true})
+		}
+	}
+	return changes
+}
+
+// Analyze compares old with current and searches the Go files in dir and its sub-directories for
+// the generated mocks of the interface and for verifications of the methods that changed. Call sites
+// are found by a quick search for the names of the generated helpers, so results are approximate.
+func Analyze(old, current *snapshot.Interface, dir string) (*Report, error) {
+	report := &Report{Interface: old.ImportPath + "." + old.Name, Changes: Compare(old, current)}
+	var changedMethods []string
+	mockBreaking := false
+	for _, change := range report.Changes {
+		if change.MockBreaking {
+			mockBreaking = true
+			// callers of added methods don't exist yet
+			if change.Method != "" && !strings.HasPrefix(change.Description, "+") {
+				changedMethods = append(changedMethods, change.Method)
+			}
+		}
+	}
+	if !mockBreaking {
+		return report, nil
+	}
+	callSitePattern := callSitePatternFor(old.Name, changedMethods)
+	// files generated with --all --output list several interfaces
+	mockHeader := regexp.MustCompile(fmt.Sprintf(`^// Source: %v \(interfaces: (.*, )?%v(, .*)?\)$`, regexp.QuoteMeta(old.ImportPath), regexp.QuoteMeta(old.Name)))
+	e := filepath.Walk(dir, func(path string, info os.FileInfo, e error) error {
+		if e != nil {
+			return e
+		}
+		if info.IsDir() {
+			if name := info.Name(); path != dir && (name == "vendor" || strings.HasPrefix(name, ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		file, e := os.Open(path)
+		if e != nil {
+			return e
+		}
+		defer file.Close()
+		isGenerated, callSites, e := scan(file, path, mockHeader, callSitePattern)
+		if e != nil {
+			return e
+		}
+		if isGenerated {
+			report.GeneratedFiles = append(report.GeneratedFiles, path)
+		} else {
+			report.CallSites = append(report.CallSites, callSites...)
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return report, nil
+}
+
+// callSitePatternFor matches verifications like mock.VerifyWasCalledOnce().Method( and uses of the
+// generated Mock<Interface>_<Method>_OngoingVerification types.
+func callSitePatternFor(interfaceName string, methods []string) *regexp.Regexp {
+	if len(methods) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(methods))
+	for i, method := range methods {
+		quoted[i] = regexp.QuoteMeta(method)
+	}
+	names := strings.Join(quoted, "|")
+	return regexp.MustCompile(fmt.Sprintf(`VerifyWasCalled\w*\(.*\)\.(%v)\(|Mock%v_(%v)_OngoingVerification`, names, regexp.QuoteMeta(interfaceName), names))
+}
+
+func scan(reader io.Reader, path string, mockHeader *regexp.Regexp, callSitePattern *regexp.Regexp) (isGenerated bool, callSites []CallSite, e error) {
+	scanner := bufio.NewScanner(reader)
+	// the header of generated mocks precedes the package clause, possibly after a license header
+	inHeader := true
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if strings.HasPrefix(text, "package ") {
+			inHeader = false
+		}
+		if inHeader && mockHeader.MatchString(text) {
+			return true, nil, nil
+		}
+		if callSitePattern != nil && callSitePattern.MatchString(text) {
+			callSites = append(callSites, CallSite{File: path, Line: line, Code: strings.TrimSpace(text)})
+		}
+	}
+	return false, callSites, scanner.Err()
+}
+
+// Write renders the report in a human-readable form.
+func (report *Report) Write(w io.Writer) {
+	fmt.Fprintf(w, "Interface %v\n", report.Interface)
+	if len(report.Changes) == 0 {
+		fmt.Fprintln(w, "\nNo changes.")
+		return
+	}
+	writeSection(w, "Mock-breaking changes", report.Changes, true)
+	writeSection(w, "Benign changes", report.Changes, false)
+	if len(report.GeneratedFiles) > 0 {
+		fmt.Fprintln(w, "\nGenerated mocks to regenerate:")
+		for _, file := range report.GeneratedFiles {
+			fmt.Fprintf(w, "\t%v\n", file)
+		}
+	}
+	if len(report.CallSites) > 0 {
+		fmt.Fprintln(w, "\nAffected verification call sites:")
+		for _, callSite := range report.CallSites {
+			fmt.Fprintf(w, "\t%v:%v: %v\n", callSite.File, callSite.Line, callSite.Code)
+		}
+	}
+}
+
+func writeSection(w io.Writer, title string, changes []Change, mockBreaking bool) {
+	var descriptions []string
+	for _, change := range changes {
+		if change.MockBreaking == mockBreaking {
+			descriptions = append(descriptions, change.Description)
+		}
+	}
+	if len(descriptions) > 0 {
+		fmt.Fprintf(w, "\n%v:\n\t%v\n", title, strings.Join(descriptions, "\n\t"))
+	}
+}
+
+func methodsByName(iface *snapshot.Interface) map[string]snapshot.Method {
+	methods := make(map[string]snapshot.Method, len(iface.Methods))
+	for _, method := range iface.Methods {
+		methods[method.Name] = method
+	}
+	return methods
+}
+
+func sortedNames(methods map[string]snapshot.Method) []string {
+	names := make([]string, 0, len(methods))
+	for name := range methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func signature(method snapshot.Method, withNames bool) string {
+	params := func(params []snapshot.Parameter, variadic *snapshot.Parameter) string {
+		rendered := make([]string, 0, len(params)+1)
+		for _, param := range params {
+			rendered = append(rendered, parameterString(param, withNames, ""))
+		}
+		if variadic != nil {
+			rendered = append(rendered, parameterString(*variadic, withNames, "..."))
+		}
+		return strings.Join(rendered, ", ")
+	}
+	result := "(" + params(method.In, method.Variadic) + ")"
+	switch {
+	case len(method.Out) == 1 && !(withNames && method.Out[0].Name != ""):
+		result += " " + method.Out[0].Type
+	case len(method.Out) > 0:
+		result += " (" + params(method.Out, nil) + ")"
+	}
+	return result
+}
+
+func parameterString(param snapshot.Parameter, withName bool, prefix string) string {
+	if withName && param.Name != "" {
+		return param.Name + " " + prefix + param.Type
+	}
+	return prefix + param.Type
+}
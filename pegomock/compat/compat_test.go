@@ -0,0 +1,122 @@
+package compat_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/pegomock/compat"
+	"github.com/petergtz/pegomock/v4/pegomock/snapshot"
+)
+
+func TestCompat(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Compat Suite")
+}
+
+var _ = Describe("Compatibility report", func() {
+	var old, current *snapshot.Interface
+
+	BeforeEach(func() {
+		newStore := func() *snapshot.Interface {
+			return &snapshot.Interface{
+				ImportPath: "example.com/store",
+				Name:       "Store",
+				Methods: []snapshot.Method{
+					{Name: "Get", In: []snapshot.Parameter{{Name: "key", Type: "string"}}, Out: []snapshot.Parameter{{Type: "string"}, {Type: "error"}}},
+					{Name: "Put", In: []snapshot.Parameter{{Name: "key", Type: "string"}, {Name: "value", Type: "string"}}, Out: []snapshot.Parameter{{Type: "error"}}},
+					{Name: "Close"},
+				},
+			}
+		}
+		old, current = newStore(), newStore()
+	})
+
+	It("classifies changes as mock-breaking or benign", func() {
+		current.Methods[0].In[0].Name = "id"
+		current.Methods[1].Out[0].Type = "bool"
+		current.Methods[2] = snapshot.Method{Name: "Delete", In: []snapshot.Parameter{{Type: "string"}}}
+
+		Expect(compat.Compare(old, current)).To(Equal([]compat.Change{
+			{Method: "Close", Description: "- removed method: Close()", MockBreaking: true},
+			{Method: "Get", Description: "renamed parameters: Get(key string) (string, error) -> Get(id string) (string, error)"},
+			{Method: "Put", Description: "~ changed method: Put(string, string) error -> Put(string, string) bool", MockBreaking: true},
+			{Method: "Delete", Description: "+ added method: Delete(string)", MockBreaking: true},
+		}))
+	})
+
+	It("reports no changes for identical interfaces", func() {
+		Expect(compat.Compare(old, current)).To(BeEmpty())
+	})
+
+	Context("with generated mocks and tests using them", func() {
+		var dir string
+
+		BeforeEach(func() {
+			dir = GinkgoT().TempDir()
+			Expect(os.MkdirAll(filepath.Join(dir, "service"), 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "service", "mock_store_test.go"), []byte(
+				"// Code generated by pegomock. DO NOT EDIT.\n"+
+					"// Source: example.com/store (interfaces: Store)\n\n"+
+					"package service_test\n"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, "service", "service_test.go"), []byte(
+				"package service_test\n\n"+
+					"func TestService(t *testing.T) {\n"+
+					"\tstore.VerifyWasCalled(Times(2)).Put(Eq(\"a\"), Any[string]())\n"+
+					"\tstore.VerifyWasCalledOnce().Get(Eq(\"a\"))\n"+
+					"\tvar verification *MockStore_Put_OngoingVerification\n"+
+					"}\n"), 0644)).To(Succeed())
+		})
+
+		It("lists the generated mocks and the verifications of changed methods", func() {
+			current.Methods[1].Out[0].Type = "bool"
+
+			report, e := compat.Analyze(old, current, dir)
+			Expect(e).NotTo(HaveOccurred())
+
+			Expect(report.GeneratedFiles).To(Equal([]string{filepath.Join(dir, "service", "mock_store_test.go")}))
+			Expect(report.CallSites).To(Equal([]compat.CallSite{
+				{File: filepath.Join(dir, "service", "service_test.go"), Line: 4, Code: `store.VerifyWasCalled(Times(2)).Put(Eq("a"), Any[string]())`},
+				{File: filepath.Join(dir, "service", "service_test.go"), Line: 6, Code: `var verification *MockStore_Put_OngoingVerification`},
+			}))
+
+			var buf bytes.Buffer
+			report.Write(&buf)
+			Expect(buf.String()).To(Equal("Interface example.com/store.Store\n\n" +
+				"Mock-breaking changes:\n" +
+				"\t~ changed method: Put(string, string) error -> Put(string, string) bool\n\n" +
+				"Generated mocks to regenerate:\n" +
+				"\t" + filepath.Join(dir, "service", "mock_store_test.go") + "\n\n" +
+				"Affected verification call sites:\n" +
+				"\t" + filepath.Join(dir, "service", "service_test.go") + ":4: store.VerifyWasCalled(Times(2)).Put(Eq(\"a\"), Any[string]())\n" +
+				"\t" + filepath.Join(dir, "service", "service_test.go") + ":6: var verification *MockStore_Put_OngoingVerification\n"))
+		})
+
+		It("finds generated mocks starting with a license header", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "service", "mock_store_test.go"), []byte(
+				"// Copyright 2026 Example Corp.\n\n"+
+					"// Code generated by pegomock. DO NOT EDIT.\n"+
+					"// Source: example.com/store (interfaces: Store)\n\n"+
+					"package service_test\n"), 0644)).To(Succeed())
+			current.Methods[1].Out[0].Type = "bool"
+
+			report, e := compat.Analyze(old, current, dir)
+			Expect(e).NotTo(HaveOccurred())
+
+			Expect(report.GeneratedFiles).To(Equal([]string{filepath.Join(dir, "service", "mock_store_test.go")}))
+		})
+
+		It("lists nothing for benign changes", func() {
+			current.Methods[0].In[0].Name = "id"
+
+			report, e := compat.Analyze(old, current, dir)
+			Expect(e).NotTo(HaveOccurred())
+
+			Expect(report.GeneratedFiles).To(BeEmpty())
+			Expect(report.CallSites).To(BeEmpty())
+		})
+	})
+})
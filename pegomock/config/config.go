@@ -0,0 +1,184 @@
+// Package config reads project config files declaring the mocks of a project, so that
+// "pegomock generate" without args can generate all of them the same way every time.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the config file "pegomock generate" looks for in the working directory.
+const FileName = ".pegomock.yaml"
+
+// Config is the content of a config file:
+//
+//	defaults:
+//	  name-template: "{{.Interface}}Fake"
+//	targets:
+//	  - dir: service
+//	    interfaces: [Store, Cache]
+//	  - package: ./...
+//	    match: ".*Repository"
+//	    flags:
+//	      output-dir: fakes
+type Config struct {
+	// Defaults are flags of "pegomock generate" applied to all targets, without leading "--".
+	Defaults map[string]interface{} `yaml:"defaults"`
+	Targets  []Target               `yaml:"targets"`
+}
+
+// Target declares mocks to generate. Exactly one of Interfaces, Match and All must be set.
+type Target struct {
+	// Dir is the directory generate runs in, relative to the config file. Relative paths in flags
+	// are relative to it.
+	Dir string `yaml:"dir"`
+	// Package is the package of Interfaces or All, or the package patterns of Match. It defaults to
+	// the package in Dir for Interfaces, and to ./... for Match.
+	Package string `yaml:"package"`
+	// Interfaces are the names of the interfaces to mock, each into its own file.
+	Interfaces []string `yaml:"interfaces"`
+	// Match mocks all exported interfaces of Package matching this regex, like --interfaces.
+	Match string `yaml:"match"`
+	// All mocks all exported interfaces of Package, like --all.
+	All bool `yaml:"all"`
+	// Flags are flags of "pegomock generate" like Defaults, overriding them. false turns off a
+	// flag turned on by Defaults.
+	Flags map[string]interface{} `yaml:"flags"`
+}
+
+// Command is a "pegomock generate" command line, without "pegomock generate" itself.
+type Command struct {
+	Dir  string
+	Args []string
+}
+
+// Load reads the config file at path.
+func Load(path string) (*Config, error) {
+	content, e := os.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	var config Config
+	if e := decoder.Decode(&config); e != nil {
+		return nil, fmt.Errorf("Could not parse %v: %v", path, e)
+	}
+	if len(config.Targets) == 0 {
+		return nil, fmt.Errorf("%v declares no targets", path)
+	}
+	for i, target := range config.Targets {
+		if e := target.validate(); e != nil {
+			return nil, fmt.Errorf("Invalid target %v in %v: %v", i+1, path, e)
+		}
+	}
+	return &config, nil
+}
+
+func (target Target) validate() error {
+	kinds := 0
+	if len(target.Interfaces) > 0 {
+		kinds++
+	}
+	if target.Match != "" {
+		kinds++
+	}
+	if target.All {
+		kinds++
+	}
+	if kinds != 1 {
+		return errors.New("exactly one of interfaces, match and all must be set")
+	}
+	if target.All && target.Package == "" {
+		return errors.New("all requires a package")
+	}
+	for flag := range target.Flags {
+		if flag == "all" || flag == "interfaces" || flag == "config" {
+			return fmt.Errorf("flag %v cannot be set in flags", flag)
+		}
+	}
+	return nil
+}
+
+// Commands returns the command lines generating the mocks of all targets, in the order of the
+// targets. dir is the directory of the config file.
+func (config *Config) Commands(dir string) ([]Command, error) {
+	var commands []Command
+	for i, target := range config.Targets {
+		flags, e := flagArgs(config.Defaults, target.Flags)
+		if e != nil {
+			return nil, fmt.Errorf("Invalid flags of target %v: %v", i+1, e)
+		}
+		targetDir := filepath.Join(dir, target.Dir)
+		switch {
+		case target.All:
+			commands = append(commands, Command{Dir: targetDir, Args: append(append([]string{"--all"}, flags...), target.Package)})
+		case target.Match != "":
+			pkg := target.Package
+			if pkg == "" {
+				pkg = "./..."
+			}
+			commands = append(commands, Command{Dir: targetDir, Args: append(append([]string{"--interfaces=" + target.Match}, flags...), pkg)})
+		default:
+			for _, interfaceName := range target.Interfaces {
+				args := append([]string{}, flags...)
+				if target.Package != "" {
+					args = append(args, target.Package)
+				}
+				commands = append(commands, Command{Dir: targetDir, Args: append(args, interfaceName)})
+			}
+		}
+	}
+	return commands, nil
+}
+
+// flagArgs turns defaults, overridden by flags, into command line flags, sorted by name.
+func flagArgs(defaults map[string]interface{}, flags map[string]interface{}) ([]string, error) {
+	merged := make(map[string]interface{}, len(defaults)+len(flags))
+	for name, value := range defaults {
+		merged[name] = value
+	}
+	for name, value := range flags {
+		merged[name] = value
+	}
+	names := make([]string, 0, len(merged))
+	for name := range merged {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var args []string
+	for _, name := range names {
+		switch value := merged[name].(type) {
+		case bool:
+			if value {
+				args = append(args, "--"+name)
+			} else {
+				args = append(args, "--no-"+name)
+			}
+		case string, int, float64:
+			args = append(args, fmt.Sprintf("--%v=%v", name, value))
+		case []interface{}:
+			for _, element := range value {
+				args = append(args, fmt.Sprintf("--%v=%v", name, element))
+			}
+		case map[string]interface{}:
+			keys := make([]string, 0, len(value))
+			for key := range value {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				args = append(args, fmt.Sprintf("--%v=%v=%v", name, key, value[key]))
+			}
+		default:
+			return nil, fmt.Errorf("unsupported value %v for flag %v", value, name)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/pegomock/config"
+)
+
+func TestConfig(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Config Suite")
+}
+
+var _ = Describe("Config files", func() {
+	var dir string
+
+	load := func(content string) (*config.Config, error) {
+		Expect(os.WriteFile(filepath.Join(dir, config.FileName), []byte(content), 0644)).To(Succeed())
+		return config.Load(filepath.Join(dir, config.FileName))
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("turns each target into generate command lines, with the defaults overridden by the target's flags", func() {
+		cfg, e := load(`
+defaults:
+  name-template: "{{.Interface}}Fake"
+  assert-interface: true
+targets:
+  - dir: service
+    interfaces: [Store, Cache]
+    flags:
+      import-alias: {html/template: htmltemplate, text/template: texttemplate}
+  - package: ./...
+    match: ".*Repository"
+    flags:
+      output-dir: fakes
+      assert-interface: false
+      build-tags: [linux]
+  - package: example.com/store
+    all: true
+`)
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(cfg.Commands(dir)).To(Equal([]config.Command{
+			{Dir: filepath.Join(dir, "service"), Args: []string{"--assert-interface",
+				"--import-alias=html/template=htmltemplate", "--import-alias=text/template=texttemplate",
+				"--name-template={{.Interface}}Fake", "Store"}},
+			{Dir: filepath.Join(dir, "service"), Args: []string{"--assert-interface",
+				"--import-alias=html/template=htmltemplate", "--import-alias=text/template=texttemplate",
+				"--name-template={{.Interface}}Fake", "Cache"}},
+			{Dir: dir, Args: []string{"--interfaces=.*Repository", "--no-assert-interface", "--build-tags=linux",
+				"--name-template={{.Interface}}Fake", "--output-dir=fakes", "./..."}},
+			{Dir: dir, Args: []string{"--all", "--assert-interface", "--name-template={{.Interface}}Fake", "example.com/store"}},
+		}))
+	})
+
+	It("reports unknown fields", func() {
+		_, e := load("targets:\n  - interface: Store\n")
+
+		Expect(e).To(MatchError(ContainSubstring("field interface not found")))
+	})
+
+	It("reports targets that don't declare exactly one kind of mocks", func() {
+		_, e := load("targets:\n  - interfaces: [Store]\n    match: Store\n")
+
+		Expect(e).To(MatchError(ContainSubstring("Invalid target 1 in")))
+		Expect(e).To(MatchError(ContainSubstring("exactly one of interfaces, match and all must be set")))
+	})
+
+	It("reports config files without targets", func() {
+		_, e := load("defaults:\n  fake: true\n")
+
+		Expect(e).To(MatchError(ContainSubstring("declares no targets")))
+	})
+})
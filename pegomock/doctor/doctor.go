@@ -0,0 +1,402 @@
+// Package doctor checks whether generated mocks still match the interfaces they were generated
+// from, e.g. after large refactorings, and suggests how to regenerate the ones that don't.
+package doctor
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Mock is a generated mock type together with the interface it was generated from.
+type Mock struct {
+	File          string
+	PackageName   string // of the mock
+	TypeName      string
+	ImportPath    string
+	InterfaceName string
+	// FileInterfaces are the names of all interfaces mocked in File, e.g. several with --all.
+	FileInterfaces []string
+}
+
+// Diagnosis is the outcome of examining a Mock.
+type Diagnosis struct {
+	Mock
+	// Problems are the methods the mock is missing, has in addition to the interface or has with
+	// other signatures, and the type errors in its file.
+	Problems []string
+}
+
+// Healthy returns whether the mock matches its interface.
+func (diagnosis Diagnosis) Healthy() bool { return len(diagnosis.Problems) == 0 }
+
+// RegenerateCommand returns a command line regenerating the mock's file, to be run in dir.
+func (diagnosis Diagnosis) RegenerateCommand(dir string) string {
+	mockDir := filepath.Dir(diagnosis.File)
+	var command []string
+	if relativeDir, e := filepath.Rel(dir, mockDir); e != nil {
+		command = append(command, "cd", mockDir, "&&")
+	} else if relativeDir != "." {
+		command = append(command, "cd", relativeDir, "&&")
+	}
+	command = append(command, "pegomock", "generate")
+	if len(diagnosis.FileInterfaces) > 1 {
+		command = append(command, "--all")
+	}
+	if diagnosis.PackageName != strings.Replace(filepath.Base(mockDir), "-", "_", -1)+"_test" {
+		command = append(command, "--package", diagnosis.PackageName)
+	}
+	if len(diagnosis.FileInterfaces) <= 1 && diagnosis.TypeName != "Mock"+diagnosis.InterfaceName {
+		command = append(command, "--mock-name", diagnosis.TypeName)
+	}
+	command = append(command, "-o", filepath.Base(diagnosis.File), diagnosis.ImportPath)
+	if len(diagnosis.FileInterfaces) <= 1 {
+		command = append(command, diagnosis.InterfaceName)
+	}
+	return strings.Join(command, " ")
+}
+
+// generatedMethods are the methods of generated mocks that don't implement interface methods.
+var generatedMethods = map[string]bool{
+	"SetFailHandler": true, "FailHandler": true, "VerifyWasCalledOnce": true, "VerifyWasCalled": true,
+	"VerifyWasCalledInOrder": true, "VerifyWasCalledEventually": true, "Func": true, "EXPECT": true,
+}
+
+var sourceLine = regexp.MustCompile(`(?m)^// Source: (\S+) \(interfaces: (.*)\)$`)
+
+// Examine type-checks the generated mocks in the packages matching patterns, e.g. "./...",
+// including their test files, and compares their methods with the current methods of their
+// interfaces. Mocks are found through their constructors' freshness checks, or by their default
+// names. Diagnoses are sorted by file and mock.
+func Examine(patterns []string) ([]Diagnosis, error) {
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+		packages.NeedImports | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo, Tests: true}, patterns...)
+	if e != nil {
+		return nil, e
+	}
+	examinedFiles := make(map[string]bool)
+	var diagnoses []Diagnosis
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			// mocks that don't compile anymore are list errors too, so only packages without files fail
+			if pkgErr.Kind == packages.ListError && len(pkg.Syntax) == 0 {
+				return nil, fmt.Errorf("Could not load %v: %v", pkg.PkgPath, pkgErr)
+			}
+		}
+		for i, file := range pkg.Syntax {
+			path := pkg.CompiledGoFiles[i]
+			if examinedFiles[path] {
+				// files of a package are part of its test variant too
+				continue
+			}
+			examinedFiles[path] = true
+			mocks, e := mocksIn(path, file)
+			if e != nil {
+				return nil, e
+			}
+			for _, mock := range mocks {
+				diagnosis := Diagnosis{Mock: mock, Problems: typeErrorsOf(pkg, file, mock, mocks)}
+				diagnosis.Problems = append(diagnosis.Problems, methodProblemsOf(pkg, mock)...)
+				diagnoses = append(diagnoses, diagnosis)
+			}
+		}
+	}
+	sort.SliceStable(diagnoses, func(i, j int) bool {
+		if diagnoses[i].File != diagnoses[j].File {
+			return diagnoses[i].File < diagnoses[j].File
+		}
+		return diagnoses[i].TypeName < diagnoses[j].TypeName
+	})
+	return diagnoses, nil
+}
+
+// mocksIn returns the mocks in the file at path, if it was generated by Pegomock.
+func mocksIn(path string, file *ast.File) ([]Mock, error) {
+	content, e := os.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	if !strings.Contains(string(content), "// Code generated by pegomock. DO NOT EDIT.") {
+		return nil, nil
+	}
+	source := sourceLine.FindStringSubmatch(string(content))
+	if source == nil {
+		return nil, nil
+	}
+	interfaceNames := strings.Split(source[2], ", ")
+	interfacesByTypeName := make(map[string]string)
+	declaredTypes := make(map[string]bool)
+	for _, decl := range file.Decls {
+		if typeName, interfaceName, isStamped := stampedConstructor(decl); isStamped {
+			interfacesByTypeName[typeName] = interfaceName
+		}
+		if genDecl, isGenDecl := decl.(*ast.GenDecl); isGenDecl && genDecl.Tok == token.TYPE {
+			for _, spec := range genDecl.Specs {
+				declaredTypes[spec.(*ast.TypeSpec).Name.Name] = true
+			}
+		}
+	}
+	// mocks without freshness checks, e.g. generated with --api-level v3, have their default names
+	for _, interfaceName := range interfaceNames {
+		if !containsValue(interfacesByTypeName, interfaceName) && declaredTypes["Mock"+interfaceName] {
+			interfacesByTypeName["Mock"+interfaceName] = interfaceName
+		}
+	}
+	var mocks []Mock
+	for typeName, interfaceName := range interfacesByTypeName {
+		mocks = append(mocks, Mock{
+			File:           path,
+			PackageName:    file.Name.Name,
+			TypeName:       typeName,
+			ImportPath:     source[1],
+			InterfaceName:  interfaceName,
+			FileInterfaces: interfaceNames,
+		})
+	}
+	return mocks, nil
+}
+
+// stampedConstructor returns the mock type and interface of decl, if it is a mock's constructor
+// calling pegomock.CheckFreshness.
+func stampedConstructor(decl ast.Decl) (typeName string, interfaceName string, isStamped bool) {
+	funcDecl, isFunc := decl.(*ast.FuncDecl)
+	if !isFunc || funcDecl.Recv != nil || funcDecl.Body == nil || !strings.HasPrefix(funcDecl.Name.Name, "New") ||
+		funcDecl.Type.Results == nil || len(funcDecl.Type.Results.List) != 1 {
+		return "", "", false
+	}
+	typeName = receiverTypeName(funcDecl.Type.Results.List[0].Type)
+	if typeName == "" {
+		return "", "", false
+	}
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, isCall := node.(*ast.CallExpr)
+		if !isCall || len(call.Args) != 3 {
+			return true
+		}
+		if selector, isSelector := call.Fun.(*ast.SelectorExpr); !isSelector || selector.Sel.Name != "CheckFreshness" {
+			return true
+		}
+		if literal, isLiteral := call.Args[1].(*ast.BasicLit); isLiteral && literal.Kind == token.STRING {
+			interfaceName, _ = strconv.Unquote(literal.Value)
+			isStamped = interfaceName != ""
+		}
+		return false
+	})
+	return typeName, interfaceName, isStamped
+}
+
+func containsValue(m map[string]string, value string) bool {
+	for _, v := range m {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// typeErrorsOf summarizes the type errors in the declarations of mock in file, i.e. in its type,
+// its constructors, its methods and its helper types like <Mock>_<Method>_OngoingVerification.
+// Type errors outside of the declarations of all mocks belong to each of them.
+func typeErrorsOf(pkg *packages.Package, file *ast.File, mock Mock, mocks []Mock) []string {
+	var typeErrors []types.Error
+	for _, typeError := range pkg.TypeErrors {
+		if typeError.Pos < file.Pos() || typeError.Pos > file.End() {
+			continue
+		}
+		owner := ""
+		for _, decl := range file.Decls {
+			if decl.Pos() <= typeError.Pos && typeError.Pos <= decl.End() {
+				owner = ownerOf(decl, mocks)
+			}
+		}
+		if owner == "" || owner == mock.TypeName {
+			typeErrors = append(typeErrors, typeError)
+		}
+	}
+	if len(typeErrors) == 0 {
+		return nil
+	}
+	sort.Slice(typeErrors, func(i, j int) bool { return typeErrors[i].Pos < typeErrors[j].Pos })
+	position := pkg.Fset.Position(typeErrors[0].Pos)
+	summary := fmt.Sprintf("does not compile: %v:%v:%v: %v", filepath.Base(position.Filename), position.Line, position.Column, typeErrors[0].Msg)
+	if len(typeErrors) > 1 {
+		summary += fmt.Sprintf(" (and %v more type errors)", len(typeErrors)-1)
+	}
+	return []string{summary}
+}
+
+// ownerOf returns which of mocks decl is generated for, or "" if none.
+func ownerOf(decl ast.Decl, mocks []Mock) string {
+	var names []string
+	switch decl := decl.(type) {
+	case *ast.FuncDecl:
+		if decl.Recv != nil && len(decl.Recv.List) == 1 {
+			names = append(names, receiverTypeName(decl.Recv.List[0].Type))
+		} else {
+			names = append(names, strings.TrimPrefix(decl.Name.Name, "New"))
+		}
+	case *ast.GenDecl:
+		for _, spec := range decl.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					names = append(names, strings.TrimPrefix(name.Name, "_"))
+				}
+			}
+		}
+	}
+	owner := ""
+	for _, name := range names {
+		for _, mock := range mocks {
+			// the longest matching name wins, e.g. MockStoreCache over MockStore
+			if (strings.HasPrefix(name, mock.TypeName) || name == "Verifier"+mock.TypeName ||
+				name == "Recorder"+mock.InterfaceName || name == "Null"+mock.InterfaceName) && len(mock.TypeName) > len(owner) {
+				owner = mock.TypeName
+			}
+		}
+	}
+	return owner
+}
+
+// receiverTypeName returns the name of the type of a receiver like *MockStore[T], or of a type
+// expression of the same form.
+func receiverTypeName(expr ast.Expr) string {
+	if star, isStar := expr.(*ast.StarExpr); isStar {
+		expr = star.X
+	}
+	switch generic := expr.(type) {
+	case *ast.IndexExpr:
+		expr = generic.X
+	case *ast.IndexListExpr:
+		expr = generic.X
+	}
+	if ident, isIdent := expr.(*ast.Ident); isIdent {
+		return ident.Name
+	}
+	return ""
+}
+
+// methodProblemsOf compares the methods declared for mock with the methods of its interface.
+// Methods of generated mocks that don't implement interface methods, like VerifyWasCalledOnce or
+// <Method>Calls, are ignored.
+func methodProblemsOf(pkg *packages.Package, mock Mock) []string {
+	mockType, isTypeName := pkg.Types.Scope().Lookup(mock.TypeName).(*types.TypeName)
+	if !isTypeName {
+		return []string{fmt.Sprintf("mock type %v not found", mock.TypeName)}
+	}
+	named, isNamed := mockType.Type().(*types.Named)
+	if !isNamed {
+		return []string{fmt.Sprintf("%v is not a mock type", mock.TypeName)}
+	}
+	expected, e := interfaceMethodsOf(pkg, mock.ImportPath, mock.InterfaceName)
+	if e != nil {
+		return []string{e.Error()}
+	}
+	actual := make(map[string]*types.Signature, named.NumMethods())
+	for i := 0; i < named.NumMethods(); i++ {
+		actual[named.Method(i).Name()] = named.Method(i).Type().(*types.Signature)
+	}
+	names := make([]string, 0, len(expected)+len(actual))
+	for name := range expected {
+		names = append(names, name)
+	}
+	for name := range actual {
+		if _, exists := expected[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	var problems []string
+	for _, name := range names {
+		expectedSignature, inInterface := expected[name]
+		actualSignature, inMock := actual[name]
+		switch {
+		case !inMock:
+			problems = append(problems, fmt.Sprintf("missing method %v%v", name, signatureString(expectedSignature, packageName)))
+		case !inInterface:
+			if !generatedMethods[name] && !(strings.HasSuffix(name, "Calls") && actual[strings.TrimSuffix(name, "Calls")] != nil) {
+				problems = append(problems, fmt.Sprintf("extra method %v%v", name, signatureString(actualSignature, packageName)))
+			}
+		case signatureString(actualSignature, packagePath) != signatureString(expectedSignature, packagePath) &&
+			// signatures with invalid types are already reported as type errors
+			!strings.Contains(signatureString(actualSignature, packagePath), "invalid type"):
+			problems = append(problems, fmt.Sprintf("incompatible method %v: the mock has %v%v, but the interface has %v%v",
+				name, name, signatureString(actualSignature, packageName), name, signatureString(expectedSignature, packageName)))
+		}
+	}
+	return problems
+}
+
+// interfaceMethodsOf returns the methods of the interface, or the function type's Call method,
+// looking it up among the packages pkg depends on, or loading it if pkg doesn't import it anymore.
+func interfaceMethodsOf(pkg *packages.Package, importPath string, interfaceName string) (map[string]*types.Signature, error) {
+	var interfacePackage *types.Package
+	packages.Visit([]*packages.Package{pkg}, func(p *packages.Package) bool {
+		if p.PkgPath == importPath && p.Types != nil && interfacePackage == nil {
+			interfacePackage = p.Types
+		}
+		return interfacePackage == nil
+	}, nil)
+	if interfacePackage == nil {
+		pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedTypes}, importPath)
+		if e != nil || len(pkgs) != 1 || len(pkgs[0].Errors) > 0 {
+			return nil, fmt.Errorf("could not load package %v of the interface", importPath)
+		}
+		interfacePackage = pkgs[0].Types
+	}
+	object, isTypeName := interfacePackage.Scope().Lookup(interfaceName).(*types.TypeName)
+	if !isTypeName {
+		return nil, fmt.Errorf("interface %v.%v doesn't exist anymore", importPath, interfaceName)
+	}
+	switch underlying := object.Type().Underlying().(type) {
+	case *types.Interface:
+		methods := make(map[string]*types.Signature, underlying.NumMethods())
+		for i := 0; i < underlying.NumMethods(); i++ {
+			methods[underlying.Method(i).Name()] = underlying.Method(i).Type().(*types.Signature)
+		}
+		return methods, nil
+	case *types.Signature:
+		return map[string]*types.Signature{"Call": underlying}, nil
+	default:
+		return nil, fmt.Errorf("%v.%v is not an interface anymore", importPath, interfaceName)
+	}
+}
+
+func packagePath(p *types.Package) string { return p.Path() }
+func packageName(p *types.Package) string { return p.Name() }
+
+// signatureString formats sig without func keyword and parameter names, e.g. "(string, ...int) error".
+func signatureString(sig *types.Signature, qualifier types.Qualifier) string {
+	params := make([]string, sig.Params().Len())
+	for i := range params {
+		paramType := sig.Params().At(i).Type()
+		if slice, isSlice := paramType.(*types.Slice); isSlice && sig.Variadic() && i == len(params)-1 {
+			params[i] = "..." + types.TypeString(slice.Elem(), qualifier)
+		} else {
+			params[i] = types.TypeString(paramType, qualifier)
+		}
+	}
+	results := make([]string, sig.Results().Len())
+	for i := range results {
+		results[i] = types.TypeString(sig.Results().At(i).Type(), qualifier)
+	}
+	switch len(results) {
+	case 0:
+		return "(" + strings.Join(params, ", ") + ")"
+	case 1:
+		return "(" + strings.Join(params, ", ") + ") " + results[0]
+	default:
+		return "(" + strings.Join(params, ", ") + ") (" + strings.Join(results, ", ") + ")"
+	}
+}
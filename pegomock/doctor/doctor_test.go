@@ -0,0 +1,132 @@
+package doctor_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/pegomock/doctor"
+)
+
+func TestDoctor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Doctor Suite")
+}
+
+var _ = Describe("Examining mocks", func() {
+	var (
+		moduleDir  string
+		originalWd string
+	)
+
+	writeFile := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(moduleDir, path)), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(moduleDir, path), []byte(content), 0644)).To(Succeed())
+	}
+
+	mockSource := func(interfaceNames string, decls string) string {
+		return "// Code generated by pegomock. DO NOT EDIT.\n" +
+			"// Source: example.com/doctor/store (interfaces: " + interfaceNames + ")\n\n" +
+			"package store_test\n\n" +
+			"import \"example.com/doctor/store\"\n\n" +
+			"var _ store.Item\n\n" +
+			decls
+	}
+
+	BeforeEach(func() {
+		var e error
+		originalWd, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		moduleDir, e = filepath.EvalSymlinks(GinkgoT().TempDir())
+		Expect(e).NotTo(HaveOccurred())
+		writeFile("go.mod", "module example.com/doctor\n\ngo 1.18\n")
+		writeFile("store/store.go", "package store\n\ntype Item struct{}\n\n"+
+			"type Store interface {\n\tGet(key string) (Item, error)\n\tPut(key string, item Item)\n}\n")
+		// stands in for the pegomock package, which the temporary module doesn't depend on
+		writeFile("store/pegomock_test.go", "package store_test\n\ntype runtime struct{}\n\n"+
+			"func (runtime) CheckFreshness(importPath, interfaceName, hash string) {}\n\nvar pegomock runtime\n")
+		Expect(os.Chdir(moduleDir)).To(Succeed())
+		// the temporary module is not part of any workspace the tests might run in
+		GinkgoT().Setenv("GOWORK", "off")
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(originalWd)).To(Succeed())
+	})
+
+	It("finds no problems in mocks matching their interfaces, ignoring the methods generated besides", func() {
+		writeFile("store/mock_store_test.go", mockSource("Store",
+			"type MockStore struct{}\n\n"+
+				"func (mock *MockStore) Get(key string) (store.Item, error) { return store.Item{}, nil }\n"+
+				"func (mock *MockStore) Put(key string, item store.Item) {}\n"+
+				"func (mock *MockStore) VerifyWasCalledOnce() {}\n"+
+				"func (mock *MockStore) GetCalls() {}\n"))
+
+		diagnoses, e := doctor.Examine([]string{"./..."})
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(diagnoses).To(HaveLen(1))
+		Expect(diagnoses[0].File).To(Equal(filepath.Join(moduleDir, "store", "mock_store_test.go")))
+		Expect(diagnoses[0].TypeName).To(Equal("MockStore"))
+		Expect(diagnoses[0].InterfaceName).To(Equal("Store"))
+		Expect(diagnoses[0].Healthy()).To(BeTrue())
+	})
+
+	It("reports missing, extra and incompatible methods", func() {
+		writeFile("store/mock_store_test.go", mockSource("Store",
+			"type MockStore struct{}\n\n"+
+				"func (mock *MockStore) Get(key string) store.Item { return store.Item{} }\n"+
+				"func (mock *MockStore) Delete(key string) {}\n"))
+
+		diagnoses, e := doctor.Examine([]string{"./..."})
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(diagnoses).To(HaveLen(1))
+		Expect(diagnoses[0].Problems).To(Equal([]string{
+			"extra method Delete(string)",
+			"incompatible method Get: the mock has Get(string) store.Item, but the interface has Get(string) (store.Item, error)",
+			"missing method Put(string, store.Item)",
+		}))
+	})
+
+	It("reports type errors of mocks that don't compile anymore", func() {
+		writeFile("store/mock_store_test.go", mockSource("Store",
+			"type MockStore struct{}\n\n"+
+				"func (mock *MockStore) Get(key string) (store.Item, error) { return store.Item{}, nil }\n"+
+				"func (mock *MockStore) Put(key string, item store.Record) {}\n"))
+
+		diagnoses, e := doctor.Examine([]string{"./..."})
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(diagnoses).To(HaveLen(1))
+		Expect(diagnoses[0].Problems).To(Equal([]string{"does not compile: mock_store_test.go:13:51: undefined: store.Record"}))
+	})
+
+	It("finds mocks with custom names through the freshness checks of their constructors", func() {
+		writeFile("store/mock_store_test.go", mockSource("Store",
+			"type FakeStore struct{}\n\n"+
+				"func NewFakeStore() *FakeStore {\n\tpegomock.CheckFreshness(\"example.com/doctor/store\", \"Store\", \"hash\")\n\treturn &FakeStore{}\n}\n\n"+
+				"func (mock *FakeStore) Get(key string) (store.Item, error) { return store.Item{}, nil }\n"))
+
+		diagnoses, e := doctor.Examine([]string{"./..."})
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(diagnoses).To(HaveLen(1))
+		Expect(diagnoses[0].TypeName).To(Equal("FakeStore"))
+		Expect(diagnoses[0].Problems).To(Equal([]string{"missing method Put(string, store.Item)"}))
+		Expect(diagnoses[0].RegenerateCommand(moduleDir)).To(Equal(
+			"cd store && pegomock generate --mock-name FakeStore -o mock_store_test.go example.com/doctor/store Store"))
+	})
+
+	It("reports mocks of interfaces that don't exist anymore", func() {
+		writeFile("store/mock_cache_test.go", mockSource("Cache", "type MockCache struct{}\n"))
+
+		diagnoses, e := doctor.Examine([]string{"./..."})
+
+		Expect(e).NotTo(HaveOccurred())
+		Expect(diagnoses).To(HaveLen(1))
+		Expect(diagnoses[0].Problems).To(Equal([]string{"interface example.com/doctor/store.Cache doesn't exist anymore"}))
+	})
+})
@@ -0,0 +1,338 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Result describes an interface extracted into one consumer package.
+type Result struct {
+	ImportPath     string // of the consumer package
+	Dir            string // of the consumer package
+	InterfaceName  string
+	InterfaceFile  string
+	RewrittenFiles []string
+}
+
+// Interface extracts an interface for concreteType, e.g. "example.com/store.Store", into every
+// package matching consumerPatterns that uses it. The interface contains the methods each
+// package calls on the type, or all its exported methods if allMethods is set. Parameters and
+// struct fields of type *Store or Store in the consumer packages are then changed to the
+// interface. Results, constructors etc. keep using the concrete type.
+func Interface(concreteType string, consumerPatterns []string, interfaceName string, allMethods bool) ([]Result, error) {
+	typePackagePath, typeName, e := splitType(concreteType)
+	if e != nil {
+		return nil, e
+	}
+
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+		packages.NeedImports | packages.NeedTypes | packages.NeedSyntax | packages.NeedTypesInfo}, consumerPatterns...)
+	if e != nil {
+		return nil, e
+	}
+	var results []Result
+	for _, pkg := range pkgs {
+		if len(pkg.Errors) > 0 {
+			return nil, fmt.Errorf("Errors while loading package %v: %v", pkg.PkgPath, pkg.Errors[0])
+		}
+		typePackage, imported := pkg.Imports[typePackagePath]
+		if !imported || typePackage.Types == nil {
+			continue
+		}
+		target, isTypeName := typePackage.Types.Scope().Lookup(typeName).(*types.TypeName)
+		if !isTypeName {
+			return nil, fmt.Errorf("Type %v not found", concreteType)
+		}
+		result, e := extractInto(pkg, target, interfaceName, allMethods)
+		if e != nil {
+			return nil, e
+		}
+		if result != nil {
+			results = append(results, *result)
+		}
+	}
+	return results, nil
+}
+
+// Wrap derives an interface from the exported method set of concreteType, e.g.
+// "example.com/store.Store", and writes it into the package declaring the type, together with an
+// assertion that the type implements it. The interface is named interfaceName, or the name of the
+// type followed by "Interface" if interfaceName is empty. Unlike Interface, it doesn't change any
+// code using the type.
+func Wrap(concreteType string, interfaceName string) (*Result, error) {
+	typePackagePath, typeName, e := splitType(concreteType)
+	if e != nil {
+		return nil, e
+	}
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+		packages.NeedImports | packages.NeedTypes}, typePackagePath)
+	if e != nil {
+		return nil, e
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return nil, fmt.Errorf("Errors while loading package %v: %v", typePackagePath, pkg.Errors[0])
+	}
+	target, isTypeName := pkg.Types.Scope().Lookup(typeName).(*types.TypeName)
+	if !isTypeName {
+		return nil, fmt.Errorf("Type %v not found", concreteType)
+	}
+	if types.IsInterface(target.Type()) {
+		return nil, fmt.Errorf("%v already is an interface", concreteType)
+	}
+	if named, isNamed := target.Type().(*types.Named); isNamed && named.TypeParams().Len() > 0 {
+		return nil, fmt.Errorf("%v is generic. Only non-generic types can be wrapped", concreteType)
+	}
+	methods := exportedMethods(target)
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("%v has no exported methods", concreteType)
+	}
+	if interfaceName == "" {
+		interfaceName = typeName + "Interface"
+	}
+	if pkg.Types.Scope().Lookup(interfaceName) != nil {
+		return nil, fmt.Errorf("Package %v already declares %v. Use --name to choose a different interface name", pkg.PkgPath, interfaceName)
+	}
+	dir := filepath.Dir(pkg.CompiledGoFiles[0])
+	result := &Result{
+		ImportPath:    pkg.PkgPath,
+		Dir:           dir,
+		InterfaceName: interfaceName,
+		InterfaceFile: filepath.Join(dir, strings.ToLower(interfaceName)+".go"),
+	}
+	if _, e := os.Stat(result.InterfaceFile); e == nil {
+		return nil, fmt.Errorf("File %v already exists", result.InterfaceFile)
+	}
+	source, e := interfaceSource(pkg, target, interfaceName, methods,
+		fmt.Sprintf("%v has the exported methods of %v.", interfaceName, typeName),
+		fmt.Sprintf("var _ %v = (*%v)(nil)", interfaceName, typeName))
+	if e != nil {
+		return nil, e
+	}
+	if e := os.WriteFile(result.InterfaceFile, source, 0644); e != nil {
+		return nil, e
+	}
+	return result, nil
+}
+
+func splitType(concreteType string) (packagePath string, typeName string, e error) {
+	lastDot := strings.LastIndex(concreteType, ".")
+	if lastDot <= 0 || lastDot == len(concreteType)-1 {
+		return "", "", fmt.Errorf("Invalid type %q. Expected an import path and a type name, e.g. example.com/store.Store", concreteType)
+	}
+	return concreteType[:lastDot], concreteType[lastDot+1:], nil
+}
+
+func extractInto(pkg *packages.Package, target *types.TypeName, interfaceName string, allMethods bool) (*Result, error) {
+	methods := usedMethods(pkg, target)
+	if allMethods && len(methods) > 0 {
+		methods = exportedMethods(target)
+	}
+	if len(methods) == 0 {
+		return nil, nil
+	}
+	if interfaceName == "" {
+		interfaceName = target.Name()
+	}
+	if pkg.Types.Scope().Lookup(interfaceName) != nil {
+		return nil, fmt.Errorf("Package %v already declares %v. Use --name to choose a different interface name", pkg.PkgPath, interfaceName)
+	}
+	dir := filepath.Dir(pkg.CompiledGoFiles[0])
+	result := &Result{
+		ImportPath:    pkg.PkgPath,
+		Dir:           dir,
+		InterfaceName: interfaceName,
+		InterfaceFile: filepath.Join(dir, strings.ToLower(interfaceName)+".go"),
+	}
+	if _, e := os.Stat(result.InterfaceFile); e == nil {
+		return nil, fmt.Errorf("File %v already exists", result.InterfaceFile)
+	}
+
+	source, e := interfaceSource(pkg, target, interfaceName, methods,
+		fmt.Sprintf("%v is the subset of the methods of %v.%v used by this package.", interfaceName, target.Pkg().Name(), target.Name()))
+	if e != nil {
+		return nil, e
+	}
+	for i, file := range pkg.Syntax {
+		rewritten, e := rewriteFile(pkg, file, target, interfaceName)
+		if e != nil {
+			return nil, e
+		}
+		if rewritten != nil {
+			if e := os.WriteFile(pkg.CompiledGoFiles[i], rewritten, 0644); e != nil {
+				return nil, e
+			}
+			result.RewrittenFiles = append(result.RewrittenFiles, pkg.CompiledGoFiles[i])
+		}
+	}
+	if e := os.WriteFile(result.InterfaceFile, source, 0644); e != nil {
+		return nil, e
+	}
+	return result, nil
+}
+
+// usedMethods returns the methods pkg calls on values of the target type, sorted by name.
+func usedMethods(pkg *packages.Package, target *types.TypeName) []*types.Func {
+	used := make(map[string]*types.Func)
+	for _, selection := range pkg.TypesInfo.Selections {
+		if selection.Kind() == types.MethodVal && isTargetType(selection.Recv(), target) {
+			method := selection.Obj().(*types.Func)
+			used[method.Name()] = method
+		}
+	}
+	methods := make([]*types.Func, 0, len(used))
+	for _, method := range used {
+		methods = append(methods, method)
+	}
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name() < methods[j].Name() })
+	return methods
+}
+
+func exportedMethods(target *types.TypeName) []*types.Func {
+	var methods []*types.Func
+	methodSet := types.NewMethodSet(types.NewPointer(target.Type()))
+	for i := 0; i < methodSet.Len(); i++ {
+		if method := methodSet.At(i).Obj().(*types.Func); method.Exported() {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+func isTargetType(typ types.Type, target *types.TypeName) bool {
+	if pointer, isPointer := typ.(*types.Pointer); isPointer {
+		typ = pointer.Elem()
+	}
+	named, isNamed := typ.(*types.Named)
+	return isNamed && named.Obj() == target
+}
+
+func interfaceSource(pkg *packages.Package, target *types.TypeName, interfaceName string, methods []*types.Func, docComment string, extraDecls ...string) ([]byte, error) {
+	imports := make(map[string]string)
+	qualifier := func(p *types.Package) string {
+		if p == pkg.Types {
+			return ""
+		}
+		imports[p.Path()] = p.Name()
+		return p.Name()
+	}
+	var body bytes.Buffer
+	for _, method := range methods {
+		body.WriteString("\t" + method.Name())
+		types.WriteSignature(&body, method.Type().(*types.Signature), qualifier)
+		body.WriteString("\n")
+	}
+
+	var source bytes.Buffer
+	fmt.Fprintf(&source, "package %v\n\n", pkg.Name)
+	if len(imports) > 0 {
+		importPaths := make([]string, 0, len(imports))
+		for importPath := range imports {
+			importPaths = append(importPaths, importPath)
+		}
+		sort.Strings(importPaths)
+		source.WriteString("import (\n")
+		for _, importPath := range importPaths {
+			fmt.Fprintf(&source, "\t%q\n", importPath)
+		}
+		source.WriteString(")\n\n")
+	}
+	fmt.Fprintf(&source, "// %v\n", docComment)
+	fmt.Fprintf(&source, "type %v interface {\n%v}\n", interfaceName, body.String())
+	for _, decl := range extraDecls {
+		fmt.Fprintf(&source, "\n%v\n", decl)
+	}
+	formatted, e := format.Source(source.Bytes())
+	if e != nil {
+		return nil, fmt.Errorf("Could not format interface %v: %v", interfaceName, e)
+	}
+	return formatted, nil
+}
+
+// rewriteFile changes parameters and struct fields of the target type to interfaceName. It returns
+// nil if the file doesn't have any.
+func rewriteFile(pkg *packages.Package, file *ast.File, target *types.TypeName, interfaceName string) ([]byte, error) {
+	replacement := func(expr ast.Expr) ast.Expr {
+		typeExpr := expr
+		if star, isStar := expr.(*ast.StarExpr); isStar {
+			typeExpr = star.X
+		}
+		if selector, isSelector := typeExpr.(*ast.SelectorExpr); isSelector && pkg.TypesInfo.Uses[selector.Sel] == target {
+			return &ast.Ident{NamePos: expr.Pos(), Name: interfaceName}
+		}
+		return nil
+	}
+	replaced := 0
+	rewriteFields := func(fields *ast.FieldList) {
+		if fields == nil {
+			return
+		}
+		for _, field := range fields.List {
+			typ := &field.Type
+			if ellipsis, isEllipsis := field.Type.(*ast.Ellipsis); isEllipsis {
+				typ = &ellipsis.Elt
+			}
+			if newType := replacement(*typ); newType != nil {
+				*typ = newType
+				replaced++
+			}
+		}
+	}
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncType:
+			rewriteFields(n.Params)
+		case *ast.StructType:
+			rewriteFields(n.Fields)
+		}
+		return true
+	})
+	if replaced == 0 {
+		return nil, nil
+	}
+	removeImportIfUnused(pkg, file, target.Pkg().Path())
+
+	var buf bytes.Buffer
+	if e := format.Node(&buf, pkg.Fset, file); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+func removeImportIfUnused(pkg *packages.Package, file *ast.File, importPath string) {
+	used := false
+	ast.Inspect(file, func(node ast.Node) bool {
+		if selector, isSelector := node.(*ast.SelectorExpr); isSelector {
+			if ident, isIdent := selector.X.(*ast.Ident); isIdent {
+				if pkgName, isPkgName := pkg.TypesInfo.Uses[ident].(*types.PkgName); isPkgName && pkgName.Imported().Path() == importPath {
+					used = true
+				}
+			}
+		}
+		return !used
+	})
+	if used {
+		return
+	}
+	for _, spec := range file.Imports {
+		if strings.Trim(spec.Path.Value, `"`) != importPath {
+			continue
+		}
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		astutil.DeleteNamedImport(pkg.Fset, file, name, importPath)
+		return
+	}
+}
@@ -0,0 +1,167 @@
+package extract_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/pegomock/extract"
+)
+
+func TestExtract(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Extract Suite")
+}
+
+var _ = Describe("Extracting interfaces", func() {
+	var (
+		moduleDir  string
+		originalWd string
+	)
+
+	BeforeEach(func() {
+		var e error
+		originalWd, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		moduleDir = GinkgoT().TempDir()
+		Expect(exec.Command("cp", "-r", "testdata/.", moduleDir).Run()).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module example.com/extract\n\ngo 1.18\n"), 0644)).To(Succeed())
+		Expect(os.Chdir(moduleDir)).To(Succeed())
+		// the temporary module is not part of any workspace the tests might run in
+		GinkgoT().Setenv("GOWORK", "off")
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(originalWd)).To(Succeed())
+	})
+
+	readFile := func(path string) string {
+		content, e := os.ReadFile(filepath.Join(moduleDir, path))
+		Expect(e).NotTo(HaveOccurred())
+		return string(content)
+	}
+
+	It("extracts the used methods into each consumer and makes it depend on the interface", func() {
+		results, e := extract.Interface("example.com/extract/store.Store", []string{"./..."}, "", false)
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].ImportPath).To(Equal("example.com/extract/report"))
+		Expect(results[1].ImportPath).To(Equal("example.com/extract/service"))
+		Expect(results[1].InterfaceName).To(Equal("Store"))
+
+		Expect(readFile("service/store.go")).To(Equal(`package service
+
+import (
+	"context"
+	"example.com/extract/store"
+	"time"
+)
+
+// Store is the subset of the methods of store.Store used by this package.
+type Store interface {
+	Get(ctx context.Context, key string) (store.Item, error)
+	Put(ctx context.Context, item store.Item, ttl time.Duration) error
+}
+`))
+		Expect(readFile("service/service.go")).To(ContainSubstring("\tstore Store // the backing store\n"))
+		Expect(readFile("service/service.go")).To(ContainSubstring("func NewService(s Store) *Service {"))
+
+		Expect(readFile("report/store.go")).To(ContainSubstring("type Store interface {\n\tClose() error\n}"))
+		Expect(readFile("report/report.go")).To(ContainSubstring("func Close(s Store) error"))
+		Expect(readFile("report/report.go")).To(ContainSubstring("func DefaultStore() *store.Store"))
+
+		Expect(exec.Command("go", "vet", "./...").Run()).To(Succeed())
+	})
+
+	It("extracts all exported methods, if requested, with the given name", func() {
+		results, e := extract.Interface("example.com/extract/store.Store", []string{"./report"}, "ItemStore", true)
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].RewrittenFiles).To(Equal([]string{filepath.Join(results[0].Dir, "report.go")}))
+		Expect(readFile("report/itemstore.go")).To(ContainSubstring(
+			"type ItemStore interface {\n\tClose() error\n\tDelete(ctx context.Context, key string) error\n"))
+	})
+
+	It("removes the import of the concrete type's package when it is no longer needed", func() {
+		_, e := extract.Interface("example.com/extract/store.Store", []string{"./service"}, "", false)
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(readFile("service/service.go")).NotTo(ContainSubstring(`"example.com/extract/store"`))
+		Expect(exec.Command("go", "vet", "./service").Run()).To(Succeed())
+	})
+
+	It("fails for types that don't exist", func() {
+		_, e := extract.Interface("example.com/extract/store.Missing", []string{"./service"}, "", false)
+		Expect(e).To(MatchError("Type example.com/extract/store.Missing not found"))
+	})
+})
+
+var _ = Describe("Wrapping concrete types", func() {
+	var originalWd string
+
+	BeforeEach(func() {
+		var e error
+		originalWd, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		moduleDir := GinkgoT().TempDir()
+		Expect(exec.Command("cp", "-r", "testdata/.", moduleDir).Run()).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module example.com/extract\n\ngo 1.18\n"), 0644)).To(Succeed())
+		Expect(os.Chdir(moduleDir)).To(Succeed())
+		GinkgoT().Setenv("GOWORK", "off")
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(originalWd)).To(Succeed())
+	})
+
+	It("writes an interface with all exported methods next to the type", func() {
+		result, e := extract.Wrap("example.com/extract/store.Store", "")
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(result.ImportPath).To(Equal("example.com/extract/store"))
+		Expect(result.InterfaceName).To(Equal("StoreInterface"))
+		Expect(result.RewrittenFiles).To(BeEmpty())
+		content, e := os.ReadFile(result.InterfaceFile)
+		Expect(e).NotTo(HaveOccurred())
+		Expect(string(content)).To(Equal(`package store
+
+import (
+	"context"
+	"time"
+)
+
+// StoreInterface has the exported methods of Store.
+type StoreInterface interface {
+	Close() error
+	Delete(ctx context.Context, key string) error
+	Get(ctx context.Context, key string) (Item, error)
+	Put(ctx context.Context, item Item, ttl time.Duration) error
+}
+
+var _ StoreInterface = (*Store)(nil)
+`))
+		Expect(exec.Command("go", "vet", "./...").Run()).To(Succeed())
+	})
+
+	It("uses the given interface name", func() {
+		result, e := extract.Wrap("example.com/extract/store.Store", "ItemStore")
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(result.InterfaceFile).To(Equal(filepath.Join(result.Dir, "itemstore.go")))
+	})
+
+	It("fails if the package already declares the interface name", func() {
+		_, e := extract.Wrap("example.com/extract/store.Store", "Item")
+		Expect(e).To(MatchError("Package example.com/extract/store already declares Item. Use --name to choose a different interface name"))
+	})
+
+	It("fails for types without exported methods", func() {
+		_, e := extract.Wrap("example.com/extract/store.Item", "")
+		Expect(e).To(MatchError("example.com/extract/store.Item has no exported methods"))
+	})
+})
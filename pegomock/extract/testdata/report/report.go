@@ -0,0 +1,9 @@
+package report
+
+import "example.com/extract/store"
+
+func DefaultStore() *store.Store { return store.New() }
+
+func Close(s *store.Store) error { return s.Close() }
+
+func Count(s store.Item) int { return len(s.Value) }
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"example.com/extract/store"
+)
+
+// Service caches items.
+type Service struct {
+	store *store.Store // the backing store
+}
+
+func NewService(s *store.Store) *Service {
+	return &Service{store: s}
+}
+
+func (service *Service) Refresh(ctx context.Context, key string) error {
+	item, e := service.store.Get(ctx, key)
+	if e != nil {
+		return e
+	}
+	return service.store.Put(ctx, item, time.Minute)
+}
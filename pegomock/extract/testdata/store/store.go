@@ -0,0 +1,31 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type Item struct {
+	Key   string
+	Value string
+}
+
+type Store struct {
+	items map[string]Item
+}
+
+func New() *Store { return &Store{items: make(map[string]Item)} }
+
+func (s *Store) Get(ctx context.Context, key string) (Item, error) { return s.items[key], nil }
+
+func (s *Store) Put(ctx context.Context, item Item, ttl time.Duration) error {
+	s.items[item.Key] = item
+	return nil
+}
+
+func (s *Store) Delete(ctx context.Context, key string) error {
+	delete(s.items, key)
+	return nil
+}
+
+func (s *Store) Close() error { return nil }
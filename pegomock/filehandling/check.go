@@ -0,0 +1,201 @@
+package filehandling
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OutputCheck collects the generated files that differ from the files on disk, for generate
+// --check.
+type OutputCheck struct {
+	mutex    sync.Mutex
+	outdated map[string][]byte // generated content by file
+}
+
+var (
+	outputCheckMutex sync.Mutex
+	outputCheck      *OutputCheck
+)
+
+// CheckOutput makes the functions of this package compare the files they generate with the files
+// on disk and record the differences in check, instead of writing them, until restore is called.
+func CheckOutput(check *OutputCheck) (restore func()) {
+	outputCheckMutex.Lock()
+	defer outputCheckMutex.Unlock()
+	previous := outputCheck
+	outputCheck = check
+	return func() {
+		outputCheckMutex.Lock()
+		defer outputCheckMutex.Unlock()
+		outputCheck = previous
+	}
+}
+
+// Outdated returns the files whose generated content differs from the files on disk, sorted.
+func (check *OutputCheck) Outdated() []string {
+	check.mutex.Lock()
+	defer check.mutex.Unlock()
+	files := make([]string, 0, len(check.outdated))
+	for file := range check.outdated {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Diff returns a unified diff from the file on disk to its generated content.
+func (check *OutputCheck) Diff(file string) string {
+	check.mutex.Lock()
+	generated := check.outdated[file]
+	check.mutex.Unlock()
+	onDisk, e := os.ReadFile(file)
+	if os.IsNotExist(e) {
+		return unifiedDiff("/dev/null", file, nil, generated)
+	}
+	return unifiedDiff(file, file, onDisk, generated)
+}
+
+func (check *OutputCheck) compare(outputFilePath string, content []byte) {
+	onDisk, e := os.ReadFile(outputFilePath)
+	if e == nil && bytes.Equal(onDisk, content) {
+		return
+	}
+	check.mutex.Lock()
+	defer check.mutex.Unlock()
+	if check.outdated == nil {
+		check.outdated = make(map[string][]byte)
+	}
+	check.outdated[outputFilePath] = content
+}
+
+// writeOutput writes generated content to outputFilePath, or only compares it with the file when
+// checking output.
+func writeOutput(outputFilePath string, content []byte) {
+	outputCheckMutex.Lock()
+	check := outputCheck
+	outputCheckMutex.Unlock()
+	if check != nil {
+		check.compare(outputFilePath, content)
+		return
+	}
+	err := os.WriteFile(outputFilePath, content, 0664)
+	if err != nil {
+		panic(fmt.Errorf("failed writing to destination: %v", err))
+	}
+}
+
+func checkingOutput() bool {
+	outputCheckMutex.Lock()
+	defer outputCheckMutex.Unlock()
+	return outputCheck != nil
+}
+
+const diffContextLines = 3
+
+// unifiedDiff returns the differences between the lines of a and b in unified format.
+func unifiedDiff(aName string, bName string, a []byte, b []byte) string {
+	aLines, bLines := linesOf(a), linesOf(b)
+	edits := lineEdits(aLines, bLines)
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- %v\n+++ %v\n", aName, bName)
+	for start := 0; start < len(edits); {
+		if edits[start].kind == ' ' {
+			start++
+			continue
+		}
+		// a hunk extends over changes separated by at most twice the context
+		hunkStart, hunkEnd := start-diffContextLines, start
+		for next := start; next < len(edits); next++ {
+			if edits[next].kind != ' ' {
+				hunkEnd = next + 1
+			} else if next-hunkEnd >= 2*diffContextLines {
+				break
+			}
+		}
+		if hunkStart < 0 {
+			hunkStart = 0
+		}
+		if hunkEnd += diffContextLines; hunkEnd > len(edits) {
+			hunkEnd = len(edits)
+		}
+		aStart, aCount, bStart, bCount := edits[hunkStart].aLine, 0, edits[hunkStart].bLine, 0
+		for _, edit := range edits[hunkStart:hunkEnd] {
+			if edit.kind != '+' {
+				aCount++
+			}
+			if edit.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&diff, "@@ -%v +%v @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, edit := range edits[hunkStart:hunkEnd] {
+			fmt.Fprintf(&diff, "%c%v\n", edit.kind, edit.line)
+		}
+		start = hunkEnd
+	}
+	return diff.String()
+}
+
+// hunkRange formats the range of a hunk, whose lines start at index start.
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%v,0", start)
+	}
+	if count == 1 {
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%v,%v", start+1, count)
+}
+
+type lineEdit struct {
+	kind         byte // ' ', '-' or '+'
+	line         string
+	aLine, bLine int // indexes of the line in a and b, or of the next line
+}
+
+// lineEdits turns a into b with a minimal number of deleted and inserted lines, based on their
+// longest common subsequence. Generated files are small enough for its quadratic cost.
+func lineEdits(a []string, b []string) []lineEdit {
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+	var edits []lineEdit
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			edits = append(edits, lineEdit{' ', a[i], i, j})
+			i, j = i+1, j+1
+		case j == len(b) || (i < len(a) && common[i+1][j] >= common[i][j+1]):
+			edits = append(edits, lineEdit{'-', a[i], i, j})
+			i++
+		default:
+			edits = append(edits, lineEdit{'+', b[j], i, j})
+			j++
+		}
+	}
+	return edits
+}
+
+func linesOf(content []byte) []string {
+	if len(content) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
@@ -8,7 +8,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/petergtz/pegomock/v4/internal/freshness"
 	"github.com/petergtz/pegomock/v4/mockgen"
+	"github.com/petergtz/pegomock/v4/model"
 	"github.com/petergtz/pegomock/v4/modelgen/xtools_packages"
 )
 
@@ -20,11 +22,12 @@ func GenerateMockFileInOutputDir(
 	packageOut string,
 	selfPackage string,
 	debugParser bool,
-	out io.Writer) {
+	out io.Writer,
+	options ...mockgen.Option) {
 
 	// if a file path override is specified
 	// ensure all directories in the path are created
-	if outputFilePathOverride != "" {
+	if outputFilePathOverride != "" && !checkingOutput() {
 		if err := os.MkdirAll(filepath.Dir(outputFilePathOverride), 0755); err != nil {
 			panic(fmt.Errorf("failed to make output directory, error: %v", err))
 		}
@@ -37,7 +40,8 @@ func GenerateMockFileInOutputDir(
 		packageOut,
 		selfPackage,
 		debugParser,
-		out)
+		out,
+		options...)
 }
 
 func OutputFilePath(args []string, outputDirPath string, outputFilePathOverride string) string {
@@ -48,27 +52,141 @@ func OutputFilePath(args []string, outputDirPath string, outputFilePathOverride
 	}
 }
 
-func GenerateMockFile(args []string, outputFilePath string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer) {
-	mockSourceCode := GenerateMockSourceCode(args, nameOut, packageOut, selfPackage, debugParser, out)
+func GenerateMockFile(args []string, outputFilePath string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, options ...mockgen.Option) {
+	mockSourceCode := GenerateMockSourceCode(args, nameOut, packageOut, selfPackage, debugParser, out, options...)
 
-	err := os.WriteFile(outputFilePath, mockSourceCode, 0664)
-	if err != nil {
-		panic(fmt.Errorf("failed writing to destination: %v", err))
+	writeOutput(outputFilePath, mockSourceCode)
+}
+
+func GenerateMockSourceCode(args []string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, options ...mockgen.Option) []byte {
+	if len(args) != 2 {
+		log.Fatal("Expected exactly two arguments, but got " + fmt.Sprint(args))
+	}
+	return generateMocksSourceCode(args[0], []string{args[1]}, nameOut, packageOut, selfPackage, debugParser, out, options...)
+}
+
+// GenerateMocksFile generates the mocks for all interfaceNames of the package with importPath into
+// one file.
+func GenerateMocksFile(importPath string, interfaceNames []string, outputFilePath string, packageOut string, selfPackage string, debugParser bool, out io.Writer, options ...mockgen.Option) {
+	if !checkingOutput() {
+		if err := os.MkdirAll(filepath.Dir(outputFilePath), 0755); err != nil {
+			panic(fmt.Errorf("failed to make output directory, error: %v", err))
+		}
+	}
+	mockSourceCode := generateMocksSourceCode(importPath, interfaceNames, "", packageOut, selfPackage, debugParser, out, options...)
+
+	writeOutput(outputFilePath, mockSourceCode)
+}
+
+// GenerateMockFileFromArchive is like GenerateMockFile, but reads the interface from the compiled
+// package archive at archivePath instead of from source. args are the import path the archive was
+// compiled for and the interface name.
+func GenerateMockFileFromArchive(archivePath string, args []string, outputFilePath string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, options ...mockgen.Option) {
+	if len(args) != 2 {
+		log.Fatal("Expected exactly two arguments, but got " + fmt.Sprint(args))
+	}
+	loadModel := func(importPath, interfaceName string) (*model.Package, []string, error) {
+		pkg, err := xtools_packages.GenerateModelFromArchive(archivePath, importPath, interfaceName)
+		return pkg, nil, err
+	}
+	// the source of the archive's package might not be available, so its hash isn't recorded
+	mockSourceCode := generateMocksSourceCodeWith(loadModel, false, args[0], []string{args[1]}, nameOut, packageOut, selfPackage, debugParser, out, options...)
+
+	writeOutput(outputFilePath, mockSourceCode)
+}
+
+// GenerateInlineMocksFile generates the mocks for the models of interfaces given on the command
+// line, as returned by xtools_packages.GenerateModelsFromSource, into one file.
+func GenerateInlineMocksFile(models []*model.Package, outputFilePath string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, options ...mockgen.Option) {
+	modelsByName := make(map[string]*model.Package, len(models))
+	interfaceNames := make([]string, len(models))
+	for i, pkg := range models {
+		interfaceNames[i] = pkg.Interfaces[0].Name
+		modelsByName[interfaceNames[i]] = pkg
 	}
+	loadModel := func(importPath, interfaceName string) (*model.Package, []string, error) {
+		return modelsByName[interfaceName], nil, nil
+	}
+	// there's no package the interfaces are declared in, so neither their hashes are recorded, nor
+	// does the header name an import path that tools like pegomock doctor would try to load
+	mockSourceCode := generateMocksSourceCodeWith(loadModel, false, "inline source", interfaceNames, nameOut, packageOut, selfPackage, debugParser, out, options...)
+
+	writeOutput(outputFilePath, mockSourceCode)
+}
+
+func generateMocksSourceCode(importPath string, interfaceNames []string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, options ...mockgen.Option) []byte {
+	return generateMocksSourceCodeWith(xtools_packages.GenerateModelWithWarnings, true, importPath, interfaceNames, nameOut, packageOut, selfPackage, debugParser, out, options...)
+}
+
+func generateMocksSourceCodeWith(
+	loadModel func(importPath, interfaceName string) (*model.Package, []string, error), hashSources bool,
+	importPath string, interfaceNames []string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer, options ...mockgen.Option) []byte {
+	pkg := &model.Package{}
+	for _, interfaceName := range interfaceNames {
+		ast, warnings, err := loadModel(importPath, interfaceName)
+		if err != nil {
+			panic(fmt.Errorf("loading input failed: %v", err))
+		}
+		if err := mockgen.CheckSupported(ast); err != nil {
+			panic(err)
+		}
+		if selfPackage == "" && strings.HasSuffix(ast.PkgPath, "_test") {
+			// interfaces declared in an external test package can only be mocked in that package
+			selfPackage = ast.PkgPath
+		}
+		if ast.Interfaces[0].NeedsOwnPackage {
+			// interfaces referring to unexported names can only be mocked in their own package
+			if packageOut == ast.Name+"_test" {
+				packageOut = ast.Name
+			}
+			if packageOut != ast.Name {
+				panic(fmt.Errorf("Interface %v refers to unexported names of package %v, so its mock must be generated into "+
+					"package %v, but the package of the generated code is %v.", interfaceName, ast.PkgPath, ast.Name, packageOut))
+			}
+			if selfPackage == "" {
+				selfPackage = ast.PkgPath
+			}
+		}
+		if len(warnings) > 0 {
+			fmt.Fprintf(out, "Warning: package %v has errors that don't affect interface %v. "+
+				"Generating mock from partial type information. Errors:\n\t%v\n",
+				importPath, interfaceName, strings.Join(warnings, "\n\t"))
+		}
+		if hashSources {
+			if hash, e := freshness.SourceHash(importPath, interfaceName); e == nil {
+				// copies options, which callers might share between concurrent generations
+				options = append(options[:len(options):len(options)], mockgen.WithSourceHash(importPath, interfaceName, hash))
+			}
+		}
+		pkg.Name, pkg.PkgPath = ast.Name, ast.PkgPath
+		pkg.Interfaces = append(pkg.Interfaces, ast.Interfaces...)
+		pkg.DotImports = append(pkg.DotImports, ast.DotImports...)
+	}
+
+	if debugParser {
+		pkg.Print(out)
+	}
+	src := fmt.Sprintf("%v (interfaces: %v)", importPath, strings.Join(interfaceNames, ", "))
+	return mockgen.GenerateOutput(pkg, src, nameOut, packageOut, selfPackage, options...)
 }
 
-func GenerateMockSourceCode(args []string, nameOut string, packageOut string, selfPackage string, debugParser bool, out io.Writer) []byte {
+func GenerateConformanceFile(args []string, outputFilePath string, fakeTypeName string, packageOut string, selfPackage string) {
 	if len(args) != 2 {
 		log.Fatal("Expected exactly two arguments, but got " + fmt.Sprint(args))
 	}
 	ast, err := xtools_packages.GenerateModel(args[0], args[1])
-	src := fmt.Sprintf("%v (interfaces: %v)", args[0], args[1])
 	if err != nil {
 		panic(fmt.Errorf("loading input failed: %v", err))
 	}
-
-	if debugParser {
-		ast.Print(out)
+	if len(ast.Interfaces[0].TypeParams) > 0 {
+		panic(fmt.Errorf("conformance assertions are not supported for generic interface %v", args[1]))
+	}
+	if ast.Interfaces[0].FuncType {
+		panic(fmt.Errorf("conformance assertions are not supported for function type %v", args[1]))
 	}
-	return mockgen.GenerateOutput(ast, src, nameOut, packageOut, selfPackage)
+	if err := mockgen.CheckSupported(ast); err != nil {
+		panic(err)
+	}
+	src := fmt.Sprintf("%v (interfaces: %v)", args[0], args[1])
+	writeOutput(outputFilePath, mockgen.GenerateConformanceOutput(ast, src, args[0], fakeTypeName, packageOut, selfPackage))
 }
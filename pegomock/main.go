@@ -16,16 +16,31 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 
+	"github.com/petergtz/pegomock/v4/mockgen"
+	"github.com/petergtz/pegomock/v4/model"
+	"github.com/petergtz/pegomock/v4/modelgen/xtools_packages"
+	"github.com/petergtz/pegomock/v4/pegomock/cache"
+	"github.com/petergtz/pegomock/v4/pegomock/compat"
+	"github.com/petergtz/pegomock/v4/pegomock/config"
+	"github.com/petergtz/pegomock/v4/pegomock/doctor"
+	"github.com/petergtz/pegomock/v4/pegomock/extract"
 	"github.com/petergtz/pegomock/v4/pegomock/filehandling"
 	"github.com/petergtz/pegomock/v4/pegomock/remove"
+	"github.com/petergtz/pegomock/v4/pegomock/scaffold"
+	"github.com/petergtz/pegomock/v4/pegomock/snapshot"
+	"github.com/petergtz/pegomock/v4/pegomock/staleness"
 	"github.com/petergtz/pegomock/v4/pegomock/util"
 	"github.com/petergtz/pegomock/v4/pegomock/watch"
 )
@@ -44,34 +59,471 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 	app.FatalIfError(err, "")
 
 	var (
-		generateCmd    = app.Command("generate", "Generate mocks based on the args provided. ")
-		destination    = generateCmd.Flag("output", "Output file; defaults to mock_<interface>_test.go.").Short('o').String()
-		destinationDir = generateCmd.Flag("output-dir", "Output directory; defaults to current directory. If set, package name defaults to this directory, unless explicitly overridden.").String()
-		mockNameOut    = generateCmd.Flag("mock-name", "Struct name of the generated mock; defaults to the interface prefixed with Mock").String()
-		packageOut     = generateCmd.Flag("package", "Package of the generated code; defaults to the package from which pegomock was executed suffixed with _test").String()
-		// TODO: self_package was taken as is from GoMock.
-		//       Still don't understand what it's really there for.
-		//       So for now it's not tested.
-		selfPackage     = generateCmd.Flag("self_package", "If set, the package this mock will be part of.").String()
+		generateCmd     = app.Command("generate", "Generate mocks based on the args provided. ")
+		destination     = generateCmd.Flag("output", "Output file; defaults to mock_<interface>_test.go.").Short('o').String()
+		destinationDir  = generateCmd.Flag("output-dir", "Output directory; defaults to current directory. If set, package name defaults to this directory, unless explicitly overridden.").String()
+		mockNameOut     = generateCmd.Flag("mock-name", "Struct name of the generated mock; defaults to the interface prefixed with Mock").String()
+		packageOut      = generateCmd.Flag("package", "Package of the generated code; defaults to the package from which pegomock was executed suffixed with _test").String()
+		selfPackage     = generateCmd.Flag("self_package", "Import path of the package the mock will be part of, so that the mock doesn't import it; defaults to the package in the output directory, unless the package of the mock is a _test package.").String()
 		debugParser     = generateCmd.Flag("debug", "Print debug information.").Short('d').Bool()
-		generateCmdArgs = generateCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file").Required().Strings()
+		assertOnly      = generateCmd.Flag("assert-implements-only", "Instead of a mock, only generate a compile-time assertion and a method set test checking that the given hand-written fake type implements the interface.").PlaceHolder("FAKE-TYPE").String()
+		hybridFake      = generateCmd.Flag("fake", "Generate a hybrid fake: CRUD-shaped methods like PutX/GetX/DeleteX fall back to in-memory behavior when not stubbed.").Bool()
+		recorder        = generateCmd.Flag("recorder", "Additionally generate a lightweight Recorder<Interface> type that only records invocations and returns zero values.").Bool()
+		nullObject      = generateCmd.Flag("null-object", "\"alongside\" additionally generates a Null<Interface> type implementing the interface with methods that only return zero values, \"only\" generates it instead of the mock, without depending on Pegomock.").Default("none").Enum("none", "alongside", "only")
+		matchers        = generateCmd.Flag("matchers", "\"embedded\" additionally generates typed matcher helpers for the interface's parameter types into the mock file, prefixed with the mock name.").Default("none").Enum("none", "embedded")
+		importAliases   = generateCmd.Flag("import-alias", "Import the package with this path under the given alias instead of its base name, e.g. to satisfy importas lint rules. Repeatable.").PlaceHolder("PKGPATH=ALIAS").StringMap()
+		apiLevel        = generateCmd.Flag("api-level", "Pegomock runtime API the generated code is written against: \"v4\", or \"v3\" for packages still using github.com/petergtz/pegomock/v3. Cannot be \"v3\" together with --fake, --recorder or --default-options.").Default("v4").Enum("v3", "v4")
+		defaultOptions  = generateCmd.Flag("default-options", "Comma-separated runtime options the generated constructors apply by default, e.g. \"strict,cloneArgs\". Valid options: "+strings.Join(mockgen.DefaultOptionNames(), ", ")+".").PlaceHolder("OPTIONS").String()
+		outputTemplate  = generateCmd.Flag("template", "Generate the output with this text/template instead of generating Pegomock mocks. It is executed with a mockgen.TemplateData.").PlaceHolder("FILE").String()
+		plugin          = generateCmd.Flag("plugin", "Generate the output with this generator plugin instead of generating Pegomock mocks: the path of an executable, or NAME for the executable "+util.PluginPrefix+"NAME in PATH. It receives a mockgen.PluginRequest as JSON on stdin and writes the generated file to stdout.").PlaceHolder("NAME").String()
+		nameTemplate    = generateCmd.Flag("name-template", "text/template for the names of the generated mocks, e.g. \"{{.Interface}}Fake\" or \"Stub{{.Interface}}\"; defaults to the interface prefixed with Mock. Cannot be used together with --mock-name.").PlaceHolder("TEMPLATE").String()
+		includeMethods  = generateCmd.Flag("include-methods", "Only mock the methods matching this regex or comma-separated list, e.g. \"Get.*\" or \"Get,Put\". The other methods are generated as stubs that panic.").PlaceHolder("METHODS").String()
+		excludeMethods  = generateCmd.Flag("exclude-methods", "Don't mock the methods matching this regex or comma-separated list, but generate them as stubs that panic.").PlaceHolder("METHODS").String()
+		buildTags       = generateCmd.Flag("build-tags", "Add a //go:build constraint to the generated file: an expression like \"linux && amd64\", or comma-separated tags that must all be satisfied, like \"linux,amd64\".").PlaceHolder("TAGS").String()
+		goos            = generateCmd.Flag("goos", "Parse the packages as if building for this operating system, e.g. to mock interfaces declared in _windows.go files.").PlaceHolder("GOOS").String()
+		goarch          = generateCmd.Flag("goarch", "Parse the packages as if building for this architecture.").PlaceHolder("GOARCH").String()
+		tags            = generateCmd.Flag("tags", "Comma-separated build tags the packages are parsed with, like \"go build -tags\", e.g. to mock interfaces declared behind //go:build integration.").PlaceHolder("TAGS").String()
+		assertInterface = generateCmd.Flag("assert-interface", "Additionally generate a compile-time assertion like var _ pkg.Display = (*MockDisplay)(nil), so a mock that no longer matches its interface fails to build. Skipped for generic interfaces.").Bool()
+		slim            = generateCmd.Flag("slim", "Generate mocks without verification: no VerifyWasCalled methods, Verifier and OngoingVerification types or <Method>Calls accessors. Roughly halves the size of mocks that are only stubbed.").Bool()
+		embedInterface  = generateCmd.Flag("embed-interface", "Embed the mocked interface in the mock struct, so methods added to the interface later don't break the build of the mock, but panic when called. Useful for interfaces of fast-moving third-party modules.").Bool()
+		archive         = generateCmd.Flag("archive", "Read the interface from the export data of this compiled package archive, e.g. built with go build -o store.a example.com/store, instead of from source. Requires the package path the archive was compiled for as first arg.").PlaceHolder("FILE").String()
+		inline          = generateCmd.Flag("inline", "Generate mocks of the interfaces declared in this Go source instead of reading them from a package, e.g. 'type Store interface { Get(string) ([]byte, error) }'. Packages it refers to are imported automatically.").PlaceHolder("SOURCE").String()
+		headerFile      = generateCmd.Flag("header-file", "Start the generated file with the content of this file, e.g. a license header. Lines that aren't comments yet are turned into // comments. The \"Code generated\" line follows it.").PlaceHolder("FILE").String()
+		useCache        = generateCmd.Flag("cache", "Skip generating mocks whose interfaces' packages, their dependencies and the command line didn't change since the last generation with --cache. The cache is kept in the user's cache directory.").Bool()
+		style           = generateCmd.Flag("style", "\"gomock\" generates mocks in the style of github.com/golang/mock, with NewMock<Interface>(ctrl) and EXPECT() recorders, backed by github.com/petergtz/pegomock/v4/gomock.").Default("pegomock").Enum("pegomock", "gomock")
+		allInterfaces   = generateCmd.Flag("all", "Treat the arg as a package and generate mocks for all its exported interfaces, each into its own mock_<interface>_test.go file, or mock_<interface>.go file in --output-dir, or all into one file if --output is given.").Bool()
+		jobs            = generateCmd.Flag("jobs", "Number of mocks generated concurrently with --all or --interfaces; defaults to the number of CPUs.").Short('j').Default(strconv.Itoa(runtime.NumCPU())).Int()
+		interfaces      = generateCmd.Flag("interfaces", "Treat args as package patterns like ./internal/... and generate mocks for all exported interfaces whose names match this regex, next to their interfaces, or each into its own mock_<interface>.go file in --output-dir.").PlaceHolder("REGEX").String()
+		checkOnly       = generateCmd.Flag("check", "Don't write the generated files, but fail if they differ from the files on disk, e.g. to make sure in CI that mocks are up to date.").Bool()
+		diffOnly        = generateCmd.Flag("diff", "Like --check, but also print a unified diff for every file that differs.").Bool()
+		directives      = generateCmd.Flag("directives", "Treat args as package patterns, ./... by default, and generate a mock next to every interface marked with a "+xtools_packages.DirectivePrefix+" comment. Flags following the comment apply to that mock only.").Bool()
+		configFile      = generateCmd.Flag("config", "Generate the mocks declared in this config file; defaults to "+config.FileName+" in the working directory if no args are given.").PlaceHolder("FILE").String()
+		generateCmdArgs = generateCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file. Without args, the mocks declared in the config file are generated.").Strings()
 
 		watchCmd       = app.Command("watch", "Watch over changes in interfaces and regenerate mocks if changes are detected.")
 		watchRecursive = watchCmd.Flag("recursive", "Recursively watch sub-directories as well.").Short('r').Bool()
-		watchPackages  = watchCmd.Arg("directories...", "One or more directories of Go packages to watch").Strings()
+		watchIgnore    = watchCmd.Flag("ignore", "Glob of directories to skip when watching recursively, matched against their names and their paths relative to the watched directory. Repeatable; replaces the defaults vendor and testdata.").PlaceHolder("GLOB").Default(watch.DefaultIgnorePatterns...).Strings()
+		watchCache     = watchCmd.Flag("cache", "Skip generating mocks whose inputs didn't change, like generate --cache, instead of generating all mocks on every poll.").Bool()
+		watchPackages  = watchCmd.Arg("directories...", "One or more directories of Go packages to watch. Directories ending in /..., like ./..., are watched recursively.").Strings()
 
 		removeMocks          = app.Command("remove", "Remove mocks generated by Pegomock")
 		removeRecursive      = removeMocks.Flag("recursive", "Remove recursively in all sub-directories").Default("false").Short('r').Bool()
 		removeNonInteractive = removeMocks.Flag("non-interactive", "Don't ask for confirmation. Useful for scripts.").Default("false").Short('n').Bool()
 		removeDryRun         = removeMocks.Flag("dry-run", "Just show what would be done. Don't delete anything.").Default("false").Short('d').Bool()
+		removePatterns       = removeMocks.Flag("pattern", "Only remove the generated files matching this glob, e.g. \"mock_display*_test.go\", matched against their names and their paths relative to the root directory. Repeatable.").PlaceHolder("GLOB").Strings()
+		removeMatchersDirs   = removeMocks.Flag("matchers-dir", "Name of directories holding generated matchers, which are removed too once empty. Repeatable; replaces the default matchers.").PlaceHolder("NAME").Default(remove.DefaultMatchersDirNames...).Strings()
 		removeSilent         = removeMocks.Flag("silent", "Don't write anything to standard out.").Default("false").Short('s').Bool()
 		removePath           = removeMocks.Arg("path", "Use as root directory instead of current working directory.").Default("").String()
+
+		checkCmd      = app.Command("check", "Report generated mocks whose interfaces changed since the mocks were generated, without regenerating them. Fails if any mock is stale.")
+		checkPatterns = checkCmd.Arg("packages", "Package patterns to look for mocks in; defaults to ./...").Default("./...").Strings()
+
+		doctorCmd      = app.Command("doctor", "Type-check generated mocks against the current declarations of their interfaces, report missing, extra and incompatible methods, and suggest commands regenerating the mocks. Fails if any mock doesn't match its interface.")
+		doctorPatterns = doctorCmd.Arg("packages", "Package patterns to look for mocks in; defaults to ./...").Default("./...").Strings()
+
+		snapshotCmd    = app.Command("snapshot-interface", "Print a snapshot of the method set of an interface to stdout, e.g. to store it in a lock file.")
+		snapshotCheck  = snapshotCmd.Flag("check", "Instead of printing the snapshot, compare the interface with this lock file and fail if it changed.").String()
+		snapshotFormat = snapshotCmd.Flag("format", "\"lock\" prints the method set for lock files, \"json\" the serialized model of the interface for compat-report.").Default("lock").Enum("lock", "json")
+		snapshotArgs   = snapshotCmd.Arg("args", "A (optional) Go package path + space-separated interface").Required().Strings()
+
+		modelCmd        = app.Command("model", "Print the parsed model of interfaces as JSON, e.g. for external generators, documentation tools or audits.")
+		modelOutput     = modelCmd.Flag("output", "Output file; defaults to stdout.").Short('o').String()
+		modelPackage    = modelCmd.Arg("package", "The Go package path of the interfaces").Required().String()
+		modelInterfaces = modelCmd.Arg("interfaces", "The interfaces to include; defaults to all exported interfaces of the package").Strings()
+
+		compatCmd = app.Command("compat-report", "Classify the changes between two JSON snapshots of an interface as mock-breaking or benign, and list the generated mocks and verification call sites they affect.")
+		compatDir = compatCmd.Flag("dir", "Directory to search for generated mocks and call sites.").Default(".").String()
+		compatOld = compatCmd.Arg("old", "JSON snapshot of the previous interface, as printed by snapshot-interface --format json").Required().String()
+		compatNew = compatCmd.Arg("new", "JSON snapshot of the changed interface").Required().String()
+
+		extractCmd       = app.Command("extract-interface", "Extract a minimal interface for a concrete type from how consumer packages use it, make them depend on it, and generate a mock for it.")
+		extractMethods   = extractCmd.Flag("methods", "\"used-by\" includes the methods each consumer package calls, \"all\" all exported methods of the type.").Default("used-by").Enum("used-by", "all")
+		extractName      = extractCmd.Flag("name", "Name of the extracted interface; defaults to the name of the concrete type.").String()
+		extractType      = extractCmd.Arg("type", "The concrete type, e.g. example.com/store.Store").Required().String()
+		extractConsumers = extractCmd.Arg("consumers", "Package patterns of the consumer packages, e.g. ./service/...").Required().Strings()
+
+		wrapCmd  = app.Command("wrap-type", "Derive an interface from the exported methods of a concrete type, write it next to the type, and generate a mock for it.")
+		wrapName = wrapCmd.Flag("name", "Name of the interface; defaults to the name of the concrete type followed by \"Interface\".").String()
+		wrapType = wrapCmd.Arg("type", "The concrete type, e.g. example.com/store.Store").Required().String()
+
+		scaffoldCmd    = app.Command("scaffold-example", "Generate a small runnable example project with interfaces, mocks and tests showing how to use Pegomock.")
+		scaffoldDir    = scaffoldCmd.Flag("dir", "Directory of the example project. Must not exist yet or be empty.").Required().String()
+		scaffoldModule = scaffoldCmd.Flag("module", "Module path of the example project; defaults to example.com/<base name of dir>.").String()
 	)
 
 	app.Writer(out)
 	switch kingpin.MustParse(app.Parse(cliArgs[1:])) {
 
 	case generateCmd.FullCommand():
+		if *checkOnly || *diffOnly {
+			if *useCache {
+				app.FatalUsage("Cannot use --check or --diff together with --cache")
+			}
+			outputCheck := &filehandling.OutputCheck{}
+			restore := filehandling.CheckOutput(outputCheck)
+			defer func() {
+				restore()
+				if r := recover(); r != nil {
+					panic(r)
+				}
+				reportOutdated(outputCheck, *diffOnly, out, app)
+			}()
+		}
+		restoreBuildEnvironment, err := util.SetBuildEnvironment(*goos, *goarch, *tags)
+		if err != nil {
+			app.FatalUsage(err.Error())
+		}
+		defer restoreBuildEnvironment()
+		if *inline != "" && (len(*generateCmdArgs) > 0 || *directives || *configFile != "") {
+			app.FatalUsage("Cannot use --inline together with args, --directives or --config")
+		}
+		if *directives {
+			if *configFile != "" {
+				app.FatalUsage("Cannot use --directives and --config together")
+			}
+			patterns := *generateCmdArgs
+			if len(patterns) == 0 {
+				patterns = []string{"./..."}
+			}
+			found, err := xtools_packages.FindDirectives(patterns)
+			app.FatalIfError(err, "Could not find directives")
+			if len(found) == 0 {
+				app.Fatalf("No interfaces marked with %v found in %v", xtools_packages.DirectivePrefix, strings.Join(patterns, " "))
+			}
+			commands := make([]config.Command, len(found))
+			for i, directive := range found {
+				commands[i] = config.Command{Dir: directive.Dir, Args: append(append([]string{}, directive.Args...), directive.ImportPath, directive.Name)}
+			}
+			runGenerateCommands(commands, xtools_packages.DirectivePrefix+" directives", generateFlagArgs(cliArgs, generateCmd), out, in, app, ctx)
+			return
+		}
+		if len(*generateCmdArgs) == 0 && *inline == "" {
+			configPath := *configFile
+			if configPath == "" {
+				configPath = filepath.Join(workingDir, config.FileName)
+				if _, err := os.Stat(configPath); os.IsNotExist(err) {
+					app.FatalUsage("Expected args, or a %v in the working directory", config.FileName)
+				}
+			}
+			cfg, err := config.Load(configPath)
+			app.FatalIfError(err, "")
+			absConfigPath, err := filepath.Abs(configPath)
+			app.FatalIfError(err, "")
+			commands, err := cfg.Commands(filepath.Dir(absConfigPath))
+			app.FatalIfError(err, "")
+			runGenerateCommands(commands, configPath, generateFlagArgs(cliArgs, generateCmd), out, in, app, ctx)
+			return
+		}
+		if *configFile != "" {
+			app.FatalUsage("Cannot use --config together with args")
+		}
+
+		var options []mockgen.Option
+		// everything that affects the generated mocks besides the mocked packages
+		cacheSettings := append([]string{workingDir}, cliArgs[1:]...)
+		if *hybridFake {
+			options = append(options, mockgen.WithHybridFake())
+		}
+		if *recorder {
+			options = append(options, mockgen.WithRecorder())
+		}
+		if *matchers == "embedded" {
+			options = append(options, mockgen.WithEmbeddedMatchers())
+		}
+		if len(*importAliases) > 0 {
+			if err := util.ValidateImportAliases(*importAliases); err != nil {
+				app.FatalUsage(err.Error())
+			}
+			options = append(options, mockgen.WithImportAliases(*importAliases))
+		}
+		defaultOptionNames, err := util.ParseDefaultOptions(*defaultOptions)
+		if err != nil {
+			app.FatalUsage(err.Error())
+		}
+		options = append(options, mockgen.WithDefaultOptions(defaultOptionNames))
+		if mockgen.APILevel(*apiLevel) == mockgen.APILevelV3 && (*hybridFake || *recorder || len(defaultOptionNames) > 0) {
+			app.FatalUsage("Cannot use --fake, --recorder or --default-options together with --api-level v3")
+		}
+		options = append(options, mockgen.WithAPILevel(mockgen.APILevel(*apiLevel)))
+		if *plugin != "" {
+			if *hybridFake || *recorder || *matchers != "none" || len(defaultOptionNames) > 0 || *assertOnly != "" || *slim || *nullObject != "none" || *outputTemplate != "" {
+				app.FatalUsage("Cannot use --plugin together with --fake, --recorder, --matchers, --default-options, --assert-implements-only, --slim, --null-object or --template")
+			}
+			pluginPath, err := util.LookupPlugin(*plugin)
+			app.FatalIfError(err, "")
+			pluginInfo, err := os.Stat(pluginPath)
+			app.FatalIfError(err, "Could not find plugin")
+			options = append(options, mockgen.WithPlugin(pluginPath))
+			// a rebuilt plugin generates other output
+			cacheSettings = append(cacheSettings, fmt.Sprint(pluginPath, pluginInfo.Size(), pluginInfo.ModTime().UnixNano()))
+		}
+		if *outputTemplate != "" {
+			if *hybridFake || *recorder || *matchers != "none" || len(defaultOptionNames) > 0 || *assertOnly != "" || *slim || *nullObject != "none" {
+				app.FatalUsage("Cannot use --template together with --fake, --recorder, --matchers, --default-options, --assert-implements-only, --slim or --null-object")
+			}
+			tmpl, err := os.ReadFile(*outputTemplate)
+			app.FatalIfError(err, "Could not read template")
+			options = append(options, mockgen.WithOutputTemplate(string(tmpl)))
+			cacheSettings = append(cacheSettings, string(tmpl))
+		}
+		if *nameTemplate != "" {
+			if *mockNameOut != "" {
+				app.FatalUsage("Cannot use --name-template and --mock-name together")
+			}
+			if err := mockgen.ValidateNameTemplate(*nameTemplate); err != nil {
+				app.FatalUsage(err.Error())
+			}
+			options = append(options, mockgen.WithNameTemplate(*nameTemplate))
+		}
+		includeMethodsRegex, err := util.ParseMethodFilter(*includeMethods)
+		if err != nil {
+			app.FatalUsage(err.Error())
+		}
+		excludeMethodsRegex, err := util.ParseMethodFilter(*excludeMethods)
+		if err != nil {
+			app.FatalUsage(err.Error())
+		}
+		options = append(options, mockgen.WithMethodFilter(includeMethodsRegex, excludeMethodsRegex))
+		buildConstraint, err := util.ParseBuildTags(*buildTags)
+		if err != nil {
+			app.FatalUsage(err.Error())
+		}
+		options = append(options, mockgen.WithBuildConstraint(buildConstraint))
+		if *headerFile != "" {
+			header, err := os.ReadFile(*headerFile)
+			app.FatalIfError(err, "Could not read header file")
+			options = append(options, mockgen.WithHeader(string(header)))
+			cacheSettings = append(cacheSettings, string(header))
+		}
+		if *assertInterface {
+			options = append(options, mockgen.WithInterfaceAssertion())
+		}
+		if *embedInterface {
+			options = append(options, mockgen.WithEmbeddedInterface())
+		}
+		if *slim {
+			options = append(options, mockgen.WithSlimMocks())
+		}
+		switch *nullObject {
+		case "alongside":
+			options = append(options, mockgen.WithNullObject())
+		case "only":
+			if *hybridFake || *recorder || *matchers != "none" || len(defaultOptionNames) > 0 || *slim || *assertInterface || *embedInterface {
+				app.FatalUsage("Cannot use --null-object only together with --fake, --recorder, --matchers, --default-options, --slim, --assert-interface or --embed-interface")
+			}
+			options = append(options, mockgen.WithNullObjectOnly())
+		}
+		if *style == "gomock" {
+			if *hybridFake || *recorder || *matchers != "none" || len(defaultOptionNames) > 0 || *outputTemplate != "" || *plugin != "" ||
+				*assertOnly != "" || mockgen.APILevel(*apiLevel) == mockgen.APILevelV3 || *slim || *nullObject != "none" {
+				app.FatalUsage("Cannot use --style gomock together with --fake, --recorder, --matchers, --default-options, --template, --plugin, --assert-implements-only, --api-level v3, --slim or --null-object")
+			}
+			options = append(options, mockgen.WithGomockStyle())
+		}
+
+		generate := func(outputFilePath string, importPath string, generateMock func()) { generateMock() }
+		if *useCache {
+			if *archive != "" || *assertOnly != "" {
+				app.FatalUsage("Cannot use --cache together with --archive or --assert-implements-only")
+			}
+			cacheDir, err := cache.DefaultDir()
+			app.FatalIfError(err, "Could not determine cache directory")
+			mockCache := cache.New(cacheDir)
+			generate = func(outputFilePath string, importPath string, generateMock func()) {
+				mockCache.Generate(outputFilePath, importPath, cacheSettings, generateMock)
+			}
+		}
+
+		if *archive != "" && (*allInterfaces || *interfaces != "" || *assertOnly != "") {
+			app.FatalUsage("Cannot use --archive together with --all, --interfaces or --assert-implements-only")
+		}
+		if *archive != "" && len(*generateCmdArgs) != 2 {
+			app.FatalUsage("--archive expects the package path the archive was compiled for and an interface, but got %v", strings.Join(*generateCmdArgs, " "))
+		}
+
+		if *inline != "" {
+			if *allInterfaces || *interfaces != "" || *archive != "" || *assertOnly != "" || *useCache {
+				app.FatalUsage("Cannot use --inline together with --all, --interfaces, --archive, --assert-implements-only or --cache")
+			}
+			if *destination != "" && *destinationDir != "" {
+				app.FatalUsage("Cannot use --output and --output-dir together")
+			}
+			realPackageOut, outputDir := *packageOut, workingDir
+			if *destinationDir != "" {
+				outputDir, err = filepath.Abs(*destinationDir)
+				app.FatalIfError(err, "")
+			} else if *destination != "" {
+				outputDir, err = filepath.Abs(filepath.Dir(*destination))
+				app.FatalIfError(err, "")
+			}
+			if realPackageOut == "" && *destinationDir != "" {
+				realPackageOut, err = DetermineOutputPackageNameIn(outputDir)
+				app.FatalIfError(err, "Could not determine package name.")
+			}
+			if realPackageOut == "" {
+				realPackageOut, err = DeterminePackageNameIn(workingDir)
+				app.FatalIfError(err, "Could not determine package name.")
+			}
+			realSelfPackage := outputSelfPackage(*selfPackage, outputDir, realPackageOut)
+			// the interfaces are declared as if in the package of the generated code
+			inlinePackagePath := realSelfPackage
+			if inlinePackagePath == "" {
+				inlinePackagePath = realPackageOut
+			}
+			models, err := xtools_packages.GenerateModelsFromSource(*inline, inlinePackagePath, realPackageOut)
+			app.FatalIfError(err, "Could not parse --inline")
+
+			realDestination := *destination
+			if realDestination == "" {
+				if len(models) > 1 {
+					app.FatalUsage("--inline declares several interfaces. Use --output to name the file of their mocks.")
+				}
+				realDestination = filehandling.OutputFilePath([]string{models[0].Interfaces[0].Name}, outputDir, "")
+				if *destinationDir != "" {
+					realDestination = outputDirMockFilePath(outputDir, models[0].Interfaces[0].Name)
+				}
+			}
+			if *mockNameOut != "" && len(models) > 1 {
+				app.FatalUsage("Cannot use --mock-name for several interfaces")
+			}
+			app.FatalIfError(os.MkdirAll(filepath.Dir(realDestination), 0755), "Could not create output directory")
+			filehandling.GenerateInlineMocksFile(models, realDestination, *mockNameOut, realPackageOut, realSelfPackage, *debugParser, out, options...)
+			return
+		}
+
+		if *allInterfaces {
+			if len(*generateCmdArgs) != 1 {
+				app.FatalUsage("--all expects exactly one package, but got %v", strings.Join(*generateCmdArgs, " "))
+			}
+			if *destination != "" && *destinationDir != "" {
+				app.FatalUsage("Cannot use --output and --output-dir together")
+			}
+			if *interfaces != "" || *mockNameOut != "" || *assertOnly != "" {
+				app.FatalUsage("Cannot use --all together with --interfaces, --mock-name or --assert-implements-only")
+			}
+			refs, err := xtools_packages.FindInterfaces(*generateCmdArgs, ".*")
+			app.FatalIfError(err, "Could not find interfaces")
+			if len(refs) == 0 {
+				app.Fatalf("No exported interfaces found in %v", (*generateCmdArgs)[0])
+			}
+			if refs[0].ImportPath != refs[len(refs)-1].ImportPath {
+				app.FatalUsage("--all expects exactly one package, but %v matches several. Use --interfaces '.*' for package patterns.", (*generateCmdArgs)[0])
+			}
+
+			realPackageOut := *packageOut
+			realDestinationDir := workingDir
+			if *destinationDir != "" {
+				realDestinationDir, err = filepath.Abs(*destinationDir)
+				app.FatalIfError(err, "")
+				app.FatalIfError(os.MkdirAll(realDestinationDir, 0755), "Could not create output directory")
+				if realPackageOut == "" {
+					realPackageOut, err = DetermineOutputPackageNameIn(realDestinationDir)
+					app.FatalIfError(err, "Could not determine package name.")
+				}
+			}
+			if realPackageOut == "" {
+				realPackageOut, err = DeterminePackageNameIn(workingDir)
+				app.FatalIfError(err, "Could not determine package name.")
+			}
+
+			if *destination != "" {
+				interfaceNames := make([]string, len(refs))
+				for i, ref := range refs {
+					interfaceNames[i] = ref.Name
+				}
+				realSelfPackage := outputSelfPackage(*selfPackage, filepath.Dir(*destination), realPackageOut)
+				generate(*destination, refs[0].ImportPath, func() {
+					filehandling.GenerateMocksFile(refs[0].ImportPath, interfaceNames, *destination, realPackageOut, realSelfPackage, *debugParser, out, options...)
+				})
+				return
+			}
+			realSelfPackage := outputSelfPackage(*selfPackage, realDestinationDir, realPackageOut)
+			syncOut := util.SyncWriter(out)
+			tasks := make([]func(), len(refs))
+			for i, ref := range refs {
+				ref, realDestination := ref, ""
+				if *destinationDir != "" {
+					realDestination = outputDirMockFilePath(realDestinationDir, ref.Name)
+				}
+				args := []string{ref.ImportPath, ref.Name}
+				tasks[i] = func() {
+					generate(filehandling.OutputFilePath(args, realDestinationDir, realDestination), ref.ImportPath, func() {
+						filehandling.GenerateMockFileInOutputDir(
+							args,
+							realDestinationDir,
+							realDestination,
+							"",
+							realPackageOut,
+							realSelfPackage,
+							*debugParser,
+							syncOut,
+							options...)
+					})
+				}
+			}
+			util.RunInParallel(tasks, *jobs)
+			return
+		}
+
+		if *interfaces != "" {
+			if *destination != "" || *mockNameOut != "" || *assertOnly != "" {
+				app.FatalUsage("Cannot use --interfaces together with --output, --mock-name or --assert-implements-only")
+			}
+			refs, err := xtools_packages.FindInterfaces(*generateCmdArgs, *interfaces)
+			app.FatalIfError(err, "Could not find interfaces")
+			if len(refs) == 0 {
+				app.Fatalf("No exported interfaces matching %q found in %v", *interfaces, strings.Join(*generateCmdArgs, " "))
+			}
+
+			realDestinationDir := ""
+			if *destinationDir != "" {
+				packagesByInterfaceName := make(map[string]string, len(refs))
+				for _, ref := range refs {
+					if importPath, exists := packagesByInterfaceName[ref.Name]; exists {
+						app.FatalUsage("Cannot generate the mocks of %v.%v and %v.%v into the same --output-dir, because their files would be named the same", importPath, ref.Name, ref.ImportPath, ref.Name)
+					}
+					packagesByInterfaceName[ref.Name] = ref.ImportPath
+				}
+				realDestinationDir, err = filepath.Abs(*destinationDir)
+				app.FatalIfError(err, "")
+				app.FatalIfError(os.MkdirAll(realDestinationDir, 0755), "Could not create output directory")
+			}
+			syncOut := util.SyncWriter(out)
+			tasks := make([]func(), len(refs))
+			for i, ref := range refs {
+				ref, dir, realDestination, realPackageOut := ref, ref.Dir, "", *packageOut
+				if realDestinationDir != "" {
+					dir, realDestination = realDestinationDir, outputDirMockFilePath(realDestinationDir, ref.Name)
+				}
+				if realPackageOut == "" && realDestinationDir != "" {
+					realPackageOut, err = DetermineOutputPackageNameIn(realDestinationDir)
+					app.FatalIfError(err, "Could not determine package name.")
+				}
+				if realPackageOut == "" {
+					realPackageOut, err = DeterminePackageNameIn(ref.Dir)
+					app.FatalIfError(err, "Could not determine package name.")
+				}
+				realSelfPackage := outputSelfPackage(*selfPackage, dir, realPackageOut)
+				args := []string{ref.ImportPath, ref.Name}
+				tasks[i] = func() {
+					generate(filehandling.OutputFilePath(args, dir, realDestination), ref.ImportPath, func() {
+						filehandling.GenerateMockFileInOutputDir(
+							args,
+							dir,
+							realDestination,
+							"",
+							realPackageOut,
+							realSelfPackage,
+							*debugParser,
+							syncOut,
+							options...)
+					})
+				}
+			}
+			util.RunInParallel(tasks, *jobs)
+			return
+		}
+
 		if err := util.ValidateArgs(*generateCmdArgs); err != nil {
 			app.FatalUsage(err.Error())
 		}
@@ -96,20 +548,44 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 			realDestinationDir, err = filepath.Abs(*destinationDir)
 			app.FatalIfError(err, "")
 			if *packageOut == "" {
-				realPackageOut = filepath.Base(*destinationDir)
+				realPackageOut, err = DetermineOutputPackageNameIn(realDestinationDir)
+				app.FatalIfError(err, "Could not determine package name.")
 			}
-			realDestination = filepath.Join(*destinationDir, "mock_"+strings.ToLower(sourceArgs[len(sourceArgs)-1])+".go")
+			realDestination = outputDirMockFilePath(*destinationDir, sourceArgs[len(sourceArgs)-1])
 		}
+		outputDir := realDestinationDir
+		if realDestination != "" {
+			outputDir = filepath.Dir(realDestination)
+		}
+		realSelfPackage := outputSelfPackage(*selfPackage, outputDir, realPackageOut)
 
-		filehandling.GenerateMockFileInOutputDir(
-			sourceArgs,
-			realDestinationDir,
-			realDestination,
-			*mockNameOut,
-			realPackageOut,
-			*selfPackage,
-			*debugParser,
-			out)
+		if *assertOnly != "" {
+			if realDestination == "" {
+				realDestination = filepath.Join(realDestinationDir, strings.ToLower(sourceArgs[len(sourceArgs)-1])+"_conformance_test.go")
+			}
+			filehandling.GenerateConformanceFile(sourceArgs, realDestination, *assertOnly, realPackageOut, realSelfPackage)
+			return
+		}
+
+		if *archive != "" {
+			realDestination = filehandling.OutputFilePath(sourceArgs, realDestinationDir, realDestination)
+			app.FatalIfError(os.MkdirAll(filepath.Dir(realDestination), 0755), "Could not create output directory")
+			filehandling.GenerateMockFileFromArchive(*archive, sourceArgs, realDestination, *mockNameOut, realPackageOut, realSelfPackage, *debugParser, out, options...)
+			return
+		}
+
+		generate(filehandling.OutputFilePath(sourceArgs, realDestinationDir, realDestination), sourceArgs[0], func() {
+			filehandling.GenerateMockFileInOutputDir(
+				sourceArgs,
+				realDestinationDir,
+				realDestination,
+				*mockNameOut,
+				realPackageOut,
+				realSelfPackage,
+				*debugParser,
+				out,
+				options...)
+		})
 
 	case watchCmd.FullCommand():
 		var targetPaths []string
@@ -119,7 +595,18 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 			targetPaths = *watchPackages
 		}
 		watch.CreateWellKnownInterfaceListFilesIfNecessary(targetPaths)
-		util.Ticker(watch.NewMockFileUpdater(targetPaths, *watchRecursive).Update, 2*time.Second, ctx)
+		for _, pattern := range *watchIgnore {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				app.FatalUsage("Invalid --ignore pattern %q: %v", pattern, err)
+			}
+		}
+		updater := watch.NewMockFileUpdater(targetPaths, *watchRecursive).WithIgnorePatterns(*watchIgnore)
+		if *watchCache {
+			cacheDir, err := cache.DefaultDir()
+			app.FatalIfError(err, "Could not determine cache directory")
+			updater.WithCache(cache.New(cacheDir))
+		}
+		util.Ticker(updater.Update, 2*time.Second, ctx)
 
 	case removeMocks.FullCommand():
 		path := *removePath
@@ -128,6 +615,312 @@ func Run(cliArgs []string, out io.Writer, in io.Reader, app *kingpin.Application
 			path, e = os.Getwd()
 			app.FatalIfError(e, "Could not get current working directory")
 		}
-		remove.Remove(path, *removeRecursive, !*removeNonInteractive, *removeDryRun, *removeSilent, out, in, os.Remove)
+		for _, pattern := range *removePatterns {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				app.FatalUsage("Invalid --pattern %q: %v", pattern, err)
+			}
+		}
+		remove.Remove(path, *removeRecursive, *removePatterns, *removeMatchersDirs, !*removeNonInteractive, *removeDryRun, *removeSilent, out, in, os.Remove)
+
+	case checkCmd.FullCommand():
+		results, err := staleness.Check(*checkPatterns)
+		app.FatalIfError(err, "Could not check mocks")
+		stale := 0
+		for _, result := range results {
+			switch {
+			case result.Err != nil:
+				stale++
+				fmt.Fprintf(out, "%v: could not check mock for %v.%v: %v\n", result.File, result.ImportPath, result.InterfaceName, result.Err)
+			case result.Stale:
+				stale++
+				fmt.Fprintf(out, "%v: mock for %v.%v is stale: the interface changed since the mock was generated\n", result.File, result.ImportPath, result.InterfaceName)
+			}
+		}
+		if stale > 0 {
+			app.Fatalf("%v of %v mocks are stale or could not be checked. Please regenerate them.", stale, len(results))
+		}
+		fmt.Fprintf(out, "All %v mocks are up to date\n", len(results))
+
+	case doctorCmd.FullCommand():
+		diagnoses, err := doctor.Examine(*doctorPatterns)
+		app.FatalIfError(err, "Could not examine mocks")
+		unhealthy := 0
+		for _, diagnosis := range diagnoses {
+			if diagnosis.Healthy() {
+				continue
+			}
+			unhealthy++
+			fmt.Fprintf(out, "%v: %v doesn't match %v.%v:\n", diagnosis.File, diagnosis.TypeName, diagnosis.ImportPath, diagnosis.InterfaceName)
+			for _, problem := range diagnosis.Problems {
+				fmt.Fprintf(out, "\t%v\n", problem)
+			}
+			fmt.Fprintf(out, "\tRegenerate it with: %v\n", diagnosis.RegenerateCommand(workingDir))
+		}
+		if unhealthy > 0 {
+			app.Fatalf("%v of %v mocks don't match their interfaces.", unhealthy, len(diagnoses))
+		}
+		fmt.Fprintf(out, "All %v mocks match their interfaces\n", len(diagnoses))
+
+	case snapshotCmd.FullCommand():
+		sourceArgs, err := util.SourceArgs(*snapshotArgs)
+		if err != nil {
+			app.FatalUsage(err.Error())
+		}
+		pkg, err := xtools_packages.GenerateModel(sourceArgs[0], sourceArgs[1])
+		app.FatalIfError(err, "Could not load interface")
+		currentSnapshot := snapshot.Take(sourceArgs[0], pkg.Interfaces[0])
+
+		if *snapshotFormat == "json" {
+			if *snapshotCheck != "" {
+				app.FatalUsage("Cannot use --check together with --format json")
+			}
+			jsonSnapshot, err := snapshot.TakeJSON(sourceArgs[0], pkg.Interfaces[0])
+			app.FatalIfError(err, "Could not serialize interface")
+			_, err = os.Stdout.Write(append(jsonSnapshot, '\n'))
+			app.FatalIfError(err, "")
+			return
+		}
+		if *snapshotCheck == "" {
+			_, err = os.Stdout.Write(currentSnapshot)
+			app.FatalIfError(err, "")
+			return
+		}
+		lockedSnapshot, err := os.ReadFile(*snapshotCheck)
+		app.FatalIfError(err, "Could not read lock file")
+		if diffs := snapshot.Diff(lockedSnapshot, currentSnapshot); len(diffs) > 0 {
+			app.Fatalf("Interface %v changed compared to %v:\n%v", strings.Join(sourceArgs, " "), *snapshotCheck, strings.Join(diffs, "\n"))
+		}
+
+	case modelCmd.FullCommand():
+		interfaceNames := *modelInterfaces
+		importPath := *modelPackage
+		if len(interfaceNames) == 0 {
+			refs, err := xtools_packages.FindInterfaces([]string{*modelPackage}, ".*")
+			app.FatalIfError(err, "Could not find interfaces")
+			if len(refs) == 0 {
+				app.Fatalf("No exported interfaces found in %v", *modelPackage)
+			}
+			if refs[0].ImportPath != refs[len(refs)-1].ImportPath {
+				app.FatalUsage("model expects exactly one package, but %v matches several.", *modelPackage)
+			}
+			importPath = refs[0].ImportPath
+			for _, ref := range refs {
+				interfaceNames = append(interfaceNames, ref.Name)
+			}
+		}
+		modelJSON, err := json.MarshalIndent(modelOf(importPath, interfaceNames, len(*modelInterfaces) == 0, app), "", "  ")
+		app.FatalIfError(err, "Could not serialize model")
+		modelJSON = append(modelJSON, '\n')
+		if *modelOutput == "" {
+			_, err = os.Stdout.Write(modelJSON)
+			app.FatalIfError(err, "")
+			return
+		}
+		app.FatalIfError(os.WriteFile(*modelOutput, modelJSON, 0644), "Could not write model")
+
+	case compatCmd.FullCommand():
+		oldInterface, err := readJSONSnapshot(*compatOld)
+		app.FatalIfError(err, "Could not read %v", *compatOld)
+		newInterface, err := readJSONSnapshot(*compatNew)
+		app.FatalIfError(err, "Could not read %v", *compatNew)
+		report, err := compat.Analyze(oldInterface, newInterface, *compatDir)
+		app.FatalIfError(err, "Could not analyze %v", *compatDir)
+		report.Write(out)
+
+	case extractCmd.FullCommand():
+		results, err := extract.Interface(*extractType, *extractConsumers, *extractName, *extractMethods == "all")
+		app.FatalIfError(err, "Could not extract interface")
+		if len(results) == 0 {
+			app.Fatalf("None of the packages %v uses %v", strings.Join(*extractConsumers, " "), *extractType)
+		}
+		for _, result := range results {
+			fmt.Fprintf(out, "Extracted interface %v into %v\n", result.InterfaceName, result.InterfaceFile)
+			for _, file := range result.RewrittenFiles {
+				fmt.Fprintf(out, "Changed %v to depend on %v\n", file, result.InterfaceName)
+			}
+			packageName, err := DeterminePackageNameIn(result.Dir)
+			app.FatalIfError(err, "Could not determine package name.")
+			filehandling.GenerateMockFileInOutputDir(
+				[]string{result.ImportPath, result.InterfaceName},
+				result.Dir,
+				"",
+				"",
+				packageName,
+				"",
+				false,
+				out)
+		}
+
+	case wrapCmd.FullCommand():
+		result, err := extract.Wrap(*wrapType, *wrapName)
+		app.FatalIfError(err, "Could not wrap type")
+		fmt.Fprintf(out, "Wrote interface %v into %v\n", result.InterfaceName, result.InterfaceFile)
+		packageName, err := DeterminePackageNameIn(result.Dir)
+		app.FatalIfError(err, "Could not determine package name.")
+		filehandling.GenerateMockFileInOutputDir(
+			[]string{result.ImportPath, result.InterfaceName},
+			result.Dir,
+			"",
+			"",
+			packageName,
+			"",
+			false,
+			out)
+
+	case scaffoldCmd.FullCommand():
+		project, err := scaffold.Example(*scaffoldDir, *scaffoldModule)
+		app.FatalIfError(err, "Could not scaffold example project")
+		for _, file := range project.Files {
+			fmt.Fprintf(out, "Created %v\n", file)
+		}
+		// the mocks are generated like "go generate" would, from within the new module
+		app.FatalIfError(os.Chdir(project.Dir), "")
+		defer os.Chdir(workingDir)
+		packageName, err := DeterminePackageNameIn(project.Dir)
+		app.FatalIfError(err, "Could not determine package name.")
+		for _, iface := range project.Interfaces {
+			filehandling.GenerateMockFileInOutputDir(
+				[]string{project.ModulePath, iface},
+				project.Dir,
+				"",
+				"",
+				packageName,
+				"",
+				false,
+				out)
+			fmt.Fprintf(out, "Generated mock for %v\n", iface)
+		}
+		fmt.Fprintf(out, "\nTo run the example's tests:\n\n\tcd %v\n\tgo mod tidy\n\tgo test ./...\n", *scaffoldDir)
+	}
+}
+
+// runGenerateCommands runs "pegomock generate" for all commands, e.g. of the targets of a config
+// file, adding flagArgs, the flags given on the command line, to each. Failing commands don't keep
+// the others from being run. source describes where the commands come from.
+func runGenerateCommands(commands []config.Command, source string, flagArgs []string, out io.Writer, in io.Reader, app *kingpin.Application, ctx context.Context) {
+	failed := 0
+	for _, command := range commands {
+		command := command
+		args := append(append([]string{"pegomock", "generate"}, command.Args...), flagArgs...)
+		util.WithinWorkingDir(command.Dir, func(string) {
+			defer func() {
+				if r := recover(); r != nil {
+					failed++
+					fmt.Fprintf(out, "Failed to generate %v in %v: %v\n", strings.Join(args[2:], " "), command.Dir, r)
+				}
+			}()
+			// every command needs its own application, because Run declares its flags
+			commandApp := kingpin.New(app.Name, app.Help)
+			commandApp.Terminate(func(int) { panic("invalid command line") })
+			Run(args, out, in, commandApp, ctx)
+		})
+	}
+	if failed > 0 {
+		app.Fatalf("%v of %v commands of %v failed", failed, len(commands), source)
+	}
+}
+
+// generateFlagArgs returns the flags given to generateCmd in cliArgs with their values, except
+// --config and --directives, which select the commands to run, --check and --diff, and except the
+// args.
+func generateFlagArgs(cliArgs []string, generateCmd *kingpin.CmdClause) []string {
+	takesValue := make(map[string]bool)
+	for _, flag := range generateCmd.Model().Flags {
+		takesValue["--"+flag.Name] = !flag.IsBoolFlag()
+		if flag.Short != 0 {
+			takesValue["-"+string(flag.Short)] = !flag.IsBoolFlag()
+		}
+	}
+	var flagArgs []string
+	for i := 2; i < len(cliArgs); i++ {
+		name := strings.SplitN(cliArgs[i], "=", 2)[0]
+		if !strings.HasPrefix(name, "-") {
+			continue
+		}
+		flag := []string{cliArgs[i]}
+		if takesValue[name] && name == cliArgs[i] && i+1 < len(cliArgs) {
+			flag = append(flag, cliArgs[i+1])
+			i++
+		}
+		switch strings.TrimPrefix(strings.TrimPrefix(name, "--"), "no-") {
+		case "config", "directives", "check", "diff":
+			// the commands run within this one's check, if any
+		default:
+			flagArgs = append(flagArgs, flag...)
+		}
+	}
+	return flagArgs
+}
+
+// reportOutdated fails if generated files differ from the files on disk, listing them, or printing
+// their diffs if printDiffs is set.
+func reportOutdated(outputCheck *filehandling.OutputCheck, printDiffs bool, out io.Writer, app *kingpin.Application) {
+	outdated := outputCheck.Outdated()
+	if len(outdated) == 0 {
+		return
+	}
+	for _, file := range outdated {
+		if printDiffs {
+			fmt.Fprint(out, outputCheck.Diff(file))
+		} else {
+			fmt.Fprintf(out, "%v is out of date\n", file)
+		}
+	}
+	app.Fatalf("%v generated files are out of date. Run the command without --check or --diff to update them.", len(outdated))
+}
+
+// outputDirMockFilePath returns the file a mock of interfaceName is generated into with --output-dir.
+// The mock is part of that directory's package, so the file must not end with _test.go.
+func outputDirMockFilePath(outputDir, interfaceName string) string {
+	return filepath.Join(outputDir, "mock_"+strings.ToLower(interfaceName)+".go")
+}
+
+// outputSelfPackage returns the import path of the package that mocks generated into dir as
+// package packageName are part of, so that they refer to its types without importing it. Mocks in
+// a _test package are never part of the package next to them.
+func outputSelfPackage(selfPackage string, dir string, packageName string) string {
+	if selfPackage != "" || strings.HasSuffix(packageName, "_test") {
+		return selfPackage
+	}
+	return DetermineImportPathOf(dir)
+}
+
+func readJSONSnapshot(path string) (*snapshot.Interface, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return snapshot.ParseJSON(content)
+}
+
+// modelOf merges the models of the interfaces of the package at importPath into one. With
+// skipTestPackage, interfaces declared in the external test package are left out.
+func modelOf(importPath string, interfaceNames []string, skipTestPackage bool, app *kingpin.Application) *model.Package {
+	var merged *model.Package
+	dotImports := map[string]bool{}
+	for _, interfaceName := range interfaceNames {
+		pkg, err := xtools_packages.GenerateModel(importPath, interfaceName)
+		app.FatalIfError(err, "Could not load interface %v", interfaceName)
+		if skipTestPackage && pkg.PkgPath != importPath && strings.HasSuffix(pkg.PkgPath, "_test") {
+			continue
+		}
+		if merged == nil {
+			merged = &model.Package{Name: pkg.Name, PkgPath: pkg.PkgPath}
+		}
+		if pkg.PkgPath != merged.PkgPath {
+			app.Fatalf("Interface %v is declared in package %v, but %v in %v. Print their models separately.",
+				interfaceName, pkg.PkgPath, merged.Interfaces[0].Name, merged.PkgPath)
+		}
+		merged.Interfaces = append(merged.Interfaces, pkg.Interfaces...)
+		for _, dotImport := range pkg.DotImports {
+			if !dotImports[dotImport] {
+				dotImports[dotImport] = true
+				merged.DotImports = append(merged.DotImports, dotImport)
+			}
+		}
+	}
+	if merged == nil {
+		app.Fatalf("No exported interfaces found in %v", importPath)
 	}
+	return merged
 }
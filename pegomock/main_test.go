@@ -17,7 +17,9 @@ package main_test
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
@@ -26,6 +28,8 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/mockgen"
+	"github.com/petergtz/pegomock/v4/model"
 	main "github.com/petergtz/pegomock/v4/pegomock"
 
 	. "github.com/petergtz/pegomock/v4/pegomock/testutil"
@@ -114,6 +118,14 @@ var _ = Describe("CLI", func() {
 					BeAnExistingFile(),
 					BeAFileContainingSubString("MockMyDisplay")))
 			})
+
+			It(`generates a constructor that checks the mock when the test finishes`, func() {
+				main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func NewMockMyDisplayWithT(t testing.TB, options ...pegomock.Option) *MockMyDisplay {")))
+			})
 		})
 
 		Context(`with args "pegomocktest/subpackage SubDisplay"`, func() {
@@ -157,6 +169,44 @@ var _ = Describe("CLI", func() {
 			})
 		})
 
+		Context("with args --output-dir of another package than the one with the interface", func() {
+			BeforeEach(func() {
+				WriteFile(joinPath(packageDir, "repo.go"),
+					"package pegomocktest; type Entity interface { ID() string }; type Record struct{}; type Repo[T Entity] interface { Put(r Record, entity T) }")
+			})
+
+			It(`generates the mocks into the package of the interface without importing it`, func() {
+				main.Run(cmd("pegomock generate Repo --output-dir ."), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_repo.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package pegomocktest\n"),
+					BeAFileContainingSubString("type MockRepo[T Entity] struct"),
+					BeAFileContainingSubString("Put(r Record, entity T)"),
+					Not(BeAFileContainingSubString(`pegomocktest "pegomocktest"`))))
+			})
+
+			It(`generates the mocks into a package of another module, importing the package of the interface`, func() {
+				mocksModuleDir := joinPath(packageDir, "shared-mocks")
+				Expect(os.MkdirAll(mocksModuleDir, 0755)).To(Succeed())
+				WriteFile(joinPath(mocksModuleDir, "go.mod"),
+					`module example.com/shared-mocks
+					go 1.18
+					require pegomocktest v0.0.0
+					replace pegomocktest => ../`)
+				Expect(os.Chdir(mocksModuleDir)).To(Succeed())
+
+				main.Run(cmd("pegomock generate --output-dir . pegomocktest Repo"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(mocksModuleDir, "mock_repo.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package shared_mocks\n"),
+					BeAFileContainingSubString(`pegomocktest "pegomocktest"`),
+					BeAFileContainingSubString("type MockRepo[T pegomocktest.Entity] struct"),
+					BeAFileContainingSubString("Put(r pegomocktest.Record, entity T)")))
+			})
+		})
+
 		Context("with args --mock-name", func() {
 			It(`sets the mock name as given`, func() {
 				main.Run(cmd("pegomock generate MyDisplay --mock-name RenamedMock"), os.Stdout, os.Stdin, app, context.Background())
@@ -167,6 +217,866 @@ var _ = Describe("CLI", func() {
 			})
 		})
 
+		Context("with args --name-template", func() {
+			It(`names the mocks after the template`, func() {
+				main.Run(cmd("pegomock generate --all pegomocktest --name-template {{.Interface}}Fake -o mocks_test.go"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mocks_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("type MyDisplayFake struct {"),
+					BeAFileContainingSubString("type RequestHandlerFake struct {"),
+					Not(BeAFileContainingSubString("MockMyDisplay"))))
+			})
+
+			It(`reports an error for templates producing invalid names`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --name-template {{.Interface}}-fake"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring(`Name template "{{.Interface}}-fake" produces "Interface-fake", which is not a valid Go identifier`))
+			})
+		})
+
+		Context("with args --include-methods and --exclude-methods", func() {
+			BeforeEach(func() {
+				WriteFile(joinPath(packageDir, "store.go"),
+					"package pegomocktest; type Store interface { Get(key string) string; GetAll() []string; Put(key, value string); Delete(key string) }")
+			})
+
+			It(`mocks only the included methods and stubs the others`, func() {
+				main.Run(cmd("pegomock generate Store --include-methods Get.*,Put --exclude-methods GetAll"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_store_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func (verifier *VerifierMockStore) Get(key string) *MockStore_Get_OngoingVerification {"),
+					BeAFileContainingSubString("func (verifier *VerifierMockStore) Put(key string, value string) *MockStore_Put_OngoingVerification {"),
+					BeAFileContainingSubString("func (mock *MockStore) Delete(key string) {\n\tpanic(\"MockStore.Delete is not mocked"),
+					BeAFileContainingSubString("func (mock *MockStore) GetAll() []string {\n\tpanic(\"MockStore.GetAll is not mocked"),
+					Not(BeAFileContainingSubString("MockStore_Delete_OngoingVerification")),
+					Not(BeAFileContainingSubString("MockStore_GetAll_OngoingVerification"))))
+			})
+
+			It(`reports an error for invalid regexes`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate Store --exclude-methods Get("), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring(`Invalid method filter "Get("`))
+			})
+
+			It(`removes the imports the stubs don't need`, func() {
+				main.Run(cmd("pegomock generate Store --exclude-methods .*"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_store_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("import (\n\ttesting \"testing\"\n\t\"time\"\n\n\tpegomock \"github.com/petergtz/pegomock/v4\"\n)")))
+			})
+		})
+
+		Context("with args --build-tags", func() {
+			It(`adds the //go:build constraint to the generated file`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --build-tags linux,amd64"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("// Source: pegomocktest (interfaces: MyDisplay)\n\n//go:build linux && amd64\n\npackage pegomocktest_test")))
+			})
+
+			It(`reports an error for invalid constraints`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --build-tags linux&&"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring(`Invalid build tags "linux&&"`))
+			})
+		})
+
+		Context("with args --header-file", func() {
+			It(`starts the file with the header as comments, followed by the "Code generated" line`, func() {
+				WriteFile(joinPath(packageDir, "license.txt"), "Copyright 2026 Example Corp.\n\nLicensed under the MIT License.\n")
+
+				main.Run(cmd("pegomock generate MyDisplay --header-file license.txt"), os.Stdout, os.Stdin, app, context.Background())
+
+				content, e := os.ReadFile(joinPath(packageDir, "mock_mydisplay_test.go"))
+				Expect(e).NotTo(HaveOccurred())
+				Expect(string(content)).To(HavePrefix("// Copyright 2026 Example Corp.\n//\n// Licensed under the MIT License.\n\n" +
+					"// Code generated by pegomock. DO NOT EDIT.\n"))
+			})
+
+			It(`keeps headers that already are comments`, func() {
+				WriteFile(joinPath(packageDir, "license.txt"), "/*\n * Copyright 2026 Example Corp.\n */\n")
+
+				main.Run(cmd("pegomock generate MyDisplay --header-file license.txt"), os.Stdout, os.Stdin, app, context.Background())
+
+				content, e := os.ReadFile(joinPath(packageDir, "mock_mydisplay_test.go"))
+				Expect(e).NotTo(HaveOccurred())
+				Expect(string(content)).To(HavePrefix("/*\n * Copyright 2026 Example Corp.\n */\n\n// Code generated by pegomock. DO NOT EDIT.\n"))
+			})
+		})
+
+		Context("with args --check or --diff", func() {
+			BeforeEach(func() {
+				main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, context.Background())
+			})
+
+			It(`succeeds without output when the mocks are up to date`, func() {
+				var buf bytes.Buffer
+
+				main.Run(cmd("pegomock generate MyDisplay --check"), &buf, os.Stdin, app, context.Background())
+
+				Expect(buf.String()).To(BeEmpty())
+			})
+
+			It(`fails without writing files when a mock is out of date or missing`, func() {
+				WriteFile(joinPath(packageDir, "mydisplay.go"), "package pegomocktest; type MyDisplay interface {  Show(something string); Hide() }")
+				before, e := os.ReadFile(joinPath(packageDir, "mock_mydisplay_test.go"))
+				Expect(e).NotTo(HaveOccurred())
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock generate ./... --interfaces .*Display --check"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring(joinPath(packageDir, "mock_mydisplay_test.go") + " is out of date\n"))
+				Expect(buf.String()).To(ContainSubstring(joinPath(subPackageDir, "mock_subdisplay_test.go") + " is out of date\n"))
+				Expect(buf.String()).To(ContainSubstring("2 generated files are out of date."))
+				Expect(os.ReadFile(joinPath(packageDir, "mock_mydisplay_test.go"))).To(Equal(before))
+				Expect(joinPath(subPackageDir, "mock_subdisplay_test.go")).NotTo(BeAnExistingFile())
+			})
+
+			It(`prints a unified diff of the mocks that are out of date`, func() {
+				WriteFile(joinPath(packageDir, "mydisplay.go"), "package pegomocktest; type MyDisplay interface {  Show(something string); Hide() }")
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --diff"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(HavePrefix("--- " + joinPath(packageDir, "mock_mydisplay_test.go") + "\n+++ " + joinPath(packageDir, "mock_mydisplay_test.go") + "\n@@ -"))
+				Expect(buf.String()).To(ContainSubstring("\n+func (mock *MockMyDisplay) Hide() {\n"))
+				Expect(buf.String()).NotTo(ContainSubstring("\n-func (mock *MockMyDisplay) Show("))
+			})
+		})
+
+		Context("with args --directives", func() {
+			It(`generates mocks next to the interfaces marked with //pegomock:generate`, func() {
+				WriteFile(joinPath(packageDir, "marked.go"), "package pegomocktest\n\n"+
+					"//pegomock:generate --mock-name FakeStore\ntype Store interface { Get(key string) string }\n\n"+
+					"type Unmarked interface { Do() }\n")
+				WriteFile(joinPath(subPackageDir, "marked.go"), "package subpackage\n\n"+
+					"//pegomock:generate\ntype Cache interface { Put(key string) }\n")
+
+				main.Run(cmd("pegomock generate --directives --assert-interface"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_store_test.go")).To(SatisfyAll(
+					BeAFileContainingSubString("package pegomocktest_test"),
+					BeAFileContainingSubString("var _ pegomocktest.Store = (*FakeStore)(nil)")))
+				Expect(joinPath(subPackageDir, "mock_cache_test.go")).To(SatisfyAll(
+					BeAFileContainingSubString("package subpackage_test"),
+					BeAFileContainingSubString("var _ subpackage.Cache = (*MockCache)(nil)")))
+				Expect(joinPath(packageDir, "mock_unmarked_test.go")).NotTo(BeAnExistingFile())
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+			})
+
+			It(`reports an error when no interface is marked`, func() {
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock generate --directives ./..."), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("No interfaces marked with //pegomock:generate found in ./..."))
+			})
+		})
+
+		Context("without args", func() {
+			It(`generates the mocks declared in .pegomock.yaml`, func() {
+				WriteFile(joinPath(packageDir, ".pegomock.yaml"), `
+defaults:
+  assert-interface: true
+targets:
+  - interfaces: [MyDisplay]
+  - dir: subpackage
+    interfaces: [SubDisplay]
+    flags:
+      mock-name: FakeSubDisplay
+      assert-interface: false
+`)
+
+				main.Run(cmd("pegomock generate"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAFileContainingSubString("MockMyDisplay"),
+					BeAFileContainingSubString("var _ pegomocktest.MyDisplay = (*MockMyDisplay)(nil)")))
+				Expect(joinPath(subPackageDir, "mock_subdisplay_test.go")).To(SatisfyAll(
+					BeAFileContainingSubString("FakeSubDisplay"),
+					Not(BeAFileContainingSubString("var _ "))))
+			})
+
+			It(`adds the flags given on the command line to every target`, func() {
+				WriteFile(joinPath(packageDir, "pegomock-config.yaml"), "targets:\n  - interfaces: [MyDisplay]\n")
+
+				main.Run(cmd("pegomock generate --config pegomock-config.yaml --output-dir fakes"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "fakes", "mock_mydisplay.go")).To(BeAFileContainingSubString("package fakes"))
+			})
+
+			It(`generates the other targets when one fails, and reports it`, func() {
+				WriteFile(joinPath(packageDir, ".pegomock.yaml"), "targets:\n  - interfaces: [Missing, MyDisplay]\n")
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock generate"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Failed to generate Missing in " + packageDir))
+				Expect(buf.String()).To(ContainSubstring("1 of 2 commands of " + joinPath(packageDir, ".pegomock.yaml") + " failed"))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+			})
+
+			It(`reports an error when there is no .pegomock.yaml`, func() {
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock generate"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Expected args, or a .pegomock.yaml in the working directory"))
+			})
+		})
+
+		Context("with args --cache", func() {
+			BeforeEach(func() {
+				GinkgoT().Setenv("XDG_CACHE_HOME", GinkgoT().TempDir())
+			})
+
+			It(`generates the mock again only when its interface changed`, func() {
+				var buf bytes.Buffer
+				main.Run(cmd("pegomock generate MyDisplay --cache --debug"), &buf, os.Stdin, app, context.Background())
+				Expect(buf.String()).To(ContainSubstring("MyDisplay"))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+
+				buf.Reset()
+				main.Run(cmd("pegomock generate MyDisplay --cache --debug"), &buf, os.Stdin, app, context.Background())
+				Expect(buf.String()).To(BeEmpty())
+
+				WriteFile(joinPath(packageDir, "mydisplay.go"), "package pegomocktest; type MyDisplay interface {  Show(something string); Hide() }")
+				main.Run(cmd("pegomock generate MyDisplay --cache --debug"), &buf, os.Stdin, app, context.Background())
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAFileContainingSubString("func (mock *MockMyDisplay) Hide()"))
+			})
+
+			It(`reports an error when combined with --archive`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate pegomocktest MyDisplay --cache --archive pegomocktest.a"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot use --cache together with --archive or --assert-implements-only"))
+			})
+		})
+
+		Context("with args --matchers embedded", func() {
+			BeforeEach(func() {
+				WriteFile(joinPath(packageDir, "registry.go"), "package pegomocktest; "+
+					"type Set[T comparable] map[T]bool; type SliceOfString string; "+
+					"type registry interface { Add(names []string, tags Set[string]); Rename(name SliceOfString, owner *MyDisplay) }")
+			})
+
+			It(`generates typed helpers for every distinct parameter type`, func() {
+				main.Run(cmd("pegomock generate registry --matchers embedded"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_registry_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func mockRegistryAnySliceOfString() []string {"),
+					BeAFileContainingSubString("func mockRegistryNotNilSliceOfString() []string {"),
+					BeAFileContainingSubString("func mockRegistryCaptorSliceOfString() *pegomock.ArgumentCaptor[[]string] {"),
+					BeAFileContainingSubString("func mockRegistryEqSetOfString(value Set[string]) Set[string] {"),
+					BeAFileContainingSubString("func mockRegistryNotNilPtrToMyDisplay() *MyDisplay {"),
+					Not(BeAFileContainingSubString("func mockRegistryNotNilSetOfString()"))))
+			})
+
+			It(`numbers helpers of different types with the same name`, func() {
+				main.Run(cmd("pegomock generate registry --matchers embedded"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_registry_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func mockRegistryAnySliceOfString2() SliceOfString {"),
+					Not(BeAFileContainingSubString("func mockRegistryNotNilSliceOfString2()"))))
+			})
+		})
+
+		Context("with args --archive", func() {
+			It(`generates the mock from the archive without needing the interface's source`, func() {
+				output, e := exec.Command("go", "build", "-o", joinPath(packageDir, "pegomocktest.a"), "pegomocktest").CombinedOutput()
+				Expect(e).NotTo(HaveOccurred(), string(output))
+				Expect(os.Remove(joinPath(packageDir, "mydisplay.go"))).To(Succeed())
+
+				main.Run(cmd("pegomock generate --archive pegomocktest.a pegomocktest MyDisplay"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package pegomocktest_test"),
+					BeAFileContainingSubString("func (mock *MockMyDisplay) Show(something string) {")))
+			})
+
+			It(`reports an error if the package path is missing`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate --archive pegomocktest.a MyDisplay"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("--archive expects the package path the archive was compiled for and an interface, but got MyDisplay"))
+			})
+		})
+
+		Context("with vendored dependencies", func() {
+			It(`generates the mock with the dependencies' import paths`, func() {
+				WriteFile(joinPath(packageDir, "checker.go"),
+					`package pegomocktest; import "github.com/onsi/gomega/types"; type Checker interface { Check(m types.GomegaMatcher) error }`)
+				for _, args := range [][]string{{"mod", "tidy"}, {"mod", "vendor"}} {
+					output, e := exec.Command("go", args...).CombinedOutput()
+					Expect(e).NotTo(HaveOccurred(), string(output))
+				}
+				GinkgoT().Setenv("GOFLAGS", "-mod=vendor")
+
+				main.Run(cmd("pegomock generate Checker"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_checker_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString(`types "github.com/onsi/gomega/types"`),
+					Not(BeAFileContainingSubString("vendor/"))))
+			})
+		})
+
+		Context("within a go.work workspace", func() {
+			BeforeEach(func() {
+				storeModuleDir := joinPath(packageDir, "store")
+				Expect(os.MkdirAll(storeModuleDir, 0755)).To(Succeed())
+				WriteFile(joinPath(storeModuleDir, "go.mod"), "module example.com/store\n\ngo 1.18\n")
+				WriteFile(joinPath(storeModuleDir, "store.go"),
+					"package store; type Item struct{}; type Store interface { Get(key string) (Item, error) }")
+				WriteFile(joinPath(packageDir, "go.work"), "go 1.18\n\nuse (\n\t.\n\t./store\n)\n")
+				// the workspace must not be overridden by the environment the tests run in
+				GinkgoT().Setenv("GOWORK", "")
+				GinkgoT().Setenv("GOFLAGS", "")
+			})
+
+			It(`generates mocks of interfaces in other modules of the workspace by their import path`, func() {
+				main.Run(cmd("pegomock generate example.com/store Store"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_store_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package pegomocktest_test"),
+					BeAFileContainingSubString(`store "example.com/store"`),
+					BeAFileContainingSubString("func (mock *MockStore) Get(key string) (store.Item, error)")))
+			})
+
+			It(`generates mocks into other modules of the workspace`, func() {
+				WriteFile(joinPath(packageDir, "archive.go"),
+					"package pegomocktest; type Record struct{}; type Archive interface { Put(r Record) }")
+
+				main.Run(cmd("pegomock generate --output-dir store/storemocks Archive"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "store", "storemocks", "mock_archive.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package storemocks\n"),
+					BeAFileContainingSubString(`pegomocktest "pegomocktest"`),
+					BeAFileContainingSubString("Put(r pegomocktest.Record)")))
+			})
+		})
+
+		Context("with args --assert-interface", func() {
+			It(`asserts at compile time that the mock implements the interface`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --assert-interface"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString(`pegomocktest "pegomocktest"`),
+					BeAFileContainingSubString("var _ pegomocktest.MyDisplay = (*MockMyDisplay)(nil)")))
+			})
+		})
+
+		Context("with args --embed-interface", func() {
+			It(`embeds the interface in the mock struct`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --embed-interface"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("type MockMyDisplay struct {\n\tpegomocktest.MyDisplay\n")))
+			})
+		})
+
+		Context("with args --slim", func() {
+			It(`generates the mock without verification code`, func() {
+				WriteFile(joinPath(packageDir, "phonebook.go"),
+					"package pegomocktest; type PhoneBook interface { GetPhoneNumber(name string) (string, error) }")
+
+				main.Run(cmd("pegomock generate PhoneBook --slim"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_phonebook_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func (mock *MockPhoneBook) GetPhoneNumber(name string) (string, error) {"),
+					Not(BeAFileContainingSubString("VerifyWasCalled")),
+					Not(BeAFileContainingSubString("OngoingVerification")),
+					Not(BeAFileContainingSubString("GetPhoneNumberCalls()"))))
+			})
+		})
+
+		Context("with args --null-object", func() {
+			BeforeEach(func() {
+				WriteFile(joinPath(packageDir, "phonebook.go"),
+					"package pegomocktest; type PhoneBook interface { GetPhoneNumber(name string) (string, error) }")
+			})
+
+			It(`additionally generates a null object with "alongside"`, func() {
+				main.Run(cmd("pegomock generate PhoneBook --null-object alongside"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_phonebook_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("type MockPhoneBook struct"),
+					BeAFileContainingSubString("type NullPhoneBook struct{}"),
+					BeAFileContainingSubString("func (NullPhoneBook) GetPhoneNumber(name string) (string, error) {")))
+			})
+
+			It(`generates only the null object, without importing pegomock, with "only"`, func() {
+				main.Run(cmd("pegomock generate PhoneBook --null-object only"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_phonebook_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("type NullPhoneBook struct{}"),
+					Not(BeAFileContainingSubString("MockPhoneBook")),
+					Not(BeAFileContainingSubString("github.com/petergtz/pegomock"))))
+			})
+		})
+
+		Context("with args --import-alias", func() {
+			It(`imports the package under the given alias`, func() {
+				main.Run(cmd("pegomock generate RequestHandler --import-alias net/http=nethttp"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_requesthandler_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString(`nethttp "net/http"`),
+					BeAFileContainingSubString("r *nethttp.Request")))
+			})
+		})
+
+		Context("with a function type", func() {
+			BeforeEach(func() {
+				WriteFile(joinPath(packageDir, "handler_func.go"),
+					"package pegomocktest; type HandlerFunc func(request string) (string, error)")
+			})
+
+			It(`generates a mock with a Call method and a Func accessor`, func() {
+				main.Run(cmd("pegomock generate HandlerFunc"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_handlerfunc_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func (mock *MockHandlerFunc) Call(request string) (string, error) {"),
+					BeAFileContainingSubString("func (mock *MockHandlerFunc) Func() pegomocktest.HandlerFunc {\n\treturn mock.Call\n}"),
+					BeAFileContainingSubString("func (verifier *VerifierMockHandlerFunc) Call(request string) *MockHandlerFunc_Call_OngoingVerification {")))
+			})
+		})
+
+		Context("with an unexported interface", func() {
+			BeforeEach(func() {
+				WriteFile(joinPath(packageDir, "secret_store.go"),
+					"package pegomocktest; type secret string; type secretStore interface {  Get(key string) secret }")
+			})
+
+			It(`generates an unexported mock into the interface's own package`, func() {
+				main.Run(cmd("pegomock generate secretStore"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_secretstore_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package pegomocktest\n"),
+					BeAFileContainingSubString("type mockSecretStore struct {"),
+					BeAFileContainingSubString("func (mock *mockSecretStore) Get(key string) secret {")))
+			})
+
+			It(`reports an error for other packages`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate secretStore --output-dir fakes"), &buf, os.Stdin, app, context.Background())
+				}).To(PanicWith(MatchError(ContainSubstring("Interface secretStore refers to unexported names of package pegomocktest, " +
+					"so its mock must be generated into package pegomocktest, but the package of the generated code is fakes."))))
+			})
+		})
+
+		Context("with args --default-options", func() {
+			It(`makes the constructor apply the options before the given ones`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --default-options strict,cloneArgs"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("options = append([]pegomock.Option{pegomock.WithStrictStubbing(), pegomock.WithArgumentCloning()}, options...)")))
+			})
+
+			It(`reports an error for unknown options`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --default-options strict,lenient"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring(`Unknown default option "lenient". Valid options are: callSites, cloneArgs, deepCloneArgs, noRecording, strict, testScoped.`))
+			})
+		})
+
+		Context("with args --assert-implements-only", func() {
+			It(`generates a compile-time assertion and a test logging extra methods of the fake`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --assert-implements-only FakeDisplay"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mydisplay_conformance_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("var _ pegomocktest.MyDisplay = (*FakeDisplay)(nil)"),
+					BeAFileContainingSubString("func TestFakeDisplayConformsToMyDisplay(t *testing.T) {"),
+					BeAFileContainingSubString("which is not (or no longer) part of MyDisplay"),
+					Not(BeAFileContainingSubString("t.Errorf"))))
+			})
+		})
+
+		Context("with args --fake", func() {
+			It(`generates fake behavior only for entities whose methods agree on key and value types`, func() {
+				WriteFile(joinPath(packageDir, "users.go"), "package pegomocktest\n\n"+
+					"type User struct{ Name string }\n\n"+
+					"type Users interface {\n"+
+					"\tSaveUser(id string, u *User) error\n"+
+					"\tGetUser(id string) (User, error)\n"+
+					"\tPutGroup(id string, members []string) error\n"+
+					"\tGetGroup(id string) ([]string, bool)\n"+
+					"}\n")
+
+				main.Run(cmd("pegomock generate Users --fake"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_users_test.go")).To(SatisfyAll(
+					BeAFileContainingSubString(`Put("Group", id, members)`),
+					BeAFileContainingSubString(`Get("Group", id)`),
+					Not(BeAFileContainingSubString(`Put("User"`)),
+					Not(BeAFileContainingSubString(`Get("User"`))))
+			})
+		})
+
+		Context("with args --template", func() {
+			It(`generates the output with the template`, func() {
+				WriteFile(joinPath(packageDir, "stub.tmpl"),
+					"package {{.PackageName}}\n"+
+						"{{range $iface := .Package.Interfaces}}\n"+
+						"type Stub{{$iface.Name}} struct{}\n"+
+						"{{range .Methods}}\n"+
+						"func (Stub{{$iface.Name}}) {{.Name}}({{params .}}) {{results .}} { panic(\"not implemented\") }\n"+
+						"{{end}}{{end}}")
+
+				main.Run(cmd("pegomock generate MyDisplay --template stub.tmpl"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package pegomocktest_test"),
+					BeAFileContainingSubString("type StubMyDisplay struct{}"),
+					BeAFileContainingSubString(`func (StubMyDisplay) Show(something string) { panic("not implemented") }`),
+					Not(BeAFileContainingSubString("pegomock."))))
+			})
+
+			It(`reports an error when combined with --fake`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --template stub.tmpl --fake"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot use --template together with --fake"))
+			})
+		})
+
+		Context("with args --inline", func() {
+			It(`generates a mock of the interface declared in the given source`, func() {
+				main.Run([]string{"pegomock", "generate", "--inline", "type Store interface { Get(key string) (io.ReadCloser, error) }"},
+					os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_store_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("// Source: inline source (interfaces: Store)"),
+					BeAFileContainingSubString("package pegomocktest_test"),
+					BeAFileContainingSubString(`io "io"`),
+					BeAFileContainingSubString("func (mock *MockStore) Get(key string) (io.ReadCloser, error)"),
+					Not(BeAFileContainingSubString("CheckFreshness"))))
+			})
+
+			It(`generates the mocks of several interfaces into the file given with --output`, func() {
+				main.Run([]string{"pegomock", "generate", "--inline", "type Reader interface { Read() string }; type Writer interface { Write(s string) }", "-o", "rw_test.go"},
+					os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "rw_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("type MockReader struct"),
+					BeAFileContainingSubString("type MockWriter struct")))
+			})
+
+			It(`reports an error for several interfaces without --output`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run([]string{"pegomock", "generate", "--inline", "type Reader interface { Read() string }; type Writer interface { Write(s string) }"},
+						&buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("--inline declares several interfaces. Use --output to name the file of their mocks."))
+			})
+
+			It(`reports an error when combined with args`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run([]string{"pegomock", "generate", "--inline", "type Reader interface { Read() string }", "MyDisplay"},
+						&buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot use --inline together with args"))
+			})
+		})
+
+		Context("with args --goos, --goarch and --tags", func() {
+			BeforeEach(func() {
+				WriteFile(joinPath(packageDir, "console_windows.go"),
+					"package pegomocktest; type Console interface { SetTitle(title string) }")
+				WriteFile(joinPath(packageDir, "fixtures.go"),
+					"//go:build integration\n\npackage pegomocktest; type Fixtures interface { Load(name string) error }")
+			})
+
+			It(`finds interfaces declared in files for other platforms`, func() {
+				main.Run(cmd("pegomock generate --goos windows --goarch amd64 Console"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_console_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func (mock *MockConsole) SetTitle(title string)")))
+				Expect(os.Getenv("GOOS")).To(BeEmpty())
+			})
+
+			It(`finds interfaces declared behind build tags`, func() {
+				main.Run(cmd("pegomock generate --tags integration --build-tags integration Fixtures"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_fixtures_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("//go:build integration"),
+					BeAFileContainingSubString("func (mock *MockFixtures) Load(name string) error")))
+			})
+
+			It(`reports an error for interfaces of other platforms without them`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate Fixtures"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+			})
+
+			It(`reports an error for invalid tags`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate --tags integration|e2e Fixtures"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring(`Invalid tags "integration|e2e"`))
+			})
+		})
+
+		Context("with args --plugin", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(joinPath(packageDir, "pegomock-gen-stub"), []byte("#!/bin/sh\n"+
+					"cat > request.json\n"+
+					"printf 'package pegomocktest_test\\n\\ntype StubMyDisplay struct{}\\n'\n"), 0755)).To(Succeed())
+			})
+
+			It(`generates the output with the plugin, passing it the request on stdin`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --plugin ./pegomock-gen-stub"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("type StubMyDisplay struct{}"),
+					Not(BeAFileContainingSubString("pegomock."))))
+				request, e := os.ReadFile(joinPath(packageDir, "request.json"))
+				Expect(e).NotTo(HaveOccurred())
+				var pluginRequest mockgen.PluginRequest
+				Expect(json.Unmarshal(request, &pluginRequest)).To(Succeed())
+				Expect(pluginRequest.PackageName).To(Equal("pegomocktest_test"))
+				Expect(pluginRequest.Package.Interfaces[0].Name).To(Equal("MyDisplay"))
+				Expect(pluginRequest.Package.Interfaces[0].Methods[0].In[0].Type).To(Equal(model.PredeclaredType("string")))
+			})
+
+			It(`finds plugins by name in PATH`, func() {
+				GinkgoT().Setenv("PATH", packageDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+				main.Run(cmd("pegomock generate MyDisplay --plugin stub"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAFileContainingSubString("type StubMyDisplay struct{}"))
+			})
+
+			It(`reports the errors of failing plugins`, func() {
+				Expect(os.WriteFile(joinPath(packageDir, "failing"), []byte("#!/bin/sh\necho 'unsupported interface' >&2\nexit 3\n"), 0755)).To(Succeed())
+				var buf bytes.Buffer
+
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --plugin ./failing"), &buf, os.Stdin, app, context.Background())
+				}).To(PanicWith(MatchError(ContainSubstring("unsupported interface"))))
+			})
+
+			It(`reports an error when combined with --template`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --plugin ./pegomock-gen-stub --template stub.tmpl"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot use --plugin together with"))
+			})
+		})
+
+		Context("with args --style gomock", func() {
+			It(`generates a mock with an EXPECT() recorder`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --style gomock"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString(`"github.com/petergtz/pegomock/v4/gomock"`),
+					BeAFileContainingSubString("func NewMockMyDisplay(ctrl *gomock.Controller) *MockMyDisplay {"),
+					BeAFileContainingSubString("func (mock *MockMyDisplay) EXPECT() *MockMyDisplayMockRecorder {"),
+					BeAFileContainingSubString("func (recorder *MockMyDisplayMockRecorder) Show(something interface{}) *gomock.Call {"),
+					Not(BeAFileContainingSubString("pegomock.GetGenericMockFrom"))))
+			})
+
+			It(`reports an error when combined with --fake`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --style gomock --fake"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot use --style gomock together with --fake"))
+			})
+		})
+
+		Context("with args --api-level v3", func() {
+			It(`generates code against the v3 runtime`, func() {
+				main.Run(cmd("pegomock generate MyDisplay --api-level v3"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString(`"github.com/petergtz/pegomock/v3"`),
+					Not(BeAFileContainingSubString("InvokeTyped")),
+					Not(BeAFileContainingSubString("Calls()"))))
+			})
+
+			It(`reports an error when combined with --fake`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate MyDisplay --api-level v3 --fake"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot use --fake, --recorder or --default-options together with --api-level v3"))
+			})
+		})
+
+		Context("with args --all", func() {
+			It(`generates a mock file per exported interface of the package`, func() {
+				main.Run(cmd("pegomock generate --all pegomocktest"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("MockMyDisplay")))
+				Expect(joinPath(packageDir, "mock_requesthandler_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("MockRequestHandler")))
+			})
+
+			It(`generates all mocks into one file with --output`, func() {
+				main.Run(cmd("pegomock generate --all pegomocktest -o mocks_test.go"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mocks_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("// Source: pegomocktest (interfaces: MyDisplay, RequestHandler)"),
+					BeAFileContainingSubString("MockMyDisplay"),
+					BeAFileContainingSubString("MockRequestHandler")))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+			})
+
+			It(`generates a mock file per interface into the package of --output-dir`, func() {
+				main.Run(cmd("pegomock generate --all pegomocktest --output-dir fakes"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "fakes", "mock_mydisplay.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package fakes"),
+					BeAFileContainingSubString("MockMyDisplay")))
+				Expect(joinPath(packageDir, "fakes", "mock_requesthandler.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package fakes"),
+					BeAFileContainingSubString("MockRequestHandler")))
+			})
+
+			It(`also generates mocks of the interfaces declared in test files`, func() {
+				WriteFile(joinPath(packageDir, "clock_test.go"), "package pegomocktest_test; type Clock interface { Now() int64 }")
+				WriteFile(joinPath(packageDir, "timer_test.go"), "package pegomocktest; type Timer interface { Stop() bool }")
+
+				main.Run(cmd("pegomock generate --all pegomocktest"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "mock_clock_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package pegomocktest_test"),
+					BeAFileContainingSubString("func (mock *MockClock) Now() int64")))
+				Expect(joinPath(packageDir, "mock_timer_test.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("func (mock *MockTimer) Stop() bool")))
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).To(BeAnExistingFile())
+			})
+
+			It(`reports an error when combined with --mock-name`, func() {
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate --all pegomocktest --mock-name Renamed"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot use --all together with --interfaces, --mock-name or --assert-implements-only"))
+			})
+		})
+
+		Context("with args --interfaces and --output-dir", func() {
+			It(`generates a mock file per matching interface into the package of the output dir`, func() {
+				main.Run(cmd("pegomock generate ./... --interfaces .*Display --output-dir fakes"), os.Stdout, os.Stdin, app, context.Background())
+
+				Expect(joinPath(packageDir, "fakes", "mock_mydisplay.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package fakes"),
+					BeAFileContainingSubString("MockMyDisplay")))
+				Expect(joinPath(packageDir, "fakes", "mock_subdisplay.go")).To(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("package fakes"),
+					BeAFileContainingSubString("MockSubDisplay")))
+				Expect(joinPath(packageDir, "fakes", "mock_requesthandler.go")).NotTo(BeAnExistingFile())
+				Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+			})
+
+			It(`generates the same mocks when generating them concurrently`, func() {
+				main.Run(cmd("pegomock generate ./... --interfaces .* --output-dir fakes --jobs 1"), os.Stdout, os.Stdin, app, context.Background())
+				sequential := map[string][]byte{}
+				for _, name := range []string{"mock_mydisplay.go", "mock_subdisplay.go", "mock_requesthandler.go"} {
+					content, e := os.ReadFile(joinPath(packageDir, "fakes", name))
+					Expect(e).NotTo(HaveOccurred())
+					sequential[name] = content
+				}
+				Expect(os.RemoveAll(joinPath(packageDir, "fakes"))).To(Succeed())
+
+				main.Run(cmd("pegomock generate ./... --interfaces .* --output-dir fakes --jobs 4"), os.Stdout, os.Stdin, app, context.Background())
+
+				for name, content := range sequential {
+					Expect(os.ReadFile(joinPath(packageDir, "fakes", name))).To(Equal(content))
+				}
+			})
+
+			It(`reports an error when two matching interfaces have the same name`, func() {
+				WriteFile(joinPath(subPackageDir, "mydisplay.go"),
+					"package subpackage; type MyDisplay interface {  Show(something string) }")
+				var buf bytes.Buffer
+				Expect(func() {
+					main.Run(cmd("pegomock generate ./... --interfaces MyDisplay --output-dir fakes"), &buf, os.Stdin, app, context.Background())
+				}).To(Panic())
+
+				Expect(buf.String()).To(ContainSubstring("Cannot generate the mocks of pegomocktest.MyDisplay and pegomocktest/subpackage.MyDisplay into the same --output-dir"))
+			})
+		})
+
 		Context("with too many args", func() {
 
 			It(`reports an error and the usage`, func() {
@@ -205,6 +1115,109 @@ var _ = Describe("CLI", func() {
 
 	})
 
+	Describe(`"check" command`, func() {
+		BeforeEach(func() {
+			main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, context.Background())
+		})
+
+		It("succeeds as long as the interfaces are unchanged", func() {
+			var buf bytes.Buffer
+
+			main.Run(cmd("pegomock check"), &buf, os.Stdin, app, context.Background())
+
+			Expect(buf.String()).To(Equal("All 1 mocks are up to date\n"))
+		})
+
+		It("reports mocks whose interfaces changed", func() {
+			WriteFile(joinPath(packageDir, "mydisplay.go"),
+				"package pegomocktest; type MyDisplay interface {  Show(something string) error }")
+			var buf bytes.Buffer
+
+			Expect(func() {
+				main.Run(cmd("pegomock check ./..."), &buf, os.Stdin, app, context.Background())
+			}).To(Panic())
+
+			Expect(buf.String()).To(ContainSubstring(joinPath(packageDir, "mock_mydisplay_test.go") +
+				": mock for pegomocktest.MyDisplay is stale: the interface changed since the mock was generated\n"))
+			Expect(buf.String()).To(ContainSubstring("1 of 1 mocks are stale or could not be checked. Please regenerate them."))
+		})
+	})
+
+	Describe(`"doctor" command`, func() {
+		BeforeEach(func() {
+			// type-checking the mocks needs the runtime API they're generated against
+			goMod, e := os.ReadFile(joinPath(packageDir, "go.mod"))
+			Expect(e).NotTo(HaveOccurred())
+			WriteFile(joinPath(packageDir, "go.mod"), string(goMod)+"\nreplace github.com/petergtz/pegomock/v4 => "+filepath.Dir(origWorkingDir)+"\n")
+			main.Run(cmd("pegomock generate MyDisplay"), os.Stdout, os.Stdin, app, context.Background())
+		})
+
+		It("succeeds as long as the mocks match their interfaces", func() {
+			var buf bytes.Buffer
+
+			main.Run(cmd("pegomock doctor"), &buf, os.Stdin, app, context.Background())
+
+			Expect(buf.String()).To(Equal("All 1 mocks match their interfaces\n"))
+		})
+
+		It("reports the methods that don't match and how to regenerate the mocks", func() {
+			WriteFile(joinPath(packageDir, "mydisplay.go"),
+				"package pegomocktest; type MyDisplay interface {  Show(something string) error; Hide() }")
+			var buf bytes.Buffer
+
+			Expect(func() {
+				main.Run(cmd("pegomock doctor ./..."), &buf, os.Stdin, app, context.Background())
+			}).To(Panic())
+
+			Expect(buf.String()).To(ContainSubstring(joinPath(packageDir, "mock_mydisplay_test.go") + ": MockMyDisplay doesn't match pegomocktest.MyDisplay:\n" +
+				"\tmissing method Hide()\n" +
+				"\tincompatible method Show: the mock has Show(string), but the interface has Show(string) error\n" +
+				"\tRegenerate it with: pegomock generate -o mock_mydisplay_test.go pegomocktest MyDisplay\n"))
+			Expect(buf.String()).To(ContainSubstring("1 of 1 mocks don't match their interfaces."))
+		})
+	})
+
+	Describe(`"model" command`, func() {
+		It("writes the models of all exported interfaces of a package as JSON", func() {
+			main.Run(cmd("pegomock model -o model.json pegomocktest"), os.Stdout, os.Stdin, app, context.Background())
+
+			content, e := os.ReadFile(joinPath(packageDir, "model.json"))
+			Expect(e).NotTo(HaveOccurred())
+			pkg, e := model.ParseJSON(content)
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pkg.PkgPath).To(Equal("pegomocktest"))
+			Expect(pkg.Interfaces).To(HaveLen(2))
+			Expect(pkg.Interfaces[0].Name).To(Equal("MyDisplay"))
+			Expect(pkg.Interfaces[1].Name).To(Equal("RequestHandler"))
+			Expect(pkg.Interfaces[1].Methods[0].In[0].Type).To(Equal(&model.PointerType{Type: &model.NamedType{Package: "net/http", Type: "Request"}}))
+		})
+
+		It("leaves out the interfaces of the external test package", func() {
+			WriteFile(joinPath(packageDir, "clock_test.go"), "package pegomocktest_test; type Clock interface { Now() int64 }")
+
+			main.Run(cmd("pegomock model -o model.json pegomocktest"), os.Stdout, os.Stdin, app, context.Background())
+
+			content, e := os.ReadFile(joinPath(packageDir, "model.json"))
+			Expect(e).NotTo(HaveOccurred())
+			pkg, e := model.ParseJSON(content)
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pkg.PkgPath).To(Equal("pegomocktest"))
+			Expect(pkg.Interfaces).To(HaveLen(2))
+		})
+
+		It("only includes the given interfaces", func() {
+			main.Run(cmd("pegomock model -o model.json pegomocktest RequestHandler"), os.Stdout, os.Stdin, app, context.Background())
+
+			content, e := os.ReadFile(joinPath(packageDir, "model.json"))
+			Expect(e).NotTo(HaveOccurred())
+			Expect(content).To(ContainSubstring(`"kind": "pointer"`))
+			pkg, e := model.ParseJSON(content)
+			Expect(e).NotTo(HaveOccurred())
+			Expect(pkg.Interfaces).To(HaveLen(1))
+			Expect(pkg.Interfaces[0].Name).To(Equal("RequestHandler"))
+		})
+	})
+
 	Describe(`"remove" command`, func() {
 		Context("there are no mock files", func() {
 			It("removes mock files in current directory only", func() {
@@ -315,6 +1328,48 @@ Continue? [y/n]:`))
 				})
 			})
 
+			Context("with --pattern", func() {
+				It("removes only the mock files matching the pattern", func() {
+					main.Run(cmd("pegomock generate RequestHandler"), os.Stdout, os.Stdin, app, context.Background())
+					var buf bytes.Buffer
+
+					main.Run(cmd("pegomock remove -n -r --pattern mock_my*_test.go --pattern subpackage/*.go"), &buf, os.Stdin, app, context.Background())
+
+					Expect(buf.String()).To(ContainSubstring(`Deleting the following files:
+` + packageDir + `/mock_mydisplay_test.go
+` + packageDir + `/subpackage/mock_subdisplay.go`))
+					Expect(joinPath(packageDir, "mock_mydisplay_test.go")).NotTo(BeAnExistingFile())
+					Expect(joinPath(subPackageDir, "mock_subdisplay.go")).NotTo(BeAnExistingFile())
+					Expect(joinPath(packageDir, "mock_requesthandler_test.go")).To(BeAnExistingFile())
+				})
+			})
+
+			Context("with --matchers-dir", func() {
+				It("removes the matchers directories once they are empty", func() {
+					main.Run(cmd("pegomock generate --output-dir "+joinPath(packageDir, "fakematchers")+" MyDisplay"), os.Stdout, os.Stdin, app, context.Background())
+					var buf bytes.Buffer
+
+					main.Run(cmd("pegomock remove -n -r --matchers-dir fakematchers"), &buf, os.Stdin, app, context.Background())
+
+					Expect(buf.String()).To(ContainSubstring(packageDir + `/fakematchers
+` + packageDir + `/fakematchers/mock_mydisplay.go`))
+					Expect(joinPath(packageDir, "fakematchers")).NotTo(BeAnExistingFile())
+				})
+			})
+
+			Context("with mocks starting with a license header", func() {
+				It("recognizes them as generated", func() {
+					WriteFile(joinPath(packageDir, "license.txt"), "Copyright 2026 Example Corp.\n\nLicensed under the Apache License, Version 2.0 (the \"License\").\n")
+					main.Run(cmd("pegomock generate RequestHandler --header-file license.txt"), os.Stdout, os.Stdin, app, context.Background())
+					var buf bytes.Buffer
+
+					main.Run(cmd("pegomock remove --dry-run --pattern mock_requesthandler_test.go"), &buf, os.Stdin, app, context.Background())
+
+					Expect(buf.String()).To(ContainSubstring(`Would delete the following files:
+` + packageDir + `/mock_requesthandler_test.go`))
+				})
+			})
+
 			Context("dry-run", func() {
 				It("removes no mock files, but provides files that would be deleted", func() {
 					var buf bytes.Buffer
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -8,3 +12,51 @@ import (
 func DeterminePackageNameIn(dir string) (string, error) {
 	return strings.Replace(filepath.Base(dir), "-", "_", -1) + "_test", nil
 }
+
+// DetermineOutputPackageNameIn returns the package name of mocks generated into the output
+// directory dir: the name of the package already in dir, or otherwise the directory name.
+func DetermineOutputPackageNameIn(dir string) (string, error) {
+	absDir, e := filepath.Abs(dir)
+	if e != nil {
+		return "", e
+	}
+	goFiles, e := filepath.Glob(filepath.Join(absDir, "*.go"))
+	if e != nil {
+		return "", e
+	}
+	for _, goFile := range goFiles {
+		if strings.HasSuffix(goFile, "_test.go") {
+			continue
+		}
+		file, e := parser.ParseFile(token.NewFileSet(), goFile, nil, parser.PackageClauseOnly)
+		if e == nil {
+			return file.Name.Name, nil
+		}
+	}
+	return strings.Replace(filepath.Base(absDir), "-", "_", -1), nil
+}
+
+// DetermineImportPathOf returns the import path of the package in dir, which might not exist yet,
+// or "" if dir is neither in a module nor in GOPATH.
+func DetermineImportPathOf(dir string) string {
+	absDir, e := filepath.Abs(dir)
+	if e != nil {
+		return ""
+	}
+	if _, e := os.Stat(absDir); e != nil {
+		// go list needs the directory, and the directory might be created only by generating into it
+		parentImportPath := DetermineImportPathOf(filepath.Dir(absDir))
+		if parentImportPath == "" {
+			return ""
+		}
+		return parentImportPath + "/" + filepath.Base(absDir)
+	}
+	cmd := exec.Command("go", "list", "-e", "-f", "{{.ImportPath}}", ".")
+	cmd.Dir = absDir
+	output, e := cmd.Output()
+	importPath := strings.TrimSpace(string(output))
+	if e != nil || importPath == "" || strings.HasPrefix(importPath, "_") {
+		return ""
+	}
+	return importPath
+}
@@ -0,0 +1,12 @@
+// Command pegomockcheck reports misuse of Pegomock in Go code. Run it directly on packages, e.g.
+// pegomockcheck ./..., or as part of go vet: go vet -vettool=$(which pegomockcheck) ./...
+package main
+
+import (
+	"github.com/petergtz/pegomock/v4/pegomock/pegomockcheck"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(pegomockcheck.Analyzer)
+}
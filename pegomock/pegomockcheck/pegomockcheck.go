@@ -0,0 +1,299 @@
+// Package pegomockcheck provides an analyzer that reports common misuse of Pegomock in test code,
+// which would otherwise only surface as panics or confusing failures when the tests run.
+package pegomockcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "pegomockcheck",
+	Doc: "reports misuse of Pegomock mocks\n\n" +
+		"It reports mock invocations mixing argument matchers with plain values, When() without a " +
+		"method call on a mock, verifications of methods never stubbed on strict mocks, and tests " +
+		"creating mocks without a fail handler, i.e. without RegisterMockTestingT(t).",
+	Run: run,
+}
+
+var pegomockPackagePath = regexp.MustCompile(`^github\.com/petergtz/pegomock(/v\d+)?$`)
+
+// matcherFactories are the functions of the pegomock package that register an argument matcher
+// and return a placeholder value, like Any[T]() or Eq(value), besides the ones named AnyX or XThat.
+var matcherFactories = map[string]bool{
+	"Eq": true, "NotEq": true, "Satisfies": true, "MatchedBy": true, "IsA": true, "ArgThat": true,
+	"MatchesRegex": true, "FieldsMatch": true, "EqJSON": true, "EqCmp": true, "Contains": true,
+	"EqSlice": true, "ConsistsOf": true, "HasLen": true, "HasKey": true, "HasEntry": true,
+	"IsSubsetOf": true, "Gt": true, "Gte": true, "Lt": true, "Lte": true, "InRange": true,
+	"EqApprox": true, "ErrorIs": true, "ErrorAs": true, "TimeWithin": true, "ContextWithValue": true,
+	"ContextWithDeadline": true, "Same": true, "Not": true, "And": true, "Or": true,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	registersFailHandlerOutsideTests := false
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if !isTestFunc(pass, decl) && containsCallTo(pass, decl, "RegisterMockFailHandler", "RegisterMockTestingT") {
+				// e.g. in TestMain or in the suite function of a Ginkgo suite
+				registersFailHandlerOutsideTests = true
+			}
+		}
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if call, isCall := node.(*ast.CallExpr); isCall {
+				checkMixedMatchers(pass, call)
+				checkWhen(pass, call)
+			}
+			return true
+		})
+		for _, decl := range file.Decls {
+			if funcDecl, isFunc := decl.(*ast.FuncDecl); isFunc && funcDecl.Body != nil {
+				checkStrictVerifications(pass, funcDecl)
+				if !registersFailHandlerOutsideTests && isTestFunc(pass, funcDecl) {
+					checkFailHandler(pass, funcDecl)
+				}
+			}
+		}
+	}
+	return nil, nil
+}
+
+// checkMixedMatchers reports invocations of mocks, or of verifiers of mocks, that pass matchers
+// for some arguments and plain values for others. Arguments that are calls of other functions
+// might be custom matchers, so only arguments that aren't calls at all count as plain values.
+func checkMixedMatchers(pass *analysis.Pass, call *ast.CallExpr) {
+	if len(call.Args) < 2 || !isMockInvocation(pass, call) {
+		return
+	}
+	hasMatchers, hasPlainValues := false, false
+	for _, arg := range call.Args {
+		if isMatcherCall(pass, arg) {
+			hasMatchers = true
+		} else if _, isCall := astutil.Unparen(arg).(*ast.CallExpr); !isCall {
+			hasPlainValues = true
+		}
+	}
+	if hasMatchers && hasPlainValues {
+		pass.Reportf(call.Pos(), "invocation mixes argument matchers with plain values; "+
+			"use matchers for all arguments, e.g. Eq(value) instead of value")
+	}
+}
+
+// checkWhen reports When() calls whose argument is neither a method call on a mock nor a function.
+func checkWhen(pass *analysis.Pass, call *ast.CallExpr) {
+	if !isPegomockFunc(pass, call, "When") || len(call.Args) != 1 {
+		return
+	}
+	arg := astutil.Unparen(call.Args[0])
+	if _, isFunc := pass.TypesInfo.TypeOf(arg).Underlying().(*types.Signature); isFunc {
+		return
+	}
+	if argCall, isCall := arg.(*ast.CallExpr); isCall && isMockMethodCall(pass, argCall) {
+		return
+	}
+	pass.Reportf(arg.Pos(), "When() requires a method call on a mock, but got %v", types.ExprString(arg))
+}
+
+// checkStrictVerifications reports verifications of methods of mocks created with
+// WithStrictStubbing in funcDecl that are never stubbed in funcDecl. Their invocations fail the
+// test as unstubbed, regardless of the verification.
+func checkStrictVerifications(pass *analysis.Pass, funcDecl *ast.FuncDecl) {
+	strictMocks := make(map[types.Object]bool)
+	stubbed := make(map[types.Object]map[string]bool)
+	type verification struct {
+		call   *ast.CallExpr
+		mock   types.Object
+		method string
+	}
+	var verifications []verification
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.AssignStmt:
+			for i, rhs := range node.Rhs {
+				if i < len(node.Lhs) && isStrictMockConstruction(pass, rhs) {
+					if ident, isIdent := node.Lhs[i].(*ast.Ident); isIdent {
+						strictMocks[objectOf(pass, ident)] = true
+					}
+				}
+			}
+		case *ast.CallExpr:
+			if isPegomockFunc(pass, node, "When") && len(node.Args) == 1 {
+				ast.Inspect(node.Args[0], func(node ast.Node) bool {
+					if call, isCall := node.(*ast.CallExpr); isCall {
+						if mock, method, isMethodCall := mockAndMethodOf(pass, call); isMethodCall {
+							if stubbed[mock] == nil {
+								stubbed[mock] = make(map[string]bool)
+							}
+							stubbed[mock][method] = true
+						}
+					}
+					return true
+				})
+			}
+			if selector, isSelector := node.Fun.(*ast.SelectorExpr); isSelector {
+				if verifyCall, isCall := selector.X.(*ast.CallExpr); isCall {
+					if mock, verifyMethod, isMethodCall := mockAndMethodOf(pass, verifyCall); isMethodCall && strings.HasPrefix(verifyMethod, "Verify") {
+						verifications = append(verifications, verification{node, mock, selector.Sel.Name})
+					}
+				}
+			}
+		}
+		return true
+	})
+	for _, v := range verifications {
+		if strictMocks[v.mock] && !stubbed[v.mock][v.method] {
+			pass.Reportf(v.call.Pos(), "%v is verified, but never stubbed on strict mock %v; "+
+				"its invocations fail the test as unstubbed", v.method, v.mock.Name())
+		}
+	}
+}
+
+// checkFailHandler reports tests that create mocks without a fail handler, because verification
+// failures of such mocks panic instead of failing the test.
+func checkFailHandler(pass *analysis.Pass, funcDecl *ast.FuncDecl) {
+	if containsCallTo(pass, funcDecl, "RegisterMockFailHandler", "RegisterMockTestingT") {
+		return
+	}
+	ast.Inspect(funcDecl.Body, func(node ast.Node) bool {
+		call, isCall := node.(*ast.CallExpr)
+		if !isCall || !isMockConstruction(pass, call) {
+			return true
+		}
+		for _, arg := range call.Args {
+			if argCall, isCall := astutil.Unparen(arg).(*ast.CallExpr); isCall &&
+				(isPegomockFunc(pass, argCall, "WithT") || isPegomockFunc(pass, argCall, "WithFailHandler")) {
+				return true
+			}
+		}
+		pass.Reportf(call.Pos(), "mock is created without a fail handler; call RegisterMockTestingT(t) "+
+			"first or pass WithT(t)")
+		return true
+	})
+}
+
+func isMatcherCall(pass *analysis.Pass, expr ast.Expr) bool {
+	call, isCall := astutil.Unparen(expr).(*ast.CallExpr)
+	if !isCall {
+		return false
+	}
+	function, isFunc := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !isFunc || function.Pkg() == nil || !pegomockPackagePath.MatchString(function.Pkg().Path()) {
+		return false
+	}
+	name := function.Name()
+	if function.Type().(*types.Signature).Recv() != nil {
+		// captors register matchers too
+		return name == "Capture" || name == "CaptureMatching"
+	}
+	return matcherFactories[name] || strings.HasPrefix(name, "Any") || strings.HasSuffix(name, "That")
+}
+
+func isPegomockFunc(pass *analysis.Pass, call *ast.CallExpr, name string) bool {
+	function, isFunc := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	return isFunc && function.Name() == name && function.Pkg() != nil && pegomockPackagePath.MatchString(function.Pkg().Path())
+}
+
+func containsCallTo(pass *analysis.Pass, node ast.Node, names ...string) bool {
+	found := false
+	ast.Inspect(node, func(node ast.Node) bool {
+		if call, isCall := node.(*ast.CallExpr); isCall {
+			for _, name := range names {
+				if isPegomockFunc(pass, call, name) {
+					found = true
+				}
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+func isTestFunc(pass *analysis.Pass, decl ast.Decl) bool {
+	funcDecl, isFunc := decl.(*ast.FuncDecl)
+	if !isFunc || funcDecl.Recv != nil || !strings.HasPrefix(funcDecl.Name.Name, "Test") || funcDecl.Name.Name == "TestMain" {
+		return false
+	}
+	params := funcDecl.Type.Params.List
+	return len(params) == 1 && types.TypeString(pass.TypesInfo.TypeOf(params[0].Type), nil) == "*testing.T"
+}
+
+// isMock reports whether typ implements pegomock.Mock, which all generated mocks do.
+func isMock(typ types.Type) bool {
+	methods := types.NewMethodSet(typ)
+	return methods.Lookup(nil, "FailHandler") != nil && methods.Lookup(nil, "SetFailHandler") != nil
+}
+
+func isMockMethodCall(pass *analysis.Pass, call *ast.CallExpr) bool {
+	selector, isSelector := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !isSelector {
+		return false
+	}
+	selection, isSelection := pass.TypesInfo.Selections[selector]
+	return isSelection && selection.Kind() == types.MethodVal && isMock(selection.Recv())
+}
+
+// isMockInvocation reports whether call invokes a method of the mocked interface, either on a
+// mock or on the verifier returned by one of its Verify methods.
+func isMockInvocation(pass *analysis.Pass, call *ast.CallExpr) bool {
+	selector, isSelector := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+	if !isSelector {
+		return false
+	}
+	if isMockMethodCall(pass, call) {
+		return !strings.HasPrefix(selector.Sel.Name, "Verify")
+	}
+	verifyCall, isCall := astutil.Unparen(selector.X).(*ast.CallExpr)
+	if !isCall || !isMockMethodCall(pass, verifyCall) {
+		return false
+	}
+	return strings.HasPrefix(verifyCall.Fun.(*ast.SelectorExpr).Sel.Name, "Verify")
+}
+
+// mockAndMethodOf returns the variable and the method name of a method call like mock.Show(...).
+func mockAndMethodOf(pass *analysis.Pass, call *ast.CallExpr) (types.Object, string, bool) {
+	if !isMockMethodCall(pass, call) {
+		return nil, "", false
+	}
+	selector := astutil.Unparen(call.Fun).(*ast.SelectorExpr)
+	ident, isIdent := astutil.Unparen(selector.X).(*ast.Ident)
+	if !isIdent {
+		return nil, "", false
+	}
+	return objectOf(pass, ident), selector.Sel.Name, true
+}
+
+func isMockConstruction(pass *analysis.Pass, expr ast.Expr) bool {
+	call, isCall := astutil.Unparen(expr).(*ast.CallExpr)
+	if !isCall {
+		return false
+	}
+	function, isFunc := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	return isFunc && function.Type().(*types.Signature).Recv() == nil &&
+		strings.HasPrefix(function.Name(), "New") && isMock(pass.TypesInfo.TypeOf(call))
+}
+
+func isStrictMockConstruction(pass *analysis.Pass, expr ast.Expr) bool {
+	if !isMockConstruction(pass, expr) {
+		return false
+	}
+	for _, arg := range astutil.Unparen(expr).(*ast.CallExpr).Args {
+		if argCall, isCall := astutil.Unparen(arg).(*ast.CallExpr); isCall && isPegomockFunc(pass, argCall, "WithStrictStubbing") {
+			return true
+		}
+	}
+	return false
+}
+
+func objectOf(pass *analysis.Pass, ident *ast.Ident) types.Object {
+	if object := pass.TypesInfo.Defs[ident]; object != nil {
+		return object
+	}
+	return pass.TypesInfo.Uses[ident]
+}
@@ -0,0 +1,22 @@
+package pegomockcheck_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/pegomock/pegomockcheck"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestPegomockcheck(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Pegomockcheck Suite")
+}
+
+var _ = Describe("Analyzer", func() {
+	It("reports the misuse marked in the test data", func() {
+		// analysistest fails the spec for missing and unexpected diagnostics
+		analysistest.Run(GinkgoT(), analysistest.TestData(), pegomockcheck.Analyzer, "example", "testmain")
+	})
+})
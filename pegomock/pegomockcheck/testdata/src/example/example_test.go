@@ -0,0 +1,58 @@
+package example
+
+import (
+	"testing"
+
+	. "github.com/petergtz/pegomock/v4"
+)
+
+func TestMixedMatchers(t *testing.T) {
+	RegisterMockTestingT(t)
+	display := NewMockDisplay()
+
+	When(func() { display.Flash(Any[string](), 1) }) // want `invocation mixes argument matchers with plain values`
+	When(func() { display.Flash(Eq("Hello"), Any[int]()) })
+	When(func() { display.Flash("Hello", 1) })
+	display.VerifyWasCalledOnce().Flash(AnyString(), 1) // want `invocation mixes argument matchers with plain values`
+	display.VerifyWasCalledOnce().Flash(AnyString(), Not(1))
+	display.VerifyWasCalledOnce().Flash(Captor[string]().Capture(), 1) // want `invocation mixes argument matchers with plain values`
+	display.VerifyWasCalledOnce().Flash(Captor[string]().Capture(), Any[int]())
+	display.VerifyWasCalledOnce().Flash(AnyString(), customIntMatcher())
+}
+
+func customIntMatcher() int { return 0 }
+
+func TestWhen(t *testing.T) {
+	RegisterMockTestingT(t)
+	display := NewMockDisplay()
+	value := display.SomeValue()
+
+	When(display.SomeValue()).ThenReturn("stubbed")
+	When(func() { display.Show("Hello") })
+	When(value).ThenReturn("stubbed")        // want `When\(\) requires a method call on a mock, but got value`
+	When(len("Hello")).ThenReturn("stubbed") // want `When\(\) requires a method call on a mock, but got len\("Hello"\)`
+}
+
+func TestStrictStubbing(t *testing.T) {
+	RegisterMockTestingT(t)
+	display := NewMockDisplay(WithStrictStubbing())
+	When(display.SomeValue()).ThenReturn("stubbed")
+
+	display.VerifyWasCalledOnce().SomeValue()
+	display.VerifyWasCalledOnce().Show("Hello") // want `Show is verified, but never stubbed on strict mock display; its invocations fail the test as unstubbed`
+
+	lenient := NewMockDisplay()
+	lenient.VerifyWasCalledOnce().Show("Hello")
+}
+
+func TestWithoutFailHandler(t *testing.T) {
+	NewMockDisplay() // want `mock is created without a fail handler; call RegisterMockTestingT\(t\) first or pass WithT\(t\)`
+	NewMockDisplay(WithT(t))
+}
+
+func TestWithFailHandlerInSubtest(t *testing.T) {
+	t.Run("subtest", func(t *testing.T) {
+		RegisterMockTestingT(t)
+		NewMockDisplay()
+	})
+}
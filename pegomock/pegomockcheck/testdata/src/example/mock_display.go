@@ -0,0 +1,21 @@
+package example
+
+import "github.com/petergtz/pegomock/v4"
+
+type MockDisplay struct{ fail pegomock.FailHandler }
+
+func NewMockDisplay(options ...pegomock.Option) *MockDisplay { return &MockDisplay{} }
+
+func (mock *MockDisplay) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
+func (mock *MockDisplay) FailHandler() pegomock.FailHandler      { return mock.fail }
+
+func (mock *MockDisplay) Show(message string)                       {}
+func (mock *MockDisplay) Flash(message string, times int)           {}
+func (mock *MockDisplay) SomeValue() string                         { return "" }
+func (mock *MockDisplay) VerifyWasCalledOnce() *VerifierMockDisplay { return &VerifierMockDisplay{} }
+
+type VerifierMockDisplay struct{}
+
+func (verifier *VerifierMockDisplay) Show(message string)             {}
+func (verifier *VerifierMockDisplay) Flash(message string, times int) {}
+func (verifier *VerifierMockDisplay) SomeValue()                      {}
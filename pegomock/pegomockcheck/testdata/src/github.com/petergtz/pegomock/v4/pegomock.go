@@ -0,0 +1,37 @@
+// Package pegomock is a stub of the parts of Pegomock the analyzer tests need.
+package pegomock
+
+import "testing"
+
+type FailHandler func(message string, callerSkip ...int)
+
+type Mock interface {
+	SetFailHandler(FailHandler)
+	FailHandler() FailHandler
+}
+
+type Option interface{ Apply(Mock) }
+
+type ongoingStubbing struct{}
+
+func (*ongoingStubbing) ThenReturn(values ...interface{}) *ongoingStubbing { return nil }
+
+func When(invocation ...interface{}) *ongoingStubbing { return nil }
+
+func RegisterMockFailHandler(handler FailHandler) {}
+func RegisterMockTestingT(t *testing.T)           {}
+
+func WithT(t *testing.T) Option               { return nil }
+func WithFailHandler(fail FailHandler) Option { return nil }
+func WithStrictStubbing() Option              { return nil }
+
+func Eq[T any](value T) T     { return value }
+func Any[T any]() T           { var zero T; return zero }
+func AnyString() string       { return "" }
+func Not[T any](value T) T    { return value }
+func Times(n int) interface{} { return nil }
+
+type ArgumentCaptor[T any] struct{}
+
+func Captor[T any]() *ArgumentCaptor[T]      { return nil }
+func (captor *ArgumentCaptor[T]) Capture() T { var zero T; return zero }
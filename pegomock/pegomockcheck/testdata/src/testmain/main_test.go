@@ -0,0 +1,19 @@
+package testmain
+
+import (
+	"os"
+	"testing"
+
+	"example"
+
+	"github.com/petergtz/pegomock/v4"
+)
+
+func TestMain(m *testing.M) {
+	pegomock.RegisterMockFailHandler(func(message string, callerSkip ...int) { panic(message) })
+	os.Exit(m.Run())
+}
+
+func TestWithFailHandlerFromTestMain(t *testing.T) {
+	example.NewMockDisplay()
+}
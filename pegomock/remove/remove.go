@@ -13,9 +13,20 @@ import (
 	"errors"
 )
 
+// DefaultMatchersDirNames are the names of the directories the matchers of mocks used to be
+// generated into.
+var DefaultMatchersDirNames = []string{"matchers"}
+
+// Remove removes the files generated by Pegomock in path, and in its sub-directories if recursive
+// is set. If namePatterns are given, only generated files matching any of them are removed.
+// Patterns are filepath.Match globs matched against the name of a file and against its
+// slash-separated path relative to path, e.g. "mock_display*_test.go" or "fakes/*.go". Directories
+// named like any of matchersDirNames are removed as well once they only contain removed files.
 func Remove(
 	path string,
 	recursive bool,
+	namePatterns []string,
+	matchersDirNames []string,
 	shouldConfirm bool,
 	dryRun bool,
 	silent bool,
@@ -23,7 +34,7 @@ func Remove(
 	in io.Reader,
 	removeFn func(path string) error,
 ) {
-	filepaths, matchersDirPaths, e := getFilePaths(recursive, path, out)
+	filepaths, matchersDirPaths, e := getFilePaths(recursive, path, namePatterns, matchersDirNames, out)
 	if e != nil {
 		fmt.Fprintln(out, e.Error())
 		return
@@ -116,7 +127,7 @@ func dirEmpty(path string) bool {
 	return e == io.EOF
 }
 
-func getFilePaths(recursive bool, path string, out io.Writer) ([]string, map[string]bool, error) {
+func getFilePaths(recursive bool, path string, namePatterns []string, matchersDirNames []string, out io.Writer) ([]string, map[string]bool, error) {
 	matcherPaths := make(map[string]bool)
 	var walk func(root string, walkFn filepath.WalkFunc) error
 	if recursive {
@@ -125,10 +136,15 @@ func getFilePaths(recursive bool, path string, out io.Writer) ([]string, map[str
 		walk = walkFilesInDir
 	}
 	filepaths := make([]string, 0)
+	root := path
 	e := walk(path, func(path string, info os.FileInfo, err error) error {
-		if !info.IsDir() && filepath.Ext(path) == ".go" && isPegomockGenerated(path, out) {
+		if err != nil {
+			fmt.Fprintf(out, "Could not read %v. Error: %v\n", path, err)
+			return nil
+		}
+		if !info.IsDir() && filepath.Ext(path) == ".go" && matchesAny(namePatterns, root, path) && isPegomockGenerated(path, out) {
 			filepaths = append(filepaths, path)
-			if filepath.Base(filepath.Dir(path)) == "matchers" {
+			if contains(matchersDirNames, filepath.Base(filepath.Dir(path))) {
 				matcherPaths[filepath.Dir(path)] = true
 			}
 		}
@@ -140,10 +156,39 @@ func getFilePaths(recursive bool, path string, out io.Writer) ([]string, map[str
 	return filepaths, matcherPaths, nil
 }
 
+// matchesAny returns whether path matches any of patterns, or true if there are none.
+func matchesAny(patterns []string, root string, path string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	relativePath, e := filepath.Rel(root, path)
+	if e != nil {
+		relativePath = path
+	}
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, filepath.Base(path)); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.ToSlash(relativePath)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
 func walkFilesInDir(path string, walk filepath.WalkFunc) error {
 	fileInfos, e := ioutil.ReadDir(path)
 	if e != nil {
-		errors.New("Could not get files in path " + path)
+		return e
 	}
 	for _, info := range fileInfos {
 		walk(filepath.Join(path, info.Name()), info, nil)
@@ -163,14 +208,19 @@ func isPegomockGenerated(path string, out io.Writer) bool {
 		}
 	}()
 
-	b := make([]byte, 50)
-	_, e = file.Read(b)
-	if e != nil {
-		fmt.Fprintf(out, "Could not read from file %v. Error: %v\n", path, e)
-		return false
+	// the "Code generated" line follows the header given with --header-file, if any, but always
+	// precedes the package clause
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "// Code generated by pegomock. DO NOT EDIT.") {
+			return true
+		}
+		if strings.HasPrefix(scanner.Text(), "package ") {
+			return false
+		}
 	}
-	if strings.Contains(string(b), "// Code generated by pegomock. DO NOT EDIT.") {
-		return true
+	if e := scanner.Err(); e != nil {
+		fmt.Fprintf(out, "Could not read from file %v. Error: %v\n", path, e)
 	}
 	return false
 }
@@ -0,0 +1,91 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Project describes a scaffolded example project.
+type Project struct {
+	Dir         string
+	ModulePath  string
+	PackageName string
+	Interfaces  []string // to generate mocks for
+	Files       []string
+}
+
+// Example writes a small example project into dir: a module with a payment processor, the
+// interfaces it depends on and tests using mocks for them. The mocks themselves are not written;
+// they must be generated for Project.Interfaces. modulePath defaults to example.com/<base name of dir>.
+func Example(dir string, modulePath string) (*Project, error) {
+	absDir, e := filepath.Abs(dir)
+	if e != nil {
+		return nil, e
+	}
+	packageName := strings.Replace(filepath.Base(absDir), "-", "_", -1)
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("Cannot derive a package name from directory %v. Please use a directory name that is a valid Go identifier", dir)
+	}
+	if modulePath == "" {
+		modulePath = "example.com/" + filepath.Base(absDir)
+	}
+	if entries, e := os.ReadDir(absDir); e == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("Directory %v is not empty", dir)
+	}
+	if e := os.MkdirAll(absDir, 0755); e != nil {
+		return nil, e
+	}
+
+	project := &Project{
+		Dir:         absDir,
+		ModulePath:  modulePath,
+		PackageName: packageName,
+		Interfaces:  []string{"Gateway", "Notifier"},
+	}
+	for _, file := range []struct{ templateName, fileName string }{
+		{"go.mod.tmpl", "go.mod"},
+		{"payments.go.tmpl", packageName + ".go"},
+		{"payments_test.go.tmpl", packageName + "_test.go"},
+	} {
+		content, e := render(file.templateName, project)
+		if e != nil {
+			return nil, e
+		}
+		path := filepath.Join(absDir, file.fileName)
+		if e := os.WriteFile(path, content, 0644); e != nil {
+			return nil, e
+		}
+		project.Files = append(project.Files, path)
+	}
+	return project, nil
+}
+
+func render(templateName string, project *Project) ([]byte, error) {
+	tmpl, e := template.ParseFS(templates, "templates/"+templateName)
+	if e != nil {
+		return nil, e
+	}
+	var buf bytes.Buffer
+	if e := tmpl.Execute(&buf, project); e != nil {
+		return nil, e
+	}
+	if !strings.HasSuffix(templateName, ".go.tmpl") {
+		return buf.Bytes(), nil
+	}
+	// the package name in qualified identifiers changes the alignment gofmt wants
+	formatted, e := format.Source(buf.Bytes())
+	if e != nil {
+		return nil, fmt.Errorf("Could not format %v: %v", templateName, e)
+	}
+	return formatted, nil
+}
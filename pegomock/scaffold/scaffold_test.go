@@ -0,0 +1,79 @@
+package scaffold_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/pegomock/filehandling"
+	"github.com/petergtz/pegomock/v4/pegomock/scaffold"
+)
+
+func TestScaffold(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scaffold Suite")
+}
+
+var _ = Describe("Scaffolding the example project", func() {
+	var (
+		dir        string
+		originalWd string
+	)
+
+	BeforeEach(func() {
+		var e error
+		originalWd, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		dir = filepath.Join(GinkgoT().TempDir(), "payments")
+		// the example module is not part of any workspace the tests might run in
+		GinkgoT().Setenv("GOWORK", "off")
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(originalWd)).To(Succeed())
+	})
+
+	It("generates a project whose tests pass against the current Pegomock", func() {
+		project, e := scaffold.Example(dir, "")
+		Expect(e).NotTo(HaveOccurred())
+		Expect(project.ModulePath).To(Equal("example.com/payments"))
+		Expect(project.Files).To(Equal([]string{
+			filepath.Join(dir, "go.mod"),
+			filepath.Join(dir, "payments.go"),
+			filepath.Join(dir, "payments_test.go"),
+		}))
+
+		repoRoot, e := filepath.Abs("../..")
+		Expect(e).NotTo(HaveOccurred())
+		Expect(os.Chdir(project.Dir)).To(Succeed())
+		for _, iface := range project.Interfaces {
+			filehandling.GenerateMockFileInOutputDir([]string{project.ModulePath, iface}, ".", "", "", project.PackageName+"_test", "", false, GinkgoWriter)
+		}
+		Expect(exec.Command("go", "mod", "edit", "-replace", "github.com/petergtz/pegomock/v4="+repoRoot).Run()).To(Succeed())
+		Expect(exec.Command("go", "mod", "tidy").Run()).To(Succeed())
+
+		output, e := exec.Command("go", "test", "./...").CombinedOutput()
+		Expect(e).NotTo(HaveOccurred(), string(output))
+	})
+
+	It("uses the given module path", func() {
+		project, e := scaffold.Example(dir, "example.com/shop/payments")
+		Expect(e).NotTo(HaveOccurred())
+
+		content, e := os.ReadFile(filepath.Join(dir, "payments_test.go"))
+		Expect(e).NotTo(HaveOccurred())
+		Expect(string(content)).To(ContainSubstring("\t\"example.com/shop/payments\"\n"))
+		Expect(project.ModulePath).To(Equal("example.com/shop/payments"))
+	})
+
+	It("refuses to write into a non-empty directory", func() {
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, "main.go"), []byte("package main\n"), 0644)).To(Succeed())
+
+		_, e := scaffold.Example(dir, "")
+		Expect(e).To(MatchError(ContainSubstring("is not empty")))
+	})
+})
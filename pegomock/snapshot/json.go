@@ -0,0 +1,72 @@
+package snapshot
+
+import (
+	"encoding/json"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+// Interface is the serialized model of an interface, as written by TakeJSON. Types are fully
+// qualified with their import paths.
+type Interface struct {
+	ImportPath string   `json:"importPath"`
+	Name       string   `json:"name"`
+	TypeParams []string `json:"typeParams,omitempty"`
+	Methods    []Method `json:"methods"`
+}
+
+type Method struct {
+	Name     string      `json:"name"`
+	In       []Parameter `json:"in"`
+	Variadic *Parameter  `json:"variadic,omitempty"`
+	Out      []Parameter `json:"out"`
+}
+
+type Parameter struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// TakeJSON serializes the model of iface, e.g. for "pegomock compat-report". Unlike the lock file
+// written by Take, it keeps parameter names, so renames can be told apart from other changes.
+func TakeJSON(importPath string, iface *model.Interface) ([]byte, error) {
+	packageMap := fullyQualifyingPackageMapFor(iface)
+	result := Interface{ImportPath: importPath, Name: iface.Name, Methods: []Method{}}
+	for _, typeParam := range iface.TypeParams {
+		result.TypeParams = append(result.TypeParams, typeParam.Name+" "+typeParam.Type.String(packageMap, ""))
+	}
+	for _, method := range iface.Methods {
+		serialized := Method{
+			Name: method.Name,
+			In:   parametersOf(method.In, packageMap),
+			Out:  parametersOf(method.Out, packageMap),
+		}
+		if method.Variadic != nil {
+			variadic := parameterOf(method.Variadic, packageMap)
+			serialized.Variadic = &variadic
+		}
+		result.Methods = append(result.Methods, serialized)
+	}
+	return json.MarshalIndent(result, "", "  ")
+}
+
+// ParseJSON reads a model written by TakeJSON.
+func ParseJSON(data []byte) (*Interface, error) {
+	var iface Interface
+	if e := json.Unmarshal(data, &iface); e != nil {
+		return nil, e
+	}
+	return &iface, nil
+}
+
+func parametersOf(params []*model.Parameter, packageMap map[string]string) []Parameter {
+	result := make([]Parameter, len(params))
+	for i, param := range params {
+		result[i] = parameterOf(param, packageMap)
+	}
+	return result
+}
+
+func parameterOf(param *model.Parameter, packageMap map[string]string) Parameter {
+	return Parameter{Name: param.Name, Type: param.Type.String(packageMap, "")}
+}
@@ -0,0 +1,115 @@
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/model"
+)
+
+const header = "# Interface snapshot generated by pegomock. DO NOT EDIT."
+
+// Take renders the method set of iface as the contents of a lock file. Parameter names are
+// omitted on purpose, because renaming a parameter does not change the interface contract.
+func Take(importPath string, iface *model.Interface) []byte {
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, header)
+	packageMap := fullyQualifyingPackageMapFor(iface)
+	fmt.Fprintf(buf, "interface %v.%v%v\n", importPath, iface.Name, typeParamsString(iface.TypeParams, packageMap))
+	for _, line := range sortedMethodLines(iface, packageMap) {
+		fmt.Fprintln(buf, line)
+	}
+	return buf.Bytes()
+}
+
+// Diff compares a previously taken snapshot with a current one and returns a semantic,
+// human-readable list of differences. An empty result means the interfaces are identical.
+func Diff(old, current []byte) []string {
+	oldName, oldMethods := parse(old)
+	currentName, currentMethods := parse(current)
+
+	var diffs []string
+	if oldName != currentName {
+		diffs = append(diffs, fmt.Sprintf("interface changed: %v -> %v", oldName, currentName))
+	}
+	for _, name := range sortedKeys(oldMethods) {
+		currentSignature, exists := currentMethods[name]
+		switch {
+		case !exists:
+			diffs = append(diffs, "- removed method: "+name+oldMethods[name])
+		case currentSignature != oldMethods[name]:
+			diffs = append(diffs, fmt.Sprintf("~ changed method: %v%v -> %v%v", name, oldMethods[name], name, currentSignature))
+		}
+	}
+	for _, name := range sortedKeys(currentMethods) {
+		if _, exists := oldMethods[name]; !exists {
+			diffs = append(diffs, "+ added method: "+name+currentMethods[name])
+		}
+	}
+	return diffs
+}
+
+// fullyQualifyingPackageMapFor maps every import path to itself, so named types are qualified
+// with their full import path, which makes snapshots independent of import aliases.
+func fullyQualifyingPackageMapFor(iface *model.Interface) map[string]string {
+	packageMap := make(map[string]string)
+	for importPath := range (&model.Package{Interfaces: []*model.Interface{iface}}).Imports() {
+		packageMap[importPath] = importPath
+	}
+	return packageMap
+}
+
+func sortedMethodLines(iface *model.Interface, packageMap map[string]string) []string {
+	lines := make([]string, len(iface.Methods))
+	for i, method := range iface.Methods {
+		lines[i] = method.Name + signatureOf(method, packageMap)
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+func signatureOf(method *model.Method, packageMap map[string]string) string {
+	funcType := &model.FuncType{In: method.In, Out: method.Out, Variadic: method.Variadic}
+	return strings.TrimPrefix(funcType.String(packageMap, ""), "func")
+}
+
+func typeParamsString(typeParams []*model.Parameter, packageMap map[string]string) string {
+	if len(typeParams) == 0 {
+		return ""
+	}
+	params := make([]string, len(typeParams))
+	for i, param := range typeParams {
+		params[i] = param.Name + " " + param.Type.String(packageMap, "")
+	}
+	return "[" + strings.Join(params, ", ") + "]"
+}
+
+func parse(snapshot []byte) (interfaceName string, methods map[string]string) {
+	methods = make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(snapshot))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "interface "):
+			interfaceName = strings.TrimPrefix(line, "interface ")
+		default:
+			if i := strings.Index(line, "("); i > 0 {
+				methods[line[:i]] = line[i:]
+			}
+		}
+	}
+	return
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
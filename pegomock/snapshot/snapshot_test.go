@@ -0,0 +1,89 @@
+package snapshot_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/model"
+	"github.com/petergtz/pegomock/v4/pegomock/snapshot"
+)
+
+func TestSnapshot(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Snapshot Suite")
+}
+
+var _ = Describe("Snapshot", func() {
+	var iface *model.Interface
+
+	BeforeEach(func() {
+		iface = &model.Interface{
+			Name: "Store",
+			Methods: []*model.Method{
+				{
+					Name: "Get",
+					In:   []*model.Parameter{{Name: "key", Type: model.PredeclaredType("string")}},
+					Out: []*model.Parameter{
+						{Type: &model.NamedType{Package: "net/http", Type: "Request"}},
+						{Type: model.PredeclaredType("error")},
+					},
+				},
+				{Name: "Close"},
+			},
+		}
+	})
+
+	It("renders methods sorted and with fully qualified types", func() {
+		Expect(string(snapshot.Take("example.com/store", iface))).To(Equal(
+			"# Interface snapshot generated by pegomock. DO NOT EDIT.\n" +
+				"interface example.com/store.Store\n" +
+				"Close()\n" +
+				"Get(string) (net/http.Request, error)\n"))
+	})
+
+	It("reports no differences for an unchanged interface", func() {
+		locked := snapshot.Take("example.com/store", iface)
+		iface.Methods[0].In[0].Name = "renamedParam"
+
+		Expect(snapshot.Diff(locked, snapshot.Take("example.com/store", iface))).To(BeEmpty())
+	})
+
+	It("reports added, removed and changed methods", func() {
+		locked := snapshot.Take("example.com/store", iface)
+		iface.Methods[0].In[0].Type = model.PredeclaredType("int")
+		iface.Methods[1] = &model.Method{Name: "Open"}
+
+		Expect(snapshot.Diff(locked, snapshot.Take("example.com/store", iface))).To(Equal([]string{
+			"- removed method: Close()",
+			"~ changed method: Get(string) (net/http.Request, error) -> Get(int) (net/http.Request, error)",
+			"+ added method: Open()",
+		}))
+	})
+
+	It("serializes the model with parameter names as JSON", func() {
+		iface.Methods[1].Variadic = &model.Parameter{Name: "options", Type: model.PredeclaredType("string")}
+		serialized, e := snapshot.TakeJSON("example.com/store", iface)
+		Expect(e).NotTo(HaveOccurred())
+
+		parsed, e := snapshot.ParseJSON(serialized)
+		Expect(e).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(&snapshot.Interface{
+			ImportPath: "example.com/store",
+			Name:       "Store",
+			Methods: []snapshot.Method{
+				{
+					Name: "Get",
+					In:   []snapshot.Parameter{{Name: "key", Type: "string"}},
+					Out:  []snapshot.Parameter{{Type: "net/http.Request"}, {Type: "error"}},
+				},
+				{
+					Name:     "Close",
+					In:       []snapshot.Parameter{},
+					Variadic: &snapshot.Parameter{Name: "options", Type: "string"},
+					Out:      []snapshot.Parameter{},
+				},
+			},
+		}))
+	})
+})
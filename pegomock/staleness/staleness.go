@@ -0,0 +1,125 @@
+// Package staleness finds generated mocks whose interfaces changed since the mocks were generated,
+// using the source hashes embedded into the mocks' constructors.
+package staleness
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/petergtz/pegomock/v4/internal/freshness"
+	"golang.org/x/tools/go/packages"
+)
+
+// Mock is a generated mock together with the stamp of the interface it was generated from.
+type Mock struct {
+	File          string
+	ImportPath    string
+	InterfaceName string
+	SourceHash    string // of the interface's declaration when the mock was generated
+}
+
+// Result is the outcome of checking a Mock.
+type Result struct {
+	Mock
+	Stale bool
+	Err   error // if the current declaration of the interface couldn't be hashed
+}
+
+// Check checks all generated mocks in the packages matching patterns, e.g. "./...", including
+// their test files and files excluded by build constraints. Mocks without stamp, e.g. generated
+// with --api-level v3, are skipped. Results are sorted by file.
+func Check(patterns []string) ([]Result, error) {
+	files, e := goFilesOf(patterns)
+	if e != nil {
+		return nil, e
+	}
+	type currentHash struct {
+		hash string
+		err  error
+	}
+	currentHashes := make(map[string]currentHash)
+	var results []Result
+	for _, file := range files {
+		mocks, e := stampedMocksIn(file)
+		if e != nil {
+			return nil, e
+		}
+		for _, mock := range mocks {
+			key := mock.ImportPath + "." + mock.InterfaceName
+			current, hashed := currentHashes[key]
+			if !hashed {
+				current.hash, current.err = freshness.SourceHash(mock.ImportPath, mock.InterfaceName)
+				currentHashes[key] = current
+			}
+			results = append(results, Result{Mock: mock, Stale: current.err == nil && current.hash != mock.SourceHash, Err: current.err})
+		}
+	}
+	return results, nil
+}
+
+func goFilesOf(patterns []string) ([]string, error) {
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName | packages.NeedFiles, Tests: true}, patterns...)
+	if e != nil {
+		return nil, e
+	}
+	unique := make(map[string]bool)
+	for _, pkg := range pkgs {
+		for _, pkgErr := range pkg.Errors {
+			if pkgErr.Kind == packages.ListError {
+				return nil, fmt.Errorf("Could not load %v: %v", pkg.PkgPath, pkgErr)
+			}
+		}
+		for _, file := range append(pkg.GoFiles, pkg.IgnoredFiles...) {
+			unique[file] = true
+		}
+	}
+	files := make([]string, 0, len(unique))
+	for file := range unique {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// stampedMocksIn returns the mocks whose constructors call pegomock.CheckFreshness in path, if it
+// was generated by Pegomock.
+func stampedMocksIn(path string) ([]Mock, error) {
+	content, e := os.ReadFile(path)
+	if e != nil {
+		return nil, e
+	}
+	if !strings.Contains(string(content), "// Code generated by pegomock. DO NOT EDIT.") {
+		return nil, nil
+	}
+	file, e := parser.ParseFile(token.NewFileSet(), path, content, parser.SkipObjectResolution)
+	if e != nil {
+		return nil, e
+	}
+	var mocks []Mock
+	ast.Inspect(file, func(node ast.Node) bool {
+		call, isCall := node.(*ast.CallExpr)
+		if !isCall || len(call.Args) != 3 {
+			return true
+		}
+		if selector, isSelector := call.Fun.(*ast.SelectorExpr); !isSelector || selector.Sel.Name != "CheckFreshness" {
+			return true
+		}
+		var args [3]string
+		for i, arg := range call.Args {
+			literal, isLiteral := arg.(*ast.BasicLit)
+			if !isLiteral || literal.Kind != token.STRING {
+				return true
+			}
+			args[i], _ = strconv.Unquote(literal.Value)
+		}
+		mocks = append(mocks, Mock{File: path, ImportPath: args[0], InterfaceName: args[1], SourceHash: args[2]})
+		return false
+	})
+	return mocks, nil
+}
@@ -0,0 +1,105 @@
+package staleness_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4/internal/freshness"
+	"github.com/petergtz/pegomock/v4/pegomock/staleness"
+)
+
+func TestStaleness(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Staleness Suite")
+}
+
+var _ = Describe("Checking mocks for staleness", func() {
+	var (
+		moduleDir  string
+		originalWd string
+	)
+
+	writeFile := func(path string, content string) {
+		Expect(os.MkdirAll(filepath.Dir(filepath.Join(moduleDir, path)), 0755)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(moduleDir, path), []byte(content), 0644)).To(Succeed())
+	}
+
+	mockSource := func(interfaceName, hash string) string {
+		return "// Code generated by pegomock. DO NOT EDIT.\n" +
+			"// Source: example.com/stale/store (interfaces: " + interfaceName + ")\n\n" +
+			"package store_test\n\n" +
+			"import pegomock \"github.com/petergtz/pegomock/v4\"\n\n" +
+			"func NewMock" + interfaceName + "() {\n" +
+			"\tpegomock.CheckFreshness(\"example.com/stale/store\", \"" + interfaceName + "\", \"" + hash + "\")\n" +
+			"}\n"
+	}
+
+	BeforeEach(func() {
+		var e error
+		originalWd, e = os.Getwd()
+		Expect(e).NotTo(HaveOccurred())
+		moduleDir = GinkgoT().TempDir()
+		writeFile("go.mod", "module example.com/stale\n\ngo 1.18\n")
+		writeFile("store/store.go", "package store\n\ntype Store interface {\n\tGet(key string) string\n}\n")
+		Expect(os.Chdir(moduleDir)).To(Succeed())
+		// the temporary module is not part of any workspace the tests might run in
+		GinkgoT().Setenv("GOWORK", "off")
+	})
+
+	AfterEach(func() {
+		Expect(os.Chdir(originalWd)).To(Succeed())
+	})
+
+	It("reports mocks as fresh as long as the interface is unchanged", func() {
+		hash, e := freshness.SourceHash("example.com/stale/store", "Store")
+		Expect(e).NotTo(HaveOccurred())
+		writeFile("store/mock_store_test.go", mockSource("Store", hash))
+
+		results, e := staleness.Check([]string{"./..."})
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(results).To(Equal([]staleness.Result{{Mock: staleness.Mock{
+			File:          filepath.Join(moduleDir, "store", "mock_store_test.go"),
+			ImportPath:    "example.com/stale/store",
+			InterfaceName: "Store",
+			SourceHash:    hash,
+		}}}))
+	})
+
+	It("reports mocks as stale when the interface changed", func() {
+		hash, e := freshness.SourceHash("example.com/stale/store", "Store")
+		Expect(e).NotTo(HaveOccurred())
+		writeFile("store/mock_store_test.go", mockSource("Store", hash))
+		writeFile("store/store.go", "package store\n\ntype Store interface {\n\tGet(key string) (string, error)\n}\n")
+
+		results, e := staleness.Check([]string{"./..."})
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Stale).To(BeTrue())
+	})
+
+	It("reports an error when the interface can't be found anymore", func() {
+		writeFile("store/mock_cache_test.go", mockSource("Cache", "0123456789abcdef"))
+
+		results, e := staleness.Check([]string{"./..."})
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Stale).To(BeFalse())
+		Expect(results[0].Err).To(MatchError("Did not find declaration of Cache in package example.com/stale/store"))
+	})
+
+	It("skips mocks without stamp and files not generated by Pegomock", func() {
+		writeFile("store/mock_store_test.go", "// Code generated by pegomock. DO NOT EDIT.\n\npackage store_test\n")
+		writeFile("store/store_test.go", "package store_test\n\nfunc f() { pegomock.CheckFreshness(\"a\", \"b\", \"c\") }\n")
+
+		results, e := staleness.Check([]string{"./..."})
+		Expect(e).NotTo(HaveOccurred())
+
+		Expect(results).To(BeEmpty())
+	})
+})
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var buildTagsRegex = regexp.MustCompile(`^[\w.]+(,[\w.]+)*$`)
+
+// SetBuildEnvironment makes the go command, which parses the packages for Pegomock, select files
+// as if building for goos and goarch with the comma-separated build tags, e.g. "integration,e2e".
+// Empty values keep the defaults of the go command. The returned function restores the previous
+// environment.
+func SetBuildEnvironment(goos, goarch, tags string) (restore func(), err error) {
+	if tags != "" && !buildTagsRegex.MatchString(tags) {
+		return nil, fmt.Errorf("Invalid tags %q. Expected comma-separated build tags like \"integration,e2e\".", tags)
+	}
+	vars := map[string]string{}
+	if goos != "" {
+		vars["GOOS"] = goos
+	}
+	if goarch != "" {
+		vars["GOARCH"] = goarch
+	}
+	if tags != "" {
+		vars["GOFLAGS"] = strings.TrimSpace(os.Getenv("GOFLAGS") + " -tags=" + tags)
+	}
+	previous := make(map[string]*string, len(vars))
+	for name, value := range vars {
+		if old, isSet := os.LookupEnv(name); isSet {
+			previous[name] = &old
+		} else {
+			previous[name] = nil
+		}
+		PanicOnError(os.Setenv(name, value))
+	}
+	return func() {
+		for name, old := range previous {
+			if old == nil {
+				_ = os.Unsetenv(name)
+			} else {
+				_ = os.Setenv(name, *old)
+			}
+		}
+	}, nil
+}
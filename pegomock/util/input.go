@@ -3,10 +3,17 @@ package util
 import (
 	"errors"
 	"fmt"
-	"os"
+	"go/build/constraint"
+	"go/token"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+
+	"github.com/petergtz/pegomock/v4/mockgen"
+	"github.com/samber/lo"
+	"golang.org/x/tools/go/packages"
 )
 
 func ValidateArgs(args []string) error {
@@ -26,6 +33,111 @@ func ValidateArgs(args []string) error {
 	return nil
 }
 
+// ValidateImportAliases checks the aliases given with --import-alias PKGPATH=ALIAS.
+func ValidateImportAliases(aliases map[string]string) error {
+	importPaths := make([]string, 0, len(aliases))
+	for importPath := range aliases {
+		importPaths = append(importPaths, importPath)
+	}
+	sort.Strings(importPaths)
+	// packages the generated code imports under fixed names
+	fixedImports := map[string]string{"pegomock": "github.com/petergtz/pegomock/v4", "reflect": "reflect", "time": "time"}
+	importPathsByAlias := make(map[string]string, len(aliases))
+	for _, importPath := range importPaths {
+		alias := aliases[importPath]
+		if importPath == "" {
+			return fmt.Errorf("Import alias %q has no package path. Use PKGPATH=ALIAS.", alias)
+		}
+		if !token.IsIdentifier(alias) || alias == "_" {
+			return fmt.Errorf("Import alias %q for %v is not a valid package name.", alias, importPath)
+		}
+		for name, fixedImportPath := range fixedImports {
+			if importPath == fixedImportPath {
+				return fmt.Errorf("Package %v cannot be aliased, because generated code always imports it as %v.", importPath, name)
+			}
+			if alias == name {
+				return fmt.Errorf("Import alias %q for %v is reserved for package %v.", alias, importPath, fixedImportPath)
+			}
+		}
+		if other, exists := importPathsByAlias[alias]; exists {
+			return fmt.Errorf("Import alias %q is used for both %v and %v.", alias, other, importPath)
+		}
+		importPathsByAlias[alias] = importPath
+	}
+	return nil
+}
+
+// ParseDefaultOptions splits the comma-separated names given with --default-options and checks
+// that the generator knows them.
+func ParseDefaultOptions(names string) ([]string, error) {
+	if strings.TrimSpace(names) == "" {
+		return nil, nil
+	}
+	var result []string
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if !lo.Contains(mockgen.DefaultOptionNames(), name) {
+			return nil, fmt.Errorf("Unknown default option %q. Valid options are: %v.", name, strings.Join(mockgen.DefaultOptionNames(), ", "))
+		}
+		result = append(result, name)
+	}
+	return result, nil
+}
+
+// ParseMethodFilter compiles the filter given with --include-methods or --exclude-methods. It is
+// either a regex matching whole method names, e.g. "Get.*", or a comma-separated list of them, e.g. "Get,Put".
+// An empty filter returns nil.
+func ParseMethodFilter(filter string) (*regexp.Regexp, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, nil
+	}
+	alternatives := strings.Split(filter, ",")
+	for i := range alternatives {
+		alternatives[i] = strings.TrimSpace(alternatives[i])
+	}
+	methodRegex, e := regexp.Compile("^(?:" + strings.Join(alternatives, "|") + ")$")
+	if e != nil {
+		return nil, fmt.Errorf("Invalid method filter %q: %v", filter, e)
+	}
+	return methodRegex, nil
+}
+
+// ParseBuildTags turns what was given with --build-tags into the expression of a //go:build
+// constraint. It is either such an expression, e.g. "linux && (amd64 || arm64)", or a
+// comma-separated list of tags that must all be satisfied, like with "go build -tags", e.g.
+// "linux,amd64". An empty string returns an empty expression.
+func ParseBuildTags(tags string) (string, error) {
+	if strings.TrimSpace(tags) == "" {
+		return "", nil
+	}
+	if !strings.ContainsAny(tags, "&|!()") {
+		tags = strings.Join(strings.Split(tags, ","), " && ")
+	}
+	expr, e := constraint.Parse("//go:build " + tags)
+	if e != nil {
+		return "", fmt.Errorf("Invalid build tags %q: %v", tags, e)
+	}
+	return expr.String(), nil
+}
+
+// PluginPrefix is what the executables of generator plugins found in PATH are named with, e.g.
+// "pegomock-gen-tracing" for the plugin "tracing".
+const PluginPrefix = "pegomock-gen-"
+
+// LookupPlugin returns the absolute path of the executable of the generator plugin given with
+// --plugin. It is either a path, which must contain a path separator like "./tracing", or the name
+// of a plugin whose executable is PluginPrefix followed by the name and can be found in PATH.
+func LookupPlugin(plugin string) (string, error) {
+	if strings.ContainsRune(plugin, filepath.Separator) || strings.ContainsRune(plugin, '/') {
+		return filepath.Abs(plugin)
+	}
+	path, e := exec.LookPath(PluginPrefix + plugin)
+	if e != nil {
+		return "", fmt.Errorf("Could not find plugin %q: %v", plugin, e)
+	}
+	return filepath.Abs(path)
+}
+
 func SourceArgs(args []string) ([]string, error) {
 	if len(args) == 1 {
 		packagePath, err := packagePathFromWorkingDirectoryAndGoModule()
@@ -40,46 +152,21 @@ func SourceArgs(args []string) ([]string, error) {
 	}
 }
 
+// packagePathFromWorkingDirectoryAndGoModule resolves the import path of the package in the working
+// directory, taking nested modules, workspaces and replace directives into account.
 func packagePathFromWorkingDirectoryAndGoModule() (string, error) {
-	dir, e := os.Getwd()
+	pkgs, e := packages.Load(&packages.Config{Mode: packages.NeedName}, ".")
 	if e != nil {
 		return "", e
 	}
-	gomodDir := findModuleRoot(dir)
-	subPackage, e := filepath.Rel(gomodDir, dir)
-	if e != nil {
-		return "", errors.New("Could not get a relative path for " + dir + " based on path " + gomodDir)
-	}
-	gomodFilepath := filepath.Join(gomodDir, "go.mod")
-
-	content, e := os.ReadFile(gomodFilepath)
-	if e != nil {
-		return "", errors.New("Could not read file " + gomodFilepath)
+	if len(pkgs) != 1 {
+		return "", fmt.Errorf("Expected exactly one package in the working directory, but found %v", len(pkgs))
 	}
-	modulePathMatches := regexp.MustCompile(`^module (.*)\n`).FindSubmatch(content)
-	if len(modulePathMatches) != 2 {
-		return "", errors.New("Cannot parse" + gomodFilepath + "file. File does not start with 'module'")
-	}
-	return filepath.Join(
-			string(modulePathMatches[1]),
-			subPackage),
-		nil
-}
-
-// copied from https://github.com/golang/go/blob/ab724d43efe7e1a7516c1d13e40b55dca26a61b4/src/cmd/go/internal/modload/init.go#L480-L495:
-func findModuleRoot(dir string) (root string) {
-	dir = filepath.Clean(dir)
-
-	// Look for enclosing go.mod.
-	for {
-		if fi, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !fi.IsDir() {
-			return dir
-		}
-		d := filepath.Dir(dir)
-		if d == dir {
-			break
+	if pkgs[0].PkgPath == "" {
+		if len(pkgs[0].Errors) > 0 {
+			return "", pkgs[0].Errors[0]
 		}
-		dir = d
+		return "", errors.New("Could not resolve the import path of the working directory")
 	}
-	return ""
+	return pkgs[0].PkgPath, nil
 }
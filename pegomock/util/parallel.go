@@ -0,0 +1,56 @@
+package util
+
+import (
+	"io"
+	"sync"
+)
+
+// RunInParallel runs tasks on up to workers goroutines and returns when all of them finished. If
+// tasks panic, it then panics with the value of the first of them in the order of tasks, so the
+// reported error doesn't depend on scheduling.
+func RunInParallel(tasks []func(), workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	panics := make([]interface{}, len(tasks))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(tasks); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				func() {
+					defer func() { panics[i] = recover() }()
+					tasks[i]()
+				}()
+			}
+		}()
+	}
+	for i := range tasks {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	for _, p := range panics {
+		if p != nil {
+			panic(p)
+		}
+	}
+}
+
+// SyncWriter makes concurrent writes to w safe.
+func SyncWriter(w io.Writer) io.Writer {
+	return &syncWriter{w: w}
+}
+
+type syncWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.w.Write(p)
+}
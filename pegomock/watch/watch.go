@@ -19,10 +19,14 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/alecthomas/kingpin/v2"
 
+	"github.com/petergtz/pegomock/v4/mockgen"
+	"github.com/petergtz/pegomock/v4/pegomock/cache"
 	"github.com/petergtz/pegomock/v4/pegomock/filehandling"
 	"github.com/petergtz/pegomock/v4/pegomock/util"
 )
@@ -31,78 +35,228 @@ const wellKnownInterfaceListFile = "interfaces_to_mock"
 
 var join = strings.Join
 
+// DefaultIgnorePatterns are the directories a recursive watch skips unless told otherwise.
+var DefaultIgnorePatterns = []string{"vendor", "testdata"}
+
 type MockFileUpdater struct {
-	recursive   bool
-	targetPaths []string
-	lastErrors  map[string]string
+	recursive      bool
+	targetPaths    []string
+	ignorePatterns []string
+	lastErrors     map[string]string
+	mutex          sync.Mutex // guards lastErrors
+	cache          *cache.Cache
 }
 
+// NewMockFileUpdater returns an updater generating the mocks listed in the interfaces_to_mock
+// files of targetPaths. Target paths ending in /..., like ./..., are watched recursively, as are
+// all of them if recursive is set.
 func NewMockFileUpdater(targetPaths []string, recursive bool) *MockFileUpdater {
 	return &MockFileUpdater{
-		targetPaths: targetPaths,
-		recursive:   recursive,
-		lastErrors:  make(map[string]string),
+		targetPaths:    targetPaths,
+		recursive:      recursive,
+		ignorePatterns: DefaultIgnorePatterns,
+		lastErrors:     make(map[string]string),
 	}
 }
 
+// WithIgnorePatterns makes recursive watches skip the directories matching any of patterns instead
+// of DefaultIgnorePatterns. Patterns are filepath.Match globs matched against the name of a
+// directory and against its slash-separated path relative to the watched directory, e.g. "vendor"
+// or "internal/gen*". Like the go command, recursive watches always skip directories whose names
+// start with "." or "_".
+func (updater *MockFileUpdater) WithIgnorePatterns(patterns []string) *MockFileUpdater {
+	updater.ignorePatterns = patterns
+	return updater
+}
+
+// WithCache makes the updater skip generating mocks whose inputs didn't change according to c.
+func (updater *MockFileUpdater) WithCache(c *cache.Cache) *MockFileUpdater {
+	updater.cache = c
+	return updater
+}
+
 func (updater *MockFileUpdater) Update() {
+	for _, dir := range updater.Dirs() {
+		util.WithinWorkingDir(dir, updater.updateMockFiles)
+	}
+}
+
+// Dirs returns the directories the updater currently watches.
+func (updater *MockFileUpdater) Dirs() []string {
+	var dirs []string
 	for _, targetPath := range updater.targetPaths {
-		if updater.recursive {
-			e := filepath.Walk(targetPath, func(path string, info os.FileInfo, err error) error {
-				if err == nil && info.IsDir() {
-					util.WithinWorkingDir(path, updater.updateMockFiles)
-				}
+		targetDir, recursive := splitRecursiveTarget(targetPath)
+		if !updater.recursive && !recursive {
+			dirs = append(dirs, targetDir)
+			continue
+		}
+		e := filepath.Walk(targetDir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || !info.IsDir() {
 				return nil
-			})
-			if e != nil {
-				panic(e)
 			}
-		} else {
-			util.WithinWorkingDir(targetPath, updater.updateMockFiles)
+			if path != targetDir && updater.ignored(targetDir, path) {
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, path)
+			return nil
+		})
+		if e != nil {
+			panic(e)
 		}
 	}
+	return dirs
+}
+
+func (updater *MockFileUpdater) ignored(targetDir string, dir string) bool {
+	name := filepath.Base(dir)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return true
+	}
+	relativePath, e := filepath.Rel(targetDir, dir)
+	util.PanicOnError(e)
+	for _, pattern := range updater.ignorePatterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+		if matched, _ := filepath.Match(pattern, filepath.ToSlash(relativePath)); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// splitRecursiveTarget splits /... off targetPath, e.g. returns "." and true for "./...".
+func splitRecursiveTarget(targetPath string) (string, bool) {
+	if targetPath == "..." {
+		return ".", true
+	}
+	if strings.HasSuffix(targetPath, "/...") {
+		return filepath.Clean(strings.TrimSuffix(targetPath, "/...")), true
+	}
+	return targetPath, false
 }
 
 func (updater *MockFileUpdater) updateMockFiles(targetPath string) {
 	if _, err := os.Stat(wellKnownInterfaceListFile); os.IsNotExist(err) {
 		return
 	}
+	// the mocks of one directory are generated concurrently, directories one after the other,
+	// because generation depends on the working directory
+	var tasks []func()
 	for _, lineParts := range linesIn(wellKnownInterfaceListFile) {
-		lineCmd := kingpin.New("What should go in here", "And what should go in here")
-		destination := lineCmd.Flag("output", "Output file; defaults to mock_<interface>_test.go.").Short('o').String()
-		nameOut := lineCmd.Flag("name", "Struct name of the generated code; defaults to the name of the interface prefixed with Mock").Default(filepath.Base(targetPath) + "_test").String()
-		packageOut := lineCmd.Flag("package", "Package of the generated code; defaults to the package from which pegomock was executed suffixed with _test").Default(filepath.Base(targetPath) + "_test").String()
-		selfPackage := lineCmd.Flag("self_package", "If set, the package this mock will be part of.").String()
-		lineArgs := lineCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file").Required().Strings()
-
-		_, parseErr := lineCmd.Parse(lineParts)
-		if parseErr != nil {
-			fmt.Println("Error while trying to generate mock for line", join(lineParts, " "), ":", parseErr)
-			continue
-		}
-		defer func() {
-			err := recover()
-			if err != nil {
-				if updater.lastErrors[errorKey(*lineArgs)] != fmt.Sprint(err) {
-					fmt.Println("Error while trying to generate mock for", join(lineParts, " "), ":", err)
-					updater.lastErrors[errorKey(*lineArgs)] = fmt.Sprint(err)
-				}
+		lineParts := lineParts
+		tasks = append(tasks, func() { updater.updateMockFile(targetPath, lineParts) })
+	}
+	util.RunInParallel(tasks, runtime.NumCPU())
+}
+
+func (updater *MockFileUpdater) updateMockFile(targetPath string, lineParts []string) {
+	lineCmd := kingpin.New("What should go in here", "And what should go in here")
+	destination := lineCmd.Flag("output", "Output file; defaults to mock_<interface>_test.go.").Short('o').String()
+	nameOut := lineCmd.Flag("name", "Struct name of the generated code; defaults to the name of the interface prefixed with Mock").Default(filepath.Base(targetPath) + "_test").String()
+	packageOut := lineCmd.Flag("package", "Package of the generated code; defaults to the package from which pegomock was executed suffixed with _test").Default(filepath.Base(targetPath) + "_test").String()
+	selfPackage := lineCmd.Flag("self_package", "If set, the package this mock will be part of.").String()
+	importAliases := lineCmd.Flag("import-alias", "Import the package with this path under the given alias instead of its base name. Repeatable.").PlaceHolder("PKGPATH=ALIAS").StringMap()
+	apiLevel := lineCmd.Flag("api-level", "Pegomock runtime API the generated code is written against: \"v4\" or \"v3\".").Default("v4").Enum("v3", "v4")
+	defaultOptions := lineCmd.Flag("default-options", "Comma-separated runtime options the generated constructors apply by default, e.g. \"strict,cloneArgs\".").PlaceHolder("OPTIONS").String()
+	outputTemplate := lineCmd.Flag("template", "Generate the output with this text/template instead of generating Pegomock mocks.").PlaceHolder("FILE").String()
+	plugin := lineCmd.Flag("plugin", "Generate the output with this generator plugin instead of generating Pegomock mocks: the path of an executable, or NAME for the executable "+util.PluginPrefix+"NAME in PATH.").PlaceHolder("NAME").String()
+	includeMethods := lineCmd.Flag("include-methods", "Only mock the methods matching this regex or comma-separated list.").PlaceHolder("METHODS").String()
+	excludeMethods := lineCmd.Flag("exclude-methods", "Don't mock the methods matching this regex or comma-separated list.").PlaceHolder("METHODS").String()
+	buildTags := lineCmd.Flag("build-tags", "Add a //go:build constraint to the generated file, e.g. \"linux && amd64\" or \"linux,amd64\".").PlaceHolder("TAGS").String()
+	assertInterface := lineCmd.Flag("assert-interface", "Additionally generate a compile-time assertion that the mock implements its interface.").Bool()
+	embedInterface := lineCmd.Flag("embed-interface", "Embed the mocked interface in the mock struct, so methods added to the interface later only panic when called.").Bool()
+	headerFile := lineCmd.Flag("header-file", "Start the generated file with the content of this file, e.g. a license header.").PlaceHolder("FILE").String()
+	style := lineCmd.Flag("style", "\"gomock\" generates mocks in the style of github.com/golang/mock.").Default("pegomock").Enum("pegomock", "gomock")
+	lineArgs := lineCmd.Arg("args", "A (optional) Go package path + space-separated interface or a .go file").Required().Strings()
+
+	_, parseErr := lineCmd.Parse(lineParts)
+	if parseErr != nil {
+		fmt.Println("Error while trying to generate mock for line", join(lineParts, " "), ":", parseErr)
+		return
+	}
+	defer func() {
+		err := recover()
+		if err != nil {
+			updater.mutex.Lock()
+			defer updater.mutex.Unlock()
+			if updater.lastErrors[errorKey(*lineArgs)] != fmt.Sprint(err) {
+				fmt.Println("Error while trying to generate mock for", join(lineParts, " "), ":", err)
+				updater.lastErrors[errorKey(*lineArgs)] = fmt.Sprint(err)
 			}
-		}()
+		}
+	}()
 
-		util.PanicOnError(util.ValidateArgs(*lineArgs))
-		sourceArgs, err := util.SourceArgs(*lineArgs)
-		util.PanicOnError(err)
+	util.PanicOnError(util.ValidateArgs(*lineArgs))
+	sourceArgs, err := util.SourceArgs(*lineArgs)
+	util.PanicOnError(err)
 
-		generatedMockSourceCode := filehandling.GenerateMockSourceCode(sourceArgs, *nameOut, *packageOut, *selfPackage, false, os.Stdout)
-		mockFilePath := filehandling.OutputFilePath(sourceArgs, ".", *destination)
-		hasChanged := util.WriteFileIfChanged(mockFilePath, generatedMockSourceCode)
+	util.PanicOnError(util.ValidateImportAliases(*importAliases))
+	defaultOptionNames, err := util.ParseDefaultOptions(*defaultOptions)
+	util.PanicOnError(err)
 
-		if hasChanged || updater.lastErrors[errorKey(*lineArgs)] != "" {
-			fmt.Println("(Re)generated mock for", errorKey(*lineArgs), "in", mockFilePath)
+	options := []mockgen.Option{mockgen.WithImportAliases(*importAliases), mockgen.WithAPILevel(mockgen.APILevel(*apiLevel)), mockgen.WithDefaultOptions(defaultOptionNames)}
+	cacheSettings := append([]string{targetPath}, lineParts...)
+	if *plugin != "" {
+		if *outputTemplate != "" || *style == "gomock" {
+			panic("Cannot use --plugin together with --template or --style gomock")
 		}
-		delete(updater.lastErrors, errorKey(*lineArgs))
+		pluginPath, err := util.LookupPlugin(*plugin)
+		util.PanicOnError(err)
+		pluginInfo, err := os.Stat(pluginPath)
+		util.PanicOnError(err)
+		options = append(options, mockgen.WithPlugin(pluginPath))
+		// a rebuilt plugin generates other output
+		cacheSettings = append(cacheSettings, fmt.Sprint(pluginPath, pluginInfo.Size(), pluginInfo.ModTime().UnixNano()))
+	}
+	if *outputTemplate != "" {
+		tmpl, err := os.ReadFile(*outputTemplate)
+		util.PanicOnError(err)
+		options = append(options, mockgen.WithOutputTemplate(string(tmpl)))
+		cacheSettings = append(cacheSettings, string(tmpl))
+	}
+	includeMethodsRegex, err := util.ParseMethodFilter(*includeMethods)
+	util.PanicOnError(err)
+	excludeMethodsRegex, err := util.ParseMethodFilter(*excludeMethods)
+	util.PanicOnError(err)
+	options = append(options, mockgen.WithMethodFilter(includeMethodsRegex, excludeMethodsRegex))
+	buildConstraint, err := util.ParseBuildTags(*buildTags)
+	util.PanicOnError(err)
+	options = append(options, mockgen.WithBuildConstraint(buildConstraint))
+	if *headerFile != "" {
+		header, err := os.ReadFile(*headerFile)
+		util.PanicOnError(err)
+		options = append(options, mockgen.WithHeader(string(header)))
+		cacheSettings = append(cacheSettings, string(header))
+	}
+	if *assertInterface {
+		options = append(options, mockgen.WithInterfaceAssertion())
+	}
+	if *embedInterface {
+		options = append(options, mockgen.WithEmbeddedInterface())
+	}
+	if *style == "gomock" {
+		options = append(options, mockgen.WithGomockStyle())
+	}
+
+	mockFilePath := filehandling.OutputFilePath(sourceArgs, ".", *destination)
+	hasChanged := false
+	generateMock := func() {
+		generatedMockSourceCode := filehandling.GenerateMockSourceCode(sourceArgs, *nameOut, *packageOut, *selfPackage, false, os.Stdout, options...)
+		hasChanged = util.WriteFileIfChanged(mockFilePath, generatedMockSourceCode)
+	}
+	if updater.cache != nil {
+		updater.cache.Generate(mockFilePath, sourceArgs[0], cacheSettings, generateMock)
+	} else {
+		generateMock()
+	}
+
+	updater.mutex.Lock()
+	defer updater.mutex.Unlock()
+	if hasChanged || updater.lastErrors[errorKey(*lineArgs)] != "" {
+		fmt.Println("(Re)generated mock for", errorKey(*lineArgs), "in", mockFilePath)
 	}
+	delete(updater.lastErrors, errorKey(*lineArgs))
 }
 
 func errorKey(args []string) string {
@@ -116,6 +270,7 @@ func CreateWellKnownInterfaceListFilesIfNecessary(targetPaths []string) {
 }
 
 func CreateWellKnownInterfaceListFileIfNecessary(targetPath string) {
+	targetPath, _ = splitRecursiveTarget(targetPath)
 	file, err := os.OpenFile(filepath.Join(targetPath, wellKnownInterfaceListFile), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
 	if err != nil {
 		if os.IsExist(err) {
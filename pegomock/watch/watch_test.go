@@ -106,6 +106,20 @@ var _ = Describe("NewMockFileUpdater", func() {
 			})
 		})
 
+		Context("and generating the output with a plugin", func() {
+			It(`Eventually creates the file the plugin generates`, func() {
+				Expect(os.WriteFile(joinPath(packageDir, "pegomock-gen-stub"), []byte("#!/bin/sh\n"+
+					"printf 'package pegomocktest_test\\n\\ntype StubMyDisplay struct{}\\n'\n"), 0755)).To(Succeed())
+				WriteFile(joinPath(packageDir, "interfaces_to_mock"), "--plugin ./pegomock-gen-stub MyDisplay")
+
+				watch.NewMockFileUpdater([]string{packageDir}, false).Update()
+
+				Eventually(joinPath(packageDir, "mock_mydisplay_test.go"), "3s").Should(SatisfyAll(
+					BeAnExistingFile(),
+					BeAFileContainingSubString("type StubMyDisplay struct{}")))
+			})
+		})
+
 		Context(`and specifying the vendor path`, func() {
 
 			It(`Eventually creates a file containing the import ( vendored_package "github.com/petergtz/vendored_package" )'`, func() {
@@ -182,4 +196,34 @@ var _ = Describe("NewMockFileUpdater", func() {
 		})
 	})
 
+	Context("watching ./...", func() {
+		BeforeEach(func() {
+			Expect(os.MkdirAll(joinPath(packageDir, "testdata"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(joinPath(packageDir, ".git"), 0755)).To(Succeed())
+			Expect(os.MkdirAll(joinPath(packageDir, "internal", "generated"), 0755)).To(Succeed())
+		})
+
+		It(`watches all sub-directories except vendor, testdata and hidden ones`, func() {
+			Expect(watch.NewMockFileUpdater([]string{"./..."}, false).Dirs()).To(Equal([]string{
+				".", "internal", joinPath("internal", "generated"), "subpackage"}))
+		})
+
+		It(`skips the directories matching the ignore patterns instead`, func() {
+			updater := watch.NewMockFileUpdater([]string{"./..."}, false).WithIgnorePatterns([]string{"internal/gen*", "sub*"})
+
+			Expect(updater.Dirs()).To(Equal([]string{
+				".", "internal", "testdata", "vendor",
+				joinPath("vendor", "github.com"),
+				joinPath("vendor", "github.com", "petergtz"),
+				joinPath("vendor", "github.com", "petergtz", "vendored_package")}))
+		})
+
+		It(`creates interfaces_to_mock only in the watched directory itself`, func() {
+			watch.CreateWellKnownInterfaceListFilesIfNecessary([]string{"./..."})
+
+			Expect(joinPath(packageDir, "interfaces_to_mock")).To(BeAnExistingFile())
+			Expect(joinPath(subPackageDir, "interfaces_to_mock")).NotTo(BeAnExistingFile())
+		})
+	})
+
 })
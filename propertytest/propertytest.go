@@ -0,0 +1,160 @@
+// Package propertytest adapts pegomock to property-based testing with testing/quick: it generates
+// random exact-match stubbings for mocked collaborators and shrinks failing interaction sequences.
+package propertytest
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing/quick"
+	"time"
+
+	"github.com/petergtz/pegomock/v4"
+)
+
+// Stubbing is an exact-match stubbing with generated params and return values.
+type Stubbing struct {
+	MethodName   string
+	Params       []pegomock.Param
+	ReturnValues pegomock.ReturnValues
+}
+
+// ApplyTo stubs mock with s.
+func (s Stubbing) ApplyTo(mock pegomock.Mock) {
+	pegomock.StubInvocation(mock, s.MethodName, s.Params, s.ReturnValues)
+}
+
+func (s Stubbing) String() string {
+	params := make([]string, len(s.Params))
+	for i, param := range s.Params {
+		params[i] = fmt.Sprintf("%#v", param)
+	}
+	returnValues := make([]string, len(s.ReturnValues))
+	for i, returnValue := range s.ReturnValues {
+		returnValues[i] = fmt.Sprintf("%#v", returnValue)
+	}
+	return fmt.Sprintf("%v(%v) -> (%v)", s.MethodName, strings.Join(params, ", "), strings.Join(returnValues, ", "))
+}
+
+// RandomStubbing generates a stubbing for methodName of mock with random params and return values,
+// using quick.Value. Return values of types quick can't generate, like error, are left nil.
+func RandomStubbing(mock pegomock.Mock, methodName string, rand *rand.Rand) (Stubbing, error) {
+	method, exists := reflect.TypeOf(mock).MethodByName(methodName)
+	if !exists {
+		return Stubbing{}, fmt.Errorf("%T has no method %v", mock, methodName)
+	}
+	if method.Type.IsVariadic() {
+		return Stubbing{}, fmt.Errorf("variadic method %v is not supported", methodName)
+	}
+	stubbing := Stubbing{MethodName: methodName}
+	for i := 1; i < method.Type.NumIn(); i++ { // In(0) is the receiver
+		value, ok := quick.Value(method.Type.In(i), rand)
+		if !ok {
+			return Stubbing{}, fmt.Errorf("cannot generate values of type %v for method %v", method.Type.In(i), methodName)
+		}
+		stubbing.Params = append(stubbing.Params, value.Interface())
+	}
+	for i := 0; i < method.Type.NumOut(); i++ {
+		stubbing.ReturnValues = append(stubbing.ReturnValues, randomReturnValue(method.Type.Out(i), rand))
+	}
+	return stubbing, nil
+}
+
+func randomReturnValue(typ reflect.Type, rand *rand.Rand) pegomock.ReturnValue {
+	switch typ.Kind() {
+	case reflect.Interface, reflect.Func, reflect.Chan:
+		return nil
+	}
+	if value, ok := quick.Value(typ, rand); ok {
+		return value.Interface()
+	}
+	return reflect.Zero(typ).Interface()
+}
+
+// CheckError describes a failing interaction sequence, already shrunk to a minimal one.
+type CheckError struct {
+	Count     int
+	Stubbings []Stubbing
+}
+
+func (e *CheckError) Error() string {
+	stubbings := make([]string, len(e.Stubbings))
+	for i, stubbing := range e.Stubbings {
+		stubbings[i] = "\t" + stubbing.String()
+	}
+	return fmt.Sprintf("#%v: failed on stubbings:\n%v", e.Count, strings.Join(stubbings, "\n"))
+}
+
+// Check runs property config.MaxCount times (100 by default) against a fresh mock created by
+// newMock and stubbed with a random sequence of stubbings for methodNames. The stubbings are passed
+// to property as well, so it can drive the code under test with the stubbed params. If property
+// returns false, the sequence is shrunk by removing stubbings as long as property still fails on it.
+func Check(newMock func() pegomock.Mock, methodNames []string, property func(mock pegomock.Mock, stubbings []Stubbing) bool, config *quick.Config) error {
+	if len(methodNames) == 0 {
+		return fmt.Errorf("no method names given")
+	}
+	rand, maxCount := randAndMaxCountFrom(config)
+	for count := 1; count <= maxCount; count++ {
+		stubbings, e := randomSequence(newMock(), methodNames, rand)
+		if e != nil {
+			return e
+		}
+		if !holds(newMock, stubbings, property) {
+			return &CheckError{Count: count, Stubbings: shrink(newMock, stubbings, property)}
+		}
+	}
+	return nil
+}
+
+const maxSequenceLength = 10
+
+func randomSequence(mock pegomock.Mock, methodNames []string, rand *rand.Rand) ([]Stubbing, error) {
+	stubbings := make([]Stubbing, rand.Intn(maxSequenceLength+1))
+	for i := range stubbings {
+		var e error
+		stubbings[i], e = RandomStubbing(mock, methodNames[rand.Intn(len(methodNames))], rand)
+		if e != nil {
+			return nil, e
+		}
+	}
+	return stubbings, nil
+}
+
+func holds(newMock func() pegomock.Mock, stubbings []Stubbing, property func(mock pegomock.Mock, stubbings []Stubbing) bool) bool {
+	mock := newMock()
+	for _, stubbing := range stubbings {
+		stubbing.ApplyTo(mock)
+	}
+	return property(mock, stubbings)
+}
+
+func shrink(newMock func() pegomock.Mock, stubbings []Stubbing, property func(mock pegomock.Mock, stubbings []Stubbing) bool) []Stubbing {
+	for i := 0; i < len(stubbings); {
+		candidate := append(append([]Stubbing{}, stubbings[:i]...), stubbings[i+1:]...)
+		if !holds(newMock, candidate, property) {
+			stubbings = candidate
+		} else {
+			i++
+		}
+	}
+	return stubbings
+}
+
+func randAndMaxCountFrom(config *quick.Config) (*rand.Rand, int) {
+	if config == nil {
+		config = &quick.Config{}
+	}
+	r := config.Rand
+	if r == nil {
+		r = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	maxCount := config.MaxCount
+	if maxCount == 0 {
+		maxCount = 100
+		if config.MaxCountScale != 0 {
+			maxCount = int(config.MaxCountScale * float64(maxCount))
+		}
+	}
+	return r, maxCount
+}
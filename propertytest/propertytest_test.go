@@ -0,0 +1,98 @@
+package propertytest_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/petergtz/pegomock/v4"
+	"github.com/petergtz/pegomock/v4/propertytest"
+)
+
+func TestPropertytest(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Propertytest Suite")
+}
+
+// mockPriceList is written like a generated mock, but reduced to what these tests need.
+type mockPriceList struct{ fail pegomock.FailHandler }
+
+func (mock *mockPriceList) SetFailHandler(fh pegomock.FailHandler) { mock.fail = fh }
+func (mock *mockPriceList) FailHandler() pegomock.FailHandler      { return mock.fail }
+
+func (mock *mockPriceList) Price(item string) (int, error) {
+	result := pegomock.GetGenericMockFrom(mock).Invoke("Price", []pegomock.Param{item},
+		[]reflect.Type{reflect.TypeOf((*int)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var price int
+	if len(result) != 0 && result[0] != nil {
+		price = result[0].(int)
+	}
+	return price, nil
+}
+
+func (mock *mockPriceList) Discount(items []string) uint8 {
+	result := pegomock.GetGenericMockFrom(mock).Invoke("Discount", []pegomock.Param{items},
+		[]reflect.Type{reflect.TypeOf((*uint8)(nil)).Elem()})
+	if len(result) != 0 && result[0] != nil {
+		return result[0].(uint8)
+	}
+	return 0
+}
+
+func newMock() pegomock.Mock { return &mockPriceList{} }
+
+var _ = Describe("Property-based testing", func() {
+	var config *quick.Config
+
+	BeforeEach(func() {
+		config = &quick.Config{Rand: rand.New(rand.NewSource(42))}
+	})
+
+	It("generates exact-match stubbings with random values", func() {
+		mock := &mockPriceList{}
+		stubbing, e := propertytest.RandomStubbing(mock, "Price", config.Rand)
+		Expect(e).NotTo(HaveOccurred())
+		Expect(stubbing.Params).To(HaveLen(1))
+		Expect(stubbing.ReturnValues).To(HaveLen(2))
+		Expect(stubbing.ReturnValues[1]).To(BeNil())
+
+		stubbing.ApplyTo(mock)
+
+		Expect(mock.Price(stubbing.Params[0].(string))).To(Equal(stubbing.ReturnValues[0]))
+	})
+
+	It("fails for unknown methods", func() {
+		_, e := propertytest.RandomStubbing(&mockPriceList{}, "NoSuchMethod", config.Rand)
+		Expect(e).To(MatchError(ContainSubstring("has no method NoSuchMethod")))
+	})
+
+	It("succeeds when the property holds for all generated stubbings", func() {
+		Expect(propertytest.Check(newMock, []string{"Price", "Discount"}, func(mock pegomock.Mock, _ []propertytest.Stubbing) bool {
+			_, e := mock.(*mockPriceList).Price("unknown item")
+			return e == nil
+		}, config)).To(Succeed())
+	})
+
+	It("shrinks a failing interaction sequence to the stubbing making the property fail", func() {
+		e := propertytest.Check(newMock, []string{"Price", "Discount"}, func(mock pegomock.Mock, stubbings []propertytest.Stubbing) bool {
+			for _, stubbing := range stubbings {
+				if stubbing.MethodName == "Price" {
+					if price, _ := mock.(*mockPriceList).Price(stubbing.Params[0].(string)); price < 0 {
+						return false
+					}
+				}
+			}
+			return true
+		}, config)
+
+		Expect(e).To(HaveOccurred())
+		checkError := e.(*propertytest.CheckError)
+		Expect(checkError.Stubbings).To(HaveLen(1))
+		Expect(checkError.Stubbings[0].MethodName).To(Equal("Price"))
+		Expect(checkError.Stubbings[0].ReturnValues[0]).To(BeNumerically("<", 0))
+		Expect(e.Error()).To(ContainSubstring("failed on stubbings:\n\tPrice("))
+	})
+})
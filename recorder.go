@@ -0,0 +1,50 @@
+package pegomock
+
+import "sync"
+
+// RecordedInvocation is a single call observed by an InvocationRecorder.
+type RecordedInvocation struct {
+	MethodName string
+	Params     []Param
+}
+
+// InvocationRecorder backs the recorders generated with --recorder. It only records invocations,
+// without any stubbing or verification machinery, so it is cheap enough to observe calls outside
+// of tests, e.g. for shadow traffic.
+type InvocationRecorder struct {
+	mutex       sync.Mutex
+	invocations []RecordedInvocation
+}
+
+func (recorder *InvocationRecorder) Record(methodName string, params []Param) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.invocations = append(recorder.invocations, RecordedInvocation{MethodName: methodName, Params: params})
+}
+
+// Invocations returns all recorded invocations in the order in which they happened.
+func (recorder *InvocationRecorder) Invocations() []RecordedInvocation {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	return append([]RecordedInvocation(nil), recorder.invocations...)
+}
+
+// InvocationsOf returns the recorded invocations of method methodName.
+func (recorder *InvocationRecorder) InvocationsOf(methodName string) []RecordedInvocation {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	var result []RecordedInvocation
+	for _, invocation := range recorder.invocations {
+		if invocation.MethodName == methodName {
+			result = append(result, invocation)
+		}
+	}
+	return result
+}
+
+// Reset discards all recorded invocations.
+func (recorder *InvocationRecorder) Reset() {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.invocations = nil
+}
@@ -0,0 +1,54 @@
+package pegomock
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/petergtz/pegomock/v4/internal/verify"
+)
+
+// registries maps the names of tests registered via RegisterMockTestingT to the test doubles
+// registered in them, by interface type.
+var (
+	registriesMutex sync.Mutex
+	registries      = make(map[string]map[reflect.Type]interface{})
+)
+
+// Register makes mock the test double Get[T]() returns for the rest of the current test, so
+// deeply nested test helpers can look it up instead of having it passed down to them. It requires
+// the test to call RegisterMockTestingT(t) first. T is usually an interface type, e.g.
+// Register[io.Reader](NewMockReader()).
+func Register[T any](mock T) {
+	test, exists := currentTest()
+	verify.Argument(exists, "Register requires the current test to call RegisterMockTestingT(t) first.")
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	registriesMutex.Lock()
+	defer registriesMutex.Unlock()
+	registry, exists := registries[test.name]
+	if !exists {
+		registry = make(map[reflect.Type]interface{})
+		registries[test.name] = registry
+		test.cleanup(func() {
+			registriesMutex.Lock()
+			defer registriesMutex.Unlock()
+			delete(registries, test.name)
+		})
+	}
+	_, alreadyRegistered := registry[typ]
+	verify.Argument(!alreadyRegistered, "A test double for %v is already registered in test %v.", typ, test.name)
+	registry[typ] = mock
+}
+
+// Get returns the test double registered for T in the current test using Register.
+func Get[T any]() T {
+	test, exists := currentTest()
+	verify.Argument(exists, "Get requires the current test to call RegisterMockTestingT(t) first.")
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	registriesMutex.Lock()
+	defer registriesMutex.Unlock()
+	mock, exists := registries[test.name][typ]
+	verify.Argument(exists, "No test double for %v is registered in test %v. Use Register[%v](mock) first.", typ, test.name, typ)
+	return mock.(T)
+}
@@ -0,0 +1,67 @@
+package pegomock
+
+import (
+	"reflect"
+
+	"github.com/petergtz/pegomock/v4/internal/verify"
+)
+
+// SnapshotInteractions returns a read-only copy of mock holding the invocations recorded on it so
+// far. Verifications on the copy keep seeing exactly these invocations, even after mock is reset
+// with ResetInvocations or invoked again, so long scenario tests can assert on each phase:
+//
+//	afterLogin := SnapshotInteractions(store)
+//	ResetInvocations(store)
+//	// ... next phase ...
+//	afterLogin.VerifyWasCalledOnce().Get(Eq("session"))
+//
+// Invoking or stubbing the copy panics.
+func SnapshotInteractions[M Mock](mock M) M {
+	value := reflect.ValueOf(mock)
+	verify.Argument(value.Kind() == reflect.Ptr && !value.IsNil(),
+		"SnapshotInteractions requires a mock created with its constructor, but got %#v", mock)
+	snapshot := reflect.New(value.Type().Elem()).Interface().(M)
+	snapshot.SetFailHandler(mock.FailHandler())
+
+	genericMock := GetGenericMockFrom(mock)
+	snapshotMock := GetGenericMockFrom(snapshot)
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	for methodName, method := range genericMock.mockedMethods {
+		method.Lock()
+		snapshotMock.mockedMethods[methodName] = &mockedMethod{
+			name:        methodName,
+			invocations: append([]MethodInvocation(nil), method.recordedInvocations()...),
+			returnTypes: method.returnTypes,
+		}
+		method.Unlock()
+	}
+	snapshotMock.fail = genericMock.fail
+	snapshotMock.name = genericMock.name
+	snapshotMock.testScopedVerification = genericMock.testScopedVerification
+	for key, value := range genericMock.labels {
+		snapshotMock.setLabel(key, value)
+	}
+	snapshotMock.readOnly = true
+	return snapshot
+}
+
+// ResetInvocations forgets the invocations recorded on the mocks, e.g. between the phases of a
+// scenario test. Stubbings are kept.
+func ResetInvocations(mocks ...Mock) {
+	for _, mock := range mocks {
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		for _, method := range genericMock.mockedMethods {
+			method.Lock()
+			method.forgetInvocations()
+			method.Unlock()
+		}
+		genericMock.Unlock()
+	}
+}
+
+func (genericMock *GenericMock) verifyNotReadOnly(methodName string) {
+	verify.Argument(!genericMock.readOnly,
+		"Cannot invoke %v on a snapshot created with SnapshotInteractions. Snapshots are read-only.", methodName)
+}
@@ -0,0 +1,138 @@
+package pegomock
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// WithStrictStubbing makes invocations of the mock that are not answered by a stubbing fail the
+// test. Every failure comes with a ready-to-paste stubbing for the invocation. Since a call
+// passed to When(...) looks like any other invocation at the time it happens, unstubbed
+// invocations are reported when the test registered via RegisterMockTestingT finishes.
+// Without such a test, e.g. with Ginkgo, call VerifyNoUnstubbedInvocations explicitly.
+func WithStrictStubbing() Option {
+	return OptionFunc(func(mock Mock) {
+		if test, isRegisteredTest := currentTest(); isRegisteredTest && test.cleanup != nil {
+			test.cleanup(func() { VerifyNoUnstubbedInvocations(mock) })
+		}
+	})
+}
+
+// VerifyNoUnstubbedInvocations fails if any of the mocks was invoked with arguments that no
+// stubbing matched.
+func VerifyNoUnstubbedInvocations(mocks ...Mock) {
+	for _, mock := range mocks {
+		genericMock := GetGenericMockFrom(mock)
+		fail := genericMock.fail
+		if fail == nil {
+			fail = globalFailHandler()
+		}
+		if fail == nil {
+			panic("No FailHandler set. Please use either RegisterMockFailHandler or RegisterMockTestingT or TODO to set a fail handler.")
+		}
+		if suggestions := genericMock.stubbingSuggestions(); len(suggestions) > 0 {
+			fail(fmt.Sprintf("Mock %v has unstubbed invocations. To stub them, use e.g.:\n\n\t%v",
+				genericMock.variableName(), strings.Join(suggestions, "\n\t")))
+		}
+	}
+}
+
+func (genericMock *GenericMock) variableName() string {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	if genericMock.name == "" {
+		return "mock"
+	}
+	return genericMock.name
+}
+
+func (genericMock *GenericMock) stubbingSuggestions() []string {
+	mockName := genericMock.variableName()
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	type unstubbedInvocation struct {
+		number     int
+		suggestion string
+	}
+	var unstubbed []unstubbedInvocation
+	for methodName, method := range genericMock.mockedMethods {
+		method.Lock()
+		for _, invocation := range method.recordedInvocations() {
+			if invocation.unstubbed {
+				unstubbed = append(unstubbed, unstubbedInvocation{
+					invocation.orderingInvocationNumber,
+					stubbingSuggestionFor(mockName, methodName, invocation.params, method.returnTypes),
+				})
+			}
+		}
+		method.Unlock()
+	}
+	sort.Slice(unstubbed, func(i, j int) bool { return unstubbed[i].number < unstubbed[j].number })
+	var suggestions []string
+	seen := make(map[string]bool)
+	for _, invocation := range unstubbed {
+		if !seen[invocation.suggestion] {
+			seen[invocation.suggestion] = true
+			suggestions = append(suggestions, invocation.suggestion)
+		}
+	}
+	return suggestions
+}
+
+// stubbingSuggestionFor renders a stubbing for an invocation, inferring matchers from the actual
+// params: values that can be written as literals become Eq matchers, any other value an Any
+// matcher for its type.
+func stubbingSuggestionFor(mockName, methodName string, params []Param, returnTypes []reflect.Type) string {
+	matchers := make([]string, len(params))
+	for i, param := range params {
+		matchers[i] = matcherSuggestionFor(param)
+	}
+	call := fmt.Sprintf("%v.%v(%v)", mockName, methodName, strings.Join(matchers, ", "))
+	if len(returnTypes) == 0 {
+		return fmt.Sprintf("pegomock.When(func() { %v }).ThenReturn()", call)
+	}
+	returnValues := make([]string, len(returnTypes))
+	for i, returnType := range returnTypes {
+		returnValues[i] = zeroValueLiteralFor(returnType)
+	}
+	return fmt.Sprintf("pegomock.When(%v).ThenReturn(%v)", call, strings.Join(returnValues, ", "))
+}
+
+func matcherSuggestionFor(param Param) string {
+	if param == nil {
+		return "pegomock.Any[ /* type of nil argument */ ]()"
+	}
+	typ := reflect.TypeOf(param)
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		switch typ {
+		case reflect.TypeOf(false), reflect.TypeOf(""), reflect.TypeOf(0):
+			return fmt.Sprintf("pegomock.Eq(%#v)", param)
+		default:
+			// without a conversion, Eq would infer the default type of the literal, e.g. int for 1
+			return fmt.Sprintf("pegomock.Eq(%v(%#v))", typ, param)
+		}
+	}
+	return fmt.Sprintf("pegomock.Any[%v]()", typ)
+}
+
+func zeroValueLiteralFor(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "false"
+	case reflect.String:
+		return `""`
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr, reflect.Float32, reflect.Float64, reflect.Complex64, reflect.Complex128:
+		return "0"
+	case reflect.Struct, reflect.Array:
+		return typ.String() + "{}"
+	}
+	return "nil"
+}
@@ -0,0 +1,129 @@
+package pegomock
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// WithTestCleanup reports failures of the mock to t and checks the mock when t finishes: the test
+// fails for stubbings that never answered an invocation, and for invocations that were neither
+// answered by a stubbing nor verified. Generated NewMock<Interface>WithT constructors apply it.
+func WithTestCleanup(t testing.TB) Option {
+	return OptionFunc(func(mock Mock) {
+		WithT(t).Apply(mock)
+		t.Cleanup(func() {
+			t.Helper()
+			VerifyNoUnusedStubbings(mock)
+			VerifyNoMoreInteractions(mock)
+		})
+	})
+}
+
+// VerifyNoUnusedStubbings fails if any of the mocks has stubbings that never answered an
+// invocation. Such stubbings are usually left over from changes to the code under test.
+func VerifyNoUnusedStubbings(mocks ...Mock) {
+	for _, mock := range mocks {
+		genericMock := GetGenericMockFrom(mock)
+		if unused := genericMock.unusedStubbings(); len(unused) > 0 {
+			genericMock.failWith(fmt.Sprintf("Mock %v has unused stubbings:\n\n\t%v",
+				genericMock.variableName(), strings.Join(unused, "\n\t")))
+		}
+	}
+}
+
+// VerifyNoMoreInteractions fails if any of the mocks has invocations that no verification matched.
+// Invocations answered by a stubbing don't need to be verified, because the code under test
+// already depends on their results, and VerifyNoUnusedStubbings covers the stubbings.
+func VerifyNoMoreInteractions(mocks ...Mock) {
+	for _, mock := range mocks {
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		recordingDisabled := genericMock.recordingDisabled
+		genericMock.Unlock()
+		if recordingDisabled {
+			continue
+		}
+		if unverified := genericMock.unverifiedInteractions(); len(unverified) > 0 {
+			formatted := ""
+			for _, methodName := range sortedMethodNames(unverified) {
+				formatted += formatInvocations(methodName, unverified[methodName])
+			}
+			genericMock.failWith(fmt.Sprintf("Mock %v has unverified invocations:\n\n%v", genericMock.variableName(), formatted))
+		}
+	}
+}
+
+func (genericMock *GenericMock) failWith(message string) {
+	genericMock.Lock()
+	fail := genericMock.fail
+	genericMock.Unlock()
+	if fail == nil {
+		fail = globalFailHandler()
+	}
+	if fail == nil {
+		panic("No FailHandler set. Please use either RegisterMockFailHandler or RegisterMockTestingT or TODO to set a fail handler.")
+	}
+	fail(message)
+}
+
+func (genericMock *GenericMock) unusedStubbings() []string {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	var unused []string
+	for methodName, method := range genericMock.mockedMethods {
+		method.Lock()
+		for _, stubbing := range method.stubbings {
+			stubbing.Lock()
+			if stubbing.numAnswers == 0 {
+				unused = append(unused, methodName+"("+formatMatchers(stubbing.paramMatchers)+")")
+			}
+			stubbing.Unlock()
+		}
+		method.Unlock()
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+func (genericMock *GenericMock) unverifiedInteractions() map[string][]MethodInvocation {
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	unverified := make(map[string][]MethodInvocation)
+	for methodName, method := range genericMock.mockedMethods {
+		method.Lock()
+		for _, invocation := range method.recordedInvocations() {
+			if !invocation.verified && invocation.stubbing == nil {
+				unverified[methodName] = append(unverified[methodName], invocation)
+			}
+		}
+		method.Unlock()
+	}
+	return unverified
+}
+
+// markVerified marks the recorded invocations of methodName that are among invocations as verified.
+func (genericMock *GenericMock) markVerified(methodName string, invocations []MethodInvocation) {
+	if len(invocations) == 0 {
+		return
+	}
+	genericMock.Lock()
+	method, exists := genericMock.mockedMethods[methodName]
+	genericMock.Unlock()
+	if !exists {
+		return
+	}
+	verified := make(map[int]bool, len(invocations))
+	for _, invocation := range invocations {
+		verified[invocation.orderingInvocationNumber] = true
+	}
+	method.Lock()
+	defer method.Unlock()
+	recorded := method.recordedInvocations()
+	for i := range recorded {
+		if verified[recorded[i].orderingInvocationNumber] {
+			recorded[i].verified = true
+		}
+	}
+}
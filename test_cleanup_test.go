@@ -0,0 +1,87 @@
+package pegomock_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/petergtz/pegomock/v4"
+)
+
+// fakeTB records the failures and cleanup functions of a test. Embedding testing.TB satisfies its
+// unexported method.
+type fakeTB struct {
+	testing.TB
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeTB) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+func (t *fakeTB) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+func (t *fakeTB) Helper()          {}
+
+func (t *fakeTB) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestMockWithTPassesWhenStubbingsAreUsedAndInvocationsVerified(t *testing.T) {
+	fakeT := &fakeTB{}
+	display := NewMockDisplayWithT(fakeT)
+	pegomock.When(display.SomeValue()).ThenReturn("value")
+
+	display.SomeValue()
+	display.Show("Hello")
+	display.VerifyWasCalledOnce().Show("Hello")
+	fakeT.finish()
+
+	if len(fakeT.errors) != 0 {
+		t.Fatalf("Expected no failures, but got %v", fakeT.errors)
+	}
+}
+
+func TestMockWithTReportsUnusedStubbingsWhenTestFinishes(t *testing.T) {
+	fakeT := &fakeTB{}
+	display := NewMockDisplayWithT(fakeT)
+	pegomock.When(display.MultipleParamsAndReturnValue("used", 1)).ThenReturn("a")
+	pegomock.When(display.MultipleParamsAndReturnValue("unused", 2)).ThenReturn("b")
+
+	display.MultipleParamsAndReturnValue("used", 1)
+	fakeT.finish()
+
+	if len(fakeT.errors) != 1 || !strings.Contains(fakeT.errors[0], "Mock mock has unused stubbings:\n\n\tMultipleParamsAndReturnValue(Eq(\"unused\"), Eq(2))") {
+		t.Fatalf("Expected a failure for the unused stubbing, but got %v", fakeT.errors)
+	}
+}
+
+func TestMockWithTDoesNotCountStubbingInvocationsAsUses(t *testing.T) {
+	fakeT := &fakeTB{}
+	display := NewMockDisplayWithT(fakeT)
+	pegomock.When(display.MultipleParamsAndReturnValue(pegomock.Any[string](), pegomock.Any[int]())).ThenReturn("any")
+	// answered by the first stubbing while being stubbed
+	pegomock.When(display.MultipleParamsAndReturnValue("specific", 1)).ThenReturn("specific")
+
+	display.MultipleParamsAndReturnValue("specific", 1)
+	fakeT.finish()
+
+	if len(fakeT.errors) != 1 || !strings.Contains(fakeT.errors[0], "has unused stubbings:\n\n\tMultipleParamsAndReturnValue(Any(string), Any(int))") {
+		t.Fatalf("Expected a failure for the unused stubbing, but got %v", fakeT.errors)
+	}
+}
+
+func TestMockWithTReportsUnverifiedInvocationsWhenTestFinishes(t *testing.T) {
+	fakeT := &fakeTB{}
+	display := NewMockDisplayWithT(fakeT, pegomock.WithName("display"))
+
+	display.Show("verified")
+	display.Show("unverified")
+	display.VerifyWasCalledOnce().Show("verified")
+	fakeT.finish()
+
+	if len(fakeT.errors) != 1 || !strings.Contains(fakeT.errors[0], "Mock display has unverified invocations:\n\n\tShow(\"unverified\")") {
+		t.Fatalf("Expected a failure for the unverified invocation, but got %v", fakeT.errors)
+	}
+}
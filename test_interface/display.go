@@ -51,8 +51,19 @@ type Display interface {
 
 type GenericDisplay[N comparable, V Number] interface {
 	GenericParams(m map[N]V) V
+	GenericVariadic(values ...V)
+	GenericVariadicWithFixedParam(key N, values ...map[N][]V) int
 }
 
 type Number interface {
 	int64 | float64
 }
+
+// UserStore is a sample interface with CRUD-shaped methods to be mocked as hybrid fake.
+type UserStore interface {
+	PutUser(id string, user User) error
+	GetUser(id string) (User, error)
+	DeleteUser(id string) error
+}
+
+type User struct{ Name string }
@@ -0,0 +1,57 @@
+package pegomock
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// registeredTests maps goroutine IDs to the tests registered via RegisterMockTestingT from
+// these goroutines. This allows tagging invocations with the test they happen in, even when
+// a mock is shared across parallel subtests.
+var registeredTests sync.Map
+
+type registeredTest struct {
+	name    string
+	fail    FailHandler
+	cleanup func(func())
+}
+
+func registerTestForCurrentGoroutine(name string, fail FailHandler, cleanup func(func())) (unregister func()) {
+	id := currentGoroutineID()
+	registeredTests.Store(id, registeredTest{name, fail, cleanup})
+	return func() { registeredTests.Delete(id) }
+}
+
+func currentTest() (registeredTest, bool) {
+	test, exists := registeredTests.Load(currentGoroutineID())
+	if !exists {
+		return registeredTest{}, false
+	}
+	return test.(registeredTest), true
+}
+
+func currentGoroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	// the stack trace starts with "goroutine <id> [...]"
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	id, _ := strconv.ParseUint(string(buf[:bytes.IndexByte(buf, ' ')]), 10, 64)
+	return id
+}
+
+// WithTestScopedVerification makes verifications of a mock shared across (parallel) subtests
+// only consider invocations that happened in the test verifying them. This requires each
+// subtest to call RegisterMockTestingT(t). Invocations from goroutines started by the code under
+// test, e.g. worker pools or http.Handlers, are attributed to the test the mock was created for
+// with WithT or WithTestCleanup. Without such a test, they can't be attributed and are therefore
+// not considered by these verifications.
+func WithTestScopedVerification() Option {
+	return OptionFunc(func(mock Mock) {
+		genericMock := GetGenericMockFrom(mock)
+		genericMock.Lock()
+		defer genericMock.Unlock()
+		genericMock.testScopedVerification = true
+	})
+}
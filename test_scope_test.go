@@ -0,0 +1,155 @@
+package pegomock_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/petergtz/pegomock/v4"
+	"github.com/petergtz/pegomock/v4/test_interface"
+)
+
+func TestScopedVerificationWithParallelSubtests(t *testing.T) {
+	originalHandler := pegomock.GlobalFailHandler
+	t.Cleanup(func() { pegomock.RegisterMockFailHandler(originalHandler) })
+
+	display := NewMockDisplay(pegomock.WithTestScopedVerification())
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"first", "second", "third"} {
+			name := name
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				pegomock.RegisterMockTestingT(t)
+
+				display.Flash(name, len(name))
+				display.Flash(name, len(name))
+
+				display.VerifyWasCalled(pegomock.Times(2)).Flash(name, len(name))
+			})
+		}
+	})
+}
+
+func TestScopedVerificationIgnoresInvocationsOfOtherSubtests(t *testing.T) {
+	originalHandler := pegomock.GlobalFailHandler
+	t.Cleanup(func() { pegomock.RegisterMockFailHandler(originalHandler) })
+
+	display := NewMockDisplay(pegomock.WithTestScopedVerification())
+	t.Run("subtest", func(t *testing.T) {
+		pegomock.RegisterMockTestingT(t)
+		display.Show("Hello")
+		display.VerifyWasCalledOnce().Show("Hello")
+	})
+	t.Run("other subtest", func(t *testing.T) {
+		pegomock.RegisterMockTestingT(t)
+		display.VerifyWasCalled(pegomock.Never()).Show("Hello")
+	})
+}
+
+func TestScopedVerificationAttributesInvocationsFromSpawnedGoroutinesToTheMocksTest(t *testing.T) {
+	originalHandler := pegomock.GlobalFailHandler
+	t.Cleanup(func() { pegomock.RegisterMockFailHandler(originalHandler) })
+
+	t.Run("subtest", func(t *testing.T) {
+		pegomock.RegisterMockTestingT(t)
+		display := NewMockDisplay(pegomock.WithT(t), pegomock.WithTestScopedVerification())
+
+		showInGoroutine(display, "Hello")
+
+		display.VerifyWasCalledOnce().Show("Hello")
+	})
+}
+
+func TestScopedVerificationDoesNotCountInvocationsFromSpawnedGoroutinesOfSharedMocks(t *testing.T) {
+	originalHandler := pegomock.GlobalFailHandler
+	t.Cleanup(func() { pegomock.RegisterMockFailHandler(originalHandler) })
+
+	display := NewMockDisplay(pegomock.WithTestScopedVerification())
+	t.Run("subtest", func(t *testing.T) {
+		pegomock.RegisterMockTestingT(t)
+
+		showInGoroutine(display, "Hello")
+
+		// the invocation can't be attributed to the subtest
+		display.VerifyWasCalled(pegomock.Never()).Show("Hello")
+	})
+	display.VerifyWasCalledOnce().Show("Hello")
+}
+
+// showInGoroutine invokes display from a goroutine of its own, like code under test might.
+func showInGoroutine(display *MockDisplay, text string) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		display.Show(text)
+	}()
+	<-done
+}
+
+func TestStrictStubbingReportsUnstubbedInvocationsWhenTestFinishes(t *testing.T) {
+	originalHandler := pegomock.GlobalFailHandler
+	t.Cleanup(func() { pegomock.RegisterMockFailHandler(originalHandler) })
+
+	var failures []string
+	t.Run("subtest", func(t *testing.T) {
+		pegomock.RegisterMockTestingT(t)
+		display := NewMockDisplay(
+			pegomock.WithFailHandler(func(message string, callerSkip ...int) { failures = append(failures, message) }),
+			pegomock.WithStrictStubbing())
+		pegomock.When(display.SomeValue()).ThenReturn("stubbed")
+
+		display.SomeValue()
+		display.Show("Hello")
+
+		if len(failures) != 0 {
+			t.Fatalf("expected no failure before the test finishes, but got: %v", failures)
+		}
+	})
+	if len(failures) != 1 || !strings.Contains(failures[0], `pegomock.When(func() { mock.Show(pegomock.Eq("Hello")) }).ThenReturn()`) {
+		t.Fatalf("expected a failure suggesting to stub Show, but got: %v", failures)
+	}
+}
+
+func TestRegistryReturnsTheTestDoubleRegisteredInTheCurrentTest(t *testing.T) {
+	originalHandler := pegomock.GlobalFailHandler
+	t.Cleanup(func() { pegomock.RegisterMockFailHandler(originalHandler) })
+
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"first", "second"} {
+			name := name
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				pegomock.RegisterMockTestingT(t)
+				display := NewMockDisplay()
+				pegomock.Register[test_interface.Display](display)
+
+				showFromNestedHelper(name)
+
+				display.VerifyWasCalledOnce().Show(name)
+			})
+		}
+	})
+}
+
+func showFromNestedHelper(message string) {
+	pegomock.Get[test_interface.Display]().Show(message)
+}
+
+func TestRegistryDoesNotShareTestDoublesAcrossTests(t *testing.T) {
+	originalHandler := pegomock.GlobalFailHandler
+	t.Cleanup(func() { pegomock.RegisterMockFailHandler(originalHandler) })
+
+	t.Run("subtest", func(t *testing.T) {
+		pegomock.RegisterMockTestingT(t)
+		pegomock.Register[test_interface.Display](NewMockDisplay())
+	})
+	t.Run("other subtest", func(t *testing.T) {
+		pegomock.RegisterMockTestingT(t)
+		defer func() {
+			if message := recover(); !strings.Contains(fmt.Sprint(message), "No test double for test_interface.Display is registered in test TestRegistryDoesNotShareTestDoublesAcrossTests/other_subtest.") {
+				t.Fatalf("expected Get to panic, but got: %v", message)
+			}
+		}()
+		pegomock.Get[test_interface.Display]()
+	})
+}
@@ -31,6 +31,10 @@ type testingT interface {
 	Errorf(format string, args ...interface{})
 }
 
+type namedTest interface {
+	Name() string
+}
+
 func BuildTestingTFailHandler(t testingT) FailHandler {
 	return func(message string, callerSkip ...int) {
 		skip := 1
@@ -59,5 +63,14 @@ func pruneStack(fullStackTrace string, skip int) string {
 }
 
 func WithT(t testingT) Option {
-	return WithFailHandler(BuildTestingTFailHandler(t))
+	return OptionFunc(func(mock Mock) {
+		mock.SetFailHandler(BuildTestingTFailHandler(t))
+		if namedT, isNamed := t.(namedTest); isNamed {
+			// attributes invocations from goroutines of the code under test to t, see WithTestScopedVerification
+			genericMock := GetGenericMockFrom(mock)
+			genericMock.Lock()
+			defer genericMock.Unlock()
+			genericMock.test = namedT
+		}
+	})
 }
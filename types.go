@@ -1,5 +1,7 @@
 package pegomock
 
+import "time"
+
 type FailHandler func(message string, callerSkip ...int)
 
 type Mock interface {
@@ -19,3 +21,66 @@ func (f OptionFunc) Apply(mock Mock) { f(mock) }
 func WithFailHandler(fail FailHandler) Option {
 	return OptionFunc(func(mock Mock) { mock.SetFailHandler(fail) })
 }
+
+// WithName names a mock, so that failure messages and exported invocations identify which of
+// several mocks of the same type was involved, e.g. NewMockStore(WithName("primaryStore")).
+func WithName(name string) Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).setName(name) })
+}
+
+// WithLabel attaches a key/value label to a mock, e.g. WithLabel("layer", "cache"). Labels show up
+// in exported invocations, so that interaction reports can be sliced by them.
+func WithLabel(key, value string) Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).setLabel(key, value) })
+}
+
+// LabelsOf returns the labels attached to mock with WithLabel.
+func LabelsOf(mock Mock) map[string]string {
+	genericMock := GetGenericMockFrom(mock)
+	genericMock.Lock()
+	defer genericMock.Unlock()
+	labels := make(map[string]string, len(genericMock.labels))
+	for key, value := range genericMock.labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// WithInvocationTimeout makes invocations of the given methods (or all methods, if none are
+// given) fail the test if they are not answered within timeout, e.g. because of a blocking Answer.
+func WithInvocationTimeout(timeout time.Duration, methodNames ...string) Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).setInvocationTimeout(timeout, methodNames) })
+}
+
+// WithoutInvocationRecording makes the mock answer invocations only from its stubbings, without
+// recording them, e.g. for benchmarks and high-throughput tests. Such a mock cannot be verified and
+// has no captured arguments.
+func WithoutInvocationRecording() Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).disableRecording() })
+}
+
+// WithCallSites makes the mock record where each invocation came from, so that verification
+// failures show it for every actual interaction, e.g. Show("Hello") called from handler.go:87.
+// Recording call sites walks the stack on every invocation, so it's off by default.
+func WithCallSites() Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).setCallSiteRecording() })
+}
+
+// WithArgumentCloning makes the mock record copies of slice arguments, so that verification and
+// captured arguments see them as they were passed, not as subsequently mutated by the code under test.
+func WithArgumentCloning() Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).setArgumentCloning(true, nil) })
+}
+
+// WithDeepArgumentCloning is like WithArgumentCloning, but records deep copies of all arguments, so
+// that also maps and values behind pointers are seen as they were passed. Unexported struct fields are
+// copied, but not what they point to.
+func WithDeepArgumentCloning() Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).setDeepArgumentCloning() })
+}
+
+// WithArgumentReferences overrides WithArgumentCloning and WithDeepArgumentCloning for the given methods, which record
+// references to their arguments instead. This allows asserting on the final state of a shared buffer.
+func WithArgumentReferences(methodNames ...string) Option {
+	return OptionFunc(func(mock Mock) { GetGenericMockFrom(mock).setArgumentCloning(false, methodNames) })
+}